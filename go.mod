@@ -6,6 +6,11 @@ require (
 	github.com/caarlos0/env/v11 v11.3.1
 	github.com/google/uuid v1.6.0
 	go.uber.org/zap v1.27.0
+	golang.org/x/term v0.27.0
+	golang.org/x/text v0.21.0
 )
 
-require go.uber.org/multierr v1.11.0 // indirect
+require (
+	go.uber.org/multierr v1.11.0 // indirect
+	golang.org/x/sys v0.28.0 // indirect
+)
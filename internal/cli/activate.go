@@ -0,0 +1,126 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sort"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// ActivateAllCmd handles the activate:all command
+func ActivateAllCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fs := newFlagSet("activate:all")
+	password := fs.String("password", "", "Password to activate every inactive device with")
+	timeout := fs.Duration("timeout", cfg.SADPTimeout, "Discovery timeout")
+	dryRun := fs.Bool("dry-run", false, "List the devices that would be activated without sending anything")
+	assumeYes := fs.Bool("yes", false, "Activate without asking for confirmation")
+	force := fs.Bool("force", false, "Activate even if the password fails the device complexity rules")
+	auditFile := fs.String("audit-log", "", "Append a JSON line per activation sent (no secrets) to this file")
+	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
+	verbosity := addVerbosityFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *password == "" && !*dryRun {
+		fmt.Println("Usage: sadp activate:all --password <password> [options]")
+		fmt.Println("\nDiscovers devices via SADP and activates every one that is not yet")
+		fmt.Println("activated. Devices without a usable IP are addressed by MAC via broadcast.")
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp activate:all --dry-run")
+		fmt.Println("  sadp activate:all --password 'NewPass123!'")
+		fmt.Println("  sadp activate:all --password 'NewPass123!' --yes --audit-log activate.jsonl")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		return nil
+	}
+
+	log := newCLILogger(*debug, *verbosity)
+	defer func() { _ = log.Sync() }()
+
+	scanner := sadp.NewScannerWithOptions(*timeout, log, configDiscoverOptions(cfg))
+	opts := sadp.SendOptions{Password: *password, AllowWeakPassword: *force}
+	if !*dryRun {
+		// Catch a bad password before spending a discovery on it
+		if err := checkActivatePassword("activate", *password, *force); err != nil {
+			return err
+		}
+	}
+
+	fmt.Println("Discovering devices via SADP...")
+	devices, err := scanner.Discover()
+	if err != nil {
+		return err
+	}
+	plan := activationPlan(devices)
+	if len(plan) == 0 {
+		fmt.Printf("No inactive devices found (%d device(s) answered)\n", len(devices))
+		return nil
+	}
+
+	if *dryRun {
+		printActivationPlan(os.Stdout, plan)
+		return nil
+	}
+
+	targets := make([]sadp.BatchTarget, len(plan))
+	for i, dev := range plan {
+		targets[i] = activationTarget(dev)
+	}
+	if !*assumeYes && !confirmThen(os.Stdin, os.Stdout, "activate", targets) {
+		return fmt.Errorf("activate cancelled; nothing was sent")
+	}
+
+	audit, err := openAuditLog(*auditFile)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	bopts := sadp.BatchOptions{Attempts: 1, MinRemainingAttempts: sadp.DefaultMinRemainingAttempts}
+	return runSendBatch(scanner, "activate", opts, targets, bopts, audit)
+}
+
+// activationPlan returns the devices that still need activating, ordered by
+// MAC. Devices without a MAC cannot be addressed and are left out.
+func activationPlan(devices []*sadp.Device) []*sadp.Device {
+	var plan []*sadp.Device
+	for _, dev := range sadp.FilterDevices(devices, sadp.DeviceFilter{OnlyInactive: true}) {
+		if dev.MAC != "" {
+			plan = append(plan, dev)
+		}
+	}
+	sort.Slice(plan, func(i, j int) bool { return plan[i].MAC < plan[j].MAC })
+	return plan
+}
+
+// activationTarget addresses dev by its IP, or by broadcast (0.0.0.0) with
+// its MAC when it reports no usable IPv4 address yet
+func activationTarget(dev *sadp.Device) sadp.BatchTarget {
+	ip := net.ParseIP(dev.IPv4Address).To4()
+	if ip == nil || ip.IsUnspecified() {
+		return sadp.BatchTarget{IP: "0.0.0.0", MAC: dev.MAC}
+	}
+	return sadp.BatchTarget{IP: ip.String(), MAC: dev.MAC}
+}
+
+// printActivationPlan lists what activate:all --dry-run would send to
+func printActivationPlan(w io.Writer, plan []*sadp.Device) {
+	fmt.Fprintf(w, "Would activate %d device(s):\n", len(plan))
+	for _, dev := range plan {
+		addr := activationTarget(dev).IP
+		if addr == "0.0.0.0" {
+			addr = "broadcast"
+		}
+		fmt.Fprintf(w, "  %-15s %-17s %s\n", addr, dev.MAC, valueOrDash(dev.DeviceType))
+	}
+}
@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestActivationPlan(t *testing.T) {
+	active := &sadp.Device{MAC: "AA:BB:CC:00:00:01", Activated: "true"}
+	inactiveB := &sadp.Device{MAC: "AA:BB:CC:00:00:03", Activated: "false"}
+	inactiveA := &sadp.Device{MAC: "AA:BB:CC:00:00:02", Activated: "false"}
+	noMAC := &sadp.Device{Activated: "false", IPv4Address: "192.168.1.64"}
+
+	tests := []struct {
+		name    string
+		devices []*sadp.Device
+		want    []*sadp.Device
+	}{
+		{name: "inactive only, by MAC", devices: []*sadp.Device{inactiveB, active, inactiveA}, want: []*sadp.Device{inactiveA, inactiveB}},
+		{name: "skips devices without a MAC", devices: []*sadp.Device{noMAC, inactiveA}, want: []*sadp.Device{inactiveA}},
+		{name: "all activated", devices: []*sadp.Device{active}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := activationPlan(tt.devices); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("activationPlan() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestActivationTarget(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		want string
+	}{
+		{name: "usable IP", ip: "192.168.1.64", want: "192.168.1.64"},
+		{name: "no IP", ip: "", want: "0.0.0.0"},
+		{name: "unspecified", ip: "0.0.0.0", want: "0.0.0.0"},
+		{name: "not an IPv4 address", ip: "fe80::1", want: "0.0.0.0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := activationTarget(&sadp.Device{MAC: "AA:BB:CC:00:00:01", IPv4Address: tt.ip})
+			if got.IP != tt.want || got.MAC != "AA:BB:CC:00:00:01" {
+				t.Errorf("activationTarget(%q) = %+v, want IP %s", tt.ip, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPrintActivationPlan(t *testing.T) {
+	plan := []*sadp.Device{
+		{MAC: "AA:BB:CC:00:00:01", IPv4Address: "192.168.1.64", DeviceType: "DS-2CD2143G0-I"},
+		{MAC: "AA:BB:CC:00:00:02"},
+	}
+
+	var out bytes.Buffer
+	printActivationPlan(&out, plan)
+
+	want := "Would activate 2 device(s):\n" +
+		"  192.168.1.64    AA:BB:CC:00:00:01 DS-2CD2143G0-I\n" +
+		"  broadcast       AA:BB:CC:00:00:02 -\n"
+	if out.String() != want {
+		t.Errorf("printActivationPlan() =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestCheckActivatePassword(t *testing.T) {
+	tests := []struct {
+		name     string
+		command  string
+		password string
+		force    bool
+		wantErr  bool
+	}{
+		{name: "strong password", command: "activate", password: "N3w-Passw0rd!", wantErr: false},
+		{name: "too short", command: "activate", password: "Ab1", wantErr: true},
+		{name: "one character type", command: "activate", password: "abcdefgh", wantErr: true},
+		{name: "forced", command: "activate", password: "abcdefgh", force: true, wantErr: false},
+		{name: "empty left to the builder", command: "activate", password: "", wantErr: false},
+		{name: "other commands unchecked", command: "reboot", password: "abc", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkActivatePassword(tt.command, tt.password, tt.force)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkActivatePassword() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestNewAuditEntry(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+	secrets := sadp.SendOptions{
+		TargetIP:   "192.168.1.64",
+		TargetMAC:  "aa:bb:cc:dd:ee:ff",
+		Password:   "Secret123!",
+		Code:       "RESETCODE42",
+		VerifyCode: "VERIFY99",
+		Answers:    []string{"answer-one", "answer-two", "answer-three"},
+	}
+
+	tests := []struct {
+		name    string
+		command string
+		opts    sadp.SendOptions
+		result  *sadp.CommandResult
+		sendErr error
+		want    auditEntry
+	}{
+		{
+			name:    "successful reboot",
+			command: "reboot",
+			opts:    secrets,
+			result:  &sadp.CommandResult{Success: true},
+			want: auditEntry{
+				Time: now, Command: "reboot", TargetIP: "192.168.1.64", TargetMAC: "aa:bb:cc:dd:ee:ff",
+				DeviceUser: sadp.DefaultUsername, Success: true,
+			},
+		},
+		{
+			name:    "device rejected command",
+			command: "resetpassword",
+			opts:    secrets,
+			result:  &sadp.CommandResult{ErrorCode: "2010", Message: "invalid code"},
+			want: auditEntry{
+				Time: now, Command: "resetpassword", TargetIP: "192.168.1.64", TargetMAC: "aa:bb:cc:dd:ee:ff",
+				DeviceUser: sadp.DefaultUsername, ErrorCode: "2010", Message: "invalid code",
+			},
+		},
+		{
+			name:    "no reply",
+			command: "inquiry",
+			opts:    sadp.SendOptions{TargetIP: "192.168.1.64"},
+			sendErr: errors.New("timeout waiting for response"),
+			want: auditEntry{
+				Time: now, Command: "inquiry", TargetIP: "192.168.1.64", Message: "timeout waiting for response",
+			},
+		},
+		{
+			name:    "update records new network settings",
+			command: "update",
+			opts: sadp.SendOptions{
+				TargetIP: "192.168.1.64", Password: "Secret123!", Username: "operator",
+				NewIP: "10.0.0.5", NewMask: "255.255.255.0", NewGateway: "10.0.0.1", NewPort: 8000,
+			},
+			result: &sadp.CommandResult{Success: true},
+			want: auditEntry{
+				Time: now, Command: "update", TargetIP: "192.168.1.64", DeviceUser: "operator", Success: true,
+				Params: map[string]string{
+					"ipv4Address": "10.0.0.5", "subnetMask": "255.255.255.0", "gateway": "10.0.0.1",
+					"port": "8000", "dhcp": "false",
+				},
+			},
+		},
+		{
+			name:    "setmailbox records email",
+			command: "setmailbox",
+			opts:    sadp.SendOptions{TargetIP: "192.168.1.64", Password: "Secret123!", Email: "ops@example.com"},
+			result:  &sadp.CommandResult{Success: true},
+			want: auditEntry{
+				Time: now, Command: "setmailbox", TargetIP: "192.168.1.64", DeviceUser: sadp.DefaultUsername,
+				Success: true, Params: map[string]string{"email": "ops@example.com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newAuditEntry(tt.command, tt.opts, tt.result, tt.sendErr, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("newAuditEntry() = %+v, want %+v", got, tt.want)
+			}
+
+			line, err := json.Marshal(got)
+			if err != nil {
+				t.Fatalf("json.Marshal() error = %v", err)
+			}
+			for _, secret := range append([]string{secrets.Password, secrets.Code, secrets.VerifyCode}, secrets.Answers...) {
+				if strings.Contains(string(line), secret) {
+					t.Errorf("audit entry %s contains secret %q", line, secret)
+				}
+			}
+		})
+	}
+}
+
+func TestAuditLogRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+
+	// Each open appends, so entries from earlier runs are kept
+	for _, command := range []string{"reboot", "restore"} {
+		audit, err := openAuditLog(path)
+		if err != nil {
+			t.Fatalf("openAuditLog() error = %v", err)
+		}
+		if err := audit.record(auditEntry{Command: command, TargetIP: "192.168.1.64", Success: true}); err != nil {
+			t.Fatalf("record() error = %v", err)
+		}
+		if err := audit.Close(); err != nil {
+			t.Fatalf("Close() error = %v", err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("audit log has %d lines, want 2:\n%s", len(lines), data)
+	}
+	for i, command := range []string{"reboot", "restore"} {
+		var entry auditEntry
+		if err := json.Unmarshal([]byte(lines[i]), &entry); err != nil {
+			t.Fatalf("line %d: %v", i+1, err)
+		}
+		if entry.Command != command || !entry.Success {
+			t.Errorf("line %d = %+v, want successful %s", i+1, entry, command)
+		}
+	}
+
+	// A nil log, from an empty --audit-log, records nothing
+	audit, err := openAuditLog("")
+	if err != nil || audit != nil {
+		t.Fatalf("openAuditLog(\"\") = %v, %v, want nil, nil", audit, err)
+	}
+	if err := audit.record(auditEntry{Command: "reboot"}); err != nil {
+		t.Errorf("nil record() error = %v", err)
+	}
+}
@@ -1,15 +1,26 @@
 package cli
 
 import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io"
+	"net"
 	"os"
+	"os/signal"
+	"path/filepath"
 	"regexp"
+	"strconv"
 	"strings"
+	"syscall"
 	"time"
 
 	"github.com/cameronnewman/hikvision-tooling/internal/config"
 	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
 	"github.com/cameronnewman/hikvision-tooling/internal/logger"
 	"github.com/cameronnewman/hikvision-tooling/internal/network"
 	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
@@ -33,8 +44,24 @@ func Run(args []string) error {
 		return ProbeCmd(args[1:])
 	case "send":
 		return SendCmd(args[1:])
+	case "activate:all":
+		return ActivateAllCmd(args[1:])
+	case "check-lockout":
+		return CheckLockoutCmd(args[1:])
 	case "reset":
 		return ResetCmd(args[1:])
+	case "reset:batch":
+		return ResetBatchCmd(args[1:])
+	case "decrypt":
+		return DecryptCmd(args[1:])
+	case "fingerprint":
+		return FingerprintCmd(args[1:])
+	case "probe-template":
+		return ProbeTemplateCmd(args[1:])
+	case "shell", "repl":
+		return ShellCmd(args[1:])
+	case "serve":
+		return ServeCmd(args[1:])
 	case "help", "--help", "-h":
 		PrintUsage()
 		return nil
@@ -44,6 +71,16 @@ func Run(args []string) error {
 	}
 }
 
+// flagErrorHandling is how command FlagSets handle --help and invalid
+// flags. The shell switches it to flag.ContinueOnError so that they return
+// an error rather than exit the process.
+var flagErrorHandling = flag.ExitOnError
+
+// newFlagSet creates a command's FlagSet using flagErrorHandling
+func newFlagSet(name string) *flag.FlagSet {
+	return flag.NewFlagSet(name, flagErrorHandling)
+}
+
 // PrintUsage prints the CLI usage information
 func PrintUsage() {
 	fmt.Println("SADP - Hikvision Device Discovery Tool")
@@ -57,7 +94,15 @@ func PrintUsage() {
 	fmt.Println("  scan <CIDR>        Discover devices using both ARP and SADP")
 	fmt.Println("  probe <IP>         Check device info and status")
 	fmt.Println("  send <IP> <cmd>    Send SADP XML command to a device")
+	fmt.Println("  activate:all       Activate every inactive device found via SADP")
+	fmt.Println("  check-lockout <IP> Report password lockout state without sending a password")
 	fmt.Println("  reset              Generate password reset code (firmware < 5.3.0)")
+	fmt.Println("  reset:batch <CSV>  Generate reset codes for a CSV of serial,date rows")
+	fmt.Println("  decrypt            Decrypt Hikvision AES/XOR encrypted data")
+	fmt.Println("  fingerprint <IP>   Merge SADP, HTTP, and ISAPI details into one profile")
+	fmt.Println("  probe-template <cmd> Print a command's XML with labeled placeholders")
+	fmt.Println("  shell              Interactive prompt with a current target and history")
+	fmt.Println("  serve              Serve discovery, send and reset codes as a local HTTP API")
 	fmt.Println("")
 	fmt.Println("Environment Variables:")
 	fmt.Println("  DISCOVERY_WORKERS   Number of concurrent workers (default: 100)")
@@ -82,35 +127,44 @@ func DiscoverCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("discover", flag.ExitOnError)
+	fs := newFlagSet("discover")
 	workers := fs.Int("workers", cfg.DiscoveryWorkers, "Number of concurrent workers for scanning")
 	timeout := fs.Duration("timeout", cfg.DiscoveryTimeout, "Timeout for each host probe")
 	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
-	_ = fs.Parse(args)
+	verbosity := addVerbosityFlags(fs)
+	var excludes stringSliceFlag
+	fs.Var(&excludes, "exclude", "IPs or CIDR blocks to skip, comma-separated (repeatable)")
+	arpOnly := fs.Bool("arp-only", false, "Skip the alive scan and read Hikvision entries straight from the ARP table")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
 	if fs.NArg() < 1 {
 		fmt.Println("Usage: sadp discover [options] <CIDR>")
 		fmt.Println("\nExamples:")
 		fmt.Println("  sadp discover 192.168.1.0/24")
 		fmt.Println("  sadp discover 10.0.0.0/16")
+		fmt.Println("  sadp discover --exclude 192.168.1.1,192.168.1.0/28 192.168.1.0/24")
+		fmt.Println("  sadp discover --arp-only 10.0.0.0/16")
 		fmt.Println("\nOptions:")
 		fs.PrintDefaults()
 		return nil
 	}
 
 	cidr := fs.Arg(0)
-	log := logger.New(*debug)
+	log := newCLILogger(*debug, *verbosity)
 	defer func() { _ = log.Sync() }()
 
-	ips, err := network.ExpandCIDR(cidr)
+	var devices []discoveredDevice
+	if *arpOnly {
+		devices, err = discoverFromARPTable(cidr, excludes, log)
+	} else {
+		devices, err = discoverDevices(context.Background(), cidr, excludes, *workers, *timeout, log)
+	}
 	if err != nil {
-		return fmt.Errorf("invalid CIDR: %w", err)
+		return err
 	}
 
-	log.Infow("Scanning IP addresses", "count", len(ips), "workers", *workers)
-
-	devices := discoverDevices(ips, *workers, *timeout, log)
-
 	fmt.Printf("\nDiscovered %d Hikvision device(s):\n", len(devices))
 	fmt.Println("---------------------------------------------------")
 	for _, dev := range devices {
@@ -125,60 +179,67 @@ type discoveredDevice struct {
 	MAC string
 }
 
-func discoverDevices(ips []string, workers int, timeout time.Duration, log *logger.Logger) []discoveredDevice {
-	type result struct {
-		ip    string
-		alive bool
+// discoverDevices sweeps cidr for live hosts and returns the Hikvision
+// entries the sweep leaves in the ARP table. Cancelling ctx stops the sweep.
+func discoverDevices(ctx context.Context, cidr string, excludes []string, workers int, timeout time.Duration, log *logger.Logger) ([]discoveredDevice, error) {
+	count, err := network.CountCIDR(cidr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %w", err)
 	}
 
-	// Channel for work distribution
-	ipChan := make(chan string, len(ips))
-	resultChan := make(chan result, len(ips))
+	// Pinging every silent address makes large sparse ranges take hours
+	ping := count <= network.PingFallbackMaxHosts
+	log.Infow("Scanning IP addresses", "count", count, "workers", workers, "pingFallback", ping)
 
-	// Start workers
-	for i := 0; i < workers; i++ {
-		go func() {
-			for ip := range ipChan {
-				alive := network.IsHostAlive(ip, timeout)
-				resultChan <- result{ip: ip, alive: alive}
-			}
-		}()
-	}
-
-	// Send work
-	for _, ip := range ips {
-		ipChan <- ip
+	aliveHosts, err := network.ScanSubnetContext(ctx, cidr, excludes, workers, network.NewAliveChecker(timeout, ping))
+	if err != nil {
+		return nil, err
 	}
-	close(ipChan)
-
-	// Collect results
-	aliveHosts := make([]string, 0)
-	for i := 0; i < len(ips); i++ {
-		r := <-resultChan
-		if r.alive {
-			aliveHosts = append(aliveHosts, r.ip)
-			log.Debugw("Host alive", "ip", r.ip)
-		}
+	for _, ip := range aliveHosts {
+		log.Debugw("Host alive", "ip", ip)
 	}
 
-	// Get ARP table
-	arpTable, err := network.GetARPTable()
+	// Get the Hikvision entries of the ARP table within the range
+	arpTable, err := network.GetARPTableFiltered(cidr, true)
 	if err != nil {
 		log.Warnw("Failed to read ARP table", "error", err)
-		return nil
+		return nil, nil
 	}
 
-	// Filter Hikvision devices
 	var devices []discoveredDevice
 	for _, ip := range aliveHosts {
 		if mac, ok := arpTable[ip]; ok {
-			if network.IsHikvisionMAC(mac) {
-				devices = append(devices, discoveredDevice{IP: ip, MAC: mac})
-			}
+			devices = append(devices, discoveredDevice{IP: ip, MAC: mac})
 		}
 	}
 
-	return devices
+	return devices, nil
+}
+
+// discoverFromARPTable returns the Hikvision entries of the current ARP
+// table within cidr without probing any host. It relies on the cache being
+// warm, e.g. after a recent ping sweep.
+func discoverFromARPTable(cidr string, excludes []string, log *logger.Logger) ([]discoveredDevice, error) {
+	if _, _, err := net.ParseCIDR(cidr); err != nil {
+		return nil, fmt.Errorf("invalid CIDR: %w", err)
+	}
+
+	arpTable, err := network.GetARPTableFiltered(cidr, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ARP table: %w", err)
+	}
+	log.Infow("Read Hikvision ARP entries", "entries", len(arpTable), "cidr", cidr)
+
+	ips, err := network.HikvisionARPEntries(arpTable, cidr, excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	devices := make([]discoveredDevice, 0, len(ips))
+	for _, ip := range ips {
+		devices = append(devices, discoveredDevice{IP: ip, MAC: arpTable[ip]})
+	}
+	return devices, nil
 }
 
 // DiscoverSADPCmd handles the discover:sadp command
@@ -188,85 +249,938 @@ func DiscoverSADPCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("discover:sadp", flag.ExitOnError)
+	fs := newFlagSet("discover:sadp")
 	timeout := fs.Duration("timeout", cfg.SADPTimeout, "Discovery timeout")
 	outputFile := fs.String("output", "", "Output file path (default: stdout)")
 	xmlFormat := fs.Bool("xml", false, "Output in XML format (SADP compatible)")
 	csvFormat := fs.Bool("csv", false, "Output in CSV format")
+	jsonlFormat := fs.Bool("jsonl", false, "Output one JSON object per device per line")
+	htmlFormat := fs.Bool("html", false, "Output a self-contained HTML report with summary counts and a sortable table")
+	gzipOutput := fs.Bool("gzip", false, "Gzip-compress --output (implied by a .gz extension)")
+	appendOutput := fs.Bool("append", false, "Append --csv/--jsonl rows with a scan timestamp to --output instead of overwriting")
+	inventory := fs.String("inventory", "", "Output an inventory: ansible (INI) or terraform (.tfvars.json)")
 	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
-	_ = fs.Parse(args)
+	verbosity := addVerbosityFlags(fs)
+	site := fs.String("site", "", "Site/location name recorded on every exported device")
+	var tagValues stringSliceFlag
+	fs.Var(&tagValues, "tag", "key=value tag recorded on every exported device, comma-separated (repeatable)")
+	baselineFile := fs.String("baseline", "", "File of approved device MACs (one per line, optional label)")
+	rogueOnly := fs.Bool("rogue-only", false, "With --baseline, output only devices not in the baseline")
+	missingOnly := fs.Bool("missing-only", false, "With --baseline, output only baseline devices that did not respond")
+	typeMapFile := fs.String("type-map", "", "JSON file mapping raw device types to canonical names")
+	verifyARP := fs.Bool("verify-arp", false, "Cross-check SADP-reported IP/MAC against the ARP table")
+	resolveDNS := fs.Bool("resolve-dns", false, "Look up each device's reverse DNS (PTR) hostname")
+	autoInterface := fs.Bool("auto-interface", false, "Probe only the best-looking physical interface")
+	var interfaceNames stringSliceFlag
+	fs.Var(&interfaceNames, "interface", "Probe only from these interfaces, e.g. eth0, comma-separated (repeatable)")
+	sourceIP := fs.String("source", "", "Probe only from this local IP address")
+	fromIP := fs.String("from", "", "Alias of --source")
+	requireMulticast := fs.Bool("require-multicast", false, "Fail fast if multicast does not work on a probed interface")
+	ipv6 := fs.Bool("ipv6", false, "Also probe the IPv6 SADP group (ff02::c) from each interface's link-local address")
+	jitter := fs.Duration("jitter", sadp.DefaultProbeJitter, "Max random delay between probe sends (0 disables)")
+	probeRetries := fs.Int("probe-retries", cfg.DiscoveryRetries, "Resend the probes this many more times while listening (0 disables)")
+	probeRetryInterval := fs.Duration("probe-retry-interval", sadp.DefaultProbeRetryInterval, "Delay between probe resends")
+	var directedBroadcasts stringSliceFlag
+	fs.Var(&directedBroadcasts, "directed-broadcast", "Also probe these directed-broadcast addresses, comma-separated (repeatable)")
+	probeUUID := fs.String("probe-uuid", "", "Fixed <Uuid> sent in every probe (for matching packet captures)")
+	probeUUIDPrefix := fs.String("probe-uuid-prefix", "", "Prefix for the random probe <Uuid>")
+	strictUUID := fs.Bool("strict-uuid", false, "Discard replies whose <Uuid> does not match our probe")
+	probeTypes := fs.String("probe-types", strings.Join(sadp.DefaultProbeTypes, ","), "Comma-separated discovery probes to send")
+	noV32 := fs.Bool("no-v32", false, "Do not send the inquiry_v32 probe (some legacy firmware misbehaves on it)")
+	grace := fs.Duration("grace", 0, "Keep listening this much longer for late responders once a device has answered")
+	bufferSize := fs.Int("buffer-size", sadp.MaxPacketSize, "Read buffer per reply in bytes; replies that fill it are dropped as truncated")
+	localPorts := fs.String("local-port-range", "", "Bind probes to a free local UDP port in this range, e.g. 40000-40100")
+	fromFile := fs.String("from-file", "", "Load devices from a saved .csv, .xml, or .json file instead of scanning")
+	watch := fs.Bool("watch", false, "Re-run discovery continuously")
+	interval := fs.Duration("interval", 10*time.Second, "Interval between watch cycles")
+	watchFor := fs.Duration("watch-for", 0, "Stop watching after this duration (default: unbounded)")
+	watchCycles := fs.Int("watch-cycles", 0, "Stop watching after this many cycles (default: unbounded)")
+	webhookURL := fs.String("on-change-webhook", "", "In watch mode, POST a JSON event to this URL for each device that appears, disappears or changes")
+	webhookTimeout := fs.Duration("webhook-timeout", 5*time.Second, "Timeout for each --on-change-webhook request")
+	webhookRetries := fs.Int("webhook-retries", 3, "Retries for a failed --on-change-webhook request")
+	missedCycles := fs.Int("missed-cycles", 3, "In watch mode, report a device as disappeared only after it misses this many cycles in a row")
+	countOnly := fs.Bool("count-only", false, "Print only the number of devices found, for scripts")
+	listOutput := fs.String("list", "", "Print only this field per device, deduplicated and sorted, for scripts: ip, mac or ip:port")
+	groupBy := fs.String("group-by", "", "Section the device table by oui, type, or subnet")
+	summary := fs.Bool("summary", false, "Print counts by device type and software version, active, inactive and DHCP, instead of the table")
+	wide := fs.Bool("wide", false, "Add HTTP port, SDK-over-TLS port and SDK server status columns to the table")
+	noTruncate := fs.Bool("no-truncate", false, "Print table values in full instead of fitting the terminal width")
+	assess := fs.Bool("assess", false, "Add a Risk column and list security findings (inactive, legacy-resettable firmware, Hik-Connect) to the table")
+	filterSDKTLS := fs.Bool("filter-sdk-tls", false, "Output only devices with the SDK-over-TLS port enabled")
+	onlyInactive := fs.Bool("only-inactive", false, "Output only devices that still need activating")
+	onlyActive := fs.Bool("only-active", false, "Output only activated devices")
+	typeContains := fs.String("type-contains", "", "Output only devices whose model contains this text, ignoring case (e.g. NVR)")
+	minFirmware := fs.String("min-firmware", "", "Output only devices on at least this firmware version, e.g. 5.5.0")
+	sortByBuild := fs.Bool("sort-by-build", false, "Order devices by firmware build date, oldest first")
+	since := fs.String("since", "", "Output only devices received within this duration (e.g. 10m) or since this RFC 3339 time")
+	attempts := fs.Int("attempts", 1, "Run discovery this many times and merge the results")
+	attemptGap := fs.Duration("attempt-gap", time.Second, "Delay between --attempts")
+	showStats := fs.Bool("stats", false, "Print per-interface probe and response counts to stderr after each discovery")
+	stream := fs.Bool("stream", false, "Print each device as soon as it answers, before the full results")
+	thenCmd := fs.String("then", "", "Send this SADP command to every discovered device that passes the filters")
+	thenUser := fs.String("user", sadp.DefaultUsername, "Device account --then authenticates as")
+	thenPassword := fs.String("password", "", "Device password for --then")
+	assumeYes := fs.Bool("yes", false, "Do not ask before --then sends a state-changing command")
+	thenAuditFile := fs.String("audit-log", "", "Append a JSON line per --then command sent (no secrets) to this file")
+	protectFile := fs.String("protect-list", "", "File of device MACs or serials that --then must not change")
+	overrideProtection := fs.Bool("override-protection", false, "Let --then change devices on --protect-list, with a warning")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *countOnly && (*watch || *watchFor > 0 || *watchCycles > 0) {
+		return fmt.Errorf("--count-only cannot be combined with watch mode")
+	}
+	if *webhookURL != "" && !(*watch || *watchFor > 0 || *watchCycles > 0) {
+		return fmt.Errorf("--on-change-webhook requires watch mode")
+	}
+	var listField sadp.ListField
+	if *listOutput != "" {
+		field, err := sadp.ParseListField(*listOutput)
+		if err != nil {
+			return err
+		}
+		if *countOnly || *watch || *watchFor > 0 || *watchCycles > 0 || *thenCmd != "" {
+			return fmt.Errorf("--list cannot be combined with --count-only, --then or watch mode")
+		}
+		listField = field
+	}
+	if *minFirmware != "" {
+		if _, _, _, ok := sadp.ParseSoftwareVersion(*minFirmware); !ok {
+			return fmt.Errorf("invalid --min-firmware %q (use a version such as 5.5.0)", *minFirmware)
+		}
+	}
+	// quiet keeps stdout to the --count-only or --list output
+	quiet := *countOnly || listField != ""
+	if *attempts < 1 {
+		return fmt.Errorf("--attempts must be at least 1")
+	}
+	if *missedCycles < 1 {
+		return fmt.Errorf("--missed-cycles must be at least 1")
+	}
+	if *showStats && *fromFile != "" {
+		return fmt.Errorf("--stats cannot be combined with --from-file")
+	}
+	if *stream && (*fromFile != "" || quiet || *watch || *watchFor > 0 || *watchCycles > 0) {
+		return fmt.Errorf("--stream cannot be combined with --from-file, --count-only, --list or watch mode")
+	}
+	if *probeRetries < 0 {
+		return fmt.Errorf("--probe-retries must not be negative")
+	}
+	if *thenCmd != "" {
+		if err := checkThenCommand(*thenCmd); err != nil {
+			return err
+		}
+		if *countOnly || *watch || *watchFor > 0 || *watchCycles > 0 {
+			return fmt.Errorf("--then cannot be combined with --count-only or watch mode")
+		}
+	}
+
+	// status prints progress lines, which --count-only and --list suppress
+	status := func(format string, a ...interface{}) {
+		if !quiet {
+			fmt.Printf(format, a...)
+		}
+	}
+
+	// scriptOutput prints the --count-only or --list output and reports
+	// whether it did, in which case nothing else is printed
+	scriptOutput := func(devices []*sadp.Device) bool {
+		switch {
+		case *countOnly:
+			fmt.Println(len(devices))
+		case listField != "":
+			for _, value := range sadp.FieldList(devices, listField) {
+				fmt.Println(value)
+			}
+		default:
+			return false
+		}
+		return true
+	}
+
+	switch *inventory {
+	case "", inventoryAnsible, inventoryTerraform:
+	default:
+		return fmt.Errorf("unknown inventory format %q (use %s or %s)", *inventory, inventoryAnsible, inventoryTerraform)
+	}
+
+	outputOpts := sadpOutputOptions{
+		OutputFile: *outputFile,
+		XML:        *xmlFormat,
+		CSV:        *csvFormat,
+		JSONL:      *jsonlFormat,
+		HTML:       *htmlFormat,
+		Append:     *appendOutput,
+		Gzip:       *gzipOutput || strings.HasSuffix(strings.ToLower(*outputFile), ".gz"),
+		Inventory:  *inventory,
+		VerifyARP:  *verifyARP,
+		Table:      tableOptions{Wide: *wide, NoTruncate: *noTruncate, Assess: *assess},
+	}
+	if *groupBy != "" {
+		key, err := sadp.ParseGroupKey(*groupBy)
+		if err != nil {
+			return err
+		}
+		outputOpts.GroupBy = key
+	}
+	if *summary {
+		if outputOpts.XML || outputOpts.CSV || outputOpts.JSONL || outputOpts.HTML || outputOpts.Inventory != "" ||
+			outputOpts.GroupBy != "" || *assess || quiet {
+			return fmt.Errorf("--summary replaces the table and cannot be combined with other output formats, --group-by, --assess, --count-only or --list")
+		}
+		outputOpts.Summary = true
+	}
+	if quiet && (outputOpts.OutputFile != "" || outputOpts.XML || outputOpts.CSV || outputOpts.JSONL || outputOpts.HTML ||
+		outputOpts.Inventory != "" || outputOpts.GroupBy != "" || *assess || outputOpts.VerifyARP) {
+		return fmt.Errorf("--count-only and --list print only their own output and cannot be combined with --output, other output formats, --group-by, --assess or --verify-arp")
+	}
+	if *assess && (outputOpts.XML || outputOpts.CSV || outputOpts.JSONL || outputOpts.HTML || outputOpts.Inventory != "") {
+		return fmt.Errorf("--assess applies to the table output only")
+	}
+	if outputOpts.Append && (outputOpts.OutputFile == "" || !(outputOpts.CSV || outputOpts.JSONL)) {
+		return fmt.Errorf("--append requires --output and --csv or --jsonl")
+	}
+
+	broadcasts, err := parseDirectedBroadcasts(directedBroadcasts)
+	if err != nil {
+		return err
+	}
+	portRange, err := sadp.ParsePortRange(*localPorts)
+	if err != nil {
+		return err
+	}
+	probes, err := sadp.ParseProbeTypes(*probeTypes)
+	if err != nil {
+		return err
+	}
+	if *noV32 {
+		probes = withoutProbeType(probes, "inquiry_v32")
+		if len(probes) == 0 {
+			return fmt.Errorf("--no-v32 leaves no probe types to send")
+		}
+	}
 
-	fmt.Println("Discovering Hikvision devices via SADP protocol...")
-	fmt.Println("Sending multicast probes to 239.255.255.250:37020")
+	var typeMap sadp.DeviceTypeMap
+	if *typeMapFile != "" {
+		typeMap, err = sadp.LoadDeviceTypeMap(*typeMapFile)
+		if err != nil {
+			return err
+		}
+	}
 
-	log := logger.New(*debug)
+	tags, err := sadp.ParseTags(tagValues)
+	if err != nil {
+		return err
+	}
+
+	if (*rogueOnly || *missingOnly) && *baselineFile == "" {
+		return fmt.Errorf("--rogue-only and --missing-only require --baseline")
+	}
+	source, err := parseSourceIP(*sourceIP, *fromIP)
+	if err != nil {
+		return err
+	}
+	if source != nil && *autoInterface {
+		return fmt.Errorf("--source and --auto-interface are mutually exclusive")
+	}
+	if *onlyInactive && *onlyActive {
+		return fmt.Errorf("--only-inactive and --only-active are mutually exclusive")
+	}
+	var sinceCutoff time.Time
+	if *since != "" {
+		if sinceCutoff, err = parseSince(*since, time.Now()); err != nil {
+			return err
+		}
+	}
+	if *rogueOnly && *missingOnly {
+		return fmt.Errorf("--rogue-only and --missing-only are mutually exclusive")
+	}
+	var baseline sadp.Baseline
+	if *baselineFile != "" {
+		baseline, err = sadp.LoadBaseline(*baselineFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	audit, err := openAuditLog(*thenAuditFile)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	guard, err := newProtectionGuard(*protectFile, *overrideProtection, sadp.NewScannerWithOptions(*timeout, nil, sadp.DefaultDiscoverOptions()))
+	if err != nil {
+		return err
+	}
+
+	// finish writes the output and then runs --then against the devices
+	finish := func(scanner *sadp.Scanner, devices []*sadp.Device) error {
+		if err := writeSADPOutput(scanner, devices, outputOpts); err != nil {
+			return err
+		}
+		if *thenCmd == "" {
+			return nil
+		}
+		opts := sadp.SendOptions{Username: *thenUser, Password: *thenPassword}
+		return runThen(scanner, *thenCmd, devices, opts, *assumeYes, audit, guard)
+	}
+
+	var resolver *network.HostnameResolver
+	if *resolveDNS {
+		resolver = network.NewHostnameResolver(network.DefaultDNSTimeout, network.DefaultDNSWorkers)
+	}
+
+	process := func(devices []*sadp.Device) []*sadp.Device {
+		if *filterSDKTLS {
+			devices = sadp.WithSDKOverTLS(devices)
+		}
+		devices = sadp.FilterDevices(devices, sadp.DeviceFilter{
+			OnlyInactive: *onlyInactive,
+			OnlyActive:   *onlyActive,
+			TypeContains: *typeContains,
+		})
+		if !sinceCutoff.IsZero() {
+			devices = sadp.ReceivedSince(devices, sinceCutoff)
+		}
+		if *minFirmware != "" {
+			devices = sadp.WithMinFirmware(devices, *minFirmware)
+		}
+		if *baselineFile != "" {
+			report := sadp.ClassifyAgainstBaseline(devices, baseline)
+			status("Baseline: %d known, %d rogue, %d missing\n", len(report.Known), len(report.Rogue), len(report.Missing))
+			switch {
+			case *rogueOnly:
+				devices = report.Rogue
+			case *missingOnly:
+				devices = report.MissingDevices()
+			}
+		}
+		if *sortByBuild {
+			sadp.SortByBuildDate(devices)
+		}
+		// Rename only for display, once filters and the baseline have
+		// matched against the model the device reported
+		typeMap.Apply(devices)
+		sadp.Annotate(devices, *site, tags)
+		if resolver != nil {
+			resolveHostnames(resolver, devices)
+		}
+		return devices
+	}
+
+	// The logger writes to stdout, which --count-only and --list keep clean
+	log := newCLILogger(*debug, *verbosity)
+	if quiet {
+		log = logger.NewNop()
+	}
 	defer func() { _ = log.Sync() }()
 
-	scanner := sadp.NewScanner(*timeout, log)
-	devices, err := scanner.Discover()
+	scanner := sadp.NewScannerWithOptions(*timeout, log, sadp.DiscoverOptions{
+		AutoInterface:      *autoInterface,
+		ProbeJitter:        *jitter,
+		ProbeRetries:       *probeRetries,
+		ProbeRetryInterval: *probeRetryInterval,
+		GraceWindow:        *grace,
+		DirectedBroadcasts: broadcasts,
+		ProbeUUID:          *probeUUID,
+		ProbeUUIDPrefix:    *probeUUIDPrefix,
+		StrictUUID:         *strictUUID,
+		BufferSize:         *bufferSize,
+		ProbeTypes:         probes,
+		RequireMulticast:   *requireMulticast,
+		LocalPortRange:     portRange,
+		IPv6:               *ipv6,
+		Interfaces:         interfaceNames,
+		SourceIP:           source,
+	})
+
+	if *fromFile != "" {
+		devices, err := sadp.LoadDevicesFromFile(*fromFile)
+		if err != nil {
+			return err
+		}
+		status("Loaded %d device(s) from %s\n", len(devices), *fromFile)
+		devices = process(devices)
+		if scriptOutput(devices) {
+			return nil
+		}
+		return finish(scanner, devices)
+	}
+
+	status("Discovering Hikvision devices via SADP protocol...\n")
+	status("Sending multicast probes to 239.255.255.250:37020\n")
+
+	if *autoInterface {
+		ifaces, err := scanner.ProbeInterfaces()
+		if err != nil {
+			return err
+		}
+		status("Auto-selected interface: %s (%s)\n", ifaces[0].Name, ifaces[0].IP)
+	}
+
+	watching := *watch || *watchFor > 0 || *watchCycles > 0
+	ctx := context.Background()
+	if watching {
+		// Ctrl-C ends watch mode after the summary instead of killing it
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+	discover := func() ([]*sadp.Device, error) { return scanner.DiscoverContext(ctx) }
+	if *stream {
+		discover = streamDiscover(scanner, os.Stdout)
+	}
+	if len(interfaceNames) > 0 {
+		status("Probing only from interface(s) %s\n", strings.Join(interfaceNames, ", "))
+	}
+	if source != nil {
+		status("Probing only from %s\n", source)
+	}
+	if *showStats {
+		discover = withStats(scanner, discover, os.Stderr, *attempts)
+	}
+	if *attempts > 1 {
+		once := discover
+		discover = func() ([]*sadp.Device, error) {
+			devices, newPerAttempt, err := sadp.RepeatDiscover(once, *attempts, *attemptGap)
+			if err != nil {
+				return nil, err
+			}
+			status("%d attempt(s): %d device(s) found only after the first attempt\n",
+				*attempts, sadp.LateDiscoveries(newPerAttempt))
+			return devices, nil
+		}
+	}
+
+	if watching {
+		webhook, err := newWebhookNotifier(*webhookURL, *webhookTimeout, *webhookRetries)
+		if err != nil {
+			return err
+		}
+		return runWatch(ctx, scanner, watchOptions{
+			Interval:     *interval,
+			For:          *watchFor,
+			Cycles:       *watchCycles,
+			MissedCycles: *missedCycles,
+			Process:      process,
+			Discover:     discover,
+			Table:        outputOpts.Table,
+			Webhook:      webhook,
+			Redraw:       stdoutIsTerminal(),
+		})
+	}
+
+	devices, err := discover()
 	if err != nil {
 		return err
 	}
+	devices = process(devices)
+
+	if scriptOutput(devices) {
+		return nil
+	}
 
 	fmt.Printf("\nDiscovered %d device(s)\n", len(devices))
+	if len(devices) == 0 {
+		printContentionHint(scanner)
+	}
+	printWarningSummary(scanner)
+
+	return finish(scanner, devices)
+}
+
+// warningSummary returns the footer for n discovery warnings, or "" for none
+func warningSummary(n int) string {
+	switch n {
+	case 0:
+		return ""
+	case 1:
+		return "1 warning (use --debug for detail)"
+	default:
+		return fmt.Sprintf("%d warnings (use --debug for detail)", n)
+	}
+}
+
+// printWarningSummary prints the warning footer for the last discovery
+func printWarningSummary(scanner *sadp.Scanner) {
+	if summary := warningSummary(len(scanner.Warnings())); summary != "" {
+		fmt.Println(summary)
+	}
+}
+
+// resolveHostnames fills in each device's PTR hostname
+func resolveHostnames(resolver *network.HostnameResolver, devices []*sadp.Device) {
+	ips := make([]string, 0, len(devices))
+	for _, dev := range devices {
+		ips = append(ips, dev.IPv4Address)
+	}
+	names := resolver.LookupAll(ips)
+	for _, dev := range devices {
+		dev.Hostname = names[dev.IPv4Address]
+	}
+}
+
+// parseDirectedBroadcasts validates --directed-broadcast values as IPv4
+// addresses
+func parseDirectedBroadcasts(values []string) ([]net.IP, error) {
+	var ips []net.IP
+	for _, v := range values {
+		ip := net.ParseIP(strings.TrimSpace(v)).To4()
+		if ip == nil {
+			return nil, fmt.Errorf("invalid directed-broadcast address %q", v)
+		}
+		ips = append(ips, ip)
+	}
+	return ips, nil
+}
+
+// withoutProbeType returns probes with the given type removed
+func withoutProbeType(probes []string, drop string) []string {
+	kept := make([]string, 0, len(probes))
+	for _, p := range probes {
+		if p != drop {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// Inventory formats accepted by discover:sadp --inventory
+const (
+	inventoryAnsible   = "ansible"
+	inventoryTerraform = "terraform"
+)
+
+// sadpOutputOptions selects how a device list is rendered
+type sadpOutputOptions struct {
+	OutputFile string
+	XML        bool
+	CSV        bool
+	JSONL      bool
+	HTML       bool
+	Append     bool
+	Gzip       bool
+	Inventory  string
+	VerifyARP  bool
+	GroupBy    sadp.GroupKey
+	Table      tableOptions
+	// Summary prints counts by model and firmware instead of the table
+	Summary bool
+}
+
+// writeSADPOutput renders devices as a table, XML, or CSV to stdout or a file
+func writeSADPOutput(scanner *sadp.Scanner, devices []*sadp.Device, opts sadpOutputOptions) error {
+	if opts.VerifyARP {
+		if err := verifyARPStatus(devices); err != nil {
+			return err
+		}
+		defer printARPSummary(devices)
+	}
+
+	if opts.Append {
+		if err := appendSADPOutput(scanner, devices, opts, time.Now()); err != nil {
+			return err
+		}
+		fmt.Printf("Appended %d device(s) to: %s\n", len(devices), opts.OutputFile)
+		return nil
+	}
 
 	var output string
-	if *xmlFormat {
+	var err error
+	if opts.XML {
 		output, err = scanner.ToXML(devices)
 		if err != nil {
 			return fmt.Errorf("error generating XML: %w", err)
 		}
-	} else if *csvFormat {
+	} else if opts.CSV {
 		output = scanner.ToCSV(devices)
+	} else if opts.JSONL {
+		output, err = scanner.ToJSONL(devices, time.Now())
+		if err != nil {
+			return fmt.Errorf("error generating JSONL: %w", err)
+		}
+	} else if opts.HTML {
+		output, err = scanner.ToHTML(devices, time.Now())
+		if err != nil {
+			return fmt.Errorf("error generating HTML: %w", err)
+		}
+	} else if opts.Inventory == inventoryAnsible {
+		output = scanner.ToAnsibleInventory(devices)
+	} else if opts.Inventory == inventoryTerraform {
+		output, err = scanner.ToTerraformJSON(devices)
+		if err != nil {
+			return fmt.Errorf("error generating inventory: %w", err)
+		}
 	} else {
-		printDeviceTable(devices)
-		if *outputFile != "" {
+		if opts.Summary {
+			printSummary(devices)
+		} else if opts.GroupBy != "" {
+			printGroupedDeviceTable(devices, opts.GroupBy, opts.Table)
+		} else {
+			printDeviceTable(devices, opts.Table)
+		}
+		if opts.OutputFile != "" {
 			output, _ = scanner.ToXML(devices)
 		}
 	}
 
-	if *outputFile != "" && output != "" {
-		err := os.WriteFile(*outputFile, []byte(output), 0644)
+	if opts.OutputFile != "" && output != "" {
+		err := writeOutputFile(opts.OutputFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, output, opts.Gzip)
 		if err != nil {
-			return fmt.Errorf("error writing file: %w", err)
+			return err
 		}
-		fmt.Printf("Output written to: %s\n", *outputFile)
-	} else if output != "" && (*xmlFormat || *csvFormat) {
+		fmt.Printf("Output written to: %s\n", opts.OutputFile)
+	} else if output != "" && (opts.XML || opts.CSV || opts.JSONL || opts.HTML || opts.Inventory != "") {
 		fmt.Println(output)
 	}
 
 	return nil
 }
 
-func printDeviceTable(devices []*sadp.Device) {
+// appendSADPOutput appends timestamped CSV or JSONL rows to the output file.
+// The CSV header is written only when the file is new or empty; rows for an
+// existing file follow its header, so columns stay aligned across scans.
+func appendSADPOutput(scanner *sadp.Scanner, devices []*sadp.Device, opts sadpOutputOptions, scanTime time.Time) error {
+	var output string
+	var err error
+	if opts.JSONL {
+		output, err = scanner.ToJSONL(devices, scanTime)
+		if err != nil {
+			return fmt.Errorf("error generating JSONL: %w", err)
+		}
+	} else {
+		header, err := readCSVHeader(opts.OutputFile, opts.Gzip)
+		if err != nil {
+			return err
+		}
+		for _, col := range missingCSVColumns(header, devices) {
+			fmt.Fprintf(os.Stderr, "Warning: %s has no %s column; those values are not appended\n", opts.OutputFile, col)
+		}
+		output = scanner.ToScanCSV(devices, scanTime, header)
+	}
+
+	// Appending to a .gz file adds a new gzip member, which gzip readers
+	// decompress as one continuous stream
+	return writeOutputFile(opts.OutputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, output, opts.Gzip)
+}
+
+// readCSVHeader returns the columns of the first line of an existing CSV
+// file, or nil when the file does not exist or is empty
+func readCSVHeader(path string, gz bool) ([]string, error) {
+	f, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	if info, err := f.Stat(); err != nil {
+		return nil, fmt.Errorf("error opening file: %w", err)
+	} else if info.Size() == 0 {
+		return nil, nil
+	}
+
+	var r io.Reader = f
+	if gz {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("error reading file header: %w", err)
+		}
+		r = zr
+	}
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return nil, fmt.Errorf("error reading file header: %w", err)
+	}
+	if line = strings.TrimRight(line, "\r\n"); line == "" {
+		return nil, nil
+	}
+	return strings.Split(line, ","), nil
+}
+
+// missingCSVColumns lists the columns devices export that header lacks
+func missingCSVColumns(header []string, devices []*sadp.Device) []string {
+	if header == nil {
+		return nil
+	}
+	present := make(map[string]bool, len(header))
+	for _, name := range header {
+		present[name] = true
+	}
+	var missing []string
+	for _, col := range sadp.DeviceColumns(devices) {
+		if !present[col.Name] {
+			missing = append(missing, col.Name)
+		}
+	}
+	return missing
+}
+
+// writeOutputFile streams data to path, gzip-compressing it when gz is set
+func writeOutputFile(path string, flags int, data string, gz bool) error {
+	f, err := os.OpenFile(path, flags, 0644)
+	if err != nil {
+		return fmt.Errorf("error opening file: %w", err)
+	}
+	defer f.Close()
+
+	var w io.Writer = f
+	var zw *gzip.Writer
+	if gz {
+		zw = gzip.NewWriter(f)
+		w = zw
+	}
+
+	if _, err := io.WriteString(w, data); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	if zw != nil {
+		if err := zw.Close(); err != nil {
+			return fmt.Errorf("error writing file: %w", err)
+		}
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("error writing file: %w", err)
+	}
+	return nil
+}
+
+// verifyARPStatus records each device's ARP verification status, which the
+// table and exports then show as a column
+func verifyARPStatus(devices []*sadp.Device) error {
+	arpTable, err := network.GetARPTable()
+	if err != nil {
+		return fmt.Errorf("failed to read ARP table: %w", err)
+	}
+	for _, dev := range devices {
+		dev.ARPStatus = verifyARP(dev, arpTable)
+	}
+	return nil
+}
+
+// ARP verification statuses for SADP-discovered devices
+const (
+	arpVerified = "verified"
+	arpMismatch = "mismatch"
+	arpNotInARP = "not-in-arp"
+)
+
+// verifyARP compares a device's self-reported MAC with the ARP entry for its
+// reported IP
+func verifyARP(dev *sadp.Device, arpTable network.ARPTable) string {
+	arpMAC, ok := arpTable[dev.IPv4Address]
+	if !ok {
+		return arpNotInARP
+	}
+	if network.NormalizeMAC(arpMAC) != network.NormalizeMAC(dev.MAC) {
+		return arpMismatch
+	}
+	return arpVerified
+}
+
+// printARPSummary counts the devices ARP could not vouch for. It goes to
+// stderr so a machine format on stdout stays parseable.
+func printARPSummary(devices []*sadp.Device) {
+	unverified := 0
+	for _, dev := range devices {
+		if dev.ARPStatus != arpVerified {
+			unverified++
+		}
+	}
+	fmt.Fprintf(os.Stderr, "%d of %d device(s) could not be verified against ARP\n", unverified, len(devices))
+}
+
+// printContentionHint explains a likely cause when nothing answered even
+// though at least one interface was up to probe from
+func printContentionHint(scanner *sadp.Scanner) {
+	ifaces, err := scanner.ProbeInterfaces()
+	if err != nil || len(ifaces) == 0 {
+		return
+	}
+	if sadp.SADPPortInUse() {
+		fmt.Printf("Hint: UDP port %d is already in use. %s\n", sadp.Port, sadp.ContentionHint)
+		return
+	}
+	fmt.Printf("Hint: %s\n", sadp.ContentionHint)
+}
+
+// printDeviceTable prints devices as a table fitted to the terminal width
+func printDeviceTable(devices []*sadp.Device, topts tableOptions) {
 	if len(devices) == 0 {
 		fmt.Println("No devices found.")
 		return
 	}
 
 	fmt.Println()
-	fmt.Printf("%-3s %-15s %-17s %-20s %-8s %-6s %-15s %s\n",
-		"#", "IPv4 Address", "MAC Address", "Device Type", "Status", "Port", "Serial Number", "Software Version")
-	fmt.Println(strings.Repeat("-", 120))
+	for _, line := range formatDeviceTable(devices, topts, terminalWidth()) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+	if topts.Assess {
+		for _, line := range formatFindings(devices) {
+			fmt.Println(line)
+		}
+		fmt.Println()
+	}
+}
 
+// formatDeviceTable renders the header, rule and one line per device.
+// Device type, serial number, software version and hostname are truncated
+// as needed to fit width, unless topts.NoTruncate is set. An Adapter column
+// shows the local address each device answered on, when any device records
+// one. With topts.Assess a Risk column summarizes each device's security
+// findings.
+func formatDeviceTable(devices []*sadp.Device, topts tableOptions, width int) []string {
+	resolved, adapter, arp := false, false, false
+	for _, dev := range devices {
+		if dev.Hostname != "" {
+			resolved = true
+		}
+		if dev.AdapterIP != "" {
+			adapter = true
+		}
+		if dev.ARPStatus != "" {
+			arp = true
+		}
+	}
+
+	headers := []string{"#", "IPv4 Address", "MAC Address", "Device Type", "Status", "Port", "Serial Number", "Software Version"}
+	flexible := []bool{false, false, false, true, false, false, true, true}
+	if adapter {
+		headers = append(headers, "Adapter")
+		flexible = append(flexible, false)
+	}
+	if topts.Wide {
+		headers = append(headers, "HTTP", "SDK TLS", "SDK Status")
+		flexible = append(flexible, false, false, false)
+	}
+	if resolved {
+		headers = append(headers, "Hostname")
+		flexible = append(flexible, true)
+	}
+	if arp {
+		headers = append(headers, "ARP")
+		flexible = append(flexible, false)
+	}
+	if topts.Assess {
+		headers = append(headers, "Risk")
+		flexible = append(flexible, true)
+	}
+
+	rows := [][]string{headers}
 	for i, dev := range devices {
 		status := "Inactive"
 		if dev.Activated == "true" {
 			status = "Active"
 		}
 
-		fmt.Printf("%-3d %-15s %-17s %-20s %-8s %-6d %-15s %s\n",
-			i+1,
+		row := []string{
+			strconv.Itoa(i + 1),
 			dev.IPv4Address,
 			dev.MAC,
-			sadp.Truncate(dev.DeviceType, 20),
+			dev.DeviceType,
 			status,
-			dev.CommandPort,
-			sadp.Truncate(dev.DeviceSN, 15),
+			strconv.Itoa(int(dev.CommandPort)),
+			dev.DeviceSN,
 			dev.SoftwareVersion,
-		)
+		}
+		if adapter {
+			row = append(row, valueOrDash(dev.AdapterIP))
+		}
+		if topts.Wide {
+			row = append(row, strconv.Itoa(int(dev.HttpPort)), portOrDash(dev.SDKOverTLSPort), valueOrDash(dev.SDKServerStatus))
+		}
+		if resolved {
+			row = append(row, dev.Hostname)
+		}
+		if arp {
+			row = append(row, valueOrDash(dev.ARPStatus))
+		}
+		if topts.Assess {
+			row = append(row, sadp.RiskSummary(sadp.SecurityAssessment(dev)))
+		}
+		rows = append(rows, row)
+	}
+
+	widths := naturalWidths(rows)
+	if !topts.NoTruncate {
+		widths = fitColumnWidths(rows, flexible, width)
+	}
+
+	lines := []string{tableRow(truncateRow(headers, widths), widths), strings.Repeat("-", tableWidth(widths))}
+	for _, row := range rows[1:] {
+		lines = append(lines, tableRow(truncateRow(row, widths), widths))
+	}
+	return lines
+}
+
+// tableRow left-aligns each cell to its width, leaving the last cell
+// unpadded so rows carry no trailing spaces
+func tableRow(cells []string, widths []int) string {
+	var b strings.Builder
+	for i, cell := range cells {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		if i == len(cells)-1 {
+			b.WriteString(cell)
+			break
+		}
+		fmt.Fprintf(&b, "%-*s", widths[i], cell)
+	}
+	return b.String()
+}
+
+// portOrDash formats a port, showing an unset port as "-"
+func portOrDash(port sadp.PortNumber) string {
+	if port == 0 {
+		return "-"
+	}
+	return strconv.Itoa(int(port))
+}
+
+// valueOrDash shows an empty value as "-"
+func valueOrDash(v string) string {
+	if v == "" {
+		return "-"
+	}
+	return v
+}
+
+// groupHeader labels a device table section, naming the vendor of known OUIs
+func groupHeader(name string, key sadp.GroupKey, count int) string {
+	label := name
+	if key == sadp.GroupByOUI && network.IsHikvisionMAC(name+":00:00:00") {
+		label += " (Hikvision)"
+	}
+	return fmt.Sprintf("== %s: %d device(s) ==", label, count)
+}
+
+// printSummary prints the fleet summary of devices
+func printSummary(devices []*sadp.Device) {
+	fmt.Println()
+	for _, line := range formatSummary(sadp.Summarize(devices)) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+// printGroupedDeviceTable prints one device table per group
+func printGroupedDeviceTable(devices []*sadp.Device, key sadp.GroupKey, topts tableOptions) {
+	if len(devices) == 0 {
+		fmt.Println("No devices found.")
+		return
+	}
+
+	groups := sadp.GroupDevices(devices, key)
+	for _, name := range sadp.GroupNames(groups) {
+		fmt.Println()
+		fmt.Println(groupHeader(name, key, len(groups[name])))
+		printDeviceTable(groups[name], topts)
 	}
-	fmt.Println()
 }
 
 // SendCmd handles the send command
@@ -276,28 +1190,145 @@ func SendCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("send", flag.ExitOnError)
+	fs := newFlagSet("send")
 	mac := fs.String("mac", "", "Target device MAC address (required for most commands)")
 	password := fs.String("password", "", "Device password")
+	user := fs.String("user", sadp.DefaultUsername, "Device account to authenticate as (for update, reboot, restore, setmailbox, ezvizunbind)")
 	code := fs.String("code", "", "Security/reset code")
 	newIP := fs.String("ip", "", "New IP address (for update command)")
 	newMask := fs.String("mask", "255.255.255.0", "New subnet mask (for update command)")
 	newGateway := fs.String("gateway", "", "New gateway (for update command)")
 	newPort := fs.Int("port", 8000, "New SDK port (for update command)")
 	dhcp := fs.Bool("dhcp", false, "Enable DHCP (for update command)")
+	dns1 := fs.String("dns1", "", "Primary DNS server (for update command)")
+	dns2 := fs.String("dns2", "", "Secondary DNS server (for update command)")
+	preserve := fs.Bool("preserve", false, "With update, keep the device's current IP, mask, gateway, port and DHCP for any not given")
 	email := fs.String("email", "", "Email address (for setmailbox command)")
-	timeout := fs.Duration("timeout", cfg.SADPTimeout, "Command timeout")
+	ntpServer := fs.String("server", "", "NTP server host name or IPv4 address (for set-ntp)")
+	firmwareFile := fs.String("file", "", "Firmware image to upload (for upgrade)")
+	upgradeTimeout := fs.Duration("upgrade-timeout", 15*time.Minute, "Time allowed for the firmware upload and upgrade (for upgrade)")
+	assumeYes := fs.Bool("yes", false, "Do not ask before upgrade writes firmware")
+	force := fs.Bool("force", false, "Send activate even if the password fails the device complexity rules")
+	raw := fs.Bool("raw", false, "Do not decrypt the challenge in exchangecode and getencryptstring replies")
+	verifyCode := fs.String("verify-code", "", "Hik-Connect/EZVIZ verification code (for getbindlist, ezvizunbind)")
+	answer1 := fs.String("answer1", "", "Answer to security question 1 (for securitycode)")
+	answer2 := fs.String("answer2", "", "Answer to security question 2 (for securitycode)")
+	answer3 := fs.String("answer3", "", "Answer to security question 3 (for securitycode)")
+	timeout := fs.Duration("timeout", 0, "Reply timeout for every command (default: per command, see --list)")
+	sendUUID := fs.String("probe-uuid", "", "Fixed <Uuid> for the command (default: random)")
+	retryUntil := fs.Duration("retry-until", 0, "Keep resending until a response arrives or this much time passes")
+	retryInterval := fs.Duration("retry-interval", 2*time.Second, "Delay between resends with --retry-until")
+	localPorts := fs.String("local-port-range", "", "Send from a free local UDP port in this range, e.g. 40000-40100")
+	targetsFile := fs.String("targets", "", "File of target devices (IP and optional MAC per line) to send the command to in turn")
+	attempts := fs.Int("attempts", 1, "Maximum sends per device with --targets")
+	minAttemptsLeft := fs.Int("min-attempts-left", sadp.DefaultMinRemainingAttempts, "Stop retrying a device that reports this many or fewer password attempts left")
+	protectFile := fs.String("protect-list", "", "File of device MACs or serials that state-changing commands must not be sent to")
+	overrideProtection := fs.Bool("override-protection", false, "Send to devices on --protect-list anyway, with a warning")
+	checkLockout := fs.Bool("check-lockout", false, "Before sending a password, skip devices ISAPI reports as locked or with --min-attempts-left or fewer attempts")
+	auditFile := fs.String("audit-log", "", "Append a JSON line per command sent (no secrets) to this file")
 	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
+	verbosity := addVerbosityFlags(fs)
 	listCmds := fs.Bool("list", false, "List available commands")
 
 	reorderedArgs := reorderArgsForFlags(args)
-	_ = fs.Parse(reorderedArgs)
+	if err := fs.Parse(reorderedArgs); err != nil {
+		return err
+	}
 
 	if *listCmds {
 		printCommandList()
 		return nil
 	}
 
+	if strings.TrimSpace(*user) == "" {
+		return fmt.Errorf("--user must not be empty")
+	}
+	if *timeout < 0 {
+		return fmt.Errorf("--timeout must not be negative")
+	}
+	// The scanner's own inquiries (e.g. the securitycode pre-check) use
+	// SADP_TIMEOUT unless --timeout overrides it
+	scannerTimeout := cfg.SADPTimeout
+	if *timeout > 0 {
+		scannerTimeout = *timeout
+	}
+	portRange, err := sadp.ParsePortRange(*localPorts)
+	if err != nil {
+		return err
+	}
+	scannerOpts := sadp.DefaultDiscoverOptions()
+	scannerOpts.LocalPortRange = portRange
+
+	audit, err := openAuditLog(*auditFile)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	guard, err := newProtectionGuard(*protectFile, *overrideProtection, sadp.NewScannerWithOptions(scannerTimeout, nil, scannerOpts))
+	if err != nil {
+		return err
+	}
+
+	if *targetsFile != "" {
+		if fs.NArg() != 1 {
+			return fmt.Errorf("--targets takes the command as its only argument")
+		}
+		if *preserve {
+			return fmt.Errorf("--preserve cannot be combined with --targets")
+		}
+		if fs.Arg(0) == upgradeCommand {
+			return fmt.Errorf("%s cannot be combined with --targets", upgradeCommand)
+		}
+		if err := checkActivatePassword(fs.Arg(0), *password, *force); err != nil {
+			return err
+		}
+		targets, err := sadp.LoadBatchTargets(*targetsFile)
+		if err != nil {
+			return err
+		}
+
+		log := newCLILogger(*debug, *verbosity)
+		defer func() { _ = log.Sync() }()
+
+		opts := sadp.SendOptions{
+			TargetMAC:  network.NormalizeMAC(*mac),
+			Username:   *user,
+			Password:   *password,
+			Code:       *code,
+			NewMask:    *newMask,
+			NewGateway: *newGateway,
+			NewPort:    *newPort,
+			DHCP:       *dhcp,
+			DNS1:       *dns1,
+			DNS2:       *dns2,
+			Email:      *email,
+			VerifyCode: *verifyCode,
+			ProbeUUID:  *sendUUID,
+			Timeout:    *timeout,
+
+			AllowWeakPassword: *force,
+		}
+		if *answer1 != "" || *answer2 != "" || *answer3 != "" {
+			opts.Answers = []string{*answer1, *answer2, *answer3}
+		}
+		bopts := sadp.BatchOptions{
+			Attempts:             *attempts,
+			Interval:             *retryInterval,
+			MinRemainingAttempts: *minAttemptsLeft,
+		}
+		if err := guard.checkAll(fs.Arg(0), targets, nil); err != nil {
+			return err
+		}
+		if *checkLockout && sadp.Commands[fs.Arg(0)].NeedsPass {
+			targets = lockoutSafeTargets(newLockoutChecker(cfg), targets, *minAttemptsLeft)
+			if len(targets) == 0 {
+				return fmt.Errorf("every target is locked or near lockout; nothing was sent")
+			}
+		}
+		return runSendBatch(sadp.NewScannerWithOptions(scannerTimeout, log, scannerOpts), fs.Arg(0), opts, targets, bopts, audit)
+	}
+
 	if fs.NArg() < 1 {
 		fmt.Println("Usage: sadp send <IP> <command> [options]")
 		fmt.Println("       sadp send --list")
@@ -312,6 +1343,10 @@ func SendCmd(args []string) error {
 		fmt.Println("  sadp send 192.168.1.64 inquiry")
 		fmt.Println("  sadp send 192.168.1.64 exchangecode --mac 4C:BD:8F:61:CC:5C")
 		fmt.Println("  sadp send 0.0.0.0 exchangecode --mac 4C:BD:8F:61:CC:5C  (uses broadcast)")
+		fmt.Println("  sadp send 192.168.1.64 ezvizunbind --mac 4C:BD:8F:61:CC:5C --verify-code ABCDEF")
+		fmt.Println("  sadp send 192.168.1.64 inquiry --retry-until 60s  (wait for a rebooting device)")
+		fmt.Println("  sadp send --targets cameras.txt reboot --password secret --attempts 2")
+		fmt.Println("  sadp send 192.168.1.64 upgrade --file digicap.dav --password secret")
 		return nil
 	}
 
@@ -320,16 +1355,52 @@ func SendCmd(args []string) error {
 	if fs.NArg() >= 2 {
 		command = fs.Arg(1)
 	}
+	if err := checkActivatePassword(command, *password, *force); err != nil {
+		return err
+	}
+
+	if err := guard.check(command, sadp.BatchTarget{IP: targetIP, MAC: network.NormalizeMAC(*mac)}, nil); err != nil {
+		return err
+	}
+
+	if command == setNTPCommand {
+		isapiPassword := *password
+		if isapiPassword == "" {
+			isapiPassword = cfg.ISAPIPassword
+		}
+		httpClient := network.NewHTTPClient(cfg.UserAgent, cfg.HTTPTimeout)
+		err := runSetNTP(isapi.NewClient(httpClient, *user, isapiPassword), targetIP, *ntpServer)
+
+		entry := newAuditEntry(command, sadp.SendOptions{TargetIP: targetIP, Username: *user}, nil, err, time.Now())
+		entry.Success = err == nil
+		entry.Params = map[string]string{"server": *ntpServer}
+		return errors.Join(err, audit.record(entry))
+	}
+
+	if command == upgradeCommand {
+		isapiPassword := *password
+		if isapiPassword == "" {
+			isapiPassword = cfg.ISAPIPassword
+		}
+		httpClient := network.NewHTTPClient(cfg.UserAgent, cfg.HTTPTimeout)
+		err := runUpgrade(isapi.NewClient(httpClient, *user, isapiPassword), targetIP, *firmwareFile, *upgradeTimeout, *assumeYes, os.Stdin)
+
+		entry := newAuditEntry(command, sadp.SendOptions{TargetIP: targetIP, Username: *user}, nil, err, time.Now())
+		entry.Success = err == nil
+		entry.Params = map[string]string{"file": filepath.Base(*firmwareFile)}
+		return errors.Join(err, audit.record(entry))
+	}
 
-	macAddr := strings.ToUpper(strings.ReplaceAll(*mac, "-", ":"))
+	macAddr := network.NormalizeMAC(*mac)
 
-	log := logger.New(*debug)
+	log := newCLILogger(*debug, *verbosity)
 	defer func() { _ = log.Sync() }()
 
-	scanner := sadp.NewScanner(*timeout, log)
+	scanner := sadp.NewScannerWithOptions(scannerTimeout, log, scannerOpts)
 	opts := sadp.SendOptions{
 		TargetIP:   targetIP,
 		TargetMAC:  macAddr,
+		Username:   *user,
 		Password:   *password,
 		Code:       *code,
 		NewIP:      *newIP,
@@ -337,8 +1408,51 @@ func SendCmd(args []string) error {
 		NewGateway: *newGateway,
 		NewPort:    *newPort,
 		DHCP:       *dhcp,
+		DNS1:       *dns1,
+		DNS2:       *dns2,
 		Email:      *email,
+		VerifyCode: *verifyCode,
+		ProbeUUID:  *sendUUID,
 		Timeout:    *timeout,
+
+		AllowWeakPassword: *force,
+	}
+	if *answer1 != "" || *answer2 != "" || *answer3 != "" {
+		opts.Answers = []string{*answer1, *answer2, *answer3}
+	}
+
+	if *checkLockout && sadp.Commands[command].NeedsPass {
+		if targetIP == "0.0.0.0" {
+			fmt.Fprintln(os.Stderr, "Warning: --check-lockout needs the device IP; skipped in broadcast mode")
+		} else if err := checkLockoutRisk(newLockoutChecker(cfg), targetIP, *minAttemptsLeft); err != nil {
+			return fmt.Errorf("%w; nothing was sent", err)
+		}
+	}
+
+	if *preserve {
+		if command != "update" {
+			return fmt.Errorf("--preserve only applies to the update command")
+		}
+		current, err := fetchCurrentConfig(scanner, targetIP, macAddr)
+		if err != nil {
+			return fmt.Errorf("--preserve: could not read current settings: %w", err)
+		}
+		set := setFlags(fs)
+		opts = preserveUpdateSettings(opts, current, set)
+		printUpdatePlan(opts, set)
+	}
+
+	if command == "securitycode" && targetIP != "0.0.0.0" {
+		// Learn which fields the device's reset method needs before
+		// spending a reset attempt
+		if dev, err := scanner.InquireDevice(targetIP); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: could not detect reset mode: %v\n", err)
+		} else {
+			opts.ResetMode = sadp.ParseResetMode(dev.PasswordResetMode)
+			if opts.ResetMode != sadp.ResetModeUnknown {
+				fmt.Printf("Device reset mode: %s\n", opts.ResetMode)
+			}
+		}
 	}
 
 	fmt.Printf("Sending '%s' command to %s...\n", command, targetIP)
@@ -346,8 +1460,19 @@ func SendCmd(args []string) error {
 		fmt.Printf("Using broadcast mode (target MAC: %s)\n", macAddr)
 	}
 
-	response, err := scanner.SendCommand(command, opts)
+	var response string
+	if *retryUntil > 0 {
+		fmt.Printf("Retrying every %s for up to %s...\n", *retryInterval, *retryUntil)
+		ctx, cancel := context.WithTimeout(context.Background(), *retryUntil)
+		defer cancel()
+		response, err = scanner.SendCommandUntil(ctx, command, opts, *retryInterval)
+	} else {
+		response, err = scanner.SendCommand(command, opts)
+	}
 	if err != nil {
+		if auditErr := audit.record(newAuditEntry(command, opts, nil, err, time.Now())); auditErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", auditErr)
+		}
 		return err
 	}
 
@@ -355,15 +1480,115 @@ func SendCmd(args []string) error {
 	fmt.Println("---")
 	fmt.Println(response)
 	fmt.Println("---")
+	if sadp.ChallengeCommands[command] && !*raw {
+		printChallenge(response, cfg.AESKeyHex)
+	}
+
+	result := scanner.ParseCommandResult(response)
+	auditErr := audit.record(newAuditEntry(command, opts, result, nil, time.Now()))
+	printLockoutWarning(result, *minAttemptsLeft)
+	if !result.Success {
+		fmt.Printf("Result: FAILED (%s)\n", result.Message)
+		return errors.Join(fmt.Errorf("%s failed: %s", command, result.Message), auditErr)
+	}
+	fmt.Println("Result: SUCCESS")
+
+	return auditErr
+}
+
+// runSendBatch sends a command to each target and reports per-device results
+func runSendBatch(scanner *sadp.Scanner, command string, opts sadp.SendOptions, targets []sadp.BatchTarget, bopts sadp.BatchOptions, audit *auditLog) error {
+	fmt.Printf("Sending '%s' command to %d device(s)...\n", command, len(targets))
+
+	results, err := scanner.SendBatch(command, opts, targets, bopts)
+	if err != nil {
+		return err
+	}
+
+	failed := 0
+	var auditErr error
+	for _, res := range results {
+		targetOpts := opts
+		targetOpts.TargetIP, targetOpts.TargetMAC = res.Target.IP, res.Target.MAC
+		entry := newAuditEntry(command, targetOpts, res.Result, res.Err, time.Now())
+		entry.Attempts = res.Attempts
+		if err := audit.record(entry); err != nil && auditErr == nil {
+			auditErr = err
+		}
+
+		label := res.Target.IP
+		if res.Target.MAC != "" {
+			label += " (" + res.Target.MAC + ")"
+		}
+		switch {
+		case res.Result == nil:
+			failed++
+			fmt.Printf("%-40s NO RESPONSE after %d attempt(s): %v\n", label, res.Attempts, res.Err)
+		case res.Result.Success:
+			fmt.Printf("%-40s SUCCESS\n", label)
+		default:
+			failed++
+			fmt.Printf("%-40s FAILED (%s)\n", label, res.Result.Message)
+		}
+		if res.Result != nil {
+			printLockoutWarning(res.Result, bopts.MinRemainingAttempts)
+		}
+		if res.StoppedForLockout {
+			fmt.Printf("  Stopped retrying %s to avoid locking it out\n", res.Target.IP)
+		}
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%s failed on %d of %d device(s)", command, failed, len(results))
+	}
+	return auditErr
+}
+
+// printChallenge prints the decrypted <EncryptString> of a reply. A reply
+// without one, such as a failure, prints nothing.
+func printChallenge(response, aesKeyHex string) {
+	challenge, err := sadp.DecryptChallenge(response, aesKeyHex)
+	switch {
+	case errors.Is(err, sadp.ErrNoEncryptString):
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "Warning: could not decrypt the challenge: %v\n", err)
+	default:
+		fmt.Printf("Decrypted challenge: %s\n", challenge)
+	}
+}
 
+// checkActivatePassword rejects an activate password the device would
+// refuse, unless force is set. An empty password is left to the command
+// builder to report.
+func checkActivatePassword(command, password string, force bool) error {
+	if command != "activate" || password == "" || force {
+		return nil
+	}
+	if err := sadp.ValidatePassword(password); err != nil {
+		return fmt.Errorf("%w (use --force to send it anyway)", err)
+	}
 	return nil
 }
 
+// printLockoutWarning reports lockout state or a low remaining attempt count
+func printLockoutWarning(result *sadp.CommandResult, minRemaining int) {
+	switch {
+	case result.Locked && result.UnlockIn > 0:
+		fmt.Printf("  Warning: device is locked out for %s\n", result.UnlockIn)
+	case result.Locked:
+		fmt.Println("  Warning: device is locked out")
+	case result.LockoutRisk(minRemaining):
+		fmt.Printf("  Warning: only %d password attempt(s) left before lockout\n", result.RemainingAttempts)
+	case result.RemainingAttempts >= 0 && !result.Success:
+		fmt.Printf("  %d password attempt(s) left before lockout\n", result.RemainingAttempts)
+	}
+}
+
 func printCommandList() {
 	fmt.Println("Available SADP Commands:")
 	fmt.Println()
-	fmt.Printf("%-20s %-12s %-12s %s\n", "Command", "Needs MAC", "Needs Pass", "Description")
-	fmt.Println(strings.Repeat("-", 80))
+	fmt.Printf("%-20s %-12s %-12s %-12s %-8s %s\n", "Command", "Needs MAC", "Needs Pass", "Verify Code", "Timeout", "Description")
+	fmt.Println(strings.Repeat("-", 102))
 
 	for _, cmd := range sadp.ListCommands() {
 		mac := "No"
@@ -374,8 +1599,54 @@ func printCommandList() {
 		if cmd.NeedsPass {
 			pass = "Yes"
 		}
-		fmt.Printf("%-20s %-12s %-12s %s\n", cmd.Name, mac, pass, cmd.Description)
+		verify := "No"
+		if cmd.VerifyCodeTemplate != "" {
+			verify = "Accepted"
+		}
+		fmt.Printf("%-20s %-12s %-12s %-12s %-8s %s\n", cmd.Name, mac, pass, verify, cmd.ResponseTimeout(), cmd.Description)
 	}
+	fmt.Println()
+	fmt.Println("Commands accepting a verify code use --verify-code (the device sticker code)")
+	fmt.Println("in place of the admin password when it is supplied.")
+	fmt.Println()
+	fmt.Println("ISAPI commands (HTTP with Digest auth, using --user and --password):")
+	fmt.Printf("%-20s %s\n", setNTPCommand, "Set the device NTP server (--server)")
+	fmt.Printf("%-20s %s\n", upgradeCommand, "Upload firmware and wait for the upgrade (--file, asks first unless --yes)")
+}
+
+// parseSourceIP parses --source, or its alias --from, returning nil when
+// neither is set
+func parseSourceIP(source, from string) (net.IP, error) {
+	if source != "" && from != "" && source != from {
+		return nil, fmt.Errorf("--source and --from name different addresses")
+	}
+	if source == "" {
+		source = from
+	}
+	if source == "" {
+		return nil, nil
+	}
+	ip := net.ParseIP(source)
+	if ip == nil {
+		return nil, fmt.Errorf("invalid --source address %q", source)
+	}
+	return ip, nil
+}
+
+// stringSliceFlag collects repeatable, comma-separated flag values
+type stringSliceFlag []string
+
+func (f *stringSliceFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringSliceFlag) Set(value string) error {
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			*f = append(*f, v)
+		}
+	}
+	return nil
 }
 
 func reorderArgsForFlags(args []string) []string {
@@ -389,7 +1660,7 @@ func reorderArgsForFlags(args []string) []string {
 			flags = append(flags, arg)
 			if !strings.Contains(arg, "=") && i+1 < len(args) && !strings.HasPrefix(args[i+1], "-") {
 				flagName := strings.TrimLeft(arg, "-")
-				if flagName != "debug" && flagName != "dhcp" && flagName != "list" {
+				if flagName != "debug" && flagName != "dhcp" && flagName != "list" && flagName != "capabilities" && flagName != "json" && flagName != "resolve-dns" && flagName != "explain" && flagName != "with-verify-code" && flagName != "follow-redirects" && flagName != "preserve" && flagName != "check-lockout" && flagName != "override-protection" && flagName != "yes" && flagName != "force" && flagName != "raw" && flagName != "sadp" && flagName != "v" && flagName != "vv" && flagName != "vvv" {
 					i++
 					flags = append(flags, args[i])
 				}
@@ -410,27 +1681,59 @@ func ResetCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("reset", flag.ExitOnError)
+	fs := newFlagSet("reset")
 	serial := fs.String("serial", "", "Device serial number (case-sensitive, without model prefix)")
 	date := fs.String("date", "", "Device date in YYYYMMDD format (from device's internal clock)")
 	ip := fs.String("ip", "", "Device IP to auto-fetch serial and date")
+	candidates := fs.Bool("candidates", false, "Generate codes for several plausible serial truncations")
+	explain := fs.Bool("explain", false, "Print every stage of the code derivation")
+	packageFile := fs.String("package", "", "With --ip, write a JSON reset request package for vendor support to this file")
+	mode := fs.String("mode", "", "Reset algorithm: legacy or exchange (default legacy, or picked from the firmware with --ip)")
+	code := fs.String("code", "", "With --mode exchange, the reset code Hikvision support issued for the challenge")
+	password := fs.String("password", "", "With --code, the new admin password to set")
 	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
 
 	reorderedArgs := reorderArgsForFlags(args)
-	_ = fs.Parse(reorderedArgs)
+	if err := fs.Parse(reorderedArgs); err != nil {
+		return err
+	}
+
+	if err := parseResetMode(*mode); err != nil {
+		return err
+	}
+	if *mode == resetModeExchange && *ip == "" {
+		return fmt.Errorf("--mode exchange requires --ip")
+	}
+	if *code != "" && *mode != resetModeExchange {
+		return fmt.Errorf("--code requires --mode exchange")
+	}
+
+	if *packageFile != "" {
+		if *ip == "" {
+			return fmt.Errorf("--package requires --ip")
+		}
+		return runResetPackage(cfg, *ip, *packageFile, *debug)
+	}
 
+	fullSerial := *serial
+	model := ""
 	if *ip != "" {
-		fetchedSerial, fetchedDate, err := fetchDeviceInfo(cfg, *ip, *debug)
+		info, err := fetchDeviceInfo(cfg, *ip, *debug)
+		if err != nil && *mode == resetModeExchange {
+			return fmt.Errorf("could not fetch device info: %w", err)
+		}
 		if err != nil {
 			fmt.Fprintf(os.Stderr, "Warning: Could not auto-fetch device info: %v\n", err)
 			fmt.Println("Please provide --serial and --date manually")
 		} else {
-			if *serial == "" {
-				*serial = fetchedSerial
-			}
-			if *date == "" {
-				*date = fetchedDate
+			if selectResetMode(*mode, info.Firmware) == resetModeExchange {
+				if *mode == "" {
+					fmt.Printf("Firmware %s uses the %s reset algorithm; using --mode exchange\n",
+						info.Firmware, crypto.ResetAlgorithmV2)
+				}
+				return runExchangeReset(cfg, *ip, info, *code, *password, *debug)
 			}
+			fullSerial, model = useLegacyResetInfo(info, serial, date, os.Stderr)
 		}
 	}
 
@@ -440,6 +1743,9 @@ func ResetCmd(args []string) error {
 		fmt.Println("")
 		fmt.Println("Usage: sadp reset --serial <SERIAL> --date <YYYYMMDD>")
 		fmt.Println("       sadp reset --ip <DEVICE_IP>")
+		fmt.Println("       sadp reset --ip <DEVICE_IP> --candidates")
+		fmt.Println("       sadp reset --ip <DEVICE_IP> --package request.json")
+		fmt.Println("       sadp reset --ip <DEVICE_IP> --mode exchange [--code <CODE> --password <NEW_PASSWORD>]")
 		fmt.Println("")
 		fmt.Println("Options:")
 		fs.PrintDefaults()
@@ -449,9 +1755,10 @@ func ResetCmd(args []string) error {
 		fmt.Println("  - Remove the model prefix from the serial number")
 		fmt.Println("    Example: DS-7616NI-I20123456789 -> 0123456789")
 		fmt.Println("  - Date must match the device's internal clock, NOT today's date")
-		fmt.Println("  - Check the 'Start Time' or 'Boot Time' in SADP to find device date")
+		fmt.Println("  - SADP's 'Boot Time' is a lower bound; check the device clock itself")
 		fmt.Println("")
-		fmt.Println("Note: This only works on firmware versions < 5.3.0")
+		fmt.Println("Note: Legacy codes only work on firmware versions < 5.3.0; newer")
+		fmt.Println("firmware needs --mode exchange, which --ip selects automatically")
 		return nil
 	}
 
@@ -459,7 +1766,12 @@ func ResetCmd(args []string) error {
 		return fmt.Errorf("date must be in YYYYMMDD format (got: %s)", *date)
 	}
 
-	resetCode := crypto.GenerateResetCode(*serial, *date)
+	if *candidates {
+		printResetCandidates(crypto.GenerateResetCandidates(fullSerial, model, *date), *date)
+		return nil
+	}
+
+	resetCode, steps := crypto.GenerateResetCodeWithSteps(*serial, *date)
 
 	fmt.Println("Hikvision Password Reset Code Generator")
 	fmt.Println("========================================")
@@ -472,6 +1784,9 @@ func ResetCmd(args []string) error {
 	fmt.Printf("RESET CODE:    %s\n", resetCode)
 	fmt.Println("----------------------------------------")
 	fmt.Println("")
+	if *explain {
+		printResetExplanation(steps)
+	}
 	fmt.Println("Instructions:")
 	fmt.Println("1. Open SADP Tool and select your device")
 	fmt.Println("2. Click 'Forgot Password' or enter the security code field")
@@ -483,7 +1798,145 @@ func ResetCmd(args []string) error {
 	return nil
 }
 
-func fetchDeviceInfo(cfg *config.Config, ipAddress string, debug bool) (serial, date string, err error) {
+// useLegacyResetInfo fills in the serial and date a legacy code is generated
+// from with the fetched device info, keeping any given as flags, and returns
+// the full serial and model for --candidates. Newer firmware only gets here
+// with an explicit --mode legacy, e.g. for a device misreporting its
+// version, so it is warned about on warn but the code is still generated.
+func useLegacyResetInfo(info *deviceInfo, serial, date *string, warn io.Writer) (fullSerial, model string) {
+	fullSerial = *serial
+	if *serial == "" {
+		*serial = info.Serial
+		fullSerial = info.FullSerial
+		model = info.Model
+	}
+	if *date == "" {
+		*date = info.Date
+		printResetDate(info, time.Now().Format(resetDateLayout))
+	}
+	if err := checkLegacyResetFirmware(info.Firmware); err != nil {
+		fmt.Fprintf(warn, "Warning: %v; generating one anyway as --mode legacy was given\n", err)
+	}
+	return fullSerial, model
+}
+
+// checkLegacyResetFirmware reports an error for firmware that has moved past
+// the legacy serial+date reset code, which callers surface as a warning.
+// Unknown firmware is assumed to be legacy.
+func checkLegacyResetFirmware(firmware string) error {
+	if firmware == "" {
+		fmt.Println("Firmware version unknown; assuming the legacy reset algorithm applies")
+		return nil
+	}
+
+	algorithm := crypto.SelectResetAlgorithm(firmware)
+	fmt.Printf("Firmware %s uses the %s reset algorithm\n", firmware, algorithm)
+	if algorithm != crypto.ResetAlgorithmLegacy {
+		return fmt.Errorf("firmware %s is >= %s; legacy reset codes will not work on this device",
+			firmware, crypto.LegacyResetMaxFirmware)
+	}
+	return nil
+}
+
+// printResetExplanation prints each stage of GenerateResetCode
+func printResetExplanation(steps crypto.ResetSteps) {
+	fmt.Println("Derivation:")
+	fmt.Printf("  1. Seed (serial + date):           %s\n", steps.Seed)
+	fmt.Printf("  2. Magic (sum of (pos*char)^pos):  %d\n", steps.Magic)
+	fmt.Printf("  3. Secret (magic*1751873395, u32): %d\n", steps.Secret)
+	fmt.Printf("  4. Numeric string:                 %s\n", steps.SecretString)
+	fmt.Println("  5. Substitution (0-8 -> QRSqrdeyz, 9 unchanged):")
+	for i, sub := range steps.Substitutions {
+		fmt.Printf("       [%2d] %c -> %c\n", i+1, sub.Digit, sub.Char)
+	}
+	fmt.Println("")
+}
+
+func printResetCandidates(candidates []crypto.ResetCandidate, date string) {
+	fmt.Println("Hikvision Password Reset Code Candidates")
+	fmt.Println("========================================")
+	fmt.Println("")
+	fmt.Printf("Device Date:   %s\n", date)
+	fmt.Println("")
+	fmt.Printf("%-30s %-25s %s\n", "Derivation", "Serial", "Reset Code")
+	fmt.Println(strings.Repeat("-", 70))
+	for _, c := range candidates {
+		fmt.Printf("%-30s %-25s %s\n", c.Derivation, c.Serial, c.Code)
+	}
+	fmt.Println("")
+	fmt.Println("Try each code in turn; the derivation shows how its serial was obtained.")
+	fmt.Println("Note: This only works on firmware < 5.3.0")
+}
+
+// deviceInfo holds the identity fields fetched from a device for reset
+type deviceInfo struct {
+	Model      string
+	FullSerial string
+	Serial     string
+	Date       string
+	// DateSource says where Date came from: this host's clock, or the
+	// device's BootTime when that is later (see resetDate)
+	DateSource string
+	// Firmware, MAC and BootTime come from a SADP inquiry and are empty
+	// when the device did not answer one
+	Firmware string
+	MAC      string
+	BootTime string
+}
+
+// Values of deviceInfo.DateSource
+const (
+	dateSourceBootTime  = "SADP BootTime"
+	dateSourceHostClock = "this host's clock"
+)
+
+// fetchDeviceInfo collects what reset needs from the device at ipAddress.
+// A SADP inquiry is tried first, for the serial, firmware and the device's
+// own date; /upnpdevicedesc.xml is only fetched for the serial and model
+// when the inquiry fails or carries no serial.
+func fetchDeviceInfo(cfg *config.Config, ipAddress string, debug bool) (*deviceInfo, error) {
+	log := logger.New(debug)
+	defer func() { _ = log.Sync() }()
+
+	info := &deviceInfo{}
+	if dev, err := sadp.NewScanner(cfg.SADPTimeout, log).InquireDevice(ipAddress); err != nil {
+		log.Debugw("SADP inquiry failed; falling back to HTTP", "error", err)
+	} else {
+		info.Model = dev.DeviceType
+		info.FullSerial = dev.DeviceSN
+		info.Firmware = dev.SoftwareVersion
+		info.MAC = dev.MAC
+		info.BootTime = dev.BootTime
+	}
+
+	if info.FullSerial == "" {
+		model, serial, err := fetchUPnPSerial(cfg, ipAddress, debug)
+		if err != nil {
+			return nil, err
+		}
+		info.Model, info.FullSerial = model, serial
+	}
+
+	info.Serial = info.FullSerial
+	if info.Model != "" && strings.HasPrefix(info.Serial, info.Model) {
+		info.Serial = strings.TrimPrefix(info.Serial, info.Model)
+	}
+
+	info.Date, info.DateSource = resetDate(info.BootTime, time.Now().Format(resetDateLayout))
+
+	if debug {
+		fmt.Printf("Extracted model: %s\n", info.Model)
+		fmt.Printf("Extracted serial: %s\n", info.Serial)
+		fmt.Printf("Using date: %s (from %s)\n", info.Date, info.DateSource)
+		fmt.Printf("Firmware: %s\n", info.Firmware)
+	}
+
+	return info, nil
+}
+
+// fetchUPnPSerial reads the model and full serial number from the device's
+// UPnP description
+func fetchUPnPSerial(cfg *config.Config, ipAddress string, debug bool) (model, serial string, err error) {
 	httpClient := network.NewHTTPClient(cfg.UserAgent, cfg.HTTPTimeout)
 	resp, err := httpClient.Get(ipAddress, "/upnpdevicedesc.xml")
 	if err != nil {
@@ -494,7 +1947,7 @@ func fetchDeviceInfo(cfg *config.Config, ipAddress string, debug bool) (serial,
 		return "", "", fmt.Errorf("HTTP %d response", resp.StatusCode)
 	}
 
-	bodyStr := string(resp.Body)
+	bodyStr := resp.Text()
 
 	if debug {
 		maxLen := 500
@@ -506,9 +1959,7 @@ func fetchDeviceInfo(cfg *config.Config, ipAddress string, debug bool) (serial,
 	}
 
 	modelPattern := regexp.MustCompile(`<modelNumber>([^<]+)</modelNumber>`)
-	modelMatch := modelPattern.FindStringSubmatch(bodyStr)
-	model := ""
-	if len(modelMatch) > 1 {
+	if modelMatch := modelPattern.FindStringSubmatch(bodyStr); len(modelMatch) > 1 {
 		model = modelMatch[1]
 	}
 
@@ -517,21 +1968,66 @@ func fetchDeviceInfo(cfg *config.Config, ipAddress string, debug bool) (serial,
 	if len(serialMatch) < 2 {
 		return "", "", fmt.Errorf("could not find serial number in response")
 	}
-	serial = serialMatch[1]
+	return model, serialMatch[1], nil
+}
+
+// bootTimeLayouts are the BootTime formats devices have been seen to send
+var bootTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04:05Z07:00",
+	"2006-01-02",
+	"2006/01/02 15:04:05",
+}
 
-	if model != "" && strings.HasPrefix(serial, model) {
-		serial = strings.TrimPrefix(serial, model)
+// bootTimeDate returns the YYYYMMDD date of a SADP BootTime, which is in
+// the device's own clock and time zone
+func bootTimeDate(bootTime string) (string, bool) {
+	bootTime = strings.TrimSpace(bootTime)
+	for _, layout := range bootTimeLayouts {
+		if t, err := time.Parse(layout, bootTime); err == nil {
+			return t.Format(resetDateLayout), true
+		}
 	}
+	return "", false
+}
 
-	date = time.Now().Format("20060102")
+// resetDate picks the device date for a legacy reset code. BootTime is
+// only a lower bound on the device clock, since a device that booted days
+// ago has moved on from its boot date, so today's date is used unless the
+// device booted later than today, i.e. its clock runs ahead of this host's.
+func resetDate(bootTime, today string) (date, source string) {
+	bootDate, ok := bootTimeDate(bootTime)
+	if ok && bootDate >= today {
+		return bootDate, dateSourceBootTime
+	}
+	return today, dateSourceHostClock
+}
 
-	if debug {
-		fmt.Printf("Extracted model: %s\n", model)
-		fmt.Printf("Extracted serial: %s\n", serial)
-		fmt.Printf("Using date: %s (verify this matches device clock!)\n", date)
+// printResetDate reports the device date reset will use alongside the
+// device's boot date, which the device clock cannot be earlier than
+func printResetDate(info *deviceInfo, today string) {
+	bootDate, ok := bootTimeDate(info.BootTime)
+	switch {
+	case !ok:
+		fmt.Printf("Device boot date not reported; using %s from %s (verify it matches the device clock)\n", info.Date, info.DateSource)
+	case bootDate == today:
+		fmt.Printf("Device date %s (the device booted today)\n", info.Date)
+	case bootDate > today:
+		fmt.Printf("Device booted on %s, after today's date here (%s); using %s from %s\n", bootDate, today, info.Date, info.DateSource)
+		fmt.Println("Check the device clock, and pass --date if it shows another date")
+	default:
+		fmt.Printf("Device booted on %s; using today's date, %s, from %s\n", bootDate, info.Date, info.DateSource)
+		fmt.Printf("The device clock is no earlier than %s; pass --date if it shows another date\n", bootDate)
 	}
+}
 
-	return serial, date, nil
+// configDiscoverOptions returns the default discovery options with the
+// probe retries set from the config (DISCOVERY_RETRIES)
+func configDiscoverOptions(cfg *config.Config) sadp.DiscoverOptions {
+	opts := sadp.DefaultDiscoverOptions()
+	opts.ProbeRetries = cfg.DiscoveryRetries
+	return opts
 }
 
 // ScanCmd handles the scan command - discovers devices using both ARP and SADP
@@ -541,79 +2037,141 @@ func ScanCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	fs := newFlagSet("scan")
 	workers := fs.Int("workers", cfg.DiscoveryWorkers, "Number of concurrent workers for scanning")
 	timeout := fs.Duration("timeout", cfg.DiscoveryTimeout, "Timeout for each host probe")
 	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
-	_ = fs.Parse(args)
+	verbosity := addVerbosityFlags(fs)
+	var excludes stringSliceFlag
+	fs.Var(&excludes, "exclude", "IPs or CIDR blocks to skip, comma-separated (repeatable)")
+	sourcesFlag := fs.String("sources", "arp,sadp", "Discovery mechanisms to use: arp, sadp, or arp,sadp")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
 
-	if fs.NArg() < 1 {
+	sources, err := parseScanSources(*sourcesFlag)
+	if err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 && sources.ARP {
 		fmt.Println("Usage: sadp scan [options] <CIDR>")
 		fmt.Println("\nThis command discovers Hikvision devices using both ARP and SADP protocols.")
+		fmt.Println("The CIDR is only needed when ARP discovery is enabled.")
 		fmt.Println("\nExamples:")
 		fmt.Println("  sadp scan 192.168.1.0/24")
 		fmt.Println("  sadp scan --workers 50 10.0.0.0/24")
+		fmt.Println("  sadp scan --exclude 192.168.1.1 --exclude 192.168.1.0/28 192.168.1.0/24")
+		fmt.Println("  sadp scan --sources sadp")
 		fmt.Println("\nOptions:")
 		fs.PrintDefaults()
 		return nil
 	}
 
 	cidr := fs.Arg(0)
-	log := logger.New(*debug)
+	log := newCLILogger(*debug, *verbosity)
 	defer func() { _ = log.Sync() }()
 
-	fmt.Printf("Scanning %s for Hikvision devices...\n", cidr)
-
-	// ARP Discovery
-	fmt.Println("\n[1/2] ARP Discovery...")
-	ips, err := network.ExpandCIDR(cidr)
-	if err != nil {
-		return fmt.Errorf("invalid CIDR: %w", err)
+	if cidr != "" {
+		fmt.Printf("Scanning %s for Hikvision devices...\n", cidr)
+	} else {
+		fmt.Println("Scanning for Hikvision devices...")
 	}
 
-	arpDevices := discoverDevices(ips, *workers, *timeout, log)
-	fmt.Printf("      Found %d device(s) via ARP\n", len(arpDevices))
-
-	// SADP Discovery
-	fmt.Println("\n[2/2] SADP Discovery...")
-	scanner := sadp.NewScanner(cfg.SADPTimeout, log)
-	sadpDevices, err := scanner.Discover()
-	if err != nil {
-		log.Warnw("SADP discovery failed", "error", err)
+	steps, step := sources.count(), 0
+	var arpDevices []discoveredDevice
+	if sources.ARP {
+		step++
+		fmt.Printf("\n[%d/%d] ARP Discovery...\n", step, steps)
+		arpDevices, err = discoverDevices(context.Background(), cidr, excludes, *workers, *timeout, log)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("      Found %d device(s) via ARP\n", len(arpDevices))
 	}
-	fmt.Printf("      Found %d device(s) via SADP\n", len(sadpDevices))
 
-	// Merge results (deduplicate by MAC)
-	deviceMap := make(map[string]interface{})
-	for _, dev := range arpDevices {
-		deviceMap[strings.ToUpper(dev.MAC)] = dev
-	}
-	for _, dev := range sadpDevices {
-		deviceMap[strings.ToUpper(dev.MAC)] = dev
+	var sadpDevices []*sadp.Device
+	if sources.SADP {
+		step++
+		fmt.Printf("\n[%d/%d] SADP Discovery...\n", step, steps)
+		scanner := sadp.NewScannerWithOptions(cfg.SADPTimeout, log, configDiscoverOptions(cfg))
+		sadpDevices, err = scanner.Discover()
+		if err != nil {
+			log.Warnw("SADP discovery failed", "error", err)
+		}
+		fmt.Printf("      Found %d device(s) via SADP\n", len(sadpDevices))
 	}
 
+	devices := mergeScanResults(arpDevices, sadpDevices)
+	counts := countScanResults(devices)
+
 	fmt.Println("\n===================================================")
 	fmt.Println("                   SCAN RESULTS                    ")
 	fmt.Println("===================================================")
-	fmt.Printf("Total unique devices: %d\n\n", len(deviceMap))
+	fmt.Printf("Total unique devices: %d (ARP: %d, SADP: %d, both: %d)\n\n",
+		len(devices), counts.ARP, counts.SADP, counts.Both)
 
-	// Print ARP results
-	if len(arpDevices) > 0 {
-		fmt.Println("Devices found via ARP:")
-		fmt.Println("---------------------------------------------------")
-		for _, dev := range arpDevices {
-			fmt.Printf("  IP: %-15s  MAC: %s\n", dev.IP, dev.MAC)
+	if len(devices) > 0 {
+		for _, line := range formatScanTable(devices, terminalWidth()) {
+			fmt.Println(line)
 		}
 		fmt.Println()
 	}
 
-	// Print SADP results
-	if len(sadpDevices) > 0 {
-		fmt.Println("Devices found via SADP:")
-		printDeviceTable(sadpDevices)
+	return nil
+}
+
+// parseSince parses a --since value, either a duration back from now or an
+// RFC 3339 timestamp
+func parseSince(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		if d < 0 {
+			return time.Time{}, fmt.Errorf("invalid --since %q: duration must not be negative", value)
+		}
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q: use a duration such as 10m or an RFC 3339 time such as 2024-01-01T00:00:00Z", value)
+	}
+	return t, nil
+}
+
+// scanSources are the discovery mechanisms scan runs
+type scanSources struct {
+	ARP  bool
+	SADP bool
+}
+
+func (s scanSources) count() int {
+	n := 0
+	if s.ARP {
+		n++
+	}
+	if s.SADP {
+		n++
 	}
+	return n
+}
 
-	return nil
+// parseScanSources parses a comma-separated --sources value
+func parseScanSources(value string) (scanSources, error) {
+	var sources scanSources
+	for _, name := range strings.Split(value, ",") {
+		switch strings.ToLower(strings.TrimSpace(name)) {
+		case "arp":
+			sources.ARP = true
+		case "sadp":
+			sources.SADP = true
+		case "":
+		default:
+			return scanSources{}, fmt.Errorf("invalid --sources value %q: must be arp, sadp, or arp,sadp", name)
+		}
+	}
+	if sources.count() == 0 {
+		return scanSources{}, fmt.Errorf("--sources must name at least one of arp, sadp")
+	}
+	return sources, nil
 }
 
 // ProbeCmd handles the probe command - checks device info
@@ -623,20 +2181,71 @@ func ProbeCmd(args []string) error {
 		return fmt.Errorf("failed to load config: %w", err)
 	}
 
-	fs := flag.NewFlagSet("probe", flag.ExitOnError)
-	_ = fs.Parse(args)
+	fs := newFlagSet("probe")
+	capabilities := fs.Bool("capabilities", false, "Fetch /ISAPI/System/capabilities (Digest auth)")
+	username := fs.String("username", cfg.ISAPIUsername, "ISAPI username")
+	password := fs.String("password", cfg.ISAPIPassword, "ISAPI password")
+	resolveDNS := fs.Bool("resolve-dns", false, "Look up the device's reverse DNS (PTR) hostname")
+	followRedirects := fs.Bool("follow-redirects", false, "Follow same-host HTTP redirects when checking endpoints")
+	sadpInquiry := fs.Bool("sadp", false, "With --mac, re-inquire the device over SADP broadcast instead of probing HTTP")
+	mac := fs.String("mac", "", "Device MAC address (for --sadp)")
+	if err := fs.Parse(reorderArgsForFlags(args)); err != nil {
+		return err
+	}
+
+	if *sadpInquiry {
+		if *mac == "" {
+			return fmt.Errorf("--sadp requires --mac")
+		}
+		fmt.Printf("Inquiring device %s via SADP broadcast...\n\n", network.NormalizeMAC(*mac))
+		dev, err := sadp.NewScanner(cfg.SADPTimeout, logger.NewNop()).InquireByMAC(*mac)
+		if err != nil {
+			return err
+		}
+		printSADPDevice(dev)
+		return nil
+	}
 
 	if fs.NArg() < 1 {
-		fmt.Println("Usage: sadp probe <IP_ADDRESS>")
+		fmt.Println("Usage: sadp probe <IP_ADDRESS> [options]")
 		fmt.Println("\nProbes a Hikvision device to check its status and information.")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp probe 192.168.1.64")
+		fmt.Println("  sadp probe 192.168.1.64 --capabilities --password secret")
+		fmt.Println("  sadp probe 192.168.1.64 --follow-redirects")
+		fmt.Println("  sadp probe --mac 4C:BD:8F:61:CC:5C --sadp")
 		return nil
 	}
 
 	ipAddress := fs.Arg(0)
 	httpClient := network.NewHTTPClient(cfg.UserAgent, cfg.HTTPTimeout)
+	if *followRedirects {
+		httpClient.MaxRedirects = network.DefaultMaxRedirects
+	}
+
+	if *capabilities {
+		client := isapi.NewClient(httpClient, *username, *password)
+		caps, err := client.GetCapabilities(ipAddress)
+		if err != nil {
+			return fmt.Errorf("failed to fetch capabilities: %w", err)
+		}
+		printCapabilities(ipAddress, caps)
+		return nil
+	}
 
 	fmt.Printf("Probing device at %s...\n\n", ipAddress)
 
+	if *resolveDNS {
+		resolver := network.NewHostnameResolver(network.DefaultDNSTimeout, 1)
+		if name := resolver.Lookup(ipAddress); name != "" {
+			fmt.Printf("Hostname: %s\n\n", name)
+		} else {
+			fmt.Print("Hostname: (no PTR record)\n\n")
+		}
+	}
+
 	// Check common endpoints
 	endpoints := []struct {
 		path        string
@@ -657,9 +2266,14 @@ func ProbeCmd(args []string) error {
 			continue
 		}
 		fmt.Printf("  %-25s HTTP %d", ep.description, resp.StatusCode)
+		if n := len(resp.Redirects); n > 0 {
+			fmt.Printf(" (via %s)", resp.Redirects[n-1])
+		} else if location := resp.Headers["location"]; location != "" {
+			fmt.Printf(" (redirect to %s)", location)
+		}
 
 		if resp.StatusCode == 200 && len(resp.Body) > 0 {
-			bodyStr := string(resp.Body)
+			bodyStr := resp.Text()
 			if firmware := extractFirmwareVersion(bodyStr); firmware != "" {
 				fmt.Printf(" (Firmware: %s)", firmware)
 			}
@@ -673,6 +2287,53 @@ func ProbeCmd(args []string) error {
 	return nil
 }
 
+// printSADPDevice lists the settings a device reported over SADP
+func printSADPDevice(dev *sadp.Device) {
+	status := "Inactive"
+	if dev.Activated == "true" {
+		status = "Active"
+	}
+
+	fmt.Printf("Device %s:\n", dev.MAC)
+	fmt.Println("---------------------------------------------------")
+	fmt.Printf("  %-20s %s\n", "Device Type", valueOrDash(dev.DeviceType))
+	fmt.Printf("  %-20s %s\n", "Serial Number", valueOrDash(dev.DeviceSN))
+	fmt.Printf("  %-20s %s\n", "Status", status)
+	fmt.Printf("  %-20s %s\n", "IPv4 Address", valueOrDash(dev.IPv4Address))
+	fmt.Printf("  %-20s %s\n", "Subnet Mask", valueOrDash(dev.IPv4SubnetMask))
+	fmt.Printf("  %-20s %s\n", "Gateway", valueOrDash(dev.IPv4Gateway))
+	fmt.Printf("  %-20s %s\n", "DHCP", valueOrDash(dev.DHCP))
+	fmt.Printf("  %-20s %s\n", "SDK Port", portOrDash(dev.CommandPort))
+	fmt.Printf("  %-20s %s\n", "HTTP Port", portOrDash(dev.HttpPort))
+	fmt.Printf("  %-20s %s\n", "Software Version", valueOrDash(dev.SoftwareVersion))
+	fmt.Printf("  %-20s %s\n", "Boot Time", valueOrDash(dev.BootTime))
+}
+
+func printCapabilities(ipAddress string, caps *isapi.Capabilities) {
+	yesNo := func(b bool) string {
+		if b {
+			return "yes"
+		}
+		return "no"
+	}
+
+	smartEvents := strings.Join(caps.SmartEvents(), ", ")
+	if smartEvents == "" {
+		smartEvents = "none"
+	}
+
+	fmt.Printf("Capabilities for %s:\n", ipAddress)
+	fmt.Println("---------------------------------------------------")
+	fmt.Printf("  %-20s %d\n", "Video Inputs", caps.SysCap.VideoInputNums)
+	fmt.Printf("  %-20s %d in / %d out\n", "Audio", caps.SysCap.AudioCap.AudioInputNums, caps.SysCap.AudioCap.AudioOutputNums)
+	fmt.Printf("  %-20s %s\n", "Two-Way Audio", yesNo(caps.SupportsTwoWayAudio()))
+	fmt.Printf("  %-20s %d in / %d out\n", "Alarm I/O", caps.SysCap.IOCap.IOInputPortNums, caps.SysCap.IOCap.IOOutputPortNums)
+	fmt.Printf("  %-20s %s\n", "ONVIF", yesNo(caps.IsSupportONVIF))
+	fmt.Printf("  %-20s %s\n", "EZVIZ", yesNo(caps.IsSupportEZVIZ))
+	fmt.Printf("  %-20s %s\n", "Motion Detection", yesNo(caps.EventCap.IsSupportMotionDetection))
+	fmt.Printf("  %-20s %s\n", "Smart Events", smartEvents)
+}
+
 func extractFirmwareVersion(body string) string {
 	patterns := []string{
 		`<firmwareVersion>([^<]+)</firmwareVersion>`,
@@ -1,7 +1,19 @@
 package cli
 
 import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
 )
 
 func TestRun(t *testing.T) {
@@ -53,6 +65,16 @@ func TestReorderArgsForFlags(t *testing.T) {
 			args:     []string{"192.168.1.1", "--mac", "AA:BB:CC:DD:EE:FF"},
 			expected: []string{"--mac", "AA:BB:CC:DD:EE:FF", "192.168.1.1"},
 		},
+		{
+			name:     "verbosity flag before positional",
+			args:     []string{"-vv", "192.168.1.1", "inquiry"},
+			expected: []string{"-vv", "192.168.1.1", "inquiry"},
+		},
+		{
+			name:     "bool flag before positional",
+			args:     []string{"--with-verify-code", "getbindlist"},
+			expected: []string{"--with-verify-code", "getbindlist"},
+		},
 		{
 			name:     "empty args",
 			args:     []string{},
@@ -151,3 +173,599 @@ func TestExtractModel(t *testing.T) {
 		})
 	}
 }
+
+func TestStringSliceFlag(t *testing.T) {
+	tests := []struct {
+		name     string
+		values   []string
+		expected []string
+	}{
+		{
+			name:     "single value",
+			values:   []string{"192.168.1.1"},
+			expected: []string{"192.168.1.1"},
+		},
+		{
+			name:     "comma-separated",
+			values:   []string{"192.168.1.1,192.168.1.0/28"},
+			expected: []string{"192.168.1.1", "192.168.1.0/28"},
+		},
+		{
+			name:     "repeated with blanks",
+			values:   []string{"10.0.0.1, ", "10.0.0.2"},
+			expected: []string{"10.0.0.1", "10.0.0.2"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var f stringSliceFlag
+			for _, v := range tt.values {
+				if err := f.Set(v); err != nil {
+					t.Fatalf("Set(%q) error = %v", v, err)
+				}
+			}
+			if len(f) != len(tt.expected) {
+				t.Fatalf("got %v, want %v", f, tt.expected)
+			}
+			for i := range f {
+				if f[i] != tt.expected[i] {
+					t.Errorf("f[%d] = %q, want %q", i, f[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyARP(t *testing.T) {
+	arpTable := network.ARPTable{
+		"192.168.1.64": "4c:bd:8f:61:cc:5c",
+		"192.168.1.65": "4c:bd:8f:00:00:01",
+	}
+
+	tests := []struct {
+		name       string
+		device     *sadp.Device
+		wantStatus string
+	}{
+		{
+			name:       "matching MAC",
+			device:     &sadp.Device{IPv4Address: "192.168.1.64", MAC: "4C:BD:8F:61:CC:5C"},
+			wantStatus: arpVerified,
+		},
+		{
+			name:       "matching MAC with dashes",
+			device:     &sadp.Device{IPv4Address: "192.168.1.64", MAC: "4C-BD-8F-61-CC-5C"},
+			wantStatus: arpVerified,
+		},
+		{
+			name:       "different MAC",
+			device:     &sadp.Device{IPv4Address: "192.168.1.65", MAC: "4C:BD:8F:61:CC:5C"},
+			wantStatus: arpMismatch,
+		},
+		{
+			name:       "IP not in ARP",
+			device:     &sadp.Device{IPv4Address: "192.168.1.99", MAC: "4C:BD:8F:61:CC:5C"},
+			wantStatus: arpNotInARP,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if status := verifyARP(tt.device, arpTable); status != tt.wantStatus {
+				t.Errorf("verifyARP() status = %q, want %q", status, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestParseDirectedBroadcasts(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    []string
+		wantErr bool
+	}{
+		{name: "none", values: nil, want: nil},
+		{name: "valid addresses", values: []string{"10.0.5.255", " 192.168.2.255 "}, want: []string{"10.0.5.255", "192.168.2.255"}},
+		{name: "invalid address", values: []string{"10.0.5"}, wantErr: true},
+		{name: "ipv6 rejected", values: []string{"ff02::1"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ips, err := parseDirectedBroadcasts(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseDirectedBroadcasts() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(ips) != len(tt.want) {
+				t.Fatalf("got %d addresses, want %d", len(ips), len(tt.want))
+			}
+			for i, ip := range ips {
+				if ip.String() != tt.want[i] {
+					t.Errorf("ips[%d] = %s, want %s", i, ip, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseSourceIP(t *testing.T) {
+	tests := []struct {
+		name    string
+		source  string
+		from    string
+		want    string
+		wantErr bool
+	}{
+		{name: "neither", want: "<nil>"},
+		{name: "source", source: "192.168.50.185", want: "192.168.50.185"},
+		{name: "from alias", from: "192.168.50.185", want: "192.168.50.185"},
+		{name: "both the same", source: "10.0.0.5", from: "10.0.0.5", want: "10.0.0.5"},
+		{name: "IPv6 link-local", source: "fe80::1", want: "fe80::1"},
+		{name: "both different", source: "10.0.0.5", from: "10.0.0.6", wantErr: true},
+		{name: "invalid", source: "eth0", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := parseSourceIP(tt.source, tt.from)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSourceIP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && ip.String() != tt.want {
+				t.Errorf("parseSourceIP() = %s, want %s", ip, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseSince(t *testing.T) {
+	now := time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		value   string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "duration", value: "10m", want: time.Date(2024, 1, 15, 10, 20, 0, 0, time.UTC)},
+		{name: "timestamp", value: "2024-01-01T00:00:00Z", want: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{name: "negative duration", value: "-5m", wantErr: true},
+		{name: "date only", value: "2024-01-01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseSince(tt.value, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseSince() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("parseSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseScanSources(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    scanSources
+		wantErr bool
+	}{
+		{name: "default", value: "arp,sadp", want: scanSources{ARP: true, SADP: true}},
+		{name: "arp only", value: "arp", want: scanSources{ARP: true}},
+		{name: "sadp only", value: " SADP ", want: scanSources{SADP: true}},
+		{name: "repeated", value: "sadp,sadp", want: scanSources{SADP: true}},
+		{name: "unknown", value: "arp,icmp", wantErr: true},
+		{name: "empty", value: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseScanSources(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseScanSources() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseScanSources() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithoutProbeType(t *testing.T) {
+	tests := []struct {
+		name   string
+		probes []string
+		want   []string
+	}{
+		{name: "drops v32", probes: []string{"inquiry", "inquiry_v32"}, want: []string{"inquiry"}},
+		{name: "nothing to drop", probes: []string{"inquiry"}, want: []string{"inquiry"}},
+		{name: "only v32", probes: []string{"inquiry_v32"}, want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := withoutProbeType(tt.probes, "inquiry_v32")
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") || len(got) != len(tt.want) {
+				t.Errorf("withoutProbeType() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWarningSummary(t *testing.T) {
+	tests := []struct {
+		n    int
+		want string
+	}{
+		{n: 0, want: ""},
+		{n: 1, want: "1 warning (use --debug for detail)"},
+		{n: 3, want: "3 warnings (use --debug for detail)"},
+	}
+
+	for _, tt := range tests {
+		if got := warningSummary(tt.n); got != tt.want {
+			t.Errorf("warningSummary(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestTableRow(t *testing.T) {
+	tests := []struct {
+		name   string
+		cells  []string
+		widths []int
+		want   string
+	}{
+		{name: "pads all but the last cell", cells: []string{"1", "10.0.0.1", "Active"}, widths: []int{3, 10, 8}, want: "1   10.0.0.1   Active"},
+		{name: "long cell is not cut", cells: []string{"12345", "x"}, widths: []int{3, 3}, want: "12345 x"},
+		{name: "single cell", cells: []string{"only"}, widths: []int{10}, want: "only"},
+	}
+
+	for _, tt := range tests {
+		if got := tableRow(tt.cells, tt.widths); got != tt.want {
+			t.Errorf("%s: tableRow() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestGroupHeader(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   sadp.GroupKey
+		count int
+		want  string
+	}{
+		{name: "4C:BD:8F", key: sadp.GroupByOUI, count: 2, want: "== 4C:BD:8F (Hikvision): 2 device(s) =="},
+		{name: "00:11:22", key: sadp.GroupByOUI, count: 1, want: "== 00:11:22: 1 device(s) =="},
+		{name: "192.168.1.0/24", key: sadp.GroupBySubnet, count: 3, want: "== 192.168.1.0/24: 3 device(s) =="},
+		{name: sadp.UnknownGroup, key: sadp.GroupByOUI, count: 1, want: "== unknown: 1 device(s) =="},
+	}
+
+	for _, tt := range tests {
+		if got := groupHeader(tt.name, tt.key, tt.count); got != tt.want {
+			t.Errorf("groupHeader(%q) = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCheckLegacyResetFirmware(t *testing.T) {
+	tests := []struct {
+		name     string
+		firmware string
+		wantErr  bool
+	}{
+		{name: "unknown firmware allowed", firmware: "", wantErr: false},
+		{name: "legacy firmware", firmware: "V5.2.5 build 141201", wantErr: false},
+		{name: "cutoff firmware", firmware: "V5.3.0 build 150513", wantErr: true},
+		{name: "newer firmware", firmware: "V5.5.800 build 210628", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkLegacyResetFirmware(tt.firmware)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkLegacyResetFirmware(%q) error = %v, wantErr %v", tt.firmware, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestUseLegacyResetInfo(t *testing.T) {
+	info := &deviceInfo{
+		Model:      "DS-7616NI-I2",
+		FullSerial: "DS-7616NI-I20123456789",
+		Serial:     "0123456789",
+		Date:       "20240115",
+	}
+
+	tests := []struct {
+		name       string
+		firmware   string
+		serial     string
+		wantSerial string
+		wantFull   string
+		wantWarn   bool
+	}{
+		{name: "legacy firmware", firmware: "V5.2.5 build 141201", wantSerial: "0123456789", wantFull: "DS-7616NI-I20123456789"},
+		{name: "explicit legacy on newer firmware", firmware: "V5.5.800 build 210628", wantSerial: "0123456789", wantFull: "DS-7616NI-I20123456789", wantWarn: true},
+		{name: "serial flag kept", firmware: "V5.2.5 build 141201", serial: "9876543210", wantSerial: "9876543210", wantFull: "9876543210"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev := *info
+			dev.Firmware = tt.firmware
+			serial, date := tt.serial, ""
+			var warn bytes.Buffer
+			fullSerial, _ := useLegacyResetInfo(&dev, &serial, &date, &warn)
+			if serial != tt.wantSerial || fullSerial != tt.wantFull || date != info.Date {
+				t.Errorf("serial, full serial, date = %q, %q, %q, want %q, %q, %q",
+					serial, fullSerial, date, tt.wantSerial, tt.wantFull, info.Date)
+			}
+			if got := strings.Contains(warn.String(), "generating one anyway"); got != tt.wantWarn {
+				t.Errorf("warning = %q, want one %v", warn.String(), tt.wantWarn)
+			}
+			if crypto.GenerateResetCode(serial, date) == "" {
+				t.Error("no reset code generated from the device info")
+			}
+		})
+	}
+}
+
+func TestBootTimeDate(t *testing.T) {
+	tests := []struct {
+		name     string
+		bootTime string
+		want     string
+		wantOK   bool
+	}{
+		{name: "date and time", bootTime: "2024-01-01 10:00:00", want: "20240101", wantOK: true},
+		{name: "ISO 8601", bootTime: "2023-12-15T23:59:59", want: "20231215", wantOK: true},
+		{name: "keeps device time zone", bootTime: "2023-12-15T23:30:00+08:00", want: "20231215", wantOK: true},
+		{name: "date only", bootTime: "2024-02-29", want: "20240229", wantOK: true},
+		{name: "slashes", bootTime: "2024/03/05 08:00:00", want: "20240305", wantOK: true},
+		{name: "surrounding space", bootTime: " 2024-01-01 10:00:00\n", want: "20240101", wantOK: true},
+		{name: "empty", bootTime: "", wantOK: false},
+		{name: "garbage", bootTime: "yesterday", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := bootTimeDate(tt.bootTime)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("bootTimeDate(%q) = %q, %v, want %q, %v", tt.bootTime, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestDiscoverSADPScriptOutputConflicts(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{name: "list with csv", args: []string{"--list", "ip", "--csv"}},
+		{name: "list with output file", args: []string{"--list", "mac", "--xml", "--output", "devices.xml"}},
+		{name: "list with group-by", args: []string{"--list", "ip:port", "--group-by", "type"}},
+		{name: "count-only with verify-arp", args: []string{"--count-only", "--verify-arp"}},
+		{name: "count-only with inventory", args: []string{"--count-only", "--inventory", "ansible"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DiscoverSADPCmd(tt.args)
+			if err == nil || !strings.Contains(err.Error(), "print only their own output") {
+				t.Errorf("DiscoverSADPCmd(%q) error = %v, want a conflict error", tt.args, err)
+			}
+		})
+	}
+}
+
+func TestDiscoverSADPTypeMapAfterFilter(t *testing.T) {
+	dir := t.TempDir()
+	scanner := sadp.NewScanner(sadp.DefaultTimeout, nil)
+	devices, err := scanner.ToXML([]*sadp.Device{
+		{IPv4Address: "192.168.1.64", MAC: "4c:bd:8f:61:cc:5c", DeviceType: "DS-7608NI-K2"},
+		{IPv4Address: "192.168.1.65", MAC: "4c:bd:8f:61:cc:5d", DeviceType: "DS-2CD2042WD-I"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := filepath.Join(dir, "devices.xml")
+	typeMap := filepath.Join(dir, "types.json")
+	output := filepath.Join(dir, "devices.csv")
+	if err := os.WriteFile(input, []byte(devices), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(typeMap, []byte(`{"DS-7608NI-K2": "Lobby NVR"}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	args := []string{"--from-file", input, "--type-map", typeMap, "--type-contains", "ds-76", "--csv", "--output", output}
+	if err := DiscoverSADPCmd(args); err != nil {
+		t.Fatalf("DiscoverSADPCmd(%q) error = %v", args, err)
+	}
+	data, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(rows) != 2 || !strings.Contains(rows[1], ",Lobby NVR,") {
+		t.Errorf("output = %q, want only the NVR, filtered by its raw model and shown by its mapped name", data)
+	}
+}
+
+func TestConfigDiscoverOptions(t *testing.T) {
+	for _, retries := range []int{0, 5} {
+		opts := configDiscoverOptions(&config.Config{DiscoveryRetries: retries})
+		if opts.ProbeRetries != retries {
+			t.Errorf("ProbeRetries = %d, want %d", opts.ProbeRetries, retries)
+		}
+		if opts.ProbeRetryInterval != sadp.DefaultProbeRetryInterval {
+			t.Errorf("ProbeRetryInterval = %v, want the default", opts.ProbeRetryInterval)
+		}
+	}
+}
+
+func TestResetDate(t *testing.T) {
+	tests := []struct {
+		name       string
+		bootTime   string
+		wantDate   string
+		wantSource string
+	}{
+		{name: "booted today", bootTime: "2024-03-01 06:00:00", wantDate: "20240301", wantSource: dateSourceBootTime},
+		{name: "booted weeks ago", bootTime: "2024-02-02 06:00:00", wantDate: "20240301", wantSource: dateSourceHostClock},
+		{name: "device clock ahead", bootTime: "2024-03-02 01:00:00", wantDate: "20240302", wantSource: dateSourceBootTime},
+		{name: "no boot time", bootTime: "", wantDate: "20240301", wantSource: dateSourceHostClock},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, source := resetDate(tt.bootTime, "20240301")
+			if date != tt.wantDate || source != tt.wantSource {
+				t.Errorf("resetDate(%q) = %q, %q, want %q, %q", tt.bootTime, date, source, tt.wantDate, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestAppendSADPOutput(t *testing.T) {
+	devices := []*sadp.Device{{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "10.0.0.5"}}
+	scanner := sadp.NewScanner(sadp.DefaultTimeout, nil)
+
+	tests := []struct {
+		name       string
+		opts       sadpOutputOptions
+		wantLines  int
+		wantHeader int
+	}{
+		{name: "csv header written once", opts: sadpOutputOptions{CSV: true, Append: true}, wantLines: 3, wantHeader: 1},
+		{name: "jsonl appends lines", opts: sadpOutputOptions{JSONL: true, Append: true}, wantLines: 2, wantHeader: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.opts.OutputFile = filepath.Join(t.TempDir(), "inventory")
+			for i := 0; i < 2; i++ {
+				if err := appendSADPOutput(scanner, devices, tt.opts, time.Now()); err != nil {
+					t.Fatalf("appendSADPOutput() error = %v", err)
+				}
+			}
+
+			data, err := os.ReadFile(tt.opts.OutputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+			if len(lines) != tt.wantLines {
+				t.Errorf("got %d lines, want %d:\n%s", len(lines), tt.wantLines, data)
+			}
+			if got := strings.Count(string(data), "ScanTime,"); got != tt.wantHeader {
+				t.Errorf("got %d headers, want %d", got, tt.wantHeader)
+			}
+		})
+	}
+}
+
+func TestAppendSADPOutputKeepsHeader(t *testing.T) {
+	scanner := sadp.NewScanner(sadp.DefaultTimeout, nil)
+	plain := []*sadp.Device{{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "10.0.0.5"}}
+	annotated := []*sadp.Device{{MAC: "AA:BB:CC:DD:EE:01", IPv4Address: "10.0.0.6", Site: "Depot", AdapterIP: "10.0.0.2"}}
+
+	tests := []struct {
+		name string
+		file string
+		gz   bool
+	}{
+		{name: "csv", file: "inventory.csv"},
+		{name: "gzip csv", file: "inventory.csv.gz", gz: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := sadpOutputOptions{CSV: true, Append: true, Gzip: tt.gz, OutputFile: filepath.Join(t.TempDir(), tt.file)}
+			if err := appendSADPOutput(scanner, plain, opts, time.Now()); err != nil {
+				t.Fatalf("appendSADPOutput() error = %v", err)
+			}
+			if err := appendSADPOutput(scanner, annotated, opts, time.Now()); err != nil {
+				t.Fatalf("appendSADPOutput() error = %v", err)
+			}
+
+			header, err := readCSVHeader(opts.OutputFile, tt.gz)
+			if err != nil {
+				t.Fatalf("readCSVHeader() error = %v", err)
+			}
+			data, err := os.ReadFile(opts.OutputFile)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.gz {
+				zr, err := gzip.NewReader(bytes.NewReader(data))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if data, err = io.ReadAll(zr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			for _, line := range strings.Split(strings.TrimSuffix(string(data), "\n"), "\n") {
+				if got := len(strings.Split(line, ",")); got != len(header) {
+					t.Errorf("line %q has %d fields, header has %d", line, got, len(header))
+				}
+			}
+			devices, err := sadp.LoadDevicesFromFile(opts.OutputFile)
+			if err != nil {
+				t.Fatalf("LoadDevicesFromFile() error = %v", err)
+			}
+			if len(devices) != 2 || devices[1].IPv4Address != "10.0.0.6" || devices[1].MAC != "AA:BB:CC:DD:EE:01" {
+				t.Errorf("appended row does not line up with header %q: %+v", header, devices)
+			}
+		})
+	}
+}
+
+func TestWriteOutputFileGzip(t *testing.T) {
+	tests := []struct {
+		name   string
+		writes []string
+		flags  int
+		want   string
+	}{
+		{name: "single write", writes: []string{"<xml/>"}, flags: os.O_CREATE | os.O_TRUNC | os.O_WRONLY, want: "<xml/>"},
+		{name: "appended members", writes: []string{"a\n", "b\n"}, flags: os.O_APPEND | os.O_CREATE | os.O_WRONLY, want: "a\nb\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "out.gz")
+			for _, w := range tt.writes {
+				if err := writeOutputFile(path, tt.flags, w, true); err != nil {
+					t.Fatalf("writeOutputFile() error = %v", err)
+				}
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			zr, err := gzip.NewReader(f)
+			if err != nil {
+				t.Fatalf("output is not gzip: %v", err)
+			}
+			got, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("decompressed = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,125 @@
+package cli
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+// DecryptCmd handles the decrypt command
+func DecryptCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fs := newFlagSet("decrypt")
+	inFile := fs.String("in", "", "Read raw ciphertext from a file")
+	hexInput := fs.String("hex", "", "Ciphertext as a hex string")
+	base64Input := fs.String("base64", "", "Ciphertext as a base64 string")
+	method := fs.String("method", "aes", "Decryption method: aes or xor")
+	auto := fs.Bool("auto", false, "Try AES then XOR and keep whichever yields text (overrides --method)")
+	encode := fs.String("encode", "raw", "Output encoding: raw, hex, or base64")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *inFile == "" && *hexInput == "" && *base64Input == "" {
+		fmt.Println("Usage: sadp decrypt (--in <file> | --hex <string> | --base64 <string>) [options]")
+		fmt.Println("\nDecrypts Hikvision data using the configured AES or XOR key.")
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp decrypt --in configurationData")
+		fmt.Println("  sadp decrypt --base64 q2Vm... --encode hex")
+		fmt.Println("  sadp decrypt --hex 1a2b3c4d --method xor")
+		fmt.Println("  sadp decrypt --in configurationData --auto")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		return nil
+	}
+
+	data, err := readCiphertext(*inFile, *hexInput, *base64Input)
+	if err != nil {
+		return err
+	}
+
+	var plaintext []byte
+	switch {
+	case *auto:
+		var detected string
+		plaintext, detected, err = crypto.DecryptAuto(data, cfg.AESKeyHex, cfg.XORKeyHex)
+		if err == nil {
+			// stdout carries the plaintext, so report the method on stderr
+			fmt.Fprintf(os.Stderr, "Detected method: %s\n", detected)
+		}
+	case strings.EqualFold(*method, "aes"):
+		plaintext, err = crypto.DecryptAES(data, cfg.AESKeyHex)
+	case strings.EqualFold(*method, "xor"):
+		plaintext, err = crypto.DecryptXOR(data, cfg.XORKeyHex)
+	default:
+		return fmt.Errorf("unknown method %q (use aes or xor)", *method)
+	}
+	if err != nil {
+		return err
+	}
+
+	output, err := encodeOutput(plaintext, *encode)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(output)
+	return err
+}
+
+// readCiphertext loads ciphertext from exactly one of the supported inputs
+func readCiphertext(inFile, hexInput, base64Input string) ([]byte, error) {
+	set := 0
+	for _, v := range []string{inFile, hexInput, base64Input} {
+		if v != "" {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, fmt.Errorf("specify exactly one of --in, --hex, or --base64")
+	}
+
+	switch {
+	case inFile != "":
+		data, err := os.ReadFile(inFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read input file: %w", err)
+		}
+		return data, nil
+	case hexInput != "":
+		data, err := hex.DecodeString(strings.TrimSpace(hexInput))
+		if err != nil {
+			return nil, fmt.Errorf("malformed hex input: %w", err)
+		}
+		return data, nil
+	default:
+		data, err := base64.StdEncoding.DecodeString(strings.TrimSpace(base64Input))
+		if err != nil {
+			return nil, fmt.Errorf("malformed base64 input: %w", err)
+		}
+		return data, nil
+	}
+}
+
+// encodeOutput renders plaintext in the requested output encoding
+func encodeOutput(data []byte, encoding string) ([]byte, error) {
+	switch strings.ToLower(encoding) {
+	case "raw", "":
+		return data, nil
+	case "hex":
+		return []byte(hex.EncodeToString(data) + "\n"), nil
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(data) + "\n"), nil
+	default:
+		return nil, fmt.Errorf("unknown output encoding %q (use raw, hex, or base64)", encoding)
+	}
+}
@@ -0,0 +1,105 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestReadCiphertext(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "cipher.bin")
+	if err := os.WriteFile(path, []byte{0xde, 0xad}, 0644); err != nil {
+		t.Fatalf("failed to write input file: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		inFile   string
+		hex      string
+		base64   string
+		expected []byte
+		wantErr  bool
+	}{
+		{
+			name:     "file input",
+			inFile:   path,
+			expected: []byte{0xde, 0xad},
+		},
+		{
+			name:     "hex input",
+			hex:      "deadbeef",
+			expected: []byte{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name:     "base64 input",
+			base64:   "3q2+7w==",
+			expected: []byte{0xde, 0xad, 0xbe, 0xef},
+		},
+		{
+			name:    "malformed hex",
+			hex:     "xyz",
+			wantErr: true,
+		},
+		{
+			name:    "odd length hex",
+			hex:     "abc",
+			wantErr: true,
+		},
+		{
+			name:    "malformed base64",
+			base64:  "!!!",
+			wantErr: true,
+		},
+		{
+			name:    "multiple inputs",
+			hex:     "dead",
+			base64:  "3q0=",
+			wantErr: true,
+		},
+		{
+			name:    "missing file",
+			inFile:  filepath.Join(dir, "missing.bin"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := readCiphertext(tt.inFile, tt.hex, tt.base64)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCiphertext() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !bytes.Equal(data, tt.expected) {
+				t.Errorf("readCiphertext() = %x, want %x", data, tt.expected)
+			}
+		})
+	}
+}
+
+func TestEncodeOutput(t *testing.T) {
+	tests := []struct {
+		name     string
+		encoding string
+		expected string
+		wantErr  bool
+	}{
+		{name: "raw", encoding: "raw", expected: "hi"},
+		{name: "hex", encoding: "hex", expected: "6869\n"},
+		{name: "base64", encoding: "base64", expected: "aGk=\n"},
+		{name: "unknown", encoding: "rot13", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := encodeOutput([]byte("hi"), tt.encoding)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("encodeOutput() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && string(out) != tt.expected {
+				t.Errorf("encodeOutput() = %q, want %q", out, tt.expected)
+			}
+		})
+	}
+}
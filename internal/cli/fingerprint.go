@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/fingerprint"
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// FingerprintCmd handles the fingerprint command
+func FingerprintCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fs := newFlagSet("fingerprint")
+	username := fs.String("username", cfg.ISAPIUsername, "ISAPI username")
+	password := fs.String("password", cfg.ISAPIPassword, "ISAPI password (ISAPI is skipped when empty)")
+	timeout := fs.Duration("timeout", cfg.SADPTimeout, "SADP inquiry timeout")
+	jsonOutput := fs.Bool("json", false, "Output as JSON")
+	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
+	verbosity := addVerbosityFlags(fs)
+	if err := fs.Parse(reorderArgsForFlags(args)); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: sadp fingerprint <IP_ADDRESS> [options]")
+		fmt.Println("\nCombines SADP inquiry, the HTTP root page, and ISAPI deviceInfo/capabilities")
+		fmt.Println("into a single device profile.")
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp fingerprint 192.168.1.64")
+		fmt.Println("  sadp fingerprint 192.168.1.64 --password secret --json")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		return nil
+	}
+
+	ipAddress := fs.Arg(0)
+	log := newCLILogger(*debug, *verbosity)
+	defer func() { _ = log.Sync() }()
+
+	httpClient := network.NewHTTPClient(cfg.UserAgent, cfg.HTTPTimeout)
+	collector := &fingerprint.Collector{
+		Scanner: sadp.NewScanner(*timeout, log),
+		HTTP:    httpClient,
+		ISAPI:   isapi.NewClient(httpClient, *username, *password),
+	}
+
+	fp := collector.Collect(ipAddress)
+
+	if *jsonOutput {
+		output, err := json.MarshalIndent(fp, "", "  ")
+		if err != nil {
+			return fmt.Errorf("error generating JSON: %w", err)
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	printFingerprint(fp)
+	return nil
+}
+
+func printFingerprint(fp *fingerprint.DeviceFingerprint) {
+	row := func(label, value string) {
+		if value == "" {
+			value = "-"
+		}
+		fmt.Printf("  %-20s %s\n", label, value)
+	}
+	port := func(p int) string {
+		if p == 0 {
+			return ""
+		}
+		return fmt.Sprintf("%d", p)
+	}
+
+	fmt.Printf("Fingerprint for %s\n", fp.IP)
+	fmt.Println("---------------------------------------------------")
+	fmt.Println("Identity:")
+	row("Model", fp.Identity.Model)
+	row("Device Name", fp.Identity.DeviceName)
+	row("Device Type", fp.Identity.DeviceType)
+	row("Serial Number", fp.Identity.SerialNumber)
+	row("MAC", fp.Identity.MAC)
+
+	fmt.Println("Firmware:")
+	row("Version", fp.Firmware.Version)
+	row("Build", fp.Firmware.Build)
+	row("Encoder", fp.Firmware.EncoderVersion)
+	row("DSP", fp.Firmware.DSPVersion)
+
+	fmt.Println("Network:")
+	row("IPv4", fp.Network.IPv4Address)
+	row("Subnet Mask", fp.Network.SubnetMask)
+	row("Gateway", fp.Network.Gateway)
+	row("DHCP", fp.Network.DHCP)
+	row("HTTP Port", port(fp.Network.HTTPPort))
+	row("SDK Port", port(fp.Network.CommandPort))
+	row("HTTP Server", fp.Network.HTTPServer)
+
+	fmt.Println("Status:")
+	row("Activated", fp.Status.Activated)
+	row("Reset Mode", fp.Status.PasswordResetMode)
+
+	if fp.Capabilities != nil {
+		fmt.Println("Capabilities:")
+		row("Two-Way Audio", fmt.Sprintf("%v", fp.Capabilities.TwoWayAudio))
+		row("ONVIF", fmt.Sprintf("%v", fp.Capabilities.ONVIF))
+		row("Smart Events", strings.Join(fp.Capabilities.SmartEvents, ", "))
+	}
+
+	fmt.Printf("\nSources: %s\n", strings.Join(fp.Sources, ", "))
+	for source, msg := range fp.Errors {
+		fmt.Printf("  %s failed: %s\n", source, msg)
+	}
+}
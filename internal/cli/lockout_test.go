@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestDescribeLockStatus(t *testing.T) {
+	tests := []struct {
+		name   string
+		status *isapi.LockStatus
+		err    error
+		want   string
+	}{
+		{name: "not reported", err: isapi.ErrLockStatusUnavailable, want: "lock status not reported"},
+		{name: "query failed", err: errors.New("connection timeout to 10.0.0.5"), want: "error: connection timeout to 10.0.0.5"},
+		{name: "locked with timer", status: &isapi.LockStatus{Locked: true, UnlockIn: 30 * time.Minute}, want: "LOCKED (unlocks in 30m0s)"},
+		{name: "locked", status: &isapi.LockStatus{Locked: true, RemainingAttempts: -1}, want: "LOCKED"},
+		{name: "count unknown", status: &isapi.LockStatus{RemainingAttempts: -1}, want: "unlocked"},
+		{name: "at risk", status: &isapi.LockStatus{RemainingAttempts: 1}, want: "unlocked, only 1 attempt(s) left (at risk)"},
+		{name: "safe", status: &isapi.LockStatus{RemainingAttempts: 5}, want: "unlocked, 5 attempt(s) left"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := describeLockStatus(tt.status, tt.err, 2); got != tt.want {
+				t.Errorf("describeLockStatus() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockoutSafeTargets(t *testing.T) {
+	statuses := map[string]*isapi.LockStatus{
+		"10.0.0.1": {RemainingAttempts: 5},
+		"10.0.0.2": {RemainingAttempts: 2},
+		"10.0.0.3": {Locked: true, RemainingAttempts: -1},
+	}
+	check := func(ip string) (*isapi.LockStatus, error) {
+		switch ip {
+		case "10.0.0.4":
+			return nil, isapi.ErrLockStatusUnavailable
+		case "10.0.0.5":
+			return nil, errors.New("connection refused")
+		}
+		return statuses[ip], nil
+	}
+
+	targets := []sadp.BatchTarget{
+		{IP: "10.0.0.1"}, {IP: "10.0.0.2"}, {IP: "10.0.0.3"}, {IP: "10.0.0.4"}, {IP: "10.0.0.5", MAC: "4C:BD:8F:61:CC:5C"},
+	}
+	want := []sadp.BatchTarget{{IP: "10.0.0.1"}, {IP: "10.0.0.4"}, {IP: "10.0.0.5", MAC: "4C:BD:8F:61:CC:5C"}}
+
+	if got := lockoutSafeTargets(check, targets, 2); !reflect.DeepEqual(got, want) {
+		t.Errorf("lockoutSafeTargets() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,56 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+)
+
+// setNTPCommand is the send command that configures NTP over ISAPI, which
+// SADP itself cannot do
+const setNTPCommand = "set-ntp"
+
+// runSetNTP points the device at server and reads the setting back, since
+// some firmware answers OK without applying it
+func runSetNTP(client *isapi.Client, ip, server string) error {
+	if server == "" {
+		return fmt.Errorf("%s requires --server", setNTPCommand)
+	}
+	if client.Password == "" {
+		return fmt.Errorf("%s requires --password (or ISAPI_PASSWORD)", setNTPCommand)
+	}
+
+	ntp, err := isapi.NewNTPServer(server)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Setting NTP server on %s to %s...\n", ip, ntp.Address())
+	if err := client.SetNTPServers(ip, []isapi.NTPServer{ntp}); err != nil {
+		return fmt.Errorf("failed to set NTP server: %w", err)
+	}
+
+	servers, err := client.GetNTPServers(ip)
+	if err != nil {
+		return fmt.Errorf("NTP server was set but could not be read back: %w", err)
+	}
+	if err := verifyNTPServer(servers, ntp); err != nil {
+		return err
+	}
+
+	fmt.Printf("Result: SUCCESS (NTP server %s:%d, sync every %d min)\n",
+		ntp.Address(), ntp.PortNo, ntp.SynchronizeInterval)
+	return nil
+}
+
+// verifyNTPServer checks that the device reports want as its first server
+func verifyNTPServer(servers []isapi.NTPServer, want isapi.NTPServer) error {
+	if len(servers) == 0 {
+		return fmt.Errorf("device reports no NTP servers after the update")
+	}
+	if got := servers[0]; got.Address() != want.Address() || got.PortNo != want.PortNo {
+		return fmt.Errorf("device reports NTP server %s:%d, want %s:%d",
+			got.Address(), got.PortNo, want.Address(), want.PortNo)
+	}
+	return nil
+}
@@ -0,0 +1,52 @@
+package cli
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+func TestRunSetNTP(t *testing.T) {
+	tests := []struct {
+		name     string
+		server   string
+		password string
+		applied  bool
+		wantErr  bool
+	}{
+		{name: "applied and verified", server: "pool.ntp.org", password: "secret", applied: true},
+		{name: "device ignores the update", server: "pool.ntp.org", password: "secret", applied: false, wantErr: true},
+		{name: "missing server", password: "secret", wantErr: true},
+		{name: "missing password", server: "pool.ntp.org", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stored := `<NTPServerList><NTPServer><id>1</id><addressingFormatType>hostname</addressingFormatType><hostName>time.windows.com</hostName><portNo>123</portNo></NTPServer></NTPServerList>`
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPut {
+					body, _ := io.ReadAll(r.Body)
+					if tt.applied {
+						stored = string(body)
+					}
+					_, _ = w.Write([]byte(`<ResponseStatus><statusCode>1</statusCode><statusString>OK</statusString></ResponseStatus>`))
+					return
+				}
+				_, _ = w.Write([]byte(stored))
+			}))
+			defer srv.Close()
+
+			client := isapi.NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", tt.password)
+			err := runSetNTP(client, strings.TrimPrefix(srv.URL, "http://"), tt.server)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("runSetNTP() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
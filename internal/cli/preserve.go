@@ -0,0 +1,80 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// setFlags returns the names of the flags given on the command line, as
+// opposed to those left at their defaults
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+	return set
+}
+
+// fetchCurrentConfig asks the target for its current network settings. A
+// broadcast target (0.0.0.0) is addressed by mac; when mac is given the
+// reply must come from that device.
+func fetchCurrentConfig(scanner *sadp.Scanner, targetIP, mac string) (*sadp.Device, error) {
+	response, err := scanner.SendCommand("inquiry", sadp.SendOptions{TargetIP: targetIP, TargetMAC: mac})
+	if err != nil {
+		return nil, err
+	}
+	dev := scanner.ParseCommandResult(response).Device
+	if dev == nil {
+		return nil, fmt.Errorf("unrecognized inquiry response from %s", targetIP)
+	}
+	if mac != "" && network.NormalizeMAC(dev.MAC) != mac {
+		return nil, fmt.Errorf("%s answered as %s, not %s", targetIP, dev.MAC, mac)
+	}
+	return dev, nil
+}
+
+// preserveUpdateSettings fills the update settings whose flags were not set
+// from the device's current configuration, so only the explicitly given
+// settings change. The MAC is filled in too when --mac was omitted.
+func preserveUpdateSettings(opts sadp.SendOptions, current *sadp.Device, set map[string]bool) sadp.SendOptions {
+	if !set["mac"] && opts.TargetMAC == "" {
+		opts.TargetMAC = network.NormalizeMAC(current.MAC)
+	}
+	if !set["ip"] && current.IPv4Address != "" {
+		opts.NewIP = current.IPv4Address
+	}
+	if !set["mask"] && current.IPv4SubnetMask != "" {
+		opts.NewMask = current.IPv4SubnetMask
+	}
+	if !set["gateway"] && current.IPv4Gateway != "" {
+		opts.NewGateway = current.IPv4Gateway
+	}
+	if !set["port"] && current.CommandPort > 0 {
+		opts.NewPort = int(current.CommandPort)
+	}
+	if !set["dhcp"] && current.DHCP != "" {
+		opts.DHCP = strings.EqualFold(current.DHCP, "true")
+	}
+	return opts
+}
+
+// printUpdatePlan shows the settings update will send, marking the ones
+// kept from the device's current configuration
+func printUpdatePlan(opts sadp.SendOptions, set map[string]bool) {
+	mark := func(name string) string {
+		if set[name] {
+			return ""
+		}
+		return " (current)"
+	}
+	fmt.Println("Update settings:")
+	fmt.Printf("  %-8s %s%s\n", "IP", opts.NewIP, mark("ip"))
+	fmt.Printf("  %-8s %s%s\n", "Mask", opts.NewMask, mark("mask"))
+	fmt.Printf("  %-8s %s%s\n", "Gateway", valueOrDash(opts.NewGateway), mark("gateway"))
+	fmt.Printf("  %-8s %d%s\n", "Port", opts.NewPort, mark("port"))
+	fmt.Printf("  %-8s %t%s\n", "DHCP", opts.DHCP, mark("dhcp"))
+}
@@ -0,0 +1,123 @@
+package cli
+
+import (
+	"flag"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestPreserveUpdateSettings(t *testing.T) {
+	current := &sadp.Device{
+		MAC:            "4c-bd-8f-61-cc-5c",
+		IPv4Address:    "192.168.1.64",
+		IPv4SubnetMask: "255.255.254.0",
+		IPv4Gateway:    "192.168.0.1",
+		CommandPort:    8001,
+		DHCP:           "false",
+	}
+	// Flag defaults, as SendCmd builds them before --preserve applies
+	defaults := sadp.SendOptions{TargetIP: "192.168.1.64", NewMask: "255.255.255.0", NewPort: 8000}
+
+	tests := []struct {
+		name string
+		opts sadp.SendOptions
+		set  map[string]bool
+		want sadp.SendOptions
+	}{
+		{
+			name: "IP change keeps the rest",
+			opts: func() sadp.SendOptions { o := defaults; o.NewIP = "192.168.1.70"; return o }(),
+			set:  map[string]bool{"ip": true},
+			want: sadp.SendOptions{
+				TargetIP: "192.168.1.64", TargetMAC: "4C:BD:8F:61:CC:5C",
+				NewIP: "192.168.1.70", NewMask: "255.255.254.0", NewGateway: "192.168.0.1", NewPort: 8001,
+			},
+		},
+		{
+			name: "explicit settings win",
+			opts: func() sadp.SendOptions {
+				o := defaults
+				o.TargetMAC, o.NewMask, o.NewPort, o.DHCP = "4C:BD:8F:61:CC:5C", "255.255.255.0", 8000, true
+				return o
+			}(),
+			set: map[string]bool{"mac": true, "mask": true, "port": true, "dhcp": true},
+			want: sadp.SendOptions{
+				TargetIP: "192.168.1.64", TargetMAC: "4C:BD:8F:61:CC:5C",
+				NewIP: "192.168.1.64", NewMask: "255.255.255.0", NewGateway: "192.168.0.1", NewPort: 8000, DHCP: true,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := preserveUpdateSettings(tt.opts, current, tt.set); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("preserveUpdateSettings() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetFlags(t *testing.T) {
+	fs := flag.NewFlagSet("send", flag.ContinueOnError)
+	fs.String("ip", "", "")
+	fs.String("mask", "255.255.255.0", "")
+	fs.Int("port", 8000, "")
+	if err := fs.Parse([]string{"--ip", "10.0.0.5", "--port=8000"}); err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]bool{"ip": true, "port": true}
+	if got := setFlags(fs); !reflect.DeepEqual(got, want) {
+		t.Errorf("setFlags() = %v, want %v", got, want)
+	}
+}
+
+func TestFetchCurrentConfig(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: sadp.Port})
+	if err != nil {
+		t.Skipf("cannot bind SADP port for test: %v", err)
+	}
+	defer conn.Close()
+
+	go func() {
+		buf := make([]byte, sadp.MaxPacketSize)
+		for {
+			_, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			reply := "<ProbeMatch><Types>inquiry</Types><MAC>4c-bd-8f-61-cc-5c</MAC>" +
+				"<IPv4Address>127.0.0.1</IPv4Address><IPv4Gateway>127.0.0.254</IPv4Gateway></ProbeMatch>"
+			_, _ = conn.WriteToUDP([]byte(reply), addr)
+		}
+	}()
+
+	scanner := sadp.NewScanner(time.Second, logger.NewNop())
+
+	tests := []struct {
+		name    string
+		mac     string
+		wantErr bool
+	}{
+		{name: "any device", mac: ""},
+		{name: "matching MAC", mac: "4C:BD:8F:61:CC:5C"},
+		{name: "other device answered", mac: "4C:BD:8F:00:00:01", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, err := fetchCurrentConfig(scanner, "127.0.0.1", tt.mac)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("fetchCurrentConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && dev.IPv4Gateway != "127.0.0.254" {
+				t.Errorf("gateway = %q, want 127.0.0.254", dev.IPv4Gateway)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// ProbeTemplateCmd handles the probe-template command
+func ProbeTemplateCmd(args []string) error {
+	fs := newFlagSet("probe-template")
+	withVerifyCode := fs.Bool("with-verify-code", false, "Print the Hik-Connect/EZVIZ verification-code variant of the command")
+	if err := fs.Parse(reorderArgsForFlags(args)); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: sadp probe-template <command> [options]")
+		fmt.Println("\nPrints the XML sent for a SADP command, with each value shown as a")
+		fmt.Println("labeled placeholder such as {MAC}. Nothing is sent.")
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp probe-template inquiry")
+		fmt.Println("  sadp probe-template getbindlist --with-verify-code")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println()
+		printCommandList()
+		return nil
+	}
+
+	tmpl, err := sadp.ProbeTemplate(fs.Arg(0), *withVerifyCode)
+	if err != nil {
+		return err
+	}
+	fmt.Println(tmpl)
+	return nil
+}
@@ -0,0 +1,94 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// protectionGuard refuses state-changing commands to the devices on a
+// --protect-list. A nil *protectionGuard allows everything, so callers need
+// not check whether a list was given.
+type protectionGuard struct {
+	list     sadp.ProtectList
+	override bool
+	// identify looks up a target's MAC and serial when they are not known;
+	// it is an inquiry outside tests
+	identify func(ip, mac string) (*sadp.Device, error)
+}
+
+// newProtectionGuard loads the protect list at path, or returns nil for an
+// empty path. scanner is used to inquire targets whose identity is unknown.
+func newProtectionGuard(path string, override bool, scanner *sadp.Scanner) (*protectionGuard, error) {
+	if path == "" {
+		return nil, nil
+	}
+	list, err := sadp.LoadProtectList(path)
+	if err != nil {
+		return nil, err
+	}
+	return &protectionGuard{
+		list:     list,
+		override: override,
+		identify: func(ip, mac string) (*sadp.Device, error) {
+			return fetchCurrentConfig(scanner, ip, mac)
+		},
+	}, nil
+}
+
+// check returns an error when command would change a protected target.
+// known, when set, is the target as already discovered. A target whose
+// identity cannot be established is refused too, since it may be protected.
+// With override, both cases only print a warning.
+func (g *protectionGuard) check(command string, target sadp.BatchTarget, known *sadp.Device) error {
+	if g == nil || readOnlyCommands[command] {
+		return nil
+	}
+
+	mac, serial := target.MAC, ""
+	if known != nil {
+		mac, serial = known.MAC, known.DeviceSN
+	}
+	entry, protected := g.list.Match(mac, serial)
+	if !protected && (mac == "" || (serial == "" && g.list.HasSerials())) {
+		dev, err := g.identify(target.IP, target.MAC)
+		if err != nil {
+			return g.refuse(fmt.Errorf("%s: cannot check the protect list: %w", target.IP, err))
+		}
+		entry, protected = g.list.Match(dev.MAC, dev.DeviceSN)
+	}
+	if !protected {
+		return nil
+	}
+	return g.refuse(fmt.Errorf("%s is protected (%s); refusing to send '%s'", target.IP, entry, command))
+}
+
+// refuse returns err, or prints it as a warning and returns nil under
+// --override-protection
+func (g *protectionGuard) refuse(err error) error {
+	if g.override {
+		fmt.Printf("Warning: %v; sending anyway (--override-protection)\n", err)
+		return nil
+	}
+	return fmt.Errorf("%w (pass --override-protection to send anyway)", err)
+}
+
+// checkAll checks every target, so one run reports all the protected
+// devices in a batch. known maps IPs to already discovered devices and
+// may be nil.
+func (g *protectionGuard) checkAll(command string, targets []sadp.BatchTarget, known map[string]*sadp.Device) error {
+	if g == nil {
+		return nil
+	}
+	var errs []error
+	for _, t := range targets {
+		if err := g.check(command, t, known[t.IP]); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("nothing was sent:\n%w", errors.Join(errs...))
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+package cli
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestProtectionGuardCheck(t *testing.T) {
+	list := sadp.ProtectList{
+		{MAC: "4C:BD:8F:61:CC:5C", Label: "Core NVR"},
+		{Serial: "D98765432"},
+	}
+	// identify stands in for an inquiry of the device at ip
+	identify := func(ip, mac string) (*sadp.Device, error) {
+		switch ip {
+		case "10.0.0.1":
+			return &sadp.Device{MAC: "4C:BD:8F:61:CC:5C"}, nil
+		case "10.0.0.2":
+			return &sadp.Device{MAC: "4C:BD:8F:00:00:02", DeviceSN: "DS-2CD2143G0-I20200101AAWRD98765432"}, nil
+		case "10.0.0.3":
+			return &sadp.Device{MAC: "4C:BD:8F:00:00:03", DeviceSN: "DS-2CD2042WD-I20180101AAWR111111111"}, nil
+		}
+		return nil, errors.New("no response")
+	}
+
+	tests := []struct {
+		name     string
+		command  string
+		target   sadp.BatchTarget
+		known    *sadp.Device
+		override bool
+		wantErr  string
+	}{
+		{name: "read-only command allowed", command: "inquiry", target: sadp.BatchTarget{IP: "10.0.0.1"}},
+		{name: "protected MAC given", command: "reboot", target: sadp.BatchTarget{IP: "10.0.0.9", MAC: "4C:BD:8F:61:CC:5C"}, wantErr: "protected (MAC 4C:BD:8F:61:CC:5C (Core NVR))"},
+		{name: "protected MAC looked up", command: "reboot", target: sadp.BatchTarget{IP: "10.0.0.1"}, wantErr: "refusing to send 'reboot'"},
+		{name: "protected serial looked up", command: "restore", target: sadp.BatchTarget{IP: "10.0.0.2", MAC: "4C:BD:8F:00:00:02"}, wantErr: "serial D98765432"},
+		{name: "unprotected device", command: "reboot", target: sadp.BatchTarget{IP: "10.0.0.3"}},
+		{
+			name:    "known device is not looked up",
+			command: "reboot",
+			target:  sadp.BatchTarget{IP: "10.0.0.8", MAC: "4C:BD:8F:00:00:08"},
+			known:   &sadp.Device{MAC: "4C:BD:8F:00:00:08", DeviceSN: "DS-7616NI-I20190101AAWRD98765432"},
+			wantErr: "serial D98765432",
+		},
+		{name: "unidentified device refused", command: "reboot", target: sadp.BatchTarget{IP: "10.0.0.99"}, wantErr: "cannot check the protect list"},
+		{name: "override sends anyway", command: "reboot", target: sadp.BatchTarget{IP: "10.0.0.1"}, override: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := &protectionGuard{list: list, override: tt.override, identify: identify}
+			err := g.check(tt.command, tt.target, tt.known)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("check() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("check() error = %v, want it to contain %q", err, tt.wantErr)
+			}
+			if !strings.Contains(err.Error(), "--override-protection") {
+				t.Errorf("error %q does not mention --override-protection", err)
+			}
+		})
+	}
+}
+
+func TestProtectionGuardCheckAll(t *testing.T) {
+	g := &protectionGuard{
+		list:     sadp.ProtectList{{MAC: "4C:BD:8F:61:CC:5C"}, {MAC: "4C:BD:8F:00:00:02"}},
+		identify: func(ip, mac string) (*sadp.Device, error) { return nil, errors.New("unexpected lookup") },
+	}
+	targets := []sadp.BatchTarget{
+		{IP: "10.0.0.1", MAC: "4C:BD:8F:61:CC:5C"},
+		{IP: "10.0.0.2", MAC: "4C:BD:8F:00:00:02"},
+		{IP: "10.0.0.3", MAC: "4C:BD:8F:00:00:03"},
+	}
+
+	err := g.checkAll("reboot", targets, nil)
+	if err == nil {
+		t.Fatal("checkAll() error = nil, want protected targets reported")
+	}
+	for _, ip := range []string{"10.0.0.1", "10.0.0.2"} {
+		if !strings.Contains(err.Error(), ip) {
+			t.Errorf("error %q does not name %s", err, ip)
+		}
+	}
+	if strings.Contains(err.Error(), "10.0.0.3") {
+		t.Errorf("error %q names the unprotected target", err)
+	}
+
+	var nilGuard *protectionGuard
+	if err := nilGuard.checkAll("reboot", targets, nil); err != nil {
+		t.Errorf("nil guard checkAll() error = %v", err)
+	}
+}
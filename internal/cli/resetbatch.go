@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+// resetDateLayout is the YYYYMMDD device date the reset algorithm seeds with
+const resetDateLayout = "20060102"
+
+// resetBatchRow is one serial of a reset:batch input and its generated code.
+// Err is set instead of Code when the row could not be used.
+type resetBatchRow struct {
+	Line   int
+	Serial string
+	Date   string
+	Code   string
+	Err    error
+}
+
+// ResetBatchCmd handles the reset:batch command
+func ResetBatchCmd(args []string) error {
+	fs := newFlagSet("reset:batch")
+	date := fs.String("date", "", "Date in YYYYMMDD format for rows without one (default: today)")
+	algo := fs.String("algo", string(crypto.ResetAlgorithmLegacy), "Reset algorithm to generate codes with")
+	outputFile := fs.String("output", "", "Write the serial,date,resetcode CSV to this file (default: stdout)")
+	if err := fs.Parse(reorderArgsForFlags(args)); err != nil {
+		return err
+	}
+
+	if fs.NArg() < 1 {
+		fmt.Println("Usage: sadp reset:batch [options] <serials.csv>")
+		fmt.Println("\nGenerates a reset code for each serial,date row of a CSV file.")
+		fmt.Println("The date column is optional; rows without one use --date.")
+		fmt.Println("\nExamples:")
+		fmt.Println("  sadp reset:batch serials.csv")
+		fmt.Println("  sadp reset:batch --date 20240115 --output codes.csv serials.csv")
+		fmt.Println("\nOptions:")
+		fs.PrintDefaults()
+		fmt.Println("\nNote: This only works on firmware versions < 5.3.0")
+		return nil
+	}
+
+	if crypto.ResetAlgorithm(*algo) != crypto.ResetAlgorithmLegacy {
+		return fmt.Errorf("unsupported --algo %q: only %s codes can be generated offline; %s devices need the exchange-code flow",
+			*algo, crypto.ResetAlgorithmLegacy, crypto.ResetAlgorithmV2)
+	}
+
+	defaultDate := *date
+	if defaultDate == "" {
+		defaultDate = time.Now().Format(resetDateLayout)
+	} else if err := validateResetDate(defaultDate); err != nil {
+		return fmt.Errorf("--date: %w", err)
+	}
+
+	in, err := os.Open(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	rows, err := generateResetBatch(in, defaultDate)
+	if err != nil {
+		return err
+	}
+
+	out := io.Writer(os.Stdout)
+	if *outputFile != "" {
+		f, err := os.Create(*outputFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	if err := writeResetBatch(out, rows); err != nil {
+		return err
+	}
+
+	// stdout may carry the CSV, so problems are reported on stderr
+	failed := 0
+	for _, row := range rows {
+		if row.Err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "line %d: %v\n", row.Line, row.Err)
+		}
+	}
+	if *outputFile != "" {
+		fmt.Printf("Wrote %d reset code(s) to: %s\n", len(rows)-failed, *outputFile)
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d row(s) were skipped", failed, len(rows))
+	}
+	return nil
+}
+
+// generateResetBatch reads serial[,date] rows and generates a reset code for
+// each. A leading "serial" header row, blank lines and lines starting with #
+// are skipped. Rows with a bad date are returned with Err set.
+func generateResetBatch(r io.Reader, defaultDate string) ([]resetBatchRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	var rows []resetBatchRow
+	for first := true; ; first = false {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid serials CSV: %w", err)
+		}
+		line, _ := reader.FieldPos(0)
+
+		serial := strings.TrimSpace(record[0])
+		if first && strings.EqualFold(serial, "serial") {
+			continue
+		}
+		row := resetBatchRow{Line: line, Serial: serial, Date: defaultDate}
+		if len(record) > 1 && strings.TrimSpace(record[1]) != "" {
+			row.Date = strings.TrimSpace(record[1])
+		}
+
+		if row.Serial == "" {
+			row.Err = fmt.Errorf("missing serial")
+		} else if err := validateResetDate(row.Date); err != nil {
+			row.Err = fmt.Errorf("serial %s: %w", row.Serial, err)
+		} else {
+			row.Code = crypto.GenerateResetCode(row.Serial, row.Date)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+// validateResetDate checks that date is a real YYYYMMDD calendar date
+func validateResetDate(date string) error {
+	if _, err := time.Parse(resetDateLayout, date); err != nil {
+		return fmt.Errorf("invalid date %q: must be YYYYMMDD", date)
+	}
+	return nil
+}
+
+// writeResetBatch writes the rows that have a code as serial,date,resetcode
+func writeResetBatch(w io.Writer, rows []resetBatchRow) error {
+	cw := csv.NewWriter(w)
+	_ = cw.Write([]string{"serial", "date", "resetcode"})
+	for _, row := range rows {
+		if row.Err != nil {
+			continue
+		}
+		_ = cw.Write([]string{row.Serial, row.Date, row.Code})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+func TestGenerateResetBatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantRows  int
+		wantDates []string
+		wantErrs  []bool
+	}{
+		{
+			name:      "serial and date",
+			input:     "0123456789,20240115\n",
+			wantRows:  1,
+			wantDates: []string{"20240115"},
+			wantErrs:  []bool{false},
+		},
+		{
+			name:      "header, comment and default date",
+			input:     "serial,date\n# shop intake\n0123456789\nABC123, 20231215\n",
+			wantRows:  2,
+			wantDates: []string{"20240301", "20231215"},
+			wantErrs:  []bool{false, false},
+		},
+		{
+			name:      "invalid dates are reported",
+			input:     "0123456789,2024-01-15\nABC123,20241301\nDEF456,20240115\n",
+			wantRows:  3,
+			wantDates: []string{"2024-01-15", "20241301", "20240115"},
+			wantErrs:  []bool{true, true, false},
+		},
+		{
+			name:      "missing serial",
+			input:     ",20240115\n",
+			wantRows:  1,
+			wantDates: []string{"20240115"},
+			wantErrs:  []bool{true},
+		},
+		{
+			name:     "empty file",
+			input:    "",
+			wantRows: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, err := generateResetBatch(strings.NewReader(tt.input), "20240301")
+			if err != nil {
+				t.Fatalf("generateResetBatch() error = %v", err)
+			}
+			if len(rows) != tt.wantRows {
+				t.Fatalf("got %d rows, want %d", len(rows), tt.wantRows)
+			}
+			for i, row := range rows {
+				if row.Date != tt.wantDates[i] {
+					t.Errorf("row %d date = %q, want %q", i, row.Date, tt.wantDates[i])
+				}
+				if (row.Err != nil) != tt.wantErrs[i] {
+					t.Errorf("row %d error = %v, wantErr %v", i, row.Err, tt.wantErrs[i])
+				}
+				if row.Err == nil && row.Code != crypto.GenerateResetCode(row.Serial, row.Date) {
+					t.Errorf("row %d code = %q, want the GenerateResetCode result", i, row.Code)
+				}
+			}
+		})
+	}
+}
+
+func TestWriteResetBatch(t *testing.T) {
+	rows := []resetBatchRow{
+		{Line: 1, Serial: "0123456789", Date: "20240115", Code: "QRSq"},
+		{Line: 2, Serial: "ABC123", Date: "bad", Err: errors.New("invalid date")},
+	}
+
+	var buf bytes.Buffer
+	if err := writeResetBatch(&buf, rows); err != nil {
+		t.Fatalf("writeResetBatch() error = %v", err)
+	}
+	want := "serial,date,resetcode\n0123456789,20240115,QRSq\n"
+	if buf.String() != want {
+		t.Errorf("writeResetBatch() = %q, want %q", buf.String(), want)
+	}
+}
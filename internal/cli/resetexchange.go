@@ -0,0 +1,121 @@
+package cli
+
+import (
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// Reset modes accepted by reset --mode
+const (
+	// resetModeLegacy generates the serial+date code offline
+	resetModeLegacy = "legacy"
+	// resetModeExchange runs the exchangecode round-trip with the device
+	resetModeExchange = "exchange"
+)
+
+// parseResetMode checks a --mode value. Empty means no mode was given.
+func parseResetMode(mode string) error {
+	switch mode {
+	case "", resetModeLegacy, resetModeExchange:
+		return nil
+	}
+	return fmt.Errorf("unknown --mode %q (want %s or %s)", mode, resetModeLegacy, resetModeExchange)
+}
+
+// selectResetMode returns the mode to reset with: the one asked for, or
+// else the one the device firmware needs. Unknown firmware is legacy.
+func selectResetMode(mode, firmware string) string {
+	if mode != "" {
+		return mode
+	}
+	if firmware != "" && crypto.SelectResetAlgorithm(firmware) == crypto.ResetAlgorithmV2 {
+		return resetModeExchange
+	}
+	return resetModeLegacy
+}
+
+// runExchangeReset asks the device at ip for its exchange challenge and
+// prints it decrypted for Hikvision support, who turn it into a reset code.
+// Given that code, it sends it with resetpassword to set password instead,
+// without asking for a new challenge.
+func runExchangeReset(cfg *config.Config, ip string, info *deviceInfo, code, password string, debug bool) error {
+	if info.MAC == "" {
+		return fmt.Errorf("device did not answer a SADP inquiry; the exchange flow needs its MAC")
+	}
+	if code != "" && password == "" {
+		return fmt.Errorf("--code requires --password")
+	}
+
+	log := logger.New(debug)
+	defer func() { _ = log.Sync() }()
+	scanner := sadp.NewScanner(cfg.SADPTimeout, log)
+
+	if code != "" {
+		return sendExchangeResetCode(scanner, ip, info.MAC, code, password, cfg)
+	}
+
+	response, err := scanner.SendCommand("exchangecode", sadp.SendOptions{
+		TargetIP:  ip,
+		TargetMAC: info.MAC,
+		Timeout:   cfg.SADPTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("exchangecode failed: %w", err)
+	}
+	if debug {
+		fmt.Println("exchangecode response:")
+		fmt.Println(response)
+	}
+	challenge, err := sadp.DecryptChallenge(response, cfg.AESKeyHex)
+	if err != nil {
+		return fmt.Errorf("could not read the exchange challenge: %w", err)
+	}
+
+	fmt.Println("Hikvision Password Reset (exchange code)")
+	fmt.Println("========================================")
+	fmt.Println("")
+	fmt.Printf("Serial Number: %s\n", valueOrDash(info.FullSerial))
+	fmt.Printf("MAC:           %s\n", info.MAC)
+	fmt.Printf("Firmware:      %s\n", valueOrDash(info.Firmware))
+	fmt.Println("")
+	fmt.Println("----------------------------------------")
+	fmt.Printf("CHALLENGE:     %s\n", challenge)
+	fmt.Println("----------------------------------------")
+	fmt.Println("")
+	fmt.Println("Instructions:")
+	fmt.Println("1. Send the challenge above, with the serial number, to Hikvision support")
+	fmt.Println("2. Run this again with the code they return:")
+	fmt.Printf("   sadp reset --ip %s --mode exchange --code <CODE> --password <NEW_PASSWORD>\n", ip)
+	fmt.Println("")
+	fmt.Printf("Note: codes for firmware >= %s cannot be generated offline\n", crypto.LegacyResetMaxFirmware)
+	return nil
+}
+
+// sendExchangeResetCode sends a support-issued reset code to the device
+// with resetpassword and reports the result
+func sendExchangeResetCode(scanner *sadp.Scanner, ip, mac, code, password string, cfg *config.Config) error {
+	fmt.Printf("Sending reset code to %s (%s)...\n", ip, mac)
+	response, err := scanner.SendCommand("resetpassword", sadp.SendOptions{
+		TargetIP:  ip,
+		TargetMAC: mac,
+		Code:      code,
+		Password:  password,
+		Timeout:   cfg.SADPTimeout,
+	})
+	if err != nil {
+		return fmt.Errorf("resetpassword failed: %w", err)
+	}
+
+	result := scanner.ParseCommandResult(response)
+	printLockoutWarning(result, sadp.DefaultMinRemainingAttempts)
+	if !result.Success {
+		fmt.Printf("Result: FAILED (%s)\n", result.Message)
+		return fmt.Errorf("resetpassword failed: %s", result.Message)
+	}
+	fmt.Println("Result: SUCCESS")
+	return nil
+}
@@ -0,0 +1,75 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+)
+
+func TestParseResetMode(t *testing.T) {
+	tests := []struct {
+		mode    string
+		wantErr bool
+	}{
+		{mode: "", wantErr: false},
+		{mode: "legacy", wantErr: false},
+		{mode: "exchange", wantErr: false},
+		{mode: "v2", wantErr: true},
+		{mode: "Legacy", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			err := parseResetMode(tt.mode)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("parseResetMode(%q) error = %v, wantErr %v", tt.mode, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSelectResetMode(t *testing.T) {
+	tests := []struct {
+		name     string
+		mode     string
+		firmware string
+		want     string
+	}{
+		{name: "default with unknown firmware", mode: "", firmware: "", want: resetModeLegacy},
+		{name: "default with legacy firmware", mode: "", firmware: "V5.2.5 build 141201", want: resetModeLegacy},
+		{name: "default with cutoff firmware", mode: "", firmware: "V5.3.0 build 150513", want: resetModeExchange},
+		{name: "default with newer firmware", mode: "", firmware: "V5.4.5 build 170124", want: resetModeExchange},
+		{name: "explicit legacy wins over firmware", mode: resetModeLegacy, firmware: "V5.4.5 build 170124", want: resetModeLegacy},
+		{name: "explicit exchange wins over firmware", mode: resetModeExchange, firmware: "V5.2.5 build 141201", want: resetModeExchange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectResetMode(tt.mode, tt.firmware); got != tt.want {
+				t.Errorf("selectResetMode(%q, %q) = %q, want %q", tt.mode, tt.firmware, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunExchangeResetValidation(t *testing.T) {
+	cfg := config.DefaultConfig()
+
+	tests := []struct {
+		name     string
+		info     *deviceInfo
+		code     string
+		password string
+	}{
+		{name: "no MAC from inquiry", info: &deviceInfo{Firmware: "V5.4.5 build 170124"}},
+		{name: "code without password", info: &deviceInfo{MAC: "AA:BB:CC:DD:EE:FF"}, code: "ABCD1234"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := runExchangeReset(cfg, "192.0.2.1", tt.info, tt.code, tt.password, false); err == nil {
+				t.Error("runExchangeReset() error = nil, want an error before anything is sent")
+			}
+		})
+	}
+}
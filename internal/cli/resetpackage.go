@@ -0,0 +1,134 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// resetPackage is the set of details Hikvision support asks for before it
+// generates an unlock code for firmware the legacy algorithm cannot reset
+type resetPackage struct {
+	GeneratedAt  time.Time `json:"generatedAt"`
+	IPAddress    string    `json:"ipAddress"`
+	MAC          string    `json:"mac"`
+	Model        string    `json:"model"`
+	SerialNumber string    `json:"serialNumber"`
+	// DeviceDate is today's date on this host's clock, or the date of
+	// BootTime when that is later; DeviceDateSource says which. Support needs
+	// device's current date, so check it against the device web UI before
+	// sending.
+	DeviceDate            string `json:"deviceDate"`
+	DeviceDateSource      string `json:"deviceDateSource,omitempty"`
+	BootTime              string `json:"bootTime,omitempty"`
+	Firmware              string `json:"firmware"`
+	EncryptString         string `json:"encryptString"`
+	EncryptStringResponse string `json:"encryptStringResponse,omitempty"`
+	// Missing lists required fields that could not be collected
+	Missing []string `json:"missing,omitempty"`
+}
+
+var encryptStringPattern = regexp.MustCompile(`<EncryptString>([^<]+)</EncryptString>`)
+
+// newResetPackage assembles a package from the fetched device details and
+// the raw getencryptstring reply, which may be empty
+func newResetPackage(ip string, info *deviceInfo, encryptResponse string, now time.Time) *resetPackage {
+	pkg := &resetPackage{
+		GeneratedAt:           now.UTC(),
+		IPAddress:             ip,
+		MAC:                   info.MAC,
+		Model:                 info.Model,
+		SerialNumber:          info.FullSerial,
+		DeviceDate:            info.Date,
+		DeviceDateSource:      info.DateSource,
+		BootTime:              info.BootTime,
+		Firmware:              info.Firmware,
+		EncryptStringResponse: encryptResponse,
+	}
+	if m := encryptStringPattern.FindStringSubmatch(encryptResponse); len(m) > 1 {
+		pkg.EncryptString = m[1]
+	}
+
+	required := []struct {
+		name  string
+		value string
+	}{
+		{"serialNumber", pkg.SerialNumber},
+		{"deviceDate", pkg.DeviceDate},
+		{"model", pkg.Model},
+		{"firmware", pkg.Firmware},
+		{"mac", pkg.MAC},
+		{"encryptString", pkg.EncryptString},
+	}
+	for _, f := range required {
+		if f.value == "" {
+			pkg.Missing = append(pkg.Missing, f.name)
+		}
+	}
+	return pkg
+}
+
+// runResetPackage collects the reset request details from the device at ip
+// and writes them to path
+func runResetPackage(cfg *config.Config, ip, path string, debug bool) error {
+	info, err := fetchDeviceInfo(cfg, ip, debug)
+	if err != nil {
+		return fmt.Errorf("failed to collect device info: %w", err)
+	}
+
+	log := logger.New(debug)
+	defer func() { _ = log.Sync() }()
+
+	var encryptResponse string
+	if info.MAC == "" {
+		fmt.Fprintln(os.Stderr, "Warning: device did not answer a SADP inquiry; skipping getencryptstring")
+	} else {
+		scanner := sadp.NewScanner(cfg.SADPTimeout, log)
+		encryptResponse, err = scanner.SendCommand("getencryptstring", sadp.SendOptions{
+			TargetIP:  ip,
+			TargetMAC: info.MAC,
+			Timeout:   cfg.SADPTimeout,
+		})
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: getencryptstring failed: %v\n", err)
+		}
+	}
+
+	pkg := newResetPackage(ip, info, encryptResponse, time.Now())
+	data, err := json.MarshalIndent(pkg, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode reset package: %w", err)
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("failed to write reset package: %w", err)
+	}
+
+	printResetPackage(pkg, path)
+	return nil
+}
+
+func printResetPackage(pkg *resetPackage, path string) {
+	fmt.Println("Hikvision Password Reset Request Package")
+	fmt.Println("========================================")
+	fmt.Println("")
+	fmt.Printf("Model:          %s\n", pkg.Model)
+	fmt.Printf("Serial Number:  %s\n", pkg.SerialNumber)
+	fmt.Printf("MAC:            %s\n", pkg.MAC)
+	fmt.Printf("Firmware:       %s\n", pkg.Firmware)
+	fmt.Printf("Device Date:    %s (from %s; verify against the device)\n", pkg.DeviceDate, valueOrDash(pkg.DeviceDateSource))
+	if pkg.BootTime != "" {
+		fmt.Printf("Boot Time:      %s\n", pkg.BootTime)
+	}
+	fmt.Printf("Encrypt String: %s\n", pkg.EncryptString)
+	fmt.Println("")
+	if len(pkg.Missing) > 0 {
+		fmt.Printf("Warning: could not collect %v; support may ask for them\n", pkg.Missing)
+	}
+	fmt.Printf("Package written to %s; send it to Hikvision support.\n", path)
+}
@@ -0,0 +1,60 @@
+package cli
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewResetPackage(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	full := &deviceInfo{
+		Model:      "DS-7616NI-I2",
+		FullSerial: "DS-7616NI-I20123456789",
+		Serial:     "0123456789",
+		Date:       "20240102",
+		Firmware:   "V4.1.0 build 170301",
+		MAC:        "4C:BD:8F:61:CC:5C",
+		BootTime:   "2024-01-01 10:00:00",
+	}
+
+	tests := []struct {
+		name              string
+		info              *deviceInfo
+		encryptResponse   string
+		wantEncryptString string
+		wantMissing       []string
+	}{
+		{
+			name:              "all fields collected",
+			info:              full,
+			encryptResponse:   "<ProbeMatch><EncryptString>ABCDEF0123</EncryptString></ProbeMatch>",
+			wantEncryptString: "ABCDEF0123",
+		},
+		{
+			name:        "no encrypt string",
+			info:        full,
+			wantMissing: []string{"encryptString"},
+		},
+		{
+			name:        "no SADP inquiry",
+			info:        &deviceInfo{Model: "DS-7616NI-I2", FullSerial: "DS-7616NI-I20123456789", Date: "20240102"},
+			wantMissing: []string{"firmware", "mac", "encryptString"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pkg := newResetPackage("192.168.1.64", tt.info, tt.encryptResponse, now)
+			if pkg.EncryptString != tt.wantEncryptString {
+				t.Errorf("EncryptString = %q, want %q", pkg.EncryptString, tt.wantEncryptString)
+			}
+			if !reflect.DeepEqual(pkg.Missing, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", pkg.Missing, tt.wantMissing)
+			}
+			if pkg.SerialNumber != tt.info.FullSerial || pkg.IPAddress != "192.168.1.64" || !pkg.GeneratedAt.Equal(now) {
+				t.Errorf("package = %+v, want fields copied from the device info", pkg)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// UnifiedDevice is one device of a scan, found by ARP, SADP or both. The
+// two are the same device when their MACs match.
+type UnifiedDevice struct {
+	IP     string
+	MAC    string
+	ViaARP bool
+	// SADP is the device's SADP reply, nil when it did not answer one
+	SADP *sadp.Device
+}
+
+// Source names the mechanisms that found the device
+func (d *UnifiedDevice) Source() string {
+	switch {
+	case d.ViaARP && d.SADP != nil:
+		return "ARP+SADP"
+	case d.SADP != nil:
+		return "SADP"
+	}
+	return "ARP"
+}
+
+// mergeScanResults combines ARP and SADP results into one entry per MAC,
+// ARP devices first in sweep order and then those only SADP found. The IP
+// is the one the device answered ARP on, falling back to the one its SADP
+// reply reports. SADP replies without a MAC cannot be matched and are kept
+// as entries of their own.
+func mergeScanResults(arpDevices []discoveredDevice, sadpDevices []*sadp.Device) []*UnifiedDevice {
+	var merged []*UnifiedDevice
+	byMAC := make(map[string]*UnifiedDevice)
+	for _, dev := range arpDevices {
+		mac := network.NormalizeMAC(dev.MAC)
+		if existing, ok := byMAC[mac]; ok {
+			if existing.IP == "" {
+				existing.IP = dev.IP
+			}
+			continue
+		}
+		unified := &UnifiedDevice{IP: dev.IP, MAC: mac, ViaARP: true}
+		byMAC[mac] = unified
+		merged = append(merged, unified)
+	}
+
+	for _, dev := range sadpDevices {
+		mac := network.NormalizeMAC(dev.MAC)
+		unified, ok := byMAC[mac]
+		if !ok || mac == "" {
+			unified = &UnifiedDevice{MAC: mac}
+			merged = append(merged, unified)
+			if mac != "" {
+				byMAC[mac] = unified
+			}
+		}
+		unified.SADP = dev
+		if unified.IP == "" {
+			unified.IP = dev.IPv4Address
+		}
+	}
+	return merged
+}
+
+// scanCounts are the per-protocol tallies of merged scan results
+type scanCounts struct {
+	ARP, SADP, Both int
+}
+
+func countScanResults(devices []*UnifiedDevice) scanCounts {
+	var c scanCounts
+	for _, dev := range devices {
+		if dev.ViaARP {
+			c.ARP++
+		}
+		if dev.SADP != nil {
+			c.SADP++
+		}
+		if dev.ViaARP && dev.SADP != nil {
+			c.Both++
+		}
+	}
+	return c
+}
+
+// formatScanTable renders merged scan results as one row per device, with
+// the SADP details left as "-" for devices only ARP found
+func formatScanTable(devices []*UnifiedDevice, width int) []string {
+	headers := []string{"#", "IPv4 Address", "MAC Address", "Found By", "Device Type", "Status", "Port", "Serial Number", "Software Version"}
+	flexible := []bool{false, false, false, false, true, false, false, true, true}
+
+	rows := [][]string{headers}
+	for i, dev := range devices {
+		row := []string{strconv.Itoa(i + 1), valueOrDash(dev.IP), valueOrDash(dev.MAC), dev.Source()}
+		if d := dev.SADP; d != nil {
+			status := "Inactive"
+			if d.Activated == "true" {
+				status = "Active"
+			}
+			row = append(row, valueOrDash(d.DeviceType), status, strconv.Itoa(int(d.CommandPort)),
+				valueOrDash(d.DeviceSN), valueOrDash(d.SoftwareVersion))
+		} else {
+			row = append(row, "-", "-", "-", "-", "-")
+		}
+		rows = append(rows, row)
+	}
+
+	widths := fitColumnWidths(rows, flexible, width)
+	lines := []string{tableRow(truncateRow(headers, widths), widths), strings.Repeat("-", tableWidth(widths))}
+	for _, row := range rows[1:] {
+		lines = append(lines, tableRow(truncateRow(row, widths), widths))
+	}
+	return lines
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestMergeScanResults(t *testing.T) {
+	camera := &sadp.Device{MAC: "4c-bd-8f-61-cc-5c", IPv4Address: "192.168.1.64", DeviceType: "DS-2CD2143G0-IS"}
+	nvr := &sadp.Device{MAC: "4C-BD-8F-00-00-01", IPv4Address: "192.168.1.70", DeviceType: "DS-7616NI-I2"}
+
+	tests := []struct {
+		name       string
+		arp        []discoveredDevice
+		sadp       []*sadp.Device
+		want       []string
+		wantCounts scanCounts
+	}{
+		{
+			name: "none",
+		},
+		{
+			name:       "ARP only",
+			arp:        []discoveredDevice{{IP: "192.168.1.64", MAC: "4c:bd:8f:61:cc:5c"}},
+			want:       []string{"192.168.1.64 4C:BD:8F:61:CC:5C ARP -"},
+			wantCounts: scanCounts{ARP: 1},
+		},
+		{
+			name:       "found by both merges into one entry",
+			arp:        []discoveredDevice{{IP: "192.168.1.64", MAC: "4c:bd:8f:61:cc:5c"}},
+			sadp:       []*sadp.Device{camera},
+			want:       []string{"192.168.1.64 4C:BD:8F:61:CC:5C ARP+SADP DS-2CD2143G0-IS"},
+			wantCounts: scanCounts{ARP: 1, SADP: 1, Both: 1},
+		},
+		{
+			name: "SADP-only devices follow ARP ones",
+			arp:  []discoveredDevice{{IP: "192.168.1.64", MAC: "4C:BD:8F:61:CC:5C"}},
+			sadp: []*sadp.Device{nvr, camera},
+			want: []string{
+				"192.168.1.64 4C:BD:8F:61:CC:5C ARP+SADP DS-2CD2143G0-IS",
+				"192.168.1.70 4C:BD:8F:00:00:01 SADP DS-7616NI-I2",
+			},
+			wantCounts: scanCounts{ARP: 1, SADP: 2, Both: 1},
+		},
+		{
+			name:       "ARP IP kept when SADP reports another",
+			arp:        []discoveredDevice{{IP: "192.168.1.99", MAC: "4C:BD:8F:00:00:01"}},
+			sadp:       []*sadp.Device{nvr},
+			want:       []string{"192.168.1.99 4C:BD:8F:00:00:01 ARP+SADP DS-7616NI-I2"},
+			wantCounts: scanCounts{ARP: 1, SADP: 1, Both: 1},
+		},
+		{
+			name: "SADP replies without a MAC are not merged",
+			sadp: []*sadp.Device{{IPv4Address: "10.0.0.5"}, {IPv4Address: "10.0.0.6"}},
+			want: []string{
+				"10.0.0.5  SADP ",
+				"10.0.0.6  SADP ",
+			},
+			wantCounts: scanCounts{SADP: 2},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergeScanResults(tt.arp, tt.sadp)
+			var got []string
+			for _, dev := range merged {
+				deviceType := "-"
+				if dev.SADP != nil {
+					deviceType = dev.SADP.DeviceType
+				}
+				got = append(got, strings.Join([]string{dev.IP, dev.MAC, dev.Source(), deviceType}, " "))
+			}
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("mergeScanResults() = %q, want %q", got, tt.want)
+			}
+			if counts := countScanResults(merged); counts != tt.wantCounts {
+				t.Errorf("countScanResults() = %+v, want %+v", counts, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func TestFormatScanTable(t *testing.T) {
+	devices := []*UnifiedDevice{
+		{IP: "192.168.1.64", MAC: "4C:BD:8F:61:CC:5C", ViaARP: true,
+			SADP: &sadp.Device{DeviceType: "DS-2CD2143G0-IS", Activated: "true", CommandPort: 8000, DeviceSN: "DS-2CD2143G0-I20190101AAWRC12345678"}},
+		{IP: "192.168.1.80", MAC: "4C:BD:8F:00:00:02", ViaARP: true},
+	}
+
+	lines := formatScanTable(devices, defaultTableWidth)
+	if len(lines) != 4 {
+		t.Fatalf("got %d lines, want header, rule and two rows", len(lines))
+	}
+	if !strings.Contains(lines[0], "Found By") {
+		t.Errorf("header = %q, want a Found By column", lines[0])
+	}
+	for _, want := range []string{"ARP+SADP", "DS-2CD2143G0-IS", "Active", "8000"} {
+		if !strings.Contains(lines[2], want) {
+			t.Errorf("row 1 = %q, want %q", lines[2], want)
+		}
+	}
+	if !strings.Contains(lines[3], "ARP ") || strings.Contains(lines[3], "SADP") {
+		t.Errorf("row 2 = %q, want an ARP-only row", lines[3])
+	}
+	for _, line := range lines {
+		if len(line) > defaultTableWidth {
+			t.Errorf("line is %d wide, want at most %d: %q", len(line), defaultTableWidth, line)
+		}
+	}
+}
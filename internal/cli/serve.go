@@ -0,0 +1,408 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/config"
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// defaultListenAddr keeps the API on this host unless --listen says otherwise
+const defaultListenAddr = "127.0.0.1:8080"
+
+// maxSendBody caps a /send request body
+const maxSendBody = 64 << 10
+
+// apiServer serves discovery, commands and reset codes over HTTP
+type apiServer struct {
+	// newScanner returns the scanner for one request, so concurrent
+	// requests do not share discovery results
+	newScanner func() *sadp.Scanner
+	// interfaces lists the interfaces discovery would probe, for /readyz
+	interfaces func() ([]sadp.InterfaceInfo, error)
+	// hosts are the Host header names, besides loopback ones, that the API
+	// answers to
+	hosts map[string]bool
+	// guard and audit apply the send command's --protect-list and
+	// --audit-log to /send; either may be nil
+	guard *protectionGuard
+	audit *auditLog
+}
+
+// sendRequest is the JSON body of POST /send, mapped onto sadp.SendOptions
+type sendRequest struct {
+	Command    string   `json:"command"`
+	TargetIP   string   `json:"targetIP"`
+	TargetMAC  string   `json:"targetMAC"`
+	Username   string   `json:"username"`
+	Password   string   `json:"password"`
+	Code       string   `json:"code"`
+	NewIP      string   `json:"newIP"`
+	NewMask    string   `json:"newMask"`
+	NewGateway string   `json:"newGateway"`
+	NewPort    int      `json:"newPort"`
+	DHCP       bool     `json:"dhcp"`
+	DNS1       string   `json:"dns1"`
+	DNS2       string   `json:"dns2"`
+	Email      string   `json:"email"`
+	VerifyCode string   `json:"verifyCode"`
+	Answers    []string `json:"answers"`
+	// Timeout is a duration such as "10s"; empty uses the command default
+	Timeout string `json:"timeout"`
+
+	AllowWeakPassword bool `json:"allowWeakPassword"`
+}
+
+// options returns the SendOptions of r, with the same mask and port
+// defaults as the send command
+func (r sendRequest) options() (sadp.SendOptions, error) {
+	opts := sadp.SendOptions{
+		TargetIP:   r.TargetIP,
+		TargetMAC:  network.NormalizeMAC(r.TargetMAC),
+		Username:   r.Username,
+		Password:   r.Password,
+		Code:       r.Code,
+		NewIP:      r.NewIP,
+		NewMask:    r.NewMask,
+		NewGateway: r.NewGateway,
+		NewPort:    r.NewPort,
+		DHCP:       r.DHCP,
+		DNS1:       r.DNS1,
+		DNS2:       r.DNS2,
+		Email:      r.Email,
+		VerifyCode: r.VerifyCode,
+		Answers:    r.Answers,
+
+		AllowWeakPassword: r.AllowWeakPassword,
+	}
+	if opts.NewMask == "" {
+		opts.NewMask = "255.255.255.0"
+	}
+	if opts.NewPort == 0 {
+		opts.NewPort = 8000
+	}
+	if r.Timeout != "" {
+		timeout, err := time.ParseDuration(r.Timeout)
+		if err != nil || timeout <= 0 {
+			return sadp.SendOptions{}, fmt.Errorf("invalid timeout %q (use a duration such as 10s)", r.Timeout)
+		}
+		opts.Timeout = timeout
+	}
+	return opts, nil
+}
+
+// sendResponse is the JSON reply of POST /send. RemainingAttempts is -1
+// when the device does not report it.
+type sendResponse struct {
+	Command           string       `json:"command"`
+	Success           bool         `json:"success"`
+	ErrorCode         string       `json:"errorCode,omitempty"`
+	Message           string       `json:"message,omitempty"`
+	Locked            bool         `json:"locked"`
+	RemainingAttempts int          `json:"remainingAttempts"`
+	Device            *sadp.Device `json:"device,omitempty"`
+	Response          string       `json:"response"`
+	// AuditError is set when the command was sent but could not be
+	// written to the audit log
+	AuditError string `json:"auditError,omitempty"`
+}
+
+// resetResponse is the JSON reply of GET /reset
+type resetResponse struct {
+	Serial string `json:"serial"`
+	Date   string `json:"date"`
+	Code   string `json:"code"`
+}
+
+// ServeCmd handles the serve command
+func ServeCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+
+	fs := newFlagSet("serve")
+	listen := fs.String("listen", defaultListenAddr, "Address to serve the API on")
+	timeout := fs.Duration("timeout", cfg.SADPTimeout, "SADP discovery timeout of each /discover request")
+	protectFile := fs.String("protect-list", "", "File of device MACs or serials that /send must not change")
+	overrideProtection := fs.Bool("override-protection", false, "Let /send change devices on --protect-list, with a warning")
+	auditFile := fs.String("audit-log", "", "Append a JSON line per /send command (no secrets) to this file")
+	debug := fs.Bool("debug", cfg.Debug, "Enable debug output")
+	verbosity := addVerbosityFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	log := newCLILogger(*debug, *verbosity)
+	defer func() { _ = log.Sync() }()
+
+	audit, err := openAuditLog(*auditFile)
+	if err != nil {
+		return err
+	}
+	defer audit.Close()
+
+	api := &apiServer{
+		newScanner: func() *sadp.Scanner {
+			return sadp.NewScannerWithOptions(*timeout, log, configDiscoverOptions(cfg))
+		},
+		hosts: listenHosts(*listen),
+		audit: audit,
+	}
+	api.interfaces = func() ([]sadp.InterfaceInfo, error) { return api.newScanner().ProbeInterfaces() }
+	api.guard, err = newProtectionGuard(*protectFile, *overrideProtection, api.newScanner())
+	if err != nil {
+		return err
+	}
+
+	ln, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", *listen, err)
+	}
+	if !isLoopbackAddr(*listen) {
+		fmt.Printf("Warning: %s is reachable from other hosts and the API has no authentication\n", ln.Addr())
+	}
+	fmt.Printf("Serving the SADP API on http://%s (Ctrl-C to stop)\n", ln.Addr())
+
+	srv := &http.Server{Handler: api.routes(), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopbackAddr reports whether a host:port listen address only accepts
+// connections from this host
+func isLoopbackAddr(addr string) bool {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return false
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// listenHosts returns the host of a listen address as an allowed Host
+// header name, unless it is a wildcard or loopback address
+func listenHosts(addr string) map[string]bool {
+	hosts := make(map[string]bool)
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil || host == "" || host == "localhost" {
+		return hosts
+	}
+	if ip := net.ParseIP(host); ip != nil && (ip.IsUnspecified() || ip.IsLoopback()) {
+		return hosts
+	}
+	hosts[host] = true
+	return hosts
+}
+
+func (s *apiServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	// The probes carry no data, so they answer whatever name a container
+	// platform uses for the host
+	mux.HandleFunc("/healthz", s.handleHealth)
+	mux.HandleFunc("/readyz", s.handleReady)
+	mux.HandleFunc("/discover", s.checkHost(s.handleDiscover))
+	mux.HandleFunc("/send", s.checkHost(s.handleSend))
+	mux.HandleFunc("/reset", s.checkHost(s.handleReset))
+	return mux
+}
+
+// checkHost refuses requests whose Host header is not a loopback name or
+// the --listen address, so a DNS rebinding page in the operator's browser
+// cannot reach the API under its own host name
+func (s *apiServer) checkHost(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		host, _, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			host = r.Host
+		}
+		host = strings.Trim(host, "[]")
+		ip := net.ParseIP(host)
+		if !strings.EqualFold(host, "localhost") && !(ip != nil && ip.IsLoopback()) && !s.hosts[host] {
+			writeError(w, http.StatusForbidden, fmt.Errorf("host %q is not allowed", r.Host))
+			return
+		}
+		next(w, r)
+	}
+}
+
+// handleHealth answers 200 for as long as the process is up
+func (s *apiServer) handleHealth(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// handleReady answers 200 once discovery has an interface to probe from
+func (s *apiServer) handleReady(w http.ResponseWriter, r *http.Request) {
+	interfaces, err := s.interfaces()
+	if err == nil && len(interfaces) == 0 {
+		err = fmt.Errorf("no usable network interface")
+	}
+	if err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"status": "ready", "interfaces": len(interfaces)})
+}
+
+// handleDiscover runs SADP discovery and returns the devices as the
+// {"devices": [...]} JSON that --from-file reads
+func (s *apiServer) handleDiscover(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	scanner := s.newScanner()
+	devices, err := scanner.DiscoverContext(r.Context())
+	if err != nil {
+		if r.Context().Err() != nil {
+			// The client went away; there is no one to answer
+			return
+		}
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	output, err := scanner.ToJSON(devices)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprintln(w, output)
+}
+
+// handleSend sends one SADP command. Bad requests are refused with 400
+// before anything is sent; a device that does not answer is a 504. Only
+// JSON bodies are accepted, so a web page cannot send one as a simple
+// cross-origin form or text/plain request without a CORS preflight.
+func (s *apiServer) handleSend(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodPost) {
+		return
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		writeError(w, http.StatusUnsupportedMediaType, fmt.Errorf("/send requires Content-Type: application/json"))
+		return
+	}
+	var req sendRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSendBody)).Decode(&req); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid JSON body: %w", err))
+		return
+	}
+	cmd, ok := sadp.Commands[req.Command]
+	if !ok {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("unknown command %q", req.Command))
+		return
+	}
+	opts, err := req.options()
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if opts.TargetMAC == "" && (cmd.NeedsMAC || opts.TargetIP == "" || opts.TargetIP == "0.0.0.0") {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("targetMAC is required for %s", req.Command))
+		return
+	}
+
+	scanner := s.newScanner()
+	if _, err := scanner.BuildCommandXML(req.Command, opts); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := s.guard.check(req.Command, sadp.BatchTarget{IP: opts.TargetIP, MAC: opts.TargetMAC}, nil); err != nil {
+		writeError(w, http.StatusForbidden, err)
+		return
+	}
+	response, err := scanner.SendCommandContext(r.Context(), req.Command, opts)
+	if err != nil {
+		if auditErr := s.audit.record(newAuditEntry(req.Command, opts, nil, err, time.Now())); auditErr != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", auditErr)
+		}
+		status := http.StatusBadGateway
+		if errors.Is(err, sadp.ErrNoResponse) || errors.Is(err, context.DeadlineExceeded) {
+			status = http.StatusGatewayTimeout
+		}
+		writeError(w, status, err)
+		return
+	}
+
+	result := scanner.ParseCommandResult(response)
+	reply := sendResponse{
+		Command:           req.Command,
+		Success:           result.Success,
+		ErrorCode:         result.ErrorCode,
+		Message:           result.Message,
+		Locked:            result.Locked,
+		RemainingAttempts: result.RemainingAttempts,
+		Device:            result.Device,
+		Response:          response,
+	}
+	if auditErr := s.audit.record(newAuditEntry(req.Command, opts, result, nil, time.Now())); auditErr != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", auditErr)
+		reply.AuditError = auditErr.Error()
+	}
+	writeJSON(w, http.StatusOK, reply)
+}
+
+// handleReset generates the legacy reset code of ?serial= on ?date=
+func (s *apiServer) handleReset(w http.ResponseWriter, r *http.Request) {
+	if !allowMethod(w, r, http.MethodGet) {
+		return
+	}
+	serial := r.URL.Query().Get("serial")
+	date := r.URL.Query().Get("date")
+	if serial == "" || date == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("serial and date are required"))
+		return
+	}
+	if err := validateResetDate(date); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resetResponse{Serial: serial, Date: date, Code: crypto.GenerateResetCode(serial, date)})
+}
+
+// allowMethod answers 405 and reports false unless r uses method
+func allowMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method == method {
+		return true
+	}
+	w.Header().Set("Allow", method)
+	writeError(w, http.StatusMethodNotAllowed, fmt.Errorf("%s requires %s", r.URL.Path, method))
+	return false
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
@@ -0,0 +1,269 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func newTestAPIServer(interfaces []sadp.InterfaceInfo, err error) *apiServer {
+	return &apiServer{
+		newScanner: func() *sadp.Scanner {
+			return sadp.NewScannerWithOptions(time.Second, nil, sadp.DefaultDiscoverOptions())
+		},
+		interfaces: func() ([]sadp.InterfaceInfo, error) { return interfaces, err },
+	}
+}
+
+// newAPIRequest builds a request as a local client sends it: to a loopback
+// Host, with a JSON body
+func newAPIRequest(method, target, body string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	req.Host = "127.0.0.1:8080"
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req
+}
+
+func TestAPIHealthAndReady(t *testing.T) {
+	usable := []sadp.InterfaceInfo{{Name: "eth0", IP: net.ParseIP("192.168.1.10")}}
+
+	tests := []struct {
+		name       string
+		path       string
+		interfaces []sadp.InterfaceInfo
+		err        error
+		wantStatus int
+	}{
+		{name: "healthz without interfaces", path: "/healthz", wantStatus: http.StatusOK},
+		{name: "readyz with an interface", path: "/readyz", interfaces: usable, wantStatus: http.StatusOK},
+		{name: "readyz without interfaces", path: "/readyz", wantStatus: http.StatusServiceUnavailable},
+		{name: "readyz on an interface error", path: "/readyz", err: errors.New("no multicast interface"), wantStatus: http.StatusServiceUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newTestAPIServer(tt.interfaces, tt.err).routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET %s = %d, want %d: %s", tt.path, rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAPIReset(t *testing.T) {
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+	}{
+		{name: "serial and date", query: "?serial=0123456789&date=20231215", wantStatus: http.StatusOK},
+		{name: "missing serial", query: "?date=20231215", wantStatus: http.StatusBadRequest},
+		{name: "missing date", query: "?serial=0123456789", wantStatus: http.StatusBadRequest},
+		{name: "bad date", query: "?serial=0123456789&date=2023-12-15", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			newTestAPIServer(nil, nil).routes().ServeHTTP(rec, newAPIRequest(http.MethodGet, "/reset"+tt.query, ""))
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("GET /reset%s = %d, want %d: %s", tt.query, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var got resetResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &got); err != nil {
+				t.Fatalf("invalid JSON: %v", err)
+			}
+			if want := crypto.GenerateResetCode("0123456789", "20231215"); got.Code != want {
+				t.Errorf("code = %q, want %q", got.Code, want)
+			}
+		})
+	}
+}
+
+func TestAPISendRejectsBadRequests(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		body       string
+		wantStatus int
+	}{
+		{name: "GET", method: http.MethodGet, wantStatus: http.StatusMethodNotAllowed},
+		{name: "not JSON", method: http.MethodPost, body: "reboot", wantStatus: http.StatusBadRequest},
+		{name: "unknown command", method: http.MethodPost, body: `{"command":"format","targetIP":"192.0.2.1"}`, wantStatus: http.StatusBadRequest},
+		{name: "missing MAC", method: http.MethodPost, body: `{"command":"reboot","targetIP":"192.0.2.1","password":"x"}`, wantStatus: http.StatusBadRequest},
+		{name: "no IP or MAC", method: http.MethodPost, body: `{"command":"inquiry"}`, wantStatus: http.StatusBadRequest},
+		{name: "bad timeout", method: http.MethodPost, body: `{"command":"inquiry","targetIP":"192.0.2.1","timeout":"soon"}`, wantStatus: http.StatusBadRequest},
+		{name: "weak activate password", method: http.MethodPost,
+			body: `{"command":"activate","targetIP":"192.0.2.1","targetMAC":"4C:BD:8F:61:CC:5C","password":"12345"}`, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := newAPIRequest(tt.method, "/send", tt.body)
+			newTestAPIServer(nil, nil).routes().ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s /send %s = %d, want %d: %s", tt.method, tt.body, rec.Code, tt.wantStatus, rec.Body)
+			}
+			if !strings.Contains(rec.Body.String(), `"error"`) {
+				t.Errorf("body = %s, want an error message", rec.Body)
+			}
+		})
+	}
+}
+
+func TestAPISendTimeout(t *testing.T) {
+	// Nothing answers SADP on loopback, so the command times out
+	body := `{"command":"inquiry","targetIP":"127.0.0.1","timeout":"200ms"}`
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	audit, err := openAuditLog(path)
+	if err != nil {
+		t.Fatalf("openAuditLog() error = %v", err)
+	}
+	defer audit.Close()
+	api := newTestAPIServer(nil, nil)
+	api.audit = audit
+
+	rec := httptest.NewRecorder()
+	api.routes().ServeHTTP(rec, newAPIRequest(http.MethodPost, "/send", body))
+	if rec.Code != http.StatusGatewayTimeout {
+		t.Errorf("POST /send = %d, want %d: %s", rec.Code, http.StatusGatewayTimeout, rec.Body)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"command":"inquiry"`) {
+		t.Errorf("audit log = %q, want an inquiry entry", data)
+	}
+}
+
+func TestAPIHostAndContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		path        string
+		host        string
+		contentType string
+		hosts       map[string]bool
+		wantStatus  int
+	}{
+		{name: "rebound host name", path: "/discover", host: "attacker.example:8080", wantStatus: http.StatusForbidden},
+		{name: "rebound host on reset", path: "/reset?serial=1&date=20231215", host: "attacker.example", wantStatus: http.StatusForbidden},
+		{name: "localhost", path: "/reset?serial=1&date=20231215", host: "localhost:8080", wantStatus: http.StatusOK},
+		{name: "IPv6 loopback", path: "/reset?serial=1&date=20231215", host: "[::1]:8080", wantStatus: http.StatusOK},
+		{name: "listen address", path: "/reset?serial=1&date=20231215", host: "192.168.1.10:8080",
+			hosts: map[string]bool{"192.168.1.10": true}, wantStatus: http.StatusOK},
+		{name: "probes answer any host", path: "/healthz", host: "10.1.2.3:8080", wantStatus: http.StatusOK},
+		{name: "text/plain send", path: "/send", host: "127.0.0.1:8080", contentType: "text/plain", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "send without content type", path: "/send", host: "127.0.0.1:8080", wantStatus: http.StatusUnsupportedMediaType},
+		{name: "JSON send with charset", path: "/send", host: "127.0.0.1:8080", contentType: "application/json; charset=utf-8", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			method := http.MethodGet
+			if tt.path == "/send" {
+				method = http.MethodPost
+			}
+			req := httptest.NewRequest(method, tt.path, strings.NewReader(`{"command":"format"}`))
+			req.Host = tt.host
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+			api := newTestAPIServer(nil, nil)
+			api.hosts = tt.hosts
+			rec := httptest.NewRecorder()
+			api.routes().ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus {
+				t.Errorf("%s %s (Host %s) = %d, want %d: %s", method, tt.path, tt.host, rec.Code, tt.wantStatus, rec.Body)
+			}
+		})
+	}
+}
+
+func TestAPISendProtected(t *testing.T) {
+	api := newTestAPIServer(nil, nil)
+	api.guard = &protectionGuard{
+		list:     sadp.ProtectList{{MAC: "4C:BD:8F:61:CC:5C"}},
+		identify: func(ip, mac string) (*sadp.Device, error) { return nil, errors.New("unexpected lookup") },
+	}
+	body := `{"command":"reboot","targetIP":"192.0.2.1","targetMAC":"4c-bd-8f-61-cc-5c","password":"x"}`
+
+	rec := httptest.NewRecorder()
+	api.routes().ServeHTTP(rec, newAPIRequest(http.MethodPost, "/send", body))
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("POST /send = %d, want %d: %s", rec.Code, http.StatusForbidden, rec.Body)
+	}
+}
+
+func TestListenHosts(t *testing.T) {
+	tests := []struct {
+		addr string
+		want map[string]bool
+	}{
+		{addr: "127.0.0.1:8080", want: map[string]bool{}},
+		{addr: ":8080", want: map[string]bool{}},
+		{addr: "0.0.0.0:8080", want: map[string]bool{}},
+		{addr: "192.168.1.10:8080", want: map[string]bool{"192.168.1.10": true}},
+		{addr: "sadp.lan:8080", want: map[string]bool{"sadp.lan": true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := listenHosts(tt.addr); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("listenHosts(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendRequestOptions(t *testing.T) {
+	req := sendRequest{Command: "update", TargetIP: "192.168.1.64", TargetMAC: "4c-bd-8f-61-cc-5c", NewIP: "192.168.1.70", Timeout: "3s"}
+	opts, err := req.options()
+	if err != nil {
+		t.Fatalf("options() error = %v", err)
+	}
+	if opts.TargetMAC != "4C:BD:8F:61:CC:5C" || opts.NewMask != "255.255.255.0" || opts.NewPort != 8000 || opts.Timeout != 3*time.Second {
+		t.Errorf("options() = %+v, want a normalized MAC, the default mask and port, and a 3s timeout", opts)
+	}
+}
+
+func TestIsLoopbackAddr(t *testing.T) {
+	tests := []struct {
+		addr string
+		want bool
+	}{
+		{addr: "127.0.0.1:8080", want: true},
+		{addr: "localhost:8080", want: true},
+		{addr: "[::1]:8080", want: true},
+		{addr: ":8080", want: false},
+		{addr: "0.0.0.0:8080", want: false},
+		{addr: "192.168.1.10:8080", want: false},
+		{addr: "8080", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.addr, func(t *testing.T) {
+			if got := isLoopbackAddr(tt.addr); got != tt.want {
+				t.Errorf("isLoopbackAddr(%q) = %v, want %v", tt.addr, got, tt.want)
+			}
+		})
+	}
+}
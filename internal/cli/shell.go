@@ -0,0 +1,383 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// shellSecretFlags are the flags whose values are masked before a line is
+// written to the history file
+var shellSecretFlags = []string{"password", "code", "verify-code", "answer1", "answer2", "answer3"}
+
+// shell is an interactive session that runs CLI commands against a current
+// target without re-launching the process
+type shell struct {
+	targetIP  string
+	targetMAC string
+	history   []string
+	// historyFile, when set, receives each line with secrets masked
+	historyFile io.Writer
+	out         io.Writer
+	errOut      io.Writer
+	// run executes one command line's arguments; it is Run outside tests
+	run func(args []string) error
+}
+
+// ShellCmd handles the shell (repl) command
+func ShellCmd(args []string) error {
+	fs := newFlagSet("shell")
+	historyPath := fs.String("history", "", "Load and append command history to this file (secret flag values are masked)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	s := &shell{out: os.Stdout, errOut: os.Stderr, run: Run}
+	if *historyPath != "" {
+		f, err := os.OpenFile(*historyPath, os.O_CREATE|os.O_RDWR|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to open history file: %w", err)
+		}
+		defer f.Close()
+
+		if err := s.loadHistory(f); err != nil {
+			return fmt.Errorf("failed to read history file: %w", err)
+		}
+		s.historyFile = f
+	}
+
+	fmt.Println("SADP interactive shell. Type 'help' for shell commands, 'exit' to quit.")
+	return s.loop(os.Stdin)
+}
+
+// loop reads and runs lines from in until exit or end of input
+func (s *shell) loop(in io.Reader) error {
+	// --help and invalid flags end the command, not the shell
+	defer func(handling flag.ErrorHandling) { flagErrorHandling = handling }(flagErrorHandling)
+	flagErrorHandling = flag.ContinueOnError
+
+	lines := bufio.NewScanner(in)
+	for {
+		fmt.Fprint(s.out, s.prompt())
+		if !lines.Scan() {
+			fmt.Fprintln(s.out)
+			return lines.Err()
+		}
+		if s.exec(lines.Text()) {
+			return nil
+		}
+	}
+}
+
+// prompt shows the current target, if any
+func (s *shell) prompt() string {
+	target := strings.TrimSpace(s.targetIP + " " + s.targetMAC)
+	if target == "" {
+		return "sadp> "
+	}
+	return fmt.Sprintf("sadp [%s]> ", target)
+}
+
+// exec runs one input line and reports whether the shell should exit.
+// Command errors are printed rather than ending the session.
+func (s *shell) exec(line string) bool {
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return false
+	}
+
+	if strings.HasPrefix(line, "!") {
+		recalled, err := s.recall(line)
+		if err != nil {
+			fmt.Fprintf(s.errOut, "Error: %v\n", err)
+			return false
+		}
+		line = recalled
+		fmt.Fprintln(s.out, line)
+	}
+
+	args, err := splitShellLine(line)
+	if err != nil {
+		fmt.Fprintf(s.errOut, "Error: %v\n", err)
+		return false
+	}
+	s.addHistory(line, args)
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "help":
+		s.printHelp()
+		args = args[:1]
+	case "history":
+		for i, h := range s.history {
+			fmt.Fprintf(s.out, "%5d  %s\n", i+1, h)
+		}
+		return false
+	case "use":
+		if err := s.use(args[1:]); err != nil {
+			fmt.Fprintf(s.errOut, "Error: %v\n", err)
+		}
+		return false
+	case "shell", "repl":
+		fmt.Fprintln(s.errOut, "Error: already in the shell")
+		return false
+	}
+
+	// The FlagSet has already printed its usage for --help
+	if err := s.run(s.withTarget(args)); err != nil && !errors.Is(err, flag.ErrHelp) {
+		fmt.Fprintf(s.errOut, "Error: %v\n", err)
+	}
+	return false
+}
+
+// loadHistory appends the non-empty lines of a history file, whose secret
+// flag values were masked when it was written
+func (s *shell) loadHistory(r io.Reader) error {
+	lines := bufio.NewScanner(r)
+	for lines.Scan() {
+		if line := strings.TrimSpace(lines.Text()); line != "" {
+			s.history = append(s.history, line)
+		}
+	}
+	return lines.Err()
+}
+
+// recall resolves !! (the last line) and !N (history entry N). Entries
+// loaded from a history file with a masked secret are refused, since
+// running them would send "***" as the password and count toward lockout.
+func (s *shell) recall(line string) (string, error) {
+	if len(s.history) == 0 {
+		return "", errors.New("history is empty")
+	}
+	n := len(s.history)
+	if line != "!!" {
+		var err error
+		n, err = strconv.Atoi(strings.TrimPrefix(line, "!"))
+		if err != nil || n < 1 || n > len(s.history) {
+			return "", fmt.Errorf("no history entry %s", line)
+		}
+	}
+
+	entry := s.history[n-1]
+	if args, err := splitShellLine(entry); err == nil {
+		if name := maskedShellSecret(args); name != "" {
+			return "", fmt.Errorf("history entry %d has a masked --%s; re-type the line with the secret", n, name)
+		}
+	}
+	return entry, nil
+}
+
+// addHistory records line for this session and, masked, in the history file
+func (s *shell) addHistory(line string, args []string) {
+	s.history = append(s.history, line)
+	if s.historyFile != nil {
+		_, _ = fmt.Fprintln(s.historyFile, maskShellSecrets(line, args))
+	}
+}
+
+// use sets, shows or clears the current target
+func (s *shell) use(args []string) error {
+	switch {
+	case len(args) == 0:
+		if s.targetIP == "" && s.targetMAC == "" {
+			fmt.Fprintln(s.out, "No current target")
+			return nil
+		}
+		fmt.Fprintf(s.out, "Current target: IP %s, MAC %s\n", valueOrDash(s.targetIP), valueOrDash(s.targetMAC))
+		return nil
+	case len(args) == 1 && args[0] == "none":
+		s.targetIP, s.targetMAC = "", ""
+		return nil
+	case len(args) == 2 && args[0] == "mac":
+		if _, err := net.ParseMAC(args[1]); err != nil {
+			return fmt.Errorf("invalid MAC address %q", args[1])
+		}
+		s.targetMAC = network.NormalizeMAC(args[1])
+		return nil
+	case len(args) <= 2:
+		if net.ParseIP(args[0]) == nil {
+			return fmt.Errorf("invalid IP address %q", args[0])
+		}
+		// A new IP is a new device, so an earlier MAC no longer applies
+		s.targetIP, s.targetMAC = args[0], ""
+		if len(args) == 2 {
+			return s.use([]string{"mac", args[1]})
+		}
+		return nil
+	default:
+		return errors.New("usage: use <IP> [MAC] | use mac <MAC> | use none")
+	}
+}
+
+// withTarget fills in the current target for commands that did not name
+// one. send with only a MAC set uses broadcast mode (0.0.0.0).
+func (s *shell) withTarget(args []string) []string {
+	cmd, rest := args[0], args[1:]
+	switch cmd {
+	case "probe", "fingerprint", "check-lockout":
+		if s.targetIP != "" && !startsWithIP(rest) {
+			rest = append([]string{s.targetIP}, rest...)
+		}
+	case "send":
+		if hasFlag(rest, "list") || hasFlag(rest, "targets") {
+			break
+		}
+		ip := s.targetIP
+		if ip == "" && s.targetMAC != "" {
+			ip = "0.0.0.0"
+		}
+		if ip != "" && !startsWithIP(rest) {
+			rest = append([]string{ip}, rest...)
+		}
+		if s.targetMAC != "" && !hasFlag(rest, "mac") {
+			rest = append(rest, "--mac", s.targetMAC)
+		}
+	case "reset":
+		if s.targetIP != "" && !hasFlag(rest, "ip") && !hasFlag(rest, "serial") {
+			rest = append(rest, "--ip", s.targetIP)
+		}
+	}
+	return append([]string{cmd}, rest...)
+}
+
+func (s *shell) printHelp() {
+	fmt.Fprintln(s.out, "Shell commands:")
+	fmt.Fprintln(s.out, "  use <IP> [MAC]     Set the current target (clears the MAC unless given)")
+	fmt.Fprintln(s.out, "  use mac <MAC>      Set the current target MAC")
+	fmt.Fprintln(s.out, "  use none           Clear the current target")
+	fmt.Fprintln(s.out, "  use                Show the current target")
+	fmt.Fprintln(s.out, "  history            List previous lines")
+	fmt.Fprintln(s.out, "  !! / !N            Re-run the last line / line N")
+	fmt.Fprintln(s.out, "  exit, quit         Leave the shell")
+	fmt.Fprintln(s.out, "")
+	fmt.Fprintln(s.out, "Any sadp command can be run as usual. probe, fingerprint, check-lockout, send")
+	fmt.Fprintln(s.out, "and reset use the current target when none is given, e.g. 'send reboot --password x'.")
+	fmt.Fprintln(s.out, "")
+}
+
+// startsWithIP reports whether the first argument is an IP address
+func startsWithIP(args []string) bool {
+	return len(args) > 0 && net.ParseIP(args[0]) != nil
+}
+
+// hasFlag reports whether args set the named flag, in any of the -name,
+// --name and --name=value forms
+func hasFlag(args []string, name string) bool {
+	for _, arg := range args {
+		flagName, _, _ := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if strings.HasPrefix(arg, "-") && flagName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// maskShellSecrets returns line with the values of shellSecretFlags
+// replaced, so passwords typed at the prompt are not kept on disk
+func maskShellSecrets(line string, args []string) string {
+	masked := false
+	out := make([]string, len(args))
+	copy(out, args)
+	for i := 0; i < len(out); i++ {
+		if !strings.HasPrefix(out[i], "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(out[i], "-"), "=")
+		if !isShellSecretFlag(name) {
+			continue
+		}
+		masked = true
+		if hasValue {
+			out[i] = out[i][:strings.Index(out[i], "=")+1] + "***"
+		} else if i+1 < len(out) {
+			i++
+			out[i] = "***"
+		}
+	}
+	if !masked {
+		return line
+	}
+	for i, arg := range out {
+		if arg == "" || strings.ContainsAny(arg, " \t'\"") {
+			out[i] = strconv.Quote(arg)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// maskedShellSecret returns the name of the first secret flag in args whose
+// value was masked by maskShellSecrets, or ""
+func maskedShellSecret(args []string) string {
+	for i, arg := range args {
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, value, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		if !isShellSecretFlag(name) {
+			continue
+		}
+		if !hasValue && i+1 < len(args) {
+			value = args[i+1]
+		}
+		if value == "***" {
+			return name
+		}
+	}
+	return ""
+}
+
+func isShellSecretFlag(name string) bool {
+	for _, f := range shellSecretFlags {
+		if name == f {
+			return true
+		}
+	}
+	return false
+}
+
+// splitShellLine splits line into arguments on whitespace, honouring single
+// and double quotes so values such as passwords may contain spaces
+func splitShellLine(line string) ([]string, error) {
+	var args []string
+	var cur strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range line {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				cur.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote, inArg = r, true
+		case r == ' ' || r == '\t':
+			if inArg {
+				args = append(args, cur.String())
+				cur.Reset()
+				inArg = false
+			}
+		default:
+			cur.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated %c quote", quote)
+	}
+	if inArg {
+		args = append(args, cur.String())
+	}
+	return args, nil
+}
@@ -0,0 +1,284 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestShellWithTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		targetIP  string
+		targetMAC string
+		args      []string
+		want      []string
+	}{
+		{
+			name: "no target",
+			args: []string{"probe"},
+			want: []string{"probe"},
+		},
+		{
+			name:     "probe uses target",
+			targetIP: "192.168.1.64",
+			args:     []string{"probe", "--json"},
+			want:     []string{"probe", "192.168.1.64", "--json"},
+		},
+		{
+			name:     "explicit IP wins",
+			targetIP: "192.168.1.64",
+			args:     []string{"probe", "192.168.1.65"},
+			want:     []string{"probe", "192.168.1.65"},
+		},
+		{
+			name:      "send adds IP and MAC",
+			targetIP:  "192.168.1.64",
+			targetMAC: "4C:BD:8F:61:CC:5C",
+			args:      []string{"send", "reboot", "--password", "secret"},
+			want:      []string{"send", "192.168.1.64", "reboot", "--password", "secret", "--mac", "4C:BD:8F:61:CC:5C"},
+		},
+		{
+			name:      "send keeps explicit MAC",
+			targetIP:  "192.168.1.64",
+			targetMAC: "4C:BD:8F:61:CC:5C",
+			args:      []string{"send", "exchangecode", "--mac=AA:BB:CC:DD:EE:FF"},
+			want:      []string{"send", "192.168.1.64", "exchangecode", "--mac=AA:BB:CC:DD:EE:FF"},
+		},
+		{
+			name:      "send with only a MAC broadcasts",
+			targetMAC: "4C:BD:8F:61:CC:5C",
+			args:      []string{"send", "exchangecode"},
+			want:      []string{"send", "0.0.0.0", "exchangecode", "--mac", "4C:BD:8F:61:CC:5C"},
+		},
+		{
+			name:     "send --list is left alone",
+			targetIP: "192.168.1.64",
+			args:     []string{"send", "--list"},
+			want:     []string{"send", "--list"},
+		},
+		{
+			name:     "reset fetches from target",
+			targetIP: "192.168.1.64",
+			args:     []string{"reset"},
+			want:     []string{"reset", "--ip", "192.168.1.64"},
+		},
+		{
+			name:     "reset with serial is left alone",
+			targetIP: "192.168.1.64",
+			args:     []string{"reset", "--serial", "ABC123", "--date", "20240115"},
+			want:     []string{"reset", "--serial", "ABC123", "--date", "20240115"},
+		},
+		{
+			name:     "discover is left alone",
+			targetIP: "192.168.1.64",
+			args:     []string{"discover:sadp"},
+			want:     []string{"discover:sadp"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &shell{targetIP: tt.targetIP, targetMAC: tt.targetMAC}
+			if got := s.withTarget(tt.args); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("withTarget() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShellUse(t *testing.T) {
+	tests := []struct {
+		name    string
+		lines   [][]string
+		wantIP  string
+		wantMAC string
+		wantErr bool
+	}{
+		{name: "IP", lines: [][]string{{"192.168.1.64"}}, wantIP: "192.168.1.64"},
+		{name: "IP and MAC", lines: [][]string{{"192.168.1.64", "4c-bd-8f-61-cc-5c"}}, wantIP: "192.168.1.64", wantMAC: "4C:BD:8F:61:CC:5C"},
+		{name: "MAC after IP", lines: [][]string{{"192.168.1.64"}, {"mac", "4C:BD:8F:61:CC:5C"}}, wantIP: "192.168.1.64", wantMAC: "4C:BD:8F:61:CC:5C"},
+		{name: "new IP clears MAC", lines: [][]string{{"192.168.1.64", "4C:BD:8F:61:CC:5C"}, {"192.168.1.65"}}, wantIP: "192.168.1.65"},
+		{name: "none clears", lines: [][]string{{"192.168.1.64", "4C:BD:8F:61:CC:5C"}, {"none"}}},
+		{name: "invalid IP", lines: [][]string{{"camera1"}}, wantErr: true},
+		{name: "invalid MAC", lines: [][]string{{"mac", "nope"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &shell{out: &bytes.Buffer{}}
+			var err error
+			for _, line := range tt.lines {
+				err = s.use(line)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("use() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if s.targetIP != tt.wantIP || s.targetMAC != tt.wantMAC {
+				t.Errorf("target = %q %q, want %q %q", s.targetIP, s.targetMAC, tt.wantIP, tt.wantMAC)
+			}
+		})
+	}
+}
+
+func TestShellLoop(t *testing.T) {
+	var ran [][]string
+	var out, errOut, historyFile bytes.Buffer
+	s := &shell{
+		out:         &out,
+		errOut:      &errOut,
+		historyFile: &historyFile,
+		run: func(args []string) error {
+			ran = append(ran, args)
+			if args[0] == "send" {
+				return errors.New("timeout waiting for response")
+			}
+			return nil
+		},
+	}
+
+	input := strings.Join([]string{
+		"use 192.168.1.64",
+		"probe",
+		"send reboot --password 'my secret'",
+		"!2",
+		"shell",
+		"exit",
+		"probe",
+	}, "\n")
+	if err := s.loop(strings.NewReader(input)); err != nil {
+		t.Fatalf("loop() error = %v", err)
+	}
+
+	want := [][]string{
+		{"probe", "192.168.1.64"},
+		{"send", "192.168.1.64", "reboot", "--password", "my secret"},
+		{"probe", "192.168.1.64"},
+	}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want %q", ran, want)
+	}
+	if !strings.Contains(errOut.String(), "Error: timeout waiting for response") {
+		t.Errorf("command error not reported, stderr = %q", errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "already in the shell") {
+		t.Errorf("nested shell not refused, stderr = %q", errOut.String())
+	}
+	if !strings.Contains(out.String(), "sadp [192.168.1.64]> ") {
+		t.Errorf("prompt does not show target, stdout = %q", out.String())
+	}
+	if strings.Contains(historyFile.String(), "my secret") {
+		t.Errorf("history file contains the password:\n%s", historyFile.String())
+	}
+	if len(s.history) != 6 {
+		t.Errorf("history = %q, want 6 lines", s.history)
+	}
+}
+
+func TestShellRecallMaskedHistory(t *testing.T) {
+	var ran [][]string
+	var out, errOut bytes.Buffer
+	s := &shell{out: &out, errOut: &errOut, run: func(args []string) error {
+		ran = append(ran, args)
+		return nil
+	}}
+	file := "send 192.168.1.64 reboot --password ***\nprobe 192.168.1.64\nsend 192.168.1.64 activate --password=***\n"
+	if err := s.loadHistory(strings.NewReader(file)); err != nil {
+		t.Fatalf("loadHistory() error = %v", err)
+	}
+
+	if err := s.loop(strings.NewReader("!1\n!3\n!2\n")); err != nil {
+		t.Fatalf("loop() error = %v", err)
+	}
+
+	want := [][]string{{"probe", "192.168.1.64"}}
+	if !reflect.DeepEqual(ran, want) {
+		t.Errorf("ran %q, want only the unmasked entry %q", ran, want)
+	}
+	if got := strings.Count(errOut.String(), "re-type the line with the secret"); got != 2 {
+		t.Errorf("got %d masked-entry errors, want 2; stderr = %q", got, errOut.String())
+	}
+}
+
+func TestShellFlagErrors(t *testing.T) {
+	var out, errOut bytes.Buffer
+	s := &shell{out: &out, errOut: &errOut, run: Run}
+
+	input := strings.Join([]string{
+		"decrypt --help",
+		"probe-template --no-such-flag reboot",
+		"use 192.168.1.64",
+	}, "\n")
+	if err := s.loop(strings.NewReader(input)); err != nil {
+		t.Fatalf("loop() error = %v", err)
+	}
+
+	if s.targetIP != "192.168.1.64" {
+		t.Errorf("shell stopped before the last line, target = %q", s.targetIP)
+	}
+	if strings.Contains(errOut.String(), "help requested") {
+		t.Errorf("--help reported as an error, stderr = %q", errOut.String())
+	}
+	if !strings.Contains(errOut.String(), "Error: flag provided but not defined: -no-such-flag") {
+		t.Errorf("invalid flag not reported, stderr = %q", errOut.String())
+	}
+	if flagErrorHandling != flag.ExitOnError {
+		t.Errorf("flagErrorHandling = %v after the shell, want ExitOnError", flagErrorHandling)
+	}
+}
+
+func TestMaskShellSecrets(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "no secrets", line: "probe 192.168.1.64 --json", want: "probe 192.168.1.64 --json"},
+		{name: "separate value", line: "send reboot --password secret", want: "send reboot --password ***"},
+		{name: "equals value", line: "send resetpassword --code=ABC -password=secret", want: "send resetpassword --code=*** -password=***"},
+		{name: "answers", line: "send securitycode --answer1 'red car' --answer2 x", want: "send securitycode --answer1 *** --answer2 ***"},
+		{name: "quotes kept for other values", line: "send setmailbox --email 'a b' --password x", want: `send setmailbox --email "a b" --password ***`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args, err := splitShellLine(tt.line)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := maskShellSecrets(tt.line, args); got != tt.want {
+				t.Errorf("maskShellSecrets() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitShellLine(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "plain", line: "send  192.168.1.64\tinquiry", want: []string{"send", "192.168.1.64", "inquiry"}},
+		{name: "single quotes", line: "send reboot --password 'a b'", want: []string{"send", "reboot", "--password", "a b"}},
+		{name: "double quotes", line: `send reboot --password "it's"`, want: []string{"send", "reboot", "--password", "it's"}},
+		{name: "empty quoted value", line: `reset --serial ""`, want: []string{"reset", "--serial", ""}},
+		{name: "unterminated", line: "send reboot --password 'abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitShellLine(tt.line)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitShellLine() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitShellLine() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
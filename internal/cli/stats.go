@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// printInterfaceStats writes one row per interface of a discovery, headed
+// by title when it is not empty
+func printInterfaceStats(w io.Writer, title string, stats []sadp.InterfaceStats) {
+	if title != "" {
+		fmt.Fprintln(w, title)
+	}
+	if len(stats) == 0 {
+		fmt.Fprintln(w, "No interfaces were scanned")
+		return
+	}
+	fmt.Fprintf(w, "%-12s %-15s %6s %7s %9s %9s %7s %9s\n",
+		"Interface", "IP", "Probes", "Errors", "Responses", "Discarded", "Devices", "Only here")
+	for _, st := range stats {
+		if !st.Bound {
+			fmt.Fprintf(w, "%-12s %-15s %s\n", st.Interface, st.IP, "(could not bind)")
+			continue
+		}
+		fmt.Fprintf(w, "%-12s %-15s %6d %7d %9d %9d %7d %9d\n",
+			st.Interface, st.IP, st.ProbesSent, st.SendErrors, st.Responses, st.Discarded, st.Devices, st.OnlyHere)
+	}
+}
+
+// withStats wraps discover so each run prints the scanner's per-interface
+// stats to w. With more than one of attempts per result the runs are
+// numbered, restarting for each merged result as watch mode repeats them.
+func withStats(scanner *sadp.Scanner, discover func() ([]*sadp.Device, error), w io.Writer, attempts int) func() ([]*sadp.Device, error) {
+	run := 0
+	return func() ([]*sadp.Device, error) {
+		devices, err := discover()
+		if err != nil {
+			return nil, err
+		}
+		run++
+		title := "Interface stats:"
+		if attempts > 1 {
+			title = fmt.Sprintf("Interface stats (attempt %d of %d):", (run-1)%attempts+1, attempts)
+		}
+		printInterfaceStats(w, "\n"+title, scanner.InterfaceStats())
+		return devices, nil
+	}
+}
@@ -0,0 +1,46 @@
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestPrintInterfaceStats(t *testing.T) {
+	tests := []struct {
+		name  string
+		stats []sadp.InterfaceStats
+		want  []string
+	}{
+		{
+			name:  "none",
+			stats: nil,
+			want:  []string{"No interfaces were scanned"},
+		},
+		{
+			name: "bound and unbound",
+			stats: []sadp.InterfaceStats{
+				{Interface: "eth0", IP: "192.168.1.10", Bound: true, ProbesSent: 2, Responses: 5, Discarded: 1, Devices: 4, OnlyHere: 3},
+				{Interface: "wlan0", IP: "10.0.0.5"},
+			},
+			want: []string{
+				"Interface    IP              Probes  Errors Responses Discarded Devices Only here",
+				"eth0         192.168.1.10         2       0         5         1       4         3",
+				"wlan0        10.0.0.5        (could not bind)",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			printInterfaceStats(&out, "", tt.stats)
+			got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+			if strings.Join(got, "\n") != strings.Join(tt.want, "\n") {
+				t.Errorf("printInterfaceStats() =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// streamDiscover returns a discover function that prints each device to w
+// as soon as it answers. It returns the scanner's recorded devices, which
+// carry the fields of replies that arrived after the first.
+func streamDiscover(scanner *sadp.Scanner, w io.Writer) func() ([]*sadp.Device, error) {
+	return func() ([]*sadp.Device, error) {
+		stream, err := scanner.DiscoverStream()
+		if err != nil {
+			return nil, err
+		}
+		for dev := range stream {
+			fmt.Fprintln(w, formatStreamedDevice(dev))
+		}
+		return scanner.Devices(), nil
+	}
+}
+
+// formatStreamedDevice is the line --stream prints for a newly seen device
+func formatStreamedDevice(dev *sadp.Device) string {
+	return fmt.Sprintf("  + %-15s %-17s %s", valueOrDash(dev.IPv4Address), dev.MAC, valueOrDash(dev.DeviceType))
+}
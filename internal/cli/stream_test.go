@@ -0,0 +1,34 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestFormatStreamedDevice(t *testing.T) {
+	tests := []struct {
+		name string
+		dev  *sadp.Device
+		want string
+	}{
+		{
+			name: "full",
+			dev:  &sadp.Device{IPv4Address: "192.168.1.64", MAC: "AA-BB-CC-00-00-01", DeviceType: "DS-2CD2143G0-I"},
+			want: "  + 192.168.1.64    AA-BB-CC-00-00-01 DS-2CD2143G0-I",
+		},
+		{
+			name: "missing fields",
+			dev:  &sadp.Device{MAC: "AA-BB-CC-00-00-02"},
+			want: "  + -               AA-BB-CC-00-00-02 -",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatStreamedDevice(tt.dev); got != tt.want {
+				t.Errorf("formatStreamedDevice() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,166 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/term"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// defaultTableWidth is the width tables fit when stdout is not a terminal
+const defaultTableWidth = 120
+
+// minFlexibleWidth is the narrowest a truncatable column is squeezed to
+const minFlexibleWidth = 8
+
+// tableOptions selects the device table's columns and how it fits the
+// terminal
+type tableOptions struct {
+	// Wide adds the HTTP port, SDK-over-TLS port and SDK server status
+	Wide bool
+	// NoTruncate prints every value in full, even past the terminal width
+	NoTruncate bool
+	// Assess adds a Risk column and lists each device's security findings
+	Assess bool
+}
+
+// terminalWidth returns the column count of the terminal on stdout, or
+// defaultTableWidth when stdout is not a terminal
+var terminalWidth = func() int {
+	if w, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && w > 0 {
+		return w
+	}
+	return defaultTableWidth
+}
+
+// stdoutIsTerminal reports whether stdout is a terminal, and so whether
+// watch mode can redraw the screen
+func stdoutIsTerminal() bool {
+	return term.IsTerminal(int(os.Stdout.Fd()))
+}
+
+// naturalWidths returns the widest cell of each column
+func naturalWidths(rows [][]string) []int {
+	widths := make([]int, len(rows[0]))
+	for _, row := range rows {
+		for i, cell := range row {
+			if len(cell) > widths[i] {
+				widths[i] = len(cell)
+			}
+		}
+	}
+	return widths
+}
+
+// tableWidth is the printed width of a row with the given column widths
+func tableWidth(widths []int) int {
+	total := len(widths) - 1
+	for _, w := range widths {
+		total += w
+	}
+	return total
+}
+
+// fitColumnWidths returns column widths that fit rows (header first) into
+// width. Columns not marked flexible always get their full width. The
+// flexible ones share the rest evenly, narrowest first, so a column that
+// fits its share is never cut and the space it leaves goes to the others.
+// A table that cannot fit even with every flexible column at
+// minFlexibleWidth is left wider than width.
+func fitColumnWidths(rows [][]string, flexible []bool, width int) []int {
+	widths := naturalWidths(rows)
+	if tableWidth(widths) <= width {
+		return widths
+	}
+
+	remaining := width - (len(widths) - 1)
+	var flex []int
+	for i, w := range widths {
+		if flexible[i] {
+			flex = append(flex, i)
+		} else {
+			remaining -= w
+		}
+	}
+	sort.SliceStable(flex, func(a, b int) bool { return widths[flex[a]] < widths[flex[b]] })
+
+	for n, i := range flex {
+		share := remaining / (len(flex) - n)
+		if widths[i] > share {
+			widths[i] = share
+			if widths[i] < minFlexibleWidth {
+				widths[i] = minFlexibleWidth
+			}
+		}
+		remaining -= widths[i]
+	}
+	return widths
+}
+
+// truncateRow cuts each cell to its column width
+func truncateRow(row []string, widths []int) []string {
+	out := make([]string, len(row))
+	for i, cell := range row {
+		out[i] = sadp.Truncate(cell, widths[i])
+	}
+	return out
+}
+
+// formatFindings lists the security findings of each device, addressed by
+// its table number and IP, or notes that there are none
+func formatFindings(devices []*sadp.Device) []string {
+	var lines []string
+	for i, dev := range devices {
+		for _, f := range sadp.SecurityAssessment(dev) {
+			lines = append(lines, fmt.Sprintf("  %-3d %-15s %-6s %s", i+1, dev.IPv4Address, f.Severity, f.Message))
+		}
+	}
+	if len(lines) == 0 {
+		return []string{"No security findings."}
+	}
+	return append([]string{"Security findings:"}, lines...)
+}
+
+// formatSummary renders a fleet summary as the totals followed by the device
+// type and software version counts, aligned as one two-column table
+func formatSummary(summary sadp.Summary) []string {
+	totals := [][]string{
+		{"Devices", strconv.Itoa(summary.Total)},
+		{"Active", strconv.Itoa(summary.Active)},
+		{"Inactive", strconv.Itoa(summary.Inactive)},
+		{"DHCP enabled", strconv.Itoa(summary.DHCP)},
+	}
+	sections := []struct {
+		header []string
+		counts []sadp.SummaryCount
+	}{
+		{[]string{"Device Type", "Count"}, summary.ByType},
+		{[]string{"Software Version", "Count"}, summary.ByFirmware},
+	}
+
+	rows := append([][]string{}, totals...)
+	for _, section := range sections {
+		rows = append(rows, section.header)
+		for _, c := range section.counts {
+			rows = append(rows, []string{c.Name, strconv.Itoa(c.Count)})
+		}
+	}
+	widths := naturalWidths(rows)
+
+	var lines []string
+	for _, row := range totals {
+		lines = append(lines, tableRow(row, widths))
+	}
+	for _, section := range sections {
+		lines = append(lines, "", tableRow(section.header, widths), strings.Repeat("-", tableWidth(widths)))
+		for _, c := range section.counts {
+			lines = append(lines, tableRow([]string{c.Name, strconv.Itoa(c.Count)}, widths))
+		}
+	}
+	return lines
+}
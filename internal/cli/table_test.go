@@ -0,0 +1,222 @@
+package cli
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestFitColumnWidths(t *testing.T) {
+	rows := [][]string{
+		{"#", "Type", "Serial"},
+		{"1", "DS-2CD2143G0-I", "DS-2CD2143G0-I20190101AAWRC12345678"},
+	}
+	flexible := []bool{false, true, true}
+
+	tests := []struct {
+		name  string
+		width int
+		want  []int
+	}{
+		{name: "fits untouched", width: 80, want: []int{1, 14, 35}},
+		{name: "narrower column keeps its width", width: 40, want: []int{1, 14, 23}},
+		{name: "both columns shrink", width: 21, want: []int{1, 9, 9}},
+		{name: "never below the minimum", width: 5, want: []int{1, minFlexibleWidth, minFlexibleWidth}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := fitColumnWidths(rows, flexible, tt.width)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fitColumnWidths(%d) = %v, want %v", tt.width, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatDeviceTable(t *testing.T) {
+	devices := []*sadp.Device{{
+		IPv4Address:     "192.168.1.64",
+		MAC:             "4C:BD:8F:61:CC:5C",
+		DeviceType:      "DS-2CD2143G0-IS-EXTRA-LONG-MODEL",
+		Activated:       "true",
+		CommandPort:     8000,
+		DeviceSN:        "DS-2CD2143G0-I20190101AAWRC12345678",
+		SoftwareVersion: "V5.5.80 build 190603",
+	}}
+	serial := devices[0].DeviceSN
+
+	tests := []struct {
+		name       string
+		topts      tableOptions
+		width      int
+		wantSerial bool
+	}{
+		{name: "wide terminal shows everything", width: 200, wantSerial: true},
+		{name: "narrow terminal truncates", width: 100, wantSerial: false},
+		{name: "no-truncate ignores the width", topts: tableOptions{NoTruncate: true}, width: 60, wantSerial: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := formatDeviceTable(devices, tt.topts, tt.width)
+			if len(lines) != 3 {
+				t.Fatalf("got %d lines, want header, rule and one row", len(lines))
+			}
+			if got := strings.Contains(lines[2], serial); got != tt.wantSerial {
+				t.Errorf("full serial shown = %v, want %v: %q", got, tt.wantSerial, lines[2])
+			}
+			if len(lines[1]) < len(lines[2]) {
+				t.Errorf("rule %d wide does not span the table", len(lines[1]))
+			}
+			if !tt.topts.NoTruncate {
+				for _, line := range lines {
+					if len(line) > tt.width {
+						t.Errorf("line is %d wide, want at most %d: %q", len(line), tt.width, line)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestFormatDeviceTableAdapter(t *testing.T) {
+	tests := []struct {
+		name        string
+		devices     []*sadp.Device
+		wantAdapter bool
+		wantCells   []string
+	}{
+		{
+			name:    "loaded without adapters",
+			devices: []*sadp.Device{{IPv4Address: "192.168.1.64"}},
+		},
+		{
+			name: "one adapter per subnet",
+			devices: []*sadp.Device{
+				{IPv4Address: "192.168.1.64", AdapterIP: "192.168.1.10"},
+				{IPv4Address: "10.0.5.20", AdapterIP: "10.0.5.2"},
+				{IPv4Address: "172.16.0.9"},
+			},
+			wantAdapter: true,
+			wantCells:   []string{"192.168.1.10", "10.0.5.2", "-"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := formatDeviceTable(tt.devices, tableOptions{NoTruncate: true}, defaultTableWidth)
+			if got := strings.Contains(lines[0], "Adapter"); got != tt.wantAdapter {
+				t.Fatalf("header = %q, want Adapter column %v", lines[0], tt.wantAdapter)
+			}
+			for i, cell := range tt.wantCells {
+				if !strings.HasSuffix(strings.TrimRight(lines[i+2], " "), " "+cell) {
+					t.Errorf("row %d = %q, want adapter %s", i+1, lines[i+2], cell)
+				}
+			}
+		})
+	}
+}
+
+func TestFormatDeviceTableARP(t *testing.T) {
+	devices := []*sadp.Device{
+		{IPv4Address: "192.168.1.64", ARPStatus: arpVerified},
+		{IPv4Address: "192.168.1.65", ARPStatus: arpMismatch},
+	}
+
+	lines := formatDeviceTable(devices, tableOptions{NoTruncate: true}, defaultTableWidth)
+	if !strings.HasSuffix(lines[0], " ARP") {
+		t.Errorf("header = %q, want a trailing ARP column", lines[0])
+	}
+	for i, want := range []string{arpVerified, arpMismatch} {
+		if !strings.HasSuffix(lines[i+2], " "+want) {
+			t.Errorf("row %d = %q, want ARP status %s", i+1, lines[i+2], want)
+		}
+	}
+
+	lines = formatDeviceTable([]*sadp.Device{{IPv4Address: "192.168.1.64"}}, tableOptions{NoTruncate: true}, defaultTableWidth)
+	if strings.Contains(lines[0], "ARP") {
+		t.Errorf("header = %q, want no ARP column without --verify-arp", lines[0])
+	}
+}
+
+func TestFormatDeviceTableAssess(t *testing.T) {
+	devices := []*sadp.Device{
+		{IPv4Address: "192.168.1.64", Activated: "false", SoftwareVersion: "V5.2.5 build 141201"},
+		{IPv4Address: "192.168.1.65", Activated: "true", SoftwareVersion: "V5.5.80 build 190603"},
+	}
+
+	lines := formatDeviceTable(devices, tableOptions{Assess: true, NoTruncate: true}, defaultTableWidth)
+	if !strings.HasSuffix(lines[0], "Risk") {
+		t.Errorf("header = %q, want a trailing Risk column", lines[0])
+	}
+	if !strings.HasSuffix(lines[2], "high: inactive, legacy-reset") {
+		t.Errorf("row 1 = %q, want the high risk summary", lines[2])
+	}
+	if !strings.HasSuffix(lines[3], " -") {
+		t.Errorf("row 2 = %q, want no risk", lines[3])
+	}
+}
+
+func TestFormatFindings(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*sadp.Device
+		want    []string
+	}{
+		{
+			name:    "none",
+			devices: []*sadp.Device{{IPv4Address: "192.168.1.65", Activated: "true"}},
+			want:    []string{"No security findings."},
+		},
+		{
+			name: "numbered by table row",
+			devices: []*sadp.Device{
+				{IPv4Address: "192.168.1.65", Activated: "true"},
+				{IPv4Address: "192.168.1.66", Activated: "true", HCPlatformEnable: "true"},
+			},
+			want: []string{
+				"Security findings:",
+				"  2   192.168.1.66    medium Hik-Connect cloud access is enabled",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatFindings(tt.devices); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("formatFindings() =\n%q\nwant\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSummary(t *testing.T) {
+	summary := sadp.Summarize([]*sadp.Device{
+		{DeviceType: "DS-2CD2143G0-IS", SoftwareVersion: "V5.5.80 build 190603", Activated: "true", DHCP: "true"},
+		{DeviceType: "DS-2CD2143G0-IS", SoftwareVersion: "V5.5.80 build 190603", Activated: "false", DHCP: "false"},
+		{DeviceType: "DS-7616NI-I2", SoftwareVersion: "V4.1.0 build 170301", Activated: "true", DHCP: "false"},
+	})
+
+	want := []string{
+		"Devices              3",
+		"Active               2",
+		"Inactive             1",
+		"DHCP enabled         1",
+		"",
+		"Device Type          Count",
+		"--------------------------",
+		"DS-2CD2143G0-IS      2",
+		"DS-7616NI-I2         1",
+		"",
+		"Software Version     Count",
+		"--------------------------",
+		"V5.5.80 build 190603 2",
+		"V4.1.0 build 170301  1",
+	}
+	if got := formatSummary(summary); !reflect.DeepEqual(got, want) {
+		t.Errorf("formatSummary() =\n%q\nwant\n%q", got, want)
+	}
+}
@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// readOnlyCommands only query a device, so discover:sadp --then sends them
+// without asking for confirmation
+var readOnlyCommands = map[string]bool{
+	"inquiry":              true,
+	"inquiry_v32":          true,
+	"exchangecode":         true,
+	"getencryptstring":     true,
+	"getencryptstring_v31": true,
+	"getqrcodes":           true,
+	"getbindlist":          true,
+}
+
+// perDeviceCommands take settings or codes that differ per device, such as
+// update's IP, mask, gateway and port. --then has one set of options for
+// every device, so it does not send them.
+var perDeviceCommands = map[string]bool{
+	"update":        true,
+	"resetpassword": true,
+	"securitycode":  true,
+}
+
+// checkThenCommand reports whether --then can send command
+func checkThenCommand(command string) error {
+	if _, ok := sadp.Commands[command]; !ok {
+		return fmt.Errorf("unknown --then command: %s (see 'sadp send --list')", command)
+	}
+	if perDeviceCommands[command] {
+		return fmt.Errorf("--then cannot send %s, which needs per-device settings; use 'sadp send' for each device", command)
+	}
+	return nil
+}
+
+// thenTargets addresses each discovered device by its reported IP and MAC.
+// Devices without an IPv4 address cannot be sent to and are skipped.
+func thenTargets(devices []*sadp.Device) []sadp.BatchTarget {
+	targets := make([]sadp.BatchTarget, 0, len(devices))
+	for _, dev := range devices {
+		if dev.IPv4Address == "" {
+			continue
+		}
+		targets = append(targets, sadp.BatchTarget{IP: dev.IPv4Address, MAC: dev.MAC})
+	}
+	return targets
+}
+
+// runThen sends command to every device, asking first unless the command
+// is read-only or assumeYes is set. Each device's own MAC is used. Nothing
+// is sent if guard protects any of the devices.
+func runThen(scanner *sadp.Scanner, command string, devices []*sadp.Device, opts sadp.SendOptions, assumeYes bool, audit *auditLog, guard *protectionGuard) error {
+	if err := checkThenCommand(command); err != nil {
+		return err
+	}
+	targets := thenTargets(devices)
+	if len(targets) == 0 {
+		fmt.Printf("No devices to send '%s' to\n", command)
+		return nil
+	}
+	// Catch a missing password or other bad option before prompting
+	probe := opts
+	probe.TargetIP, probe.TargetMAC = targets[0].IP, targets[0].MAC
+	if _, err := scanner.BuildCommandXML(command, probe); err != nil {
+		return err
+	}
+	known := make(map[string]*sadp.Device, len(devices))
+	for _, dev := range devices {
+		known[dev.IPv4Address] = dev
+	}
+	if err := guard.checkAll(command, targets, known); err != nil {
+		return err
+	}
+	if !readOnlyCommands[command] && !assumeYes && !confirmThen(os.Stdin, os.Stdout, command, targets) {
+		return fmt.Errorf("%s cancelled; nothing was sent", command)
+	}
+
+	bopts := sadp.BatchOptions{Attempts: 1, MinRemainingAttempts: sadp.DefaultMinRemainingAttempts}
+	return runSendBatch(scanner, command, opts, targets, bopts, audit)
+}
+
+// confirmThen lists the targets of a state-changing command and asks on in
+// whether to go ahead
+func confirmThen(in io.Reader, out io.Writer, command string, targets []sadp.BatchTarget) bool {
+	fmt.Fprintf(out, "\n'%s' will be sent to %d device(s):\n", command, len(targets))
+	for _, t := range targets {
+		fmt.Fprintf(out, "  %-15s %s\n", t.IP, t.MAC)
+	}
+	return confirm(in, out, "Continue?")
+}
+
+// confirm asks a yes/no prompt on out and reads the answer from in.
+// Anything but y or yes, including end of input, declines.
+func confirm(in io.Reader, out io.Writer, prompt string) bool {
+	fmt.Fprintf(out, "%s [y/N] ", prompt)
+
+	line, _ := bufio.NewReader(in).ReadString('\n')
+	switch strings.ToLower(strings.TrimSpace(line)) {
+	case "y", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestThenTargets(t *testing.T) {
+	devices := []*sadp.Device{
+		{IPv4Address: "192.168.1.64", MAC: "4c-bd-8f-61-cc-5c"},
+		{MAC: "4c-bd-8f-61-cc-5d"},
+		{IPv4Address: "192.168.1.65", MAC: "4c-bd-8f-61-cc-5e"},
+	}
+	want := []sadp.BatchTarget{
+		{IP: "192.168.1.64", MAC: "4c-bd-8f-61-cc-5c"},
+		{IP: "192.168.1.65", MAC: "4c-bd-8f-61-cc-5e"},
+	}
+	if got := thenTargets(devices); !reflect.DeepEqual(got, want) {
+		t.Errorf("thenTargets() = %+v, want %+v", got, want)
+	}
+}
+
+func TestConfirmThen(t *testing.T) {
+	targets := []sadp.BatchTarget{{IP: "192.168.1.64", MAC: "4c-bd-8f-61-cc-5c"}}
+
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "yes", input: "yes\n", want: true},
+		{name: "y with spaces", input: " Y \n", want: true},
+		{name: "no", input: "n\n", want: false},
+		{name: "empty line", input: "\n", want: false},
+		{name: "end of input", input: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := confirmThen(strings.NewReader(tt.input), &out, "reboot", targets); got != tt.want {
+				t.Errorf("confirmThen() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "192.168.1.64") {
+				t.Errorf("prompt does not list the target:\n%s", out.String())
+			}
+		})
+	}
+}
+
+func TestReadOnlyCommandsExist(t *testing.T) {
+	for name := range readOnlyCommands {
+		cmd, ok := sadp.Commands[name]
+		if !ok {
+			t.Errorf("read-only command %q is not a SADP command", name)
+			continue
+		}
+		if cmd.NeedsPass {
+			t.Errorf("read-only command %q needs a password", name)
+		}
+	}
+}
+
+func TestCheckThenCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		wantErr bool
+	}{
+		{command: "inquiry", wantErr: false},
+		{command: "reboot", wantErr: false},
+		{command: "update", wantErr: true},
+		{command: "resetpassword", wantErr: true},
+		{command: "securitycode", wantErr: true},
+		{command: "format", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.command, func(t *testing.T) {
+			if err := checkThenCommand(tt.command); (err != nil) != tt.wantErr {
+				t.Errorf("checkThenCommand(%q) error = %v, wantErr %v", tt.command, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunThenRefusesUpdate(t *testing.T) {
+	scanner := sadp.NewScannerWithOptions(time.Second, nil, sadp.DefaultDiscoverOptions())
+	devices := []*sadp.Device{{IPv4Address: "192.168.1.64", MAC: "4C:BD:8F:61:CC:5C", DHCP: "true"}}
+	opts := sadp.SendOptions{Password: "secret"}
+
+	// With assumeYes nothing stops the send but the command check
+	if err := runThen(scanner, "update", devices, opts, true, nil, nil); err == nil {
+		t.Error("runThen(update) error = nil, want a refusal before anything is sent")
+	}
+}
@@ -0,0 +1,113 @@
+package cli
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+)
+
+// upgradeCommand is the send command that uploads firmware over ISAPI,
+// which SADP itself cannot do
+const upgradeCommand = "upgrade"
+
+// upgradePollInterval is how often upgrade progress is polled
+const upgradePollInterval = 2 * time.Second
+
+// runUpgrade uploads the firmware at path to the device and waits for the
+// device to finish writing it, all within timeout. Unless assumeYes is set
+// it asks on in first, since a bad image can leave a device unusable.
+func runUpgrade(client *isapi.Client, ip, path string, timeout time.Duration, assumeYes bool, in io.Reader) error {
+	if path == "" {
+		return fmt.Errorf("%s requires --file", upgradeCommand)
+	}
+	if client.Password == "" {
+		return fmt.Errorf("%s requires --password (or ISAPI_PASSWORD)", upgradeCommand)
+	}
+	if timeout <= 0 {
+		return fmt.Errorf("--upgrade-timeout must be positive")
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open firmware: %w", err)
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("failed to read firmware: %w", err)
+	}
+	if info.Size() == 0 {
+		return fmt.Errorf("firmware file %s is empty", path)
+	}
+
+	if !assumeYes && !confirmUpgrade(in, os.Stdout, ip, filepath.Base(path), info.Size()) {
+		return fmt.Errorf("%s cancelled; nothing was sent", upgradeCommand)
+	}
+
+	started := time.Now()
+	fmt.Printf("Uploading %s (%s) to %s...\n", filepath.Base(path), formatSize(info.Size()), ip)
+	rebootRequired, err := client.UploadFirmware(ip, f, info.Size(), timeout)
+	if err != nil {
+		return fmt.Errorf("firmware upload failed: %w", err)
+	}
+	fmt.Println("Upload accepted, waiting for the device to write it...")
+
+	remaining := timeout - time.Since(started)
+	if remaining < upgradePollInterval {
+		remaining = upgradePollInterval
+	}
+	if err := client.WaitForUpgrade(ip, upgradePollInterval, remaining, upgradeProgress(os.Stdout)); err != nil {
+		return err
+	}
+
+	if rebootRequired {
+		fmt.Printf("Result: SUCCESS (reboot %s to run the new firmware, e.g. 'sadp send %s reboot')\n", ip, ip)
+	} else {
+		fmt.Println("Result: SUCCESS (the device may reboot to apply the firmware)")
+	}
+	return nil
+}
+
+// upgradeProgress prints each change in upgrade progress to out, and the
+// first polling error of a run of them, so a rebooting device is noted once
+func upgradeProgress(out io.Writer) func(*isapi.UpgradeStatus, error) {
+	lastPercent, failing := -1, false
+	return func(status *isapi.UpgradeStatus, err error) {
+		if err != nil {
+			if !failing {
+				fmt.Fprintf(out, "  Device not answering (%v), still waiting...\n", err)
+			}
+			failing = true
+			return
+		}
+		failing = false
+		if status.Upgrading && status.Percent != lastPercent {
+			fmt.Fprintf(out, "  Upgrading: %d%%\n", status.Percent)
+			lastPercent = status.Percent
+		}
+	}
+}
+
+// confirmUpgrade names the device and firmware and asks on in whether to go
+// ahead
+func confirmUpgrade(in io.Reader, out io.Writer, ip, name string, size int64) bool {
+	fmt.Fprintf(out, "\nFirmware %s (%s) will be written to %s.\n", name, formatSize(size), ip)
+	fmt.Fprintln(out, "The device is unavailable while it upgrades; do not power it off.")
+	return confirm(in, out, "Continue?")
+}
+
+// formatSize renders a byte count in the largest whole unit up to MiB
+func formatSize(n int64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%d bytes", n)
+	}
+}
@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+)
+
+func TestConfirmUpgrade(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  bool
+	}{
+		{name: "yes", input: "yes\n", want: true},
+		{name: "y", input: "Y\n", want: true},
+		{name: "no", input: "n\n"},
+		{name: "end of input", input: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out bytes.Buffer
+			if got := confirmUpgrade(strings.NewReader(tt.input), &out, "192.168.1.64", "digicap.dav", 3<<20); got != tt.want {
+				t.Errorf("confirmUpgrade() = %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(out.String(), "digicap.dav (3.0 MiB) will be written to 192.168.1.64") {
+				t.Errorf("prompt = %q, want the firmware and device", out.String())
+			}
+		})
+	}
+}
+
+func TestUpgradeProgress(t *testing.T) {
+	var out bytes.Buffer
+	progress := upgradeProgress(&out)
+	progress(&isapi.UpgradeStatus{Upgrading: true, Percent: 10}, nil)
+	progress(&isapi.UpgradeStatus{Upgrading: true, Percent: 10}, nil)
+	progress(nil, errors.New("connection refused"))
+	progress(nil, errors.New("connection refused"))
+	progress(&isapi.UpgradeStatus{Upgrading: true, Percent: 60}, nil)
+	progress(&isapi.UpgradeStatus{Upgrading: false, Percent: 100}, nil)
+
+	want := "  Upgrading: 10%\n" +
+		"  Device not answering (connection refused), still waiting...\n" +
+		"  Upgrading: 60%\n"
+	if out.String() != want {
+		t.Errorf("progress output =\n%q\nwant\n%q", out.String(), want)
+	}
+}
+
+func TestFormatSize(t *testing.T) {
+	tests := []struct {
+		n    int64
+		want string
+	}{
+		{n: 512, want: "512 bytes"},
+		{n: 1536, want: "1.5 KiB"},
+		{n: 45 << 20, want: "45.0 MiB"},
+	}
+	for _, tt := range tests {
+		if got := formatSize(tt.n); got != tt.want {
+			t.Errorf("formatSize(%d) = %q, want %q", tt.n, got, tt.want)
+		}
+	}
+}
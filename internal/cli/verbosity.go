@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"flag"
+	"strconv"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+// verbosityFlag adds step to a shared -v count each time it is given, so
+// -v -v and -vv both mean two
+type verbosityFlag struct {
+	count *int
+	step  int
+}
+
+func (f verbosityFlag) IsBoolFlag() bool { return true }
+
+func (f verbosityFlag) String() string {
+	if f.count == nil {
+		return "0"
+	}
+	return strconv.Itoa(*f.count)
+}
+
+func (f verbosityFlag) Set(value string) error {
+	on, err := strconv.ParseBool(value)
+	if err != nil {
+		return err
+	}
+	if on {
+		*f.count += f.step
+	}
+	return nil
+}
+
+// addVerbosityFlags registers -v, -vv and -vvv on fs and returns the count
+func addVerbosityFlags(fs *flag.FlagSet) *int {
+	count := new(int)
+	fs.Var(verbosityFlag{count, 1}, "v", "Log info messages (-vv debug, -vvv per-packet trace)")
+	fs.Var(verbosityFlag{count, 2}, "vv", "Log debug messages (same as --debug)")
+	fs.Var(verbosityFlag{count, 3}, "vvv", "Log per-packet trace messages")
+	return count
+}
+
+// newCLILogger returns a logger at the level the -v count selects; --debug
+// counts as -vv
+func newCLILogger(debug bool, verbosity int) *logger.Logger {
+	if debug && verbosity < 2 {
+		verbosity = 2
+	}
+	return logger.NewWithLevel(logger.VerbosityLevel(verbosity))
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"flag"
+	"testing"
+
+	"go.uber.org/zap/zapcore"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func TestVerbosityFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+		want int
+	}{
+		{name: "none", args: nil, want: 0},
+		{name: "single", args: []string{"-v"}, want: 1},
+		{name: "repeated", args: []string{"-v", "-v"}, want: 2},
+		{name: "combined", args: []string{"-vv"}, want: 2},
+		{name: "trace", args: []string{"-vvv"}, want: 3},
+		{name: "explicit false", args: []string{"-v=false"}, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := flag.NewFlagSet("test", flag.ContinueOnError)
+			count := addVerbosityFlags(fs)
+			if err := fs.Parse(tt.args); err != nil {
+				t.Fatal(err)
+			}
+			if *count != tt.want {
+				t.Errorf("verbosity = %d, want %d", *count, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewCLILogger(t *testing.T) {
+	tests := []struct {
+		name      string
+		debug     bool
+		verbosity int
+		want      zapcore.Level
+	}{
+		{name: "default", want: zapcore.WarnLevel},
+		{name: "debug flag", debug: true, want: zapcore.DebugLevel},
+		{name: "debug with trace", debug: true, verbosity: 3, want: logger.TraceLevel},
+		{name: "info", verbosity: 1, want: zapcore.InfoLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			core := newCLILogger(tt.debug, tt.verbosity).Desugar().Core()
+			// zap cannot report levels below debug, so check the boundary
+			if !core.Enabled(tt.want) || core.Enabled(tt.want-1) {
+				t.Errorf("lowest enabled level is not %v", tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,249 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// watchOptions controls how long watch mode keeps re-running discovery.
+// A zero For and Cycles means run until interrupted.
+type watchOptions struct {
+	Interval time.Duration
+	For      time.Duration
+	Cycles   int
+	// MissedCycles is how many cycles in a row a device must miss before
+	// it is reported as disappeared; below 1 means the first miss
+	MissedCycles int
+	// Process, when set, transforms each cycle's devices before display
+	Process func([]*sadp.Device) []*sadp.Device
+	// Discover, when set, replaces scanner.Discover for each cycle
+	Discover func() ([]*sadp.Device, error)
+	// Table selects the columns and fitting of each cycle's table
+	Table tableOptions
+	// Webhook, when set, receives the changes found after each cycle
+	Webhook *webhookNotifier
+	// Redraw clears the screen before each cycle and colors new devices,
+	// for a terminal. Off, each cycle's output follows the last.
+	Redraw bool
+}
+
+// done reports whether watch mode should stop after the given number of
+// completed cycles and elapsed time
+func (w watchOptions) done(cycles int, elapsed time.Duration) bool {
+	if w.Cycles > 0 && cycles >= w.Cycles {
+		return true
+	}
+	if w.For > 0 && elapsed >= w.For {
+		return true
+	}
+	return false
+}
+
+// nextWait returns how long to sleep before the next cycle, never sleeping
+// past the end of a bounded watch
+func (w watchOptions) nextWait(elapsed time.Duration) time.Duration {
+	wait := w.Interval
+	if w.For > 0 && elapsed+wait > w.For {
+		wait = w.For - elapsed
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// watchStats accumulates results across watch cycles
+type watchStats struct {
+	Cycles int
+	Seen   map[string]*sadp.Device
+	// order is the MACs of Seen, first seen first
+	order []string
+	// fresh is the MACs first seen in the latest cycle
+	fresh map[string]bool
+	// lastSeen is the cycle each MAC last answered in
+	lastSeen map[string]int
+}
+
+func newWatchStats() *watchStats {
+	return &watchStats{Seen: make(map[string]*sadp.Device), lastSeen: make(map[string]int)}
+}
+
+// record adds a cycle's devices and returns how many had not been seen before
+func (w *watchStats) record(devices []*sadp.Device) int {
+	w.Cycles++
+	w.fresh = make(map[string]bool)
+	for _, dev := range devices {
+		if _, ok := w.Seen[dev.MAC]; !ok {
+			w.order = append(w.order, dev.MAC)
+			w.fresh[dev.MAC] = true
+		}
+		w.Seen[dev.MAC] = dev
+		w.lastSeen[dev.MAC] = w.Cycles
+	}
+	return len(w.fresh)
+}
+
+// present returns the devices that answered in one of the last missed
+// cycles, in their latest state, first seen first
+func (w *watchStats) present(missed int) []*sadp.Device {
+	if missed < 1 {
+		missed = 1
+	}
+	var devices []*sadp.Device
+	for _, mac := range w.order {
+		if w.Cycles-w.lastSeen[mac] < missed {
+			devices = append(devices, w.Seen[mac])
+		}
+	}
+	return devices
+}
+
+// devices returns every device seen so far in its latest state, first seen
+// first, so a device that misses a cycle stays in the table
+func (w *watchStats) devices() []*sadp.Device {
+	devices := make([]*sadp.Device, 0, len(w.order))
+	for _, mac := range w.order {
+		devices = append(devices, w.Seen[mac])
+	}
+	return devices
+}
+
+// runWatch re-runs SADP discovery every interval until the stop conditions
+// are met or ctx is done, then prints cumulative statistics. The table is
+// of every device seen so far, with those new this cycle marked.
+func runWatch(ctx context.Context, scanner *sadp.Scanner, opts watchOptions) error {
+	stats := newWatchStats()
+	start := time.Now()
+	var previous []*sadp.Device
+
+	for {
+		scanner.Reset()
+		discover := func() ([]*sadp.Device, error) { return scanner.DiscoverContext(ctx) }
+		if opts.Discover != nil {
+			discover = opts.Discover
+		}
+		devices, err := discover()
+		if ctx.Err() != nil {
+			// Interrupted mid-cycle: the partial results are not shown
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if opts.Process != nil {
+			devices = opts.Process(devices)
+		}
+
+		newCount := stats.record(devices)
+		if opts.Redraw {
+			fmt.Print(clearScreen)
+		}
+		fmt.Printf("\n[%s] Cycle %d: %d device(s), %d new, %d seen in total\n",
+			time.Now().Format("15:04:05"), stats.Cycles, len(devices), newCount, len(stats.Seen))
+		printWatchTable(stats.devices(), stats.fresh, opts)
+		printWarningSummary(scanner)
+
+		// The first cycle is the baseline the later ones are compared to.
+		// Comparing the devices still present rather than the raw cycle
+		// keeps a lost reply from reporting a device gone and back.
+		current := stats.present(opts.MissedCycles)
+		if stats.Cycles > 1 {
+			changes := sadp.DiffDevices(previous, current)
+			for _, line := range formatChanges(changes) {
+				fmt.Println(line)
+			}
+			if err := opts.Webhook.notify(changes, time.Now()); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}
+		previous = current
+
+		elapsed := time.Since(start)
+		if opts.done(stats.Cycles, elapsed) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(opts.nextWait(elapsed)):
+		}
+		if ctx.Err() != nil || opts.done(stats.Cycles, time.Since(start)) {
+			break
+		}
+	}
+
+	if ctx.Err() != nil {
+		// End the line the terminal echoed ^C on
+		fmt.Println()
+	}
+
+	printWatchSummary(stats, time.Since(start))
+	return nil
+}
+
+// clearScreen moves the cursor home and clears the terminal
+const clearScreen = "\033[H\033[2J"
+
+// newDeviceColor and resetColor highlight new devices on a terminal
+const (
+	newDeviceColor = "\033[1;32m"
+	resetColor     = "\033[0m"
+)
+
+func printWatchTable(devices []*sadp.Device, fresh map[string]bool, opts watchOptions) {
+	if len(devices) == 0 {
+		fmt.Println("No devices found.")
+		return
+	}
+
+	fmt.Println()
+	for _, line := range formatWatchTable(devices, fresh, opts.Table, terminalWidth(), opts.Redraw) {
+		fmt.Println(line)
+	}
+	fmt.Println()
+}
+
+// formatWatchTable renders the device table with a leading "+" on the rows
+// of devices in fresh, also colored when color is set
+func formatWatchTable(devices []*sadp.Device, fresh map[string]bool, topts tableOptions, width int, color bool) []string {
+	const marker = "+ "
+	table := formatDeviceTable(devices, topts, width-len(marker))
+	lines := make([]string, 0, len(table))
+	for i, line := range table {
+		// The table is a header and rule, then one row per device
+		if i < 2 || !fresh[devices[i-2].MAC] {
+			lines = append(lines, strings.Repeat(" ", len(marker))+line)
+			continue
+		}
+		if color {
+			line = newDeviceColor + line + resetColor
+		}
+		lines = append(lines, marker+line)
+	}
+	return lines
+}
+
+// formatChanges renders one line per change between two watch cycles
+func formatChanges(changes []sadp.DeviceChange) []string {
+	lines := make([]string, 0, len(changes))
+	for _, c := range changes {
+		line := fmt.Sprintf("  %-11s %-17s %s", c.Event, c.Device.MAC, valueOrDash(c.Device.IPv4Address))
+		for _, f := range c.Changes {
+			line += fmt.Sprintf(" %s: %s -> %s", f.Field, valueOrDash(f.From), valueOrDash(f.To))
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+func printWatchSummary(stats *watchStats, elapsed time.Duration) {
+	fmt.Println("===================================================")
+	fmt.Println("                  WATCH SUMMARY                    ")
+	fmt.Println("===================================================")
+	fmt.Printf("Cycles:               %d\n", stats.Cycles)
+	fmt.Printf("Duration:             %s\n", elapsed.Round(time.Second))
+	fmt.Printf("Total unique devices: %d\n", len(stats.Seen))
+}
@@ -0,0 +1,274 @@
+package cli
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestWatchOptionsDone(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     watchOptions
+		cycles   int
+		elapsed  time.Duration
+		expected bool
+	}{
+		{
+			name:     "continuous never stops",
+			opts:     watchOptions{Interval: time.Second},
+			cycles:   1000,
+			elapsed:  time.Hour,
+			expected: false,
+		},
+		{
+			name:     "cycle limit not reached",
+			opts:     watchOptions{Cycles: 3},
+			cycles:   2,
+			expected: false,
+		},
+		{
+			name:     "cycle limit reached",
+			opts:     watchOptions{Cycles: 3},
+			cycles:   3,
+			expected: true,
+		},
+		{
+			name:     "duration not reached",
+			opts:     watchOptions{For: 5 * time.Minute},
+			elapsed:  4 * time.Minute,
+			expected: false,
+		},
+		{
+			name:     "duration reached",
+			opts:     watchOptions{For: 5 * time.Minute},
+			elapsed:  5 * time.Minute,
+			expected: true,
+		},
+		{
+			name:     "either bound stops",
+			opts:     watchOptions{For: 5 * time.Minute, Cycles: 10},
+			cycles:   10,
+			elapsed:  time.Minute,
+			expected: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.done(tt.cycles, tt.elapsed); got != tt.expected {
+				t.Errorf("done(%d, %v) = %v, want %v", tt.cycles, tt.elapsed, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWatchOptionsNextWait(t *testing.T) {
+	tests := []struct {
+		name     string
+		opts     watchOptions
+		elapsed  time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "unbounded uses interval",
+			opts:     watchOptions{Interval: 10 * time.Second},
+			elapsed:  time.Hour,
+			expected: 10 * time.Second,
+		},
+		{
+			name:     "clamped to remaining duration",
+			opts:     watchOptions{Interval: 10 * time.Second, For: time.Minute},
+			elapsed:  55 * time.Second,
+			expected: 5 * time.Second,
+		},
+		{
+			name:     "past deadline",
+			opts:     watchOptions{Interval: 10 * time.Second, For: time.Minute},
+			elapsed:  2 * time.Minute,
+			expected: 0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.nextWait(tt.elapsed); got != tt.expected {
+				t.Errorf("nextWait(%v) = %v, want %v", tt.elapsed, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestWatchStatsRecord(t *testing.T) {
+	tests := []struct {
+		name       string
+		cycles     [][]*sadp.Device
+		wantNew    []int
+		wantUnique int
+		wantOrder  []string
+		wantFresh  []string
+	}{
+		{
+			name: "devices accumulate across cycles",
+			cycles: [][]*sadp.Device{
+				{{MAC: "AA:AA:AA:AA:AA:01"}, {MAC: "AA:AA:AA:AA:AA:02"}},
+				{{MAC: "AA:AA:AA:AA:AA:02"}, {MAC: "AA:AA:AA:AA:AA:03"}},
+				{},
+			},
+			wantNew:    []int{2, 1, 0},
+			wantUnique: 3,
+			wantOrder:  []string{"AA:AA:AA:AA:AA:01", "AA:AA:AA:AA:AA:02", "AA:AA:AA:AA:AA:03"},
+		},
+		{
+			name: "a device missing a cycle is kept in first-seen order",
+			cycles: [][]*sadp.Device{
+				{{MAC: "AA:AA:AA:AA:AA:02"}, {MAC: "AA:AA:AA:AA:AA:01"}},
+				{{MAC: "AA:AA:AA:AA:AA:01"}},
+				{{MAC: "AA:AA:AA:AA:AA:03"}, {MAC: "AA:AA:AA:AA:AA:02"}},
+			},
+			wantNew:    []int{2, 0, 1},
+			wantUnique: 3,
+			wantOrder:  []string{"AA:AA:AA:AA:AA:02", "AA:AA:AA:AA:AA:01", "AA:AA:AA:AA:AA:03"},
+			wantFresh:  []string{"AA:AA:AA:AA:AA:03"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := newWatchStats()
+			for i, devices := range tt.cycles {
+				if got := stats.record(devices); got != tt.wantNew[i] {
+					t.Errorf("cycle %d new = %d, want %d", i+1, got, tt.wantNew[i])
+				}
+			}
+			if stats.Cycles != len(tt.cycles) {
+				t.Errorf("Cycles = %d, want %d", stats.Cycles, len(tt.cycles))
+			}
+			if len(stats.Seen) != tt.wantUnique {
+				t.Errorf("unique = %d, want %d", len(stats.Seen), tt.wantUnique)
+			}
+			var order []string
+			for _, dev := range stats.devices() {
+				order = append(order, dev.MAC)
+			}
+			if strings.Join(order, ",") != strings.Join(tt.wantOrder, ",") {
+				t.Errorf("devices() = %v, want %v", order, tt.wantOrder)
+			}
+			if len(stats.fresh) != len(tt.wantFresh) {
+				t.Errorf("fresh = %v, want %v", stats.fresh, tt.wantFresh)
+			}
+			for _, mac := range tt.wantFresh {
+				if !stats.fresh[mac] {
+					t.Errorf("fresh = %v, want %s in it", stats.fresh, mac)
+				}
+			}
+		})
+	}
+}
+
+func TestWatchStatsPresent(t *testing.T) {
+	a := &sadp.Device{MAC: "AA:AA:AA:AA:AA:01"}
+	b := &sadp.Device{MAC: "AA:AA:AA:AA:AA:02"}
+	cycles := [][]*sadp.Device{{a, b}, {a}, {a}, {a}, {a, b}}
+
+	tests := []struct {
+		name   string
+		missed int
+		want   []string
+	}{
+		{name: "first miss", missed: 1, want: []string{"01,02", "01", "01", "01", "01,02"}},
+		{name: "unset is the first miss", missed: 0, want: []string{"01,02", "01", "01", "01", "01,02"}},
+		{name: "three misses", missed: 3, want: []string{"01,02", "01,02", "01,02", "01", "01,02"}},
+		{name: "never long enough", missed: 5, want: []string{"01,02", "01,02", "01,02", "01,02", "01,02"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := newWatchStats()
+			for i, devices := range cycles {
+				stats.record(devices)
+				var got []string
+				for _, dev := range stats.present(tt.missed) {
+					got = append(got, dev.MAC[len(dev.MAC)-2:])
+				}
+				if strings.Join(got, ",") != tt.want[i] {
+					t.Errorf("cycle %d present = %v, want %s", i+1, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestFormatWatchTable(t *testing.T) {
+	devices := []*sadp.Device{
+		{MAC: "AA:AA:AA:AA:AA:01", IPv4Address: "192.168.1.64", Activated: "true"},
+		{MAC: "AA:AA:AA:AA:AA:02", IPv4Address: "192.168.1.65", Activated: "false"},
+	}
+	fresh := map[string]bool{"AA:AA:AA:AA:AA:02": true}
+
+	tests := []struct {
+		name  string
+		color bool
+	}{
+		{name: "plain", color: false},
+		{name: "color", color: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lines := formatWatchTable(devices, fresh, tableOptions{}, defaultTableWidth, tt.color)
+			if len(lines) != 4 {
+				t.Fatalf("got %d lines, want header, rule and two rows", len(lines))
+			}
+			for i, line := range lines[:3] {
+				if !strings.HasPrefix(line, "  ") || strings.Contains(line, newDeviceColor) {
+					t.Errorf("line %d = %q, want it unmarked", i, line)
+				}
+			}
+			if !strings.HasPrefix(lines[3], "+ ") || !strings.Contains(lines[3], "192.168.1.65") {
+				t.Errorf("row 2 = %q, want it marked new", lines[3])
+			}
+			if got := strings.Contains(lines[3], newDeviceColor); got != tt.color {
+				t.Errorf("row 2 colored = %v, want %v", got, tt.color)
+			}
+			for _, line := range lines {
+				if !tt.color && len(line) > defaultTableWidth {
+					t.Errorf("line is %d wide, want at most %d: %q", len(line), defaultTableWidth, line)
+				}
+			}
+		})
+	}
+}
+
+func TestRunWatchStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	scanner := sadp.NewScannerWithOptions(time.Second, nil, sadp.DefaultDiscoverOptions())
+
+	calls := 0
+	opts := watchOptions{
+		Interval: time.Hour,
+		Discover: func() ([]*sadp.Device, error) {
+			calls++
+			time.AfterFunc(10*time.Millisecond, cancel)
+			return []*sadp.Device{{MAC: "AA:AA:AA:AA:AA:01"}}, nil
+		},
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- runWatch(ctx, scanner, opts) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("runWatch() error = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("runWatch() did not return after its context was cancelled")
+	}
+	if calls != 1 {
+		t.Errorf("discovery ran %d times, want 1", calls)
+	}
+}
@@ -0,0 +1,114 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// webhookPayload is the JSON body posted for each watch mode change
+type webhookPayload struct {
+	Event     sadp.ChangeEvent   `json:"event"`
+	Device    *sadp.Device       `json:"device"`
+	Changes   []sadp.FieldChange `json:"changes,omitempty"`
+	Timestamp time.Time          `json:"timestamp"`
+}
+
+// webhookNotifier posts device changes to a URL. Unlike the device clients
+// it uses net/http, since webhook endpoints are usually HTTPS. A nil
+// *webhookNotifier sends nothing.
+type webhookNotifier struct {
+	url    string
+	client *http.Client
+	// retries is how many times a failed post is retried, backoff the
+	// delay before the first retry, doubling after each
+	retries int
+	backoff time.Duration
+}
+
+// newWebhookNotifier validates rawURL, or returns nil for an empty one
+func newWebhookNotifier(rawURL string, timeout time.Duration, retries int) (*webhookNotifier, error) {
+	if rawURL == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid webhook URL %q (use http:// or https://)", rawURL)
+	}
+	if retries < 0 {
+		return nil, fmt.Errorf("--webhook-retries must not be negative")
+	}
+	return &webhookNotifier{
+		url:     rawURL,
+		client:  &http.Client{Timeout: timeout},
+		retries: retries,
+		backoff: time.Second,
+	}, nil
+}
+
+// notify posts one payload per change. A change that still fails after
+// its retries is reported in the returned error, after the rest are sent.
+func (w *webhookNotifier) notify(changes []sadp.DeviceChange, now time.Time) error {
+	if w == nil {
+		return nil
+	}
+	failed := 0
+	var lastErr error
+	for _, c := range changes {
+		payload := webhookPayload{Event: c.Event, Device: c.Device, Changes: c.Changes, Timestamp: now.UTC()}
+		if err := w.post(payload); err != nil {
+			failed++
+			lastErr = err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d webhook event(s) not delivered: %w", failed, len(changes), lastErr)
+	}
+	return nil
+}
+
+// post sends payload, retrying network errors, 429 and 5xx replies. Other
+// replies outside 2xx are not retried since resending will not help.
+func (w *webhookNotifier) post(payload webhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delay := w.backoff
+	for attempt := 0; ; attempt++ {
+		retry, err := w.postOnce(body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.retries {
+			return err
+		}
+		time.Sleep(delay)
+		delay *= 2
+	}
+}
+
+// postOnce makes one delivery attempt and reports whether a failure is
+// worth retrying
+func (w *webhookNotifier) postOnce(body []byte) (retry bool, err error) {
+	resp, err := w.client.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return true, fmt.Errorf("webhook post failed: %w", err)
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return false, nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return true, fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	default:
+		return false, fmt.Errorf("webhook returned HTTP %d", resp.StatusCode)
+	}
+}
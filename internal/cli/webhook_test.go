@@ -0,0 +1,116 @@
+package cli
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestNewWebhookNotifier(t *testing.T) {
+	tests := []struct {
+		name    string
+		url     string
+		wantNil bool
+		wantErr bool
+	}{
+		{name: "empty disables", url: "", wantNil: true},
+		{name: "https", url: "https://hooks.example.com/T000/B000"},
+		{name: "no scheme", url: "hooks.example.com/x", wantErr: true},
+		{name: "other scheme", url: "ftp://example.com/x", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w, err := newWebhookNotifier(tt.url, time.Second, 1)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("newWebhookNotifier() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && (w == nil) != tt.wantNil {
+				t.Errorf("newWebhookNotifier() = %v, want nil %v", w, tt.wantNil)
+			}
+		})
+	}
+}
+
+func TestWebhookNotify(t *testing.T) {
+	tests := []struct {
+		name      string
+		statuses  []int
+		wantPosts int
+		wantErr   bool
+	}{
+		{name: "delivered", statuses: []int{http.StatusOK}, wantPosts: 1},
+		{name: "retried after server error", statuses: []int{http.StatusInternalServerError, http.StatusNoContent}, wantPosts: 2},
+		{name: "client error not retried", statuses: []int{http.StatusBadRequest}, wantPosts: 1, wantErr: true},
+		{name: "gives up after retries", statuses: []int{503, 503, 503}, wantPosts: 3, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			posts := 0
+			var got webhookPayload
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				data, _ := io.ReadAll(r.Body)
+				_ = json.Unmarshal(data, &got)
+				status := tt.statuses[len(tt.statuses)-1]
+				if posts < len(tt.statuses) {
+					status = tt.statuses[posts]
+				}
+				posts++
+				w.WriteHeader(status)
+			}))
+			defer server.Close()
+
+			w, err := newWebhookNotifier(server.URL, time.Second, 2)
+			if err != nil {
+				t.Fatal(err)
+			}
+			w.backoff = time.Millisecond
+
+			now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+			changes := []sadp.DeviceChange{{
+				Event:   sadp.DeviceChanged,
+				Device:  &sadp.Device{MAC: "4C:BD:8F:61:CC:5C", Activated: "true"},
+				Changes: []sadp.FieldChange{{Field: "activated", From: "false", To: "true"}},
+			}}
+			err = w.notify(changes, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if posts != tt.wantPosts {
+				t.Errorf("posts = %d, want %d", posts, tt.wantPosts)
+			}
+			if got.Event != sadp.DeviceChanged || got.Device == nil || got.Device.MAC != "4C:BD:8F:61:CC:5C" ||
+				!got.Timestamp.Equal(now) || len(got.Changes) != 1 {
+				t.Errorf("payload = %+v, want the change event", got)
+			}
+		})
+	}
+}
+
+func TestWebhookNotifyNil(t *testing.T) {
+	var w *webhookNotifier
+	if err := w.notify([]sadp.DeviceChange{{Event: sadp.DeviceAppeared, Device: &sadp.Device{}}}, time.Now()); err != nil {
+		t.Errorf("nil notifier notify() error = %v", err)
+	}
+}
+
+func TestFormatChanges(t *testing.T) {
+	changes := []sadp.DeviceChange{
+		{Event: sadp.DeviceAppeared, Device: &sadp.Device{MAC: "4C:BD:8F:00:00:04", IPv4Address: "192.168.1.67"}},
+		{Event: sadp.DeviceChanged, Device: &sadp.Device{MAC: "4C:BD:8F:00:00:01", IPv4Address: "192.168.1.64"},
+			Changes: []sadp.FieldChange{{Field: "activated", From: "false", To: "true"}}},
+	}
+	got := strings.Join(formatChanges(changes), "\n")
+	want := "  appeared    4C:BD:8F:00:00:04 192.168.1.67\n" +
+		"  changed     4C:BD:8F:00:00:01 192.168.1.64 activated: false -> true"
+	if got != want {
+		t.Errorf("formatChanges() =\n%s\nwant\n%s", got, want)
+	}
+}
@@ -1,6 +1,8 @@
 package config
 
 import (
+	"encoding/hex"
+	"fmt"
 	"time"
 
 	"github.com/caarlos0/env/v11"
@@ -12,9 +14,15 @@ type Config struct {
 	HTTPTimeout time.Duration `env:"HTTP_TIMEOUT" envDefault:"10s"`
 	UserAgent   string        `env:"USER_AGENT" envDefault:"Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36"`
 
+	// ISAPI credentials (Digest auth)
+	ISAPIUsername string `env:"ISAPI_USERNAME" envDefault:"admin"`
+	ISAPIPassword string `env:"ISAPI_PASSWORD"`
+
 	// Discovery settings
 	DiscoveryWorkers int           `env:"DISCOVERY_WORKERS" envDefault:"100"`
 	DiscoveryTimeout time.Duration `env:"DISCOVERY_TIMEOUT" envDefault:"1s"`
+	// DiscoveryRetries is how many times SADP discovery resends its probes
+	DiscoveryRetries int `env:"DISCOVERY_RETRIES" envDefault:"2"`
 
 	// SADP settings
 	SADPTimeout time.Duration `env:"SADP_TIMEOUT" envDefault:"5s"`
@@ -34,6 +42,9 @@ func Load() (*Config, error) {
 	if err := env.Parse(cfg); err != nil {
 		return nil, err
 	}
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
@@ -43,9 +54,40 @@ func LoadWithOptions(opts env.Options) (*Config, error) {
 	if err := env.ParseWithOptions(cfg, opts); err != nil {
 		return nil, err
 	}
+	if err := ValidateConfig(cfg); err != nil {
+		return nil, err
+	}
 	return cfg, nil
 }
 
+// ValidateConfig checks that the encryption keys decode to usable lengths
+// and the retry count is not negative
+func ValidateConfig(cfg *Config) error {
+	if cfg.DiscoveryRetries < 0 {
+		return fmt.Errorf("invalid DISCOVERY_RETRIES: %d, must not be negative", cfg.DiscoveryRetries)
+	}
+
+	aesKey, err := hex.DecodeString(cfg.AESKeyHex)
+	if err != nil {
+		return fmt.Errorf("invalid AES_KEY_HEX: %w", err)
+	}
+	switch len(aesKey) {
+	case 16, 24, 32:
+	default:
+		return fmt.Errorf("invalid AES_KEY_HEX: key is %d bytes, must be 16, 24, or 32", len(aesKey))
+	}
+
+	xorKey, err := hex.DecodeString(cfg.XORKeyHex)
+	if err != nil {
+		return fmt.Errorf("invalid XOR_KEY_HEX: %w", err)
+	}
+	if len(xorKey) == 0 {
+		return fmt.Errorf("invalid XOR_KEY_HEX: key must be at least 1 byte")
+	}
+
+	return nil
+}
+
 // DefaultConfig returns a Config with default values
 func DefaultConfig() *Config {
 	return &Config{
@@ -53,6 +95,7 @@ func DefaultConfig() *Config {
 		UserAgent:        "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/91.0.4472.124 Safari/537.36",
 		DiscoveryWorkers: 100,
 		DiscoveryTimeout: 1 * time.Second,
+		DiscoveryRetries: 2,
 		SADPTimeout:      5 * time.Second,
 		OutputDir:        "data",
 		Debug:            false,
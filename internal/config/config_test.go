@@ -13,6 +13,7 @@ func TestLoad(t *testing.T) {
 		wantHTTPTimeout    time.Duration
 		wantWorkers        int
 		wantDiscTimeout    time.Duration
+		wantRetries        int
 		wantSADPTimeout    time.Duration
 		wantOutputDir      string
 		wantDebug          bool
@@ -25,6 +26,7 @@ func TestLoad(t *testing.T) {
 			wantHTTPTimeout:    10 * time.Second,
 			wantWorkers:        100,
 			wantDiscTimeout:    1 * time.Second,
+			wantRetries:        2,
 			wantSADPTimeout:    5 * time.Second,
 			wantOutputDir:      "data",
 			wantDebug:          false,
@@ -57,6 +59,10 @@ func TestLoad(t *testing.T) {
 				t.Errorf("DiscoveryTimeout = %v, want %v", cfg.DiscoveryTimeout, tt.wantDiscTimeout)
 			}
 
+			if cfg.DiscoveryRetries != tt.wantRetries {
+				t.Errorf("DiscoveryRetries = %d, want %d", cfg.DiscoveryRetries, tt.wantRetries)
+			}
+
 			if cfg.SADPTimeout != tt.wantSADPTimeout {
 				t.Errorf("SADPTimeout = %v, want %v", cfg.SADPTimeout, tt.wantSADPTimeout)
 			}
@@ -93,6 +99,7 @@ func TestDefaultConfig(t *testing.T) {
 		wantHTTPTimeout    time.Duration
 		wantWorkers        int
 		wantDiscTimeout    time.Duration
+		wantRetries        int
 		wantSADPTimeout    time.Duration
 		wantOutputDir      string
 		wantDebug          bool
@@ -105,6 +112,7 @@ func TestDefaultConfig(t *testing.T) {
 			wantHTTPTimeout:    10 * time.Second,
 			wantWorkers:        100,
 			wantDiscTimeout:    1 * time.Second,
+			wantRetries:        2,
 			wantSADPTimeout:    5 * time.Second,
 			wantOutputDir:      "data",
 			wantDebug:          false,
@@ -134,6 +142,10 @@ func TestDefaultConfig(t *testing.T) {
 				t.Errorf("DiscoveryTimeout = %v, want %v", cfg.DiscoveryTimeout, tt.wantDiscTimeout)
 			}
 
+			if cfg.DiscoveryRetries != tt.wantRetries {
+				t.Errorf("DiscoveryRetries = %d, want %d", cfg.DiscoveryRetries, tt.wantRetries)
+			}
+
 			if cfg.SADPTimeout != tt.wantSADPTimeout {
 				t.Errorf("SADPTimeout = %v, want %v", cfg.SADPTimeout, tt.wantSADPTimeout)
 			}
@@ -239,3 +251,122 @@ func TestLoadWithOptions(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateConfig(t *testing.T) {
+	tests := []struct {
+		name      string
+		aesKeyHex string
+		xorKeyHex string
+		wantErr   bool
+	}{
+		{
+			name:      "default keys",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9a",
+			xorKeyHex: "738B5544",
+			wantErr:   false,
+		},
+		{
+			name:      "AES-256 key",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9a279977f62f6cfd2d91cd75b889ce0c9a",
+			xorKeyHex: "73",
+			wantErr:   false,
+		},
+		{
+			name:      "AES key too short",
+			aesKeyHex: "279977f62f6cfd2d",
+			xorKeyHex: "738B5544",
+			wantErr:   true,
+		},
+		{
+			name:      "AES key 20 bytes",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9a279977f6",
+			xorKeyHex: "738B5544",
+			wantErr:   true,
+		},
+		{
+			name:      "AES key odd-length hex",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9",
+			xorKeyHex: "738B5544",
+			wantErr:   true,
+		},
+		{
+			name:      "XOR key odd-length hex",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9a",
+			xorKeyHex: "738B554",
+			wantErr:   true,
+		},
+		{
+			name:      "XOR key empty",
+			aesKeyHex: "279977f62f6cfd2d91cd75b889ce0c9a",
+			xorKeyHex: "",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.AESKeyHex = tt.aesKeyHex
+			cfg.XORKeyHex = tt.xorKeyHex
+
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestValidateConfigRetries(t *testing.T) {
+	tests := []struct {
+		name    string
+		retries int
+		wantErr bool
+	}{
+		{name: "default", retries: 2, wantErr: false},
+		{name: "no retries", retries: 0, wantErr: false},
+		{name: "negative", retries: -1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := DefaultConfig()
+			cfg.DiscoveryRetries = tt.retries
+
+			err := ValidateConfig(cfg)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateConfig() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestLoadRejectsInvalidKeys(t *testing.T) {
+	tests := []struct {
+		name      string
+		envAESKey string
+		envXORKey string
+	}{
+		{
+			name:      "too-short AES key",
+			envAESKey: "279977",
+			envXORKey: "738B5544",
+		},
+		{
+			name:      "odd-length XOR key",
+			envAESKey: "279977f62f6cfd2d91cd75b889ce0c9a",
+			envXORKey: "738",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("AES_KEY_HEX", tt.envAESKey)
+			t.Setenv("XOR_KEY_HEX", tt.envXORKey)
+
+			if _, err := Load(); err == nil {
+				t.Error("Load() expected error for invalid key")
+			}
+		})
+	}
+}
@@ -5,9 +5,14 @@ import (
 	"crypto/aes"
 	"encoding/hex"
 	"fmt"
+	"strings"
+	"unicode"
 )
 
-// DecryptAES decrypts data using AES-ECB mode with the given hex key
+// DecryptAES decrypts data using AES-ECB mode with the given hex key. Data
+// that is not block aligned is zero padded first, and no padding is removed
+// from the result: callers get whole blocks back and must strip any
+// trailing NULs themselves, or use DecryptAESString.
 func DecryptAES(data []byte, keyHex string) ([]byte, error) {
 	key, err := hex.DecodeString(keyHex)
 	if err != nil {
@@ -37,6 +42,21 @@ func DecryptAES(data []byte, keyHex string) ([]byte, error) {
 	return decrypted, nil
 }
 
+// DecryptAESString decrypts data like DecryptAES and returns it as a string
+// with the trailing zero padding removed. It fails if the result does not
+// look like text, which usually means the key is wrong.
+func DecryptAESString(data []byte, keyHex string) (string, error) {
+	decrypted, err := DecryptAES(data, keyHex)
+	if err != nil {
+		return "", err
+	}
+	decrypted = bytes.TrimRight(decrypted, "\x00")
+	if !LooksLikePlaintext(decrypted) {
+		return "", fmt.Errorf("decrypted data does not look like text")
+	}
+	return string(decrypted), nil
+}
+
 // DecryptXOR decrypts data using XOR with the given hex key
 func DecryptXOR(data []byte, keyHex string) ([]byte, error) {
 	key, err := hex.DecodeString(keyHex)
@@ -52,47 +72,210 @@ func DecryptXOR(data []byte, keyHex string) ([]byte, error) {
 	return decoded, nil
 }
 
+// Decryption methods reported by DecryptAuto
+const (
+	MethodAES = "aes"
+	MethodXOR = "xor"
+)
+
+// minPrintableRatio is the share of printable characters above which a
+// decryption attempt is taken to have produced text
+const minPrintableRatio = 0.9
+
+// DecryptAuto decrypts data with whichever of AES-ECB or XOR yields
+// plausible plaintext, trying AES first. It returns the plaintext and the
+// method that produced it.
+func DecryptAuto(data []byte, aesKeyHex, xorKeyHex string) ([]byte, string, error) {
+	if len(data) == 0 {
+		return nil, "", fmt.Errorf("no data to decrypt")
+	}
+
+	attempts := []struct {
+		method  string
+		decrypt func() ([]byte, error)
+	}{
+		{MethodAES, func() ([]byte, error) { return DecryptAES(data, aesKeyHex) }},
+		{MethodXOR, func() ([]byte, error) { return DecryptXOR(data, xorKeyHex) }},
+	}
+
+	var errs []string
+	for _, a := range attempts {
+		plaintext, err := a.decrypt()
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", a.method, err))
+			continue
+		}
+		if LooksLikePlaintext(plaintext) {
+			return plaintext, a.method, nil
+		}
+		errs = append(errs, a.method+": output does not look like text")
+	}
+
+	return nil, "", fmt.Errorf("could not detect encryption method (%s)", strings.Join(errs, "; "))
+}
+
+// LooksLikePlaintext reports whether data appears to be XML or text: it
+// contains an XML declaration, or nearly all of it is printable. Trailing
+// NUL padding from block decryption is ignored.
+func LooksLikePlaintext(data []byte) bool {
+	data = bytes.TrimRight(data, "\x00")
+	if len(data) == 0 {
+		return false
+	}
+	if bytes.Contains(data, []byte("<?xml")) {
+		return true
+	}
+
+	printable := 0
+	total := 0
+	for _, r := range string(data) {
+		total++
+		if r == '\n' || r == '\r' || r == '\t' || (r != unicode.ReplacementChar && unicode.IsPrint(r)) {
+			printable++
+		}
+	}
+	return float64(printable)/float64(total) >= minPrintableRatio
+}
+
+// resetMultiplier is the constant the magic number is multiplied by
+const resetMultiplier uint64 = 1751873395
+
+// resetSubstitution maps secret digits to code characters:
+// "012345678" -> "QRSqrdeyz". 9 is left unchanged.
+var resetSubstitution = map[rune]rune{
+	'0': 'Q',
+	'1': 'R',
+	'2': 'S',
+	'3': 'q',
+	'4': 'r',
+	'5': 'd',
+	'6': 'e',
+	'7': 'y',
+	'8': 'z',
+}
+
+// ResetSteps records the intermediate values of GenerateResetCode
+type ResetSteps struct {
+	// Seed is serial + date
+	Seed string
+	// Magic is the sum over the seed of (position * char) ^ position
+	Magic uint64
+	// Secret is Magic * 1751873395 truncated to 32 bits
+	Secret uint32
+	// SecretString is Secret in decimal
+	SecretString string
+	// Substitutions is the digit-to-character mapping, one per digit
+	Substitutions []Substitution
+}
+
+// Substitution is one character of the final code and the digit it came from
+type Substitution struct {
+	Digit rune
+	Char  rune
+}
+
 // GenerateResetCode generates a Hikvision password reset code
 // Works on firmware versions < 5.3.0
 func GenerateResetCode(serial, date string) string {
-	seed := serial + date
+	code, _ := GenerateResetCodeWithSteps(serial, date)
+	return code
+}
+
+// GenerateResetCodeWithSteps generates a reset code and returns the value of
+// every stage, so the derivation can be shown and audited
+func GenerateResetCodeWithSteps(serial, date string) (string, ResetSteps) {
+	steps := ResetSteps{Seed: serial + date}
 
 	// Stage 1: Calculate magic number
-	var magic uint64 = 0
-	for i, char := range seed {
+	for i, char := range steps.Seed {
 		pos := uint64(i + 1)
 		charVal := uint64(char)
-		magic += (pos * charVal) ^ pos
+		steps.Magic += (pos * charVal) ^ pos
 	}
 
 	// Stage 2: Multiply by constant and convert to uint32
-	const multiplier uint64 = 1751873395
-	secret := uint32(magic * multiplier)
+	steps.Secret = uint32(steps.Magic * resetMultiplier)
 
 	// Stage 3: Convert to string and apply character substitution
-	secretStr := fmt.Sprintf("%d", secret)
-
-	// Substitution: "012345678" -> "QRSqrdeyz"
-	substitution := map[rune]rune{
-		'0': 'Q',
-		'1': 'R',
-		'2': 'S',
-		'3': 'q',
-		'4': 'r',
-		'5': 'd',
-		'6': 'e',
-		'7': 'y',
-		'8': 'z',
-	}
-
-	var result []rune
-	for _, c := range secretStr {
-		if sub, ok := substitution[c]; ok {
-			result = append(result, sub)
-		} else {
-			result = append(result, c)
+	steps.SecretString = fmt.Sprintf("%d", steps.Secret)
+
+	result := make([]rune, 0, len(steps.SecretString))
+	for _, c := range steps.SecretString {
+		sub, ok := resetSubstitution[c]
+		if !ok {
+			sub = c
+		}
+		steps.Substitutions = append(steps.Substitutions, Substitution{Digit: c, Char: sub})
+		result = append(result, sub)
+	}
+
+	return string(result), steps
+}
+
+// ResetCandidate is a reset code generated from one derivation of a serial
+type ResetCandidate struct {
+	Derivation string
+	Serial     string
+	Code       string
+}
+
+// GenerateResetCandidates generates reset codes for several plausible
+// truncations of a full serial number, for when the model prefix stripping
+// is uncertain. Duplicate serials are only reported once, and derivations
+// that start with anything but a letter or digit (such as "-7616NI-..."
+// left by stripping the letters of "DS-7616NI-...") are skipped, as no
+// serial does.
+func GenerateResetCandidates(fullSerial, model, date string) []ResetCandidate {
+	type derivation struct {
+		label  string
+		serial string
+	}
+
+	derivations := []derivation{
+		{"full serial", fullSerial},
+	}
+	if model != "" && strings.HasPrefix(fullSerial, model) {
+		derivations = append(derivations, derivation{"serial minus model", strings.TrimPrefix(fullSerial, model)})
+	}
+	derivations = append(derivations, derivation{"serial minus leading letters",
+		strings.TrimLeftFunc(fullSerial, unicode.IsLetter)})
+
+	digits := trailingDigits(fullSerial)
+	for _, n := range []int{10, 9} {
+		if len(digits) >= n {
+			derivations = append(derivations, derivation{fmt.Sprintf("last %d digits", n), digits[len(digits)-n:]})
+		}
+	}
+
+	seen := make(map[string]bool)
+	var candidates []ResetCandidate
+	for _, d := range derivations {
+		if d.serial == "" || seen[d.serial] || !startsAlphanumeric(d.serial) {
+			continue
 		}
+		seen[d.serial] = true
+		candidates = append(candidates, ResetCandidate{
+			Derivation: d.label,
+			Serial:     d.serial,
+			Code:       GenerateResetCode(d.serial, date),
+		})
 	}
 
-	return string(result)
+	return candidates
+}
+
+// startsAlphanumeric reports whether s begins with a letter or digit
+func startsAlphanumeric(s string) bool {
+	for _, r := range s {
+		return unicode.IsLetter(r) || unicode.IsDigit(r)
+	}
+	return false
+}
+
+func trailingDigits(s string) string {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	return s[i:]
 }
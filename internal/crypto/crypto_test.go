@@ -2,6 +2,9 @@ package crypto
 
 import (
 	"bytes"
+	"crypto/aes"
+	"encoding/hex"
+	"strings"
 	"testing"
 )
 
@@ -81,6 +84,63 @@ func TestDecryptAESRoundTrip(t *testing.T) {
 	}
 }
 
+func TestDecryptAESString(t *testing.T) {
+	// "<Code>AB12cd34</Code>" zero padded to two blocks and encrypted with
+	// the default SADP key
+	knownCiphertext, _ := hex.DecodeString("f75c6a62737ecf35e8cc4ce3b5945b2968544a376f095fe372c7346a134e887c")
+
+	tests := []struct {
+		name    string
+		keyHex  string
+		data    []byte
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "known ciphertext has padding stripped",
+			keyHex: "279977f62f6cfd2d91cd75b889ce0c9a",
+			data:   knownCiphertext,
+			want:   "<Code>AB12cd34</Code>",
+		},
+		{
+			name:    "wrong key gives binary output",
+			keyHex:  "00000000000000000000000000000000",
+			data:    knownCiphertext,
+			wantErr: true,
+		},
+		{
+			name:    "invalid key hex",
+			keyHex:  "invalid",
+			data:    knownCiphertext,
+			wantErr: true,
+		},
+		{
+			name:    "empty data",
+			keyHex:  "279977f62f6cfd2d91cd75b889ce0c9a",
+			data:    []byte{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptAESString(tt.data, tt.keyHex)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptAESString() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got != tt.want {
+				t.Errorf("DecryptAESString() = %q, want %q", got, tt.want)
+			}
+			if strings.HasSuffix(got, "\x00") {
+				t.Errorf("DecryptAESString() = %q, has trailing NULs", got)
+			}
+		})
+	}
+}
+
 func TestDecryptXOR(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -150,6 +210,112 @@ func TestDecryptXORRoundTrip(t *testing.T) {
 	}
 }
 
+// encryptAESForTest is the inverse of DecryptAES for block-aligned data
+func encryptAESForTest(t *testing.T, plaintext []byte, keyHex string) []byte {
+	t.Helper()
+	key, _ := hex.DecodeString(keyHex)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make([]byte, len(plaintext))
+	for i := 0; i < len(plaintext); i += block.BlockSize() {
+		block.Encrypt(out[i:i+block.BlockSize()], plaintext[i:i+block.BlockSize()])
+	}
+	return out
+}
+
+func TestDecryptAuto(t *testing.T) {
+	const (
+		aesKey = "279977f62f6cfd2d91cd75b889ce0c9a"
+		xorKey = "738B5544"
+	)
+	xmlDoc := []byte(`<?xml version="1.0"?><Config><Name>cam</Name></Config>`)
+	text := []byte("admin password 12345 configuration blob text.\n")
+	// 48 bytes so the AES test data is block aligned
+	aesText := append([]byte("user=admin; pass=12345; host=192.168.1.64; x=1"), '\n', '\n')
+
+	xorData, _ := DecryptXOR(xmlDoc, xorKey)
+	xorText, _ := DecryptXOR(text, xorKey)
+
+	tests := []struct {
+		name       string
+		data       []byte
+		want       []byte
+		wantMethod string
+		wantErr    bool
+	}{
+		{
+			name:       "aes encrypted text",
+			data:       encryptAESForTest(t, aesText, aesKey),
+			want:       aesText,
+			wantMethod: MethodAES,
+		},
+		{
+			name:       "xor encrypted xml",
+			data:       xorData,
+			want:       xmlDoc,
+			wantMethod: MethodXOR,
+		},
+		{
+			name:       "xor encrypted text",
+			data:       xorText,
+			want:       text,
+			wantMethod: MethodXOR,
+		},
+		{
+			name:    "random bytes",
+			data:    bytes.Repeat([]byte{0x00, 0x9f, 0x81, 0xfe}, 16),
+			wantErr: true,
+		},
+		{
+			name:    "empty data",
+			data:    nil,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, method, err := DecryptAuto(tt.data, aesKey, xorKey)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecryptAuto() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if method != tt.wantMethod {
+				t.Errorf("method = %q, want %q", method, tt.wantMethod)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("plaintext = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLooksLikePlaintext(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want bool
+	}{
+		{name: "xml declaration", data: []byte("\x01\x02<?xml version=\"1.0\"?>"), want: true},
+		{name: "plain text with NUL padding", data: append([]byte("hello world\n"), 0, 0, 0, 0), want: true},
+		{name: "utf-8 text", data: []byte("caméra d'entrée"), want: true},
+		{name: "binary", data: []byte{0x01, 0x02, 0x03, 0xff, 0xfe, 'a'}, want: false},
+		{name: "only padding", data: []byte{0, 0, 0}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LooksLikePlaintext(tt.data); got != tt.want {
+				t.Errorf("LooksLikePlaintext(%q) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestGenerateResetCode(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -247,3 +413,131 @@ func TestGenerateResetCodeSubstitution(t *testing.T) {
 		})
 	}
 }
+
+func TestGenerateResetCandidates(t *testing.T) {
+	tests := []struct {
+		name        string
+		fullSerial  string
+		model       string
+		date        string
+		wantSerials []string
+	}{
+		{
+			name:        "model prefixed serial",
+			fullSerial:  "DS-7616NI-I20123456789",
+			model:       "DS-7616NI-I2",
+			date:        "20231215",
+			wantSerials: []string{"DS-7616NI-I20123456789", "0123456789", "123456789"},
+		},
+		{
+			name:        "model prefixed serial without model",
+			fullSerial:  "DS-7616NI-I20123456789",
+			model:       "",
+			date:        "20231215",
+			wantSerials: []string{"DS-7616NI-I20123456789", "0123456789", "123456789"},
+		},
+		{
+			name:        "alpha prefixed serial without model",
+			fullSerial:  "ABCD0123456789",
+			model:       "",
+			date:        "20231215",
+			wantSerials: []string{"ABCD0123456789", "0123456789", "123456789"},
+		},
+		{
+			name:        "short numeric serial",
+			fullSerial:  "12345",
+			model:       "",
+			date:        "20231215",
+			wantSerials: []string{"12345"},
+		},
+		{
+			name:        "empty serial",
+			fullSerial:  "",
+			model:       "",
+			date:        "20231215",
+			wantSerials: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			candidates := GenerateResetCandidates(tt.fullSerial, tt.model, tt.date)
+			if len(candidates) != len(tt.wantSerials) {
+				t.Fatalf("got %d candidates %+v, want %d", len(candidates), candidates, len(tt.wantSerials))
+			}
+			for i, c := range candidates {
+				if c.Serial != tt.wantSerials[i] {
+					t.Errorf("candidate[%d].Serial = %q, want %q", i, c.Serial, tt.wantSerials[i])
+				}
+				if c.Code != GenerateResetCode(c.Serial, tt.date) {
+					t.Errorf("candidate[%d].Code does not match GenerateResetCode", i)
+				}
+				if c.Derivation == "" {
+					t.Errorf("candidate[%d] has empty Derivation", i)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateResetCodeWithSteps(t *testing.T) {
+	tests := []struct {
+		name       string
+		serial     string
+		date       string
+		wantMagic  uint64
+		wantSecret uint32
+		wantCode   string
+	}{
+		{
+			name:       "standard serial and date",
+			serial:     "0123456789",
+			date:       "20231215",
+			wantMagic:  8753,
+			wantSecret: 1114579715,
+			wantCode:   "RRRrdy9yRd",
+		},
+		{
+			name:       "empty input",
+			serial:     "",
+			date:       "",
+			wantMagic:  0,
+			wantSecret: 0,
+			wantCode:   "Q",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, steps := GenerateResetCodeWithSteps(tt.serial, tt.date)
+			if code != tt.wantCode {
+				t.Errorf("code = %q, want %q", code, tt.wantCode)
+			}
+			if code != GenerateResetCode(tt.serial, tt.date) {
+				t.Errorf("code differs from GenerateResetCode")
+			}
+			if steps.Seed != tt.serial+tt.date {
+				t.Errorf("Seed = %q, want %q", steps.Seed, tt.serial+tt.date)
+			}
+			if steps.Magic != tt.wantMagic {
+				t.Errorf("Magic = %d, want %d", steps.Magic, tt.wantMagic)
+			}
+			if steps.Secret != tt.wantSecret {
+				t.Errorf("Secret = %d, want %d", steps.Secret, tt.wantSecret)
+			}
+			if len(steps.Substitutions) != len(steps.SecretString) {
+				t.Fatalf("got %d substitutions for %q", len(steps.Substitutions), steps.SecretString)
+			}
+			var rebuilt []rune
+			for i, sub := range steps.Substitutions {
+				if sub.Digit != rune(steps.SecretString[i]) {
+					t.Errorf("substitution[%d].Digit = %c, want %c", i, sub.Digit, steps.SecretString[i])
+				}
+				rebuilt = append(rebuilt, sub.Char)
+			}
+			if string(rebuilt) != code {
+				t.Errorf("substitutions spell %q, want %q", string(rebuilt), code)
+			}
+		})
+	}
+}
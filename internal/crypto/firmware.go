@@ -0,0 +1,99 @@
+package crypto
+
+import (
+	"strconv"
+	"strings"
+)
+
+// LegacyResetMaxFirmware is the first firmware version that no longer
+// accepts codes from GenerateResetCode
+const LegacyResetMaxFirmware = "5.3.0"
+
+// ResetAlgorithm identifies which password reset scheme a firmware uses
+type ResetAlgorithm string
+
+const (
+	// ResetAlgorithmLegacy is the serial+date code from GenerateResetCode
+	ResetAlgorithmLegacy ResetAlgorithm = "legacy"
+	// ResetAlgorithmV2 is the exchange-code flow used from 5.3.0 onwards
+	ResetAlgorithmV2 ResetAlgorithm = "v2"
+)
+
+// SelectResetAlgorithm picks the reset scheme for a firmware version string
+func SelectResetAlgorithm(firmware string) ResetAlgorithm {
+	if CompareFirmwareVersion(firmware, LegacyResetMaxFirmware) >= 0 {
+		return ResetAlgorithmV2
+	}
+	return ResetAlgorithmLegacy
+}
+
+// CompareFirmwareVersion compares Hikvision firmware versions such as
+// "V5.5.0 build 191126", returning -1, 0, or 1. Version components are
+// compared numerically (so V5.5.800 > V5.5.80 > V5.5.0) with missing
+// components treated as 0; the build date breaks ties, and a missing build
+// sorts before any build.
+func CompareFirmwareVersion(a, b string) int {
+	aParts, aBuild := parseFirmwareVersion(a)
+	bParts, bBuild := parseFirmwareVersion(b)
+
+	n := len(aParts)
+	if len(bParts) > n {
+		n = len(bParts)
+	}
+	for i := 0; i < n; i++ {
+		var x, y int
+		if i < len(aParts) {
+			x = aParts[i]
+		}
+		if i < len(bParts) {
+			y = bParts[i]
+		}
+		if c := compareInt(x, y); c != 0 {
+			return c
+		}
+	}
+
+	return compareInt(aBuild, bBuild)
+}
+
+// parseFirmwareVersion splits a version into numeric components and a build
+// number. Non-numeric characters inside a component are ignored after the
+// leading digits, so "0_beta" parses as 0.
+func parseFirmwareVersion(v string) (parts []int, build int) {
+	v = strings.TrimSpace(v)
+	lower := strings.ToLower(v)
+	if idx := strings.Index(lower, "build"); idx != -1 {
+		build = leadingInt(strings.TrimSpace(v[idx+len("build"):]))
+		v = v[:idx]
+	}
+
+	v = strings.TrimSpace(v)
+	v = strings.TrimLeft(v, "vV")
+	if v == "" {
+		return nil, build
+	}
+
+	for _, p := range strings.Split(v, ".") {
+		parts = append(parts, leadingInt(strings.TrimSpace(p)))
+	}
+	return parts, build
+}
+
+func leadingInt(s string) int {
+	end := 0
+	for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+		end++
+	}
+	n, _ := strconv.Atoi(s[:end])
+	return n
+}
+
+func compareInt(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,55 @@
+package crypto
+
+import "testing"
+
+func TestCompareFirmwareVersion(t *testing.T) {
+	tests := []struct {
+		name string
+		a    string
+		b    string
+		want int
+	}{
+		{name: "equal with build", a: "V5.5.0 build 191126", b: "V5.5.0 build 191126", want: 0},
+		{name: "prefix optional", a: "V5.4.5", b: "5.4.5", want: 0},
+		{name: "missing patch is zero", a: "V5.3", b: "5.3.0", want: 0},
+		{name: "numeric not lexical", a: "V5.5.800", b: "V5.5.80", want: 1},
+		{name: "double digit minor", a: "V5.10.0", b: "V5.9.0", want: 1},
+		{name: "older major", a: "V4.1.25", b: "V5.3.0", want: -1},
+		{name: "build breaks tie", a: "V5.5.0 build 191126", b: "V5.5.0 build 180101", want: 1},
+		{name: "missing build sorts first", a: "V5.5.0", b: "V5.5.0 build 170725", want: -1},
+		{name: "build without space", a: "V5.5.0build 170725", b: "V5.5.0 build 170725", want: 0},
+		{name: "lowercase v", a: "v5.4.5", b: "V5.4.4", want: 1},
+		{name: "empty sorts first", a: "", b: "V1.0.0", want: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := CompareFirmwareVersion(tt.a, tt.b); got != tt.want {
+				t.Errorf("CompareFirmwareVersion(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+			}
+			if got := CompareFirmwareVersion(tt.b, tt.a); got != -tt.want {
+				t.Errorf("CompareFirmwareVersion(%q, %q) = %d, want %d", tt.b, tt.a, got, -tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectResetAlgorithm(t *testing.T) {
+	tests := []struct {
+		firmware string
+		want     ResetAlgorithm
+	}{
+		{firmware: "V5.2.5 build 141201", want: ResetAlgorithmLegacy},
+		{firmware: "V5.3.0 build 150513", want: ResetAlgorithmV2},
+		{firmware: "V5.5.800", want: ResetAlgorithmV2},
+		{firmware: "V4.0.1", want: ResetAlgorithmLegacy},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.firmware, func(t *testing.T) {
+			if got := SelectResetAlgorithm(tt.firmware); got != tt.want {
+				t.Errorf("SelectResetAlgorithm(%q) = %q, want %q", tt.firmware, got, tt.want)
+			}
+		})
+	}
+}
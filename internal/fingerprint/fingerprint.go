@@ -0,0 +1,258 @@
+package fingerprint
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+// Source names recorded in DeviceFingerprint.Sources and Errors
+const (
+	SourceSADP         = "sadp"
+	SourceHTTP         = "http"
+	SourceDeviceInfo   = "isapi-deviceinfo"
+	SourceCapabilities = "isapi-capabilities"
+)
+
+// DeviceFingerprint is a merged profile of a device built from SADP, the
+// HTTP root page, and ISAPI
+type DeviceFingerprint struct {
+	IP           string            `json:"ip"`
+	Identity     Identity          `json:"identity"`
+	Firmware     Firmware          `json:"firmware"`
+	Network      Network           `json:"network"`
+	Status       Status            `json:"status"`
+	Capabilities *Capabilities     `json:"capabilities,omitempty"`
+	Sources      []string          `json:"sources"`
+	Errors       map[string]string `json:"errors,omitempty"`
+}
+
+// Identity describes what the device is
+type Identity struct {
+	Model        string `json:"model"`
+	DeviceName   string `json:"deviceName"`
+	DeviceType   string `json:"deviceType"`
+	SerialNumber string `json:"serialNumber"`
+	MAC          string `json:"mac"`
+}
+
+// Firmware describes the software running on the device
+type Firmware struct {
+	Version        string `json:"version"`
+	Build          string `json:"build"`
+	EncoderVersion string `json:"encoderVersion"`
+	DSPVersion     string `json:"dspVersion"`
+}
+
+// Network describes how the device is addressed
+type Network struct {
+	IPv4Address string `json:"ipv4Address"`
+	SubnetMask  string `json:"subnetMask"`
+	Gateway     string `json:"gateway"`
+	DHCP        string `json:"dhcp"`
+	HTTPPort    int    `json:"httpPort"`
+	CommandPort int    `json:"commandPort"`
+	HTTPServer  string `json:"httpServer"`
+	HTTPRealm   string `json:"httpRealm"`
+	HTTPStatus  int    `json:"httpStatus"`
+}
+
+// Status describes activation and password reset support
+type Status struct {
+	Activated         string `json:"activated"`
+	PasswordResetMode string `json:"passwordResetMode"`
+	SupportReset      string `json:"supportReset"`
+}
+
+// Capabilities is a summary of the ISAPI capabilities document
+type Capabilities struct {
+	TwoWayAudio bool     `json:"twoWayAudio"`
+	ONVIF       bool     `json:"onvif"`
+	EZVIZ       bool     `json:"ezviz"`
+	SmartEvents []string `json:"smartEvents"`
+}
+
+// HTTPProbe is what an unauthenticated request to the web root reveals
+type HTTPProbe struct {
+	StatusCode int
+	Server     string
+	Realm      string
+}
+
+// Sources holds the raw results gathered from each source; nil entries
+// were not collected or failed
+type Sources struct {
+	SADP         *sadp.Device
+	HTTP         *HTTPProbe
+	DeviceInfo   *isapi.DeviceInfo
+	Capabilities *isapi.Capabilities
+}
+
+// Merge combines the sources into one fingerprint. Authenticated ISAPI data
+// is preferred for identity and firmware since it is what the device reports
+// about itself; SADP is preferred for network and activation state, which
+// ISAPI deviceInfo does not carry.
+func Merge(ip string, src Sources) *DeviceFingerprint {
+	fp := &DeviceFingerprint{IP: ip}
+
+	var dev sadp.Device
+	if src.SADP != nil {
+		dev = *src.SADP
+		fp.Sources = append(fp.Sources, SourceSADP)
+	}
+	var info isapi.DeviceInfo
+	if src.DeviceInfo != nil {
+		info = *src.DeviceInfo
+		fp.Sources = append(fp.Sources, SourceDeviceInfo)
+	}
+	var web HTTPProbe
+	if src.HTTP != nil {
+		web = *src.HTTP
+		fp.Sources = append(fp.Sources, SourceHTTP)
+	}
+
+	fp.Identity = Identity{
+		Model:        first(info.Model, dev.DeviceType),
+		DeviceName:   first(info.DeviceName, dev.DeviceDescription),
+		DeviceType:   first(info.DeviceType, dev.DeviceDescription),
+		SerialNumber: first(info.SerialNumber, dev.DeviceSN),
+		MAC:          first(network.NormalizeMAC(info.MACAddress), network.NormalizeMAC(dev.MAC)),
+	}
+
+	version, build := splitSoftwareVersion(dev.SoftwareVersion)
+	fp.Firmware = Firmware{
+		Version:        first(info.FirmwareVersion, version),
+		Build:          first(info.FirmwareReleasedDate, build),
+		EncoderVersion: info.EncoderVersion,
+		DSPVersion:     dev.DSPVersion,
+	}
+
+	fp.Network = Network{
+		IPv4Address: first(dev.IPv4Address, ip),
+		SubnetMask:  dev.IPv4SubnetMask,
+		Gateway:     dev.IPv4Gateway,
+		DHCP:        dev.DHCP,
+		HTTPPort:    int(dev.HttpPort),
+		CommandPort: int(dev.CommandPort),
+		HTTPServer:  web.Server,
+		HTTPRealm:   web.Realm,
+		HTTPStatus:  web.StatusCode,
+	}
+
+	fp.Status = Status{
+		Activated:         dev.Activated,
+		PasswordResetMode: dev.PasswordResetMode,
+		SupportReset:      dev.SupportReset,
+	}
+
+	if src.Capabilities != nil {
+		fp.Sources = append(fp.Sources, SourceCapabilities)
+		fp.Capabilities = &Capabilities{
+			TwoWayAudio: src.Capabilities.SupportsTwoWayAudio(),
+			ONVIF:       src.Capabilities.IsSupportONVIF,
+			EZVIZ:       src.Capabilities.IsSupportEZVIZ,
+			SmartEvents: src.Capabilities.SmartEvents(),
+		}
+	}
+
+	sort.Strings(fp.Sources)
+	return fp
+}
+
+// Collector gathers fingerprint sources from a device
+type Collector struct {
+	Scanner *sadp.Scanner
+	HTTP    *network.HTTPClient
+	// ISAPI is optional; without credentials the ISAPI sources are skipped
+	ISAPI *isapi.Client
+}
+
+// Collect queries every configured source and merges the results. Failures
+// are recorded per source in Errors rather than aborting the fingerprint.
+func (c *Collector) Collect(ip string) *DeviceFingerprint {
+	var src Sources
+	errs := make(map[string]string)
+
+	if c.Scanner != nil {
+		dev, err := c.Scanner.InquireDevice(ip)
+		if err != nil {
+			errs[SourceSADP] = err.Error()
+		} else {
+			src.SADP = dev
+		}
+	}
+
+	if c.HTTP != nil {
+		resp, err := c.HTTP.Get(ip, "/")
+		if err != nil {
+			errs[SourceHTTP] = err.Error()
+		} else {
+			src.HTTP = &HTTPProbe{
+				StatusCode: resp.StatusCode,
+				Server:     resp.Headers["server"],
+				Realm:      realm(resp.Headers["www-authenticate"]),
+			}
+		}
+	}
+
+	if c.ISAPI != nil && c.ISAPI.Password != "" {
+		info, err := c.ISAPI.GetDeviceInfo(ip)
+		if err != nil {
+			errs[SourceDeviceInfo] = err.Error()
+		} else {
+			src.DeviceInfo = info
+		}
+
+		caps, err := c.ISAPI.GetCapabilities(ip)
+		if err != nil {
+			errs[SourceCapabilities] = err.Error()
+		} else {
+			src.Capabilities = caps
+		}
+	}
+
+	fp := Merge(ip, src)
+	if len(errs) > 0 {
+		fp.Errors = errs
+	}
+	return fp
+}
+
+// first returns the first non-empty value
+func first(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// splitSoftwareVersion splits SADP's "V5.5.80build 190603" style version
+// into its version and build parts
+func splitSoftwareVersion(v string) (version, build string) {
+	v = strings.TrimSpace(v)
+	idx := strings.Index(strings.ToLower(v), "build")
+	if idx == -1 {
+		return v, ""
+	}
+	return strings.TrimSpace(v[:idx]), strings.TrimSpace(v[idx:])
+}
+
+// realm extracts the realm from a WWW-Authenticate header
+func realm(header string) string {
+	const key = `realm="`
+	start := strings.Index(header, key)
+	if start == -1 {
+		return ""
+	}
+	rest := header[start+len(key):]
+	end := strings.Index(rest, `"`)
+	if end == -1 {
+		return ""
+	}
+	return rest[:end]
+}
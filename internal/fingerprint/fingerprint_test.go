@@ -0,0 +1,172 @@
+package fingerprint
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/isapi"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+	"github.com/cameronnewman/hikvision-tooling/internal/sadp"
+)
+
+func TestMerge(t *testing.T) {
+	sadpDevice := &sadp.Device{
+		DeviceType:        "DS-2CD2143G0-I",
+		DeviceDescription: "IPCamera",
+		DeviceSN:          "SADP-SERIAL",
+		MAC:               "4c-bd-8f-61-cc-5c",
+		IPv4Address:       "192.168.1.64",
+		IPv4SubnetMask:    "255.255.255.0",
+		IPv4Gateway:       "192.168.1.1",
+		DHCP:              "false",
+		HttpPort:          80,
+		CommandPort:       8000,
+		SoftwareVersion:   "V5.5.0build 170725",
+		DSPVersion:        "V7.3",
+		Activated:         "true",
+		PasswordResetMode: "2",
+	}
+	deviceInfo := &isapi.DeviceInfo{
+		DeviceName:           "Front Door",
+		Model:                "DS-2CD2143G0-IS",
+		SerialNumber:         "ISAPI-SERIAL",
+		MACAddress:           "4c:bd:8f:61:cc:5c",
+		FirmwareVersion:      "V5.5.80",
+		FirmwareReleasedDate: "build 190603",
+	}
+
+	tests := []struct {
+		name         string
+		src          Sources
+		wantIdentity Identity
+		wantFirmware Firmware
+		wantIP       string
+		wantSources  []string
+	}{
+		{
+			name: "isapi wins identity and firmware",
+			src:  Sources{SADP: sadpDevice, DeviceInfo: deviceInfo, HTTP: &HTTPProbe{StatusCode: 200, Server: "App-webs/"}},
+			wantIdentity: Identity{
+				Model:        "DS-2CD2143G0-IS",
+				DeviceName:   "Front Door",
+				DeviceType:   "IPCamera",
+				SerialNumber: "ISAPI-SERIAL",
+				MAC:          "4C:BD:8F:61:CC:5C",
+			},
+			wantFirmware: Firmware{Version: "V5.5.80", Build: "build 190603", DSPVersion: "V7.3"},
+			wantIP:       "192.168.1.64",
+			wantSources:  []string{SourceHTTP, SourceDeviceInfo, SourceSADP},
+		},
+		{
+			name: "sadp only",
+			src:  Sources{SADP: sadpDevice},
+			wantIdentity: Identity{
+				Model:        "DS-2CD2143G0-I",
+				DeviceName:   "IPCamera",
+				DeviceType:   "IPCamera",
+				SerialNumber: "SADP-SERIAL",
+				MAC:          "4C:BD:8F:61:CC:5C",
+			},
+			wantFirmware: Firmware{Version: "V5.5.0", Build: "build 170725", DSPVersion: "V7.3"},
+			wantIP:       "192.168.1.64",
+			wantSources:  []string{SourceSADP},
+		},
+		{
+			name:        "no sources falls back to queried IP",
+			src:         Sources{},
+			wantIP:      "10.0.0.9",
+			wantSources: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fp := Merge("10.0.0.9", tt.src)
+			if fp.Identity != tt.wantIdentity {
+				t.Errorf("Identity = %+v, want %+v", fp.Identity, tt.wantIdentity)
+			}
+			if fp.Firmware != tt.wantFirmware {
+				t.Errorf("Firmware = %+v, want %+v", fp.Firmware, tt.wantFirmware)
+			}
+			if fp.Network.IPv4Address != tt.wantIP {
+				t.Errorf("Network.IPv4Address = %q, want %q", fp.Network.IPv4Address, tt.wantIP)
+			}
+			if !reflect.DeepEqual(fp.Sources, tt.wantSources) {
+				t.Errorf("Sources = %v, want %v", fp.Sources, tt.wantSources)
+			}
+		})
+	}
+}
+
+func TestSplitSoftwareVersion(t *testing.T) {
+	tests := []struct {
+		input       string
+		wantVersion string
+		wantBuild   string
+	}{
+		{input: "V5.5.0build 170725", wantVersion: "V5.5.0", wantBuild: "build 170725"},
+		{input: "V4.1.25 build 200305", wantVersion: "V4.1.25", wantBuild: "build 200305"},
+		{input: "V5.4.5", wantVersion: "V5.4.5", wantBuild: ""},
+		{input: "", wantVersion: "", wantBuild: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			version, build := splitSoftwareVersion(tt.input)
+			if version != tt.wantVersion || build != tt.wantBuild {
+				t.Errorf("splitSoftwareVersion(%q) = %q, %q, want %q, %q", tt.input, version, build, tt.wantVersion, tt.wantBuild)
+			}
+		})
+	}
+}
+
+func TestCollectorCollect(t *testing.T) {
+	tests := []struct {
+		name        string
+		password    string
+		wantSources []string
+		wantCaps    bool
+	}{
+		{name: "without credentials", password: "", wantSources: []string{SourceHTTP}},
+		{name: "with credentials", password: "secret", wantSources: []string{SourceHTTP, SourceCapabilities, SourceDeviceInfo}, wantCaps: true},
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case isapi.DeviceInfoPath:
+			_, _ = w.Write([]byte(`<DeviceInfo><model>DS-2CD2143G0-I</model></DeviceInfo>`))
+		case isapi.CapabilitiesPath:
+			_, _ = w.Write([]byte(`<DeviceCap><isSupportONVIF>true</isSupportONVIF></DeviceCap>`))
+		default:
+			w.Header().Set("Server", "App-webs/")
+			w.WriteHeader(http.StatusOK)
+		}
+	}))
+	defer server.Close()
+	addr := strings.TrimPrefix(server.URL, "http://")
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			httpClient := network.NewHTTPClient("TestAgent", 5*time.Second)
+			c := &Collector{
+				HTTP:  httpClient,
+				ISAPI: isapi.NewClient(httpClient, "admin", tt.password),
+			}
+
+			fp := c.Collect(addr)
+			if !reflect.DeepEqual(fp.Sources, tt.wantSources) {
+				t.Errorf("Sources = %v, want %v (errors %v)", fp.Sources, tt.wantSources, fp.Errors)
+			}
+			if fp.Network.HTTPServer != "App-webs/" {
+				t.Errorf("HTTPServer = %q", fp.Network.HTTPServer)
+			}
+			if (fp.Capabilities != nil) != tt.wantCaps {
+				t.Errorf("Capabilities = %+v, wantCaps %v", fp.Capabilities, tt.wantCaps)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+package isapi
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// CapabilitiesPath is the ISAPI endpoint describing device capabilities
+const CapabilitiesPath = "/ISAPI/System/capabilities"
+
+// Client queries ISAPI endpoints using Digest authentication
+type Client struct {
+	HTTP     *network.HTTPClient
+	Username string
+	Password string
+}
+
+// NewClient creates a new ISAPI client
+func NewClient(httpClient *network.HTTPClient, username, password string) *Client {
+	return &Client{
+		HTTP:     httpClient,
+		Username: username,
+		Password: password,
+	}
+}
+
+// Capabilities is the DeviceCap document returned by /ISAPI/System/capabilities
+type Capabilities struct {
+	XMLName           xml.Name `xml:"DeviceCap"`
+	SysCap            SysCap   `xml:"SysCap"`
+	EventCap          EventCap `xml:"EventCap"`
+	SmartCap          SmartCap `xml:"SmartCap"`
+	IsSupportONVIF    bool     `xml:"isSupportONVIF"`
+	IsSupportEZVIZ    bool     `xml:"isSupportEZVIZ"`
+	IsSupportSnapshot bool     `xml:"isSupportSnapshot"`
+}
+
+// SysCap describes system-level I/O and audio capabilities
+type SysCap struct {
+	IsSupportDst   bool     `xml:"isSupportDst"`
+	IOCap          IOCap    `xml:"IOCap"`
+	AudioCap       AudioCap `xml:"AudioCap"`
+	VideoInputNums int      `xml:"VideoCap>videoInputPortNums"`
+}
+
+// IOCap describes alarm input and output ports
+type IOCap struct {
+	IOInputPortNums  int `xml:"IOInputPortNums"`
+	IOOutputPortNums int `xml:"IOOutputPortNums"`
+}
+
+// AudioCap describes audio input and output channels
+type AudioCap struct {
+	AudioInputNums  int `xml:"audioInputNums"`
+	AudioOutputNums int `xml:"audioOutputNums"`
+}
+
+// EventCap describes basic event detection support
+type EventCap struct {
+	IsSupportMotionDetection  bool `xml:"isSupportMotionDetection"`
+	IsSupportTamperDetection  bool `xml:"isSupportTamperDetection"`
+	IsSupportVideoLoss        bool `xml:"isSupportVideoLoss"`
+	IsSupportIOInputAlarm     bool `xml:"isSupportIOInputAlarm"`
+	IsSupportExceptionAlarm   bool `xml:"isSupportExceptionAlarm"`
+	IsSupportAudioDetection   bool `xml:"isSupportAudioDetection"`
+	IsSupportFaceDetection    bool `xml:"isSupportFaceDetection"`
+	IsSupportSceneChangeAlarm bool `xml:"isSupportSceneChangeDetection"`
+}
+
+// SmartCap describes smart (VCA) event support
+type SmartCap struct {
+	IsSupportIntrusionDetection bool `xml:"isSupportIntrusionDetection"`
+	IsSupportLineDetection      bool `xml:"isSupportLineDetection"`
+	IsSupportRegionEntrance     bool `xml:"isSupportRegionEntrance"`
+	IsSupportRegionExiting      bool `xml:"isSupportRegionExiting"`
+	IsSupportFaceDetect         bool `xml:"isSupportFaceDetect"`
+	IsSupportUnattendedBaggage  bool `xml:"isSupportUnattendedBaggage"`
+	IsSupportAttendedBaggage    bool `xml:"isSupportAttendedBaggage"`
+}
+
+// SupportsTwoWayAudio reports whether the device has both audio in and out
+func (c *Capabilities) SupportsTwoWayAudio() bool {
+	return c.SysCap.AudioCap.AudioInputNums > 0 && c.SysCap.AudioCap.AudioOutputNums > 0
+}
+
+// SmartEvents returns the names of the smart events the device supports
+func (c *Capabilities) SmartEvents() []string {
+	events := []struct {
+		name      string
+		supported bool
+	}{
+		{"intrusion", c.SmartCap.IsSupportIntrusionDetection},
+		{"line-crossing", c.SmartCap.IsSupportLineDetection},
+		{"region-entrance", c.SmartCap.IsSupportRegionEntrance},
+		{"region-exiting", c.SmartCap.IsSupportRegionExiting},
+		{"face", c.SmartCap.IsSupportFaceDetect || c.EventCap.IsSupportFaceDetection},
+		{"unattended-baggage", c.SmartCap.IsSupportUnattendedBaggage},
+		{"object-removal", c.SmartCap.IsSupportAttendedBaggage},
+		{"scene-change", c.EventCap.IsSupportSceneChangeAlarm},
+		{"audio-exception", c.EventCap.IsSupportAudioDetection},
+	}
+
+	var names []string
+	for _, e := range events {
+		if e.supported {
+			names = append(names, e.name)
+		}
+	}
+	return names
+}
+
+// ParseCapabilities unmarshals a DeviceCap XML document
+func ParseCapabilities(data []byte) (*Capabilities, error) {
+	var caps Capabilities
+	if err := xml.Unmarshal(data, &caps); err != nil {
+		return nil, fmt.Errorf("invalid capabilities XML: %w", err)
+	}
+	return &caps, nil
+}
+
+// GetCapabilities fetches and parses the capabilities of the device at ipAddress
+func (c *Client) GetCapabilities(ipAddress string) (*Capabilities, error) {
+	body, err := c.get(ipAddress, CapabilitiesPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseCapabilities(body)
+}
+
+// get performs an authenticated GET and returns the body of a 200 response
+func (c *Client) get(ipAddress, path string) ([]byte, error) {
+	resp, err := c.HTTP.GetWithDigest(ipAddress, path, c.Username, c.Password)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case 200:
+		return resp.Body, nil
+	case 401:
+		return nil, fmt.Errorf("authentication failed for %s", ipAddress)
+	default:
+		return nil, fmt.Errorf("unexpected HTTP %d from %s%s", resp.StatusCode, ipAddress, path)
+	}
+}
+
+// ResponseStatus is the status document ISAPI returns for write requests
+type ResponseStatus struct {
+	StatusCode    int    `xml:"statusCode"`
+	StatusString  string `xml:"statusString"`
+	SubStatusCode string `xml:"subStatusCode"`
+}
+
+// put performs an authenticated PUT of an XML body. ISAPI reports failures
+// in a ResponseStatus body, so its statusString is included in the error.
+func (c *Client) put(ipAddress, path string, body []byte) error {
+	resp, err := c.HTTP.DoWithDigest("PUT", ipAddress, path, "application/xml", body, c.Username, c.Password)
+	if err != nil {
+		return err
+	}
+
+	var status ResponseStatus
+	_ = xml.Unmarshal(resp.Body, &status)
+
+	switch {
+	case resp.StatusCode == 200 && (status.StatusCode == 0 || status.StatusCode == 1):
+		return nil
+	case resp.StatusCode == 401:
+		return fmt.Errorf("authentication failed for %s", ipAddress)
+	case status.StatusString != "":
+		return fmt.Errorf("%s%s rejected the request: %s (%s)", ipAddress, path, status.StatusString, status.SubStatusCode)
+	default:
+		return fmt.Errorf("unexpected HTTP %d from %s%s", resp.StatusCode, ipAddress, path)
+	}
+}
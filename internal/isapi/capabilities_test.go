@@ -0,0 +1,130 @@
+package isapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+const sampleCapabilities = `<?xml version="1.0" encoding="UTF-8"?>
+<DeviceCap version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">
+  <SysCap>
+    <isSupportDst>true</isSupportDst>
+    <IOCap>
+      <IOInputPortNums>1</IOInputPortNums>
+      <IOOutputPortNums>1</IOOutputPortNums>
+    </IOCap>
+    <VideoCap>
+      <videoInputPortNums>1</videoInputPortNums>
+    </VideoCap>
+    <AudioCap>
+      <audioInputNums>1</audioInputNums>
+      <audioOutputNums>1</audioOutputNums>
+    </AudioCap>
+  </SysCap>
+  <EventCap>
+    <isSupportMotionDetection>true</isSupportMotionDetection>
+    <isSupportSceneChangeDetection>true</isSupportSceneChangeDetection>
+  </EventCap>
+  <SmartCap>
+    <isSupportIntrusionDetection>true</isSupportIntrusionDetection>
+    <isSupportLineDetection>true</isSupportLineDetection>
+  </SmartCap>
+  <isSupportONVIF>true</isSupportONVIF>
+</DeviceCap>`
+
+func TestParseCapabilities(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantTwoWay   bool
+		wantONVIF    bool
+		wantEvents   []string
+		wantIOInputs int
+		wantErr      bool
+	}{
+		{
+			name:         "full document",
+			data:         sampleCapabilities,
+			wantTwoWay:   true,
+			wantONVIF:    true,
+			wantEvents:   []string{"intrusion", "line-crossing", "scene-change"},
+			wantIOInputs: 1,
+		},
+		{
+			name: "audio input only",
+			data: `<DeviceCap><SysCap><AudioCap><audioInputNums>1</audioInputNums></AudioCap></SysCap></DeviceCap>`,
+		},
+		{
+			name:    "invalid xml",
+			data:    "not xml",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caps, err := ParseCapabilities([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCapabilities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := caps.SupportsTwoWayAudio(); got != tt.wantTwoWay {
+				t.Errorf("SupportsTwoWayAudio() = %v, want %v", got, tt.wantTwoWay)
+			}
+			if caps.IsSupportONVIF != tt.wantONVIF {
+				t.Errorf("IsSupportONVIF = %v, want %v", caps.IsSupportONVIF, tt.wantONVIF)
+			}
+			if got := caps.SmartEvents(); !reflect.DeepEqual(got, tt.wantEvents) {
+				t.Errorf("SmartEvents() = %v, want %v", got, tt.wantEvents)
+			}
+			if caps.SysCap.IOCap.IOInputPortNums != tt.wantIOInputs {
+				t.Errorf("IOInputPortNums = %d, want %d", caps.SysCap.IOCap.IOInputPortNums, tt.wantIOInputs)
+			}
+		})
+	}
+}
+
+func TestClientGetCapabilities(t *testing.T) {
+	tests := []struct {
+		name       string
+		statusCode int
+		wantErr    bool
+	}{
+		{name: "success", statusCode: http.StatusOK},
+		{name: "unauthorized", statusCode: http.StatusUnauthorized, wantErr: true},
+		{name: "not found", statusCode: http.StatusNotFound, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != CapabilitiesPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(sampleCapabilities))
+			}))
+			defer server.Close()
+
+			addr := strings.TrimPrefix(server.URL, "http://")
+			client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+
+			caps, err := client.GetCapabilities(addr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GetCapabilities() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !caps.SupportsTwoWayAudio() {
+				t.Error("expected two-way audio support")
+			}
+		})
+	}
+}
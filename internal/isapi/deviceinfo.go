@@ -0,0 +1,42 @@
+package isapi
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// DeviceInfoPath is the ISAPI endpoint describing device identity and firmware
+const DeviceInfoPath = "/ISAPI/System/deviceInfo"
+
+// DeviceInfo is the DeviceInfo document returned by /ISAPI/System/deviceInfo
+type DeviceInfo struct {
+	XMLName              xml.Name `xml:"DeviceInfo" json:"-"`
+	DeviceName           string   `xml:"deviceName" json:"deviceName"`
+	DeviceID             string   `xml:"deviceID" json:"deviceID"`
+	Model                string   `xml:"model" json:"model"`
+	SerialNumber         string   `xml:"serialNumber" json:"serialNumber"`
+	MACAddress           string   `xml:"macAddress" json:"macAddress"`
+	FirmwareVersion      string   `xml:"firmwareVersion" json:"firmwareVersion"`
+	FirmwareReleasedDate string   `xml:"firmwareReleasedDate" json:"firmwareReleasedDate"`
+	EncoderVersion       string   `xml:"encoderVersion" json:"encoderVersion"`
+	EncoderReleasedDate  string   `xml:"encoderReleasedDate" json:"encoderReleasedDate"`
+	DeviceType           string   `xml:"deviceType" json:"deviceType"`
+}
+
+// ParseDeviceInfo unmarshals a DeviceInfo XML document
+func ParseDeviceInfo(data []byte) (*DeviceInfo, error) {
+	var info DeviceInfo
+	if err := xml.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("invalid deviceInfo XML: %w", err)
+	}
+	return &info, nil
+}
+
+// GetDeviceInfo fetches and parses the device info of the device at ipAddress
+func (c *Client) GetDeviceInfo(ipAddress string) (*DeviceInfo, error) {
+	body, err := c.get(ipAddress, DeviceInfoPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDeviceInfo(body)
+}
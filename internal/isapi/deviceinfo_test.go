@@ -0,0 +1,75 @@
+package isapi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+const sampleDeviceInfo = `<?xml version="1.0" encoding="UTF-8"?>
+<DeviceInfo version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">
+  <deviceName>Front Door</deviceName>
+  <deviceID>88c1e4a2-1dd2-11b2-8000-4cbd8f61cc5c</deviceID>
+  <model>DS-2CD2143G0-I</model>
+  <serialNumber>DS-2CD2143G0-I20190101AAWRC12345678</serialNumber>
+  <macAddress>4c:bd:8f:61:cc:5c</macAddress>
+  <firmwareVersion>V5.5.80</firmwareVersion>
+  <firmwareReleasedDate>build 190603</firmwareReleasedDate>
+  <encoderVersion>V7.3</encoderVersion>
+  <deviceType>IPCamera</deviceType>
+</DeviceInfo>`
+
+func TestParseDeviceInfo(t *testing.T) {
+	tests := []struct {
+		name         string
+		data         string
+		wantModel    string
+		wantFirmware string
+		wantErr      bool
+	}{
+		{name: "full document", data: sampleDeviceInfo, wantModel: "DS-2CD2143G0-I", wantFirmware: "V5.5.80"},
+		{name: "invalid xml", data: "<<<", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := ParseDeviceInfo([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDeviceInfo() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if info.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", info.Model, tt.wantModel)
+			}
+			if info.FirmwareVersion != tt.wantFirmware {
+				t.Errorf("FirmwareVersion = %q, want %q", info.FirmwareVersion, tt.wantFirmware)
+			}
+		})
+	}
+}
+
+func TestClientGetDeviceInfo(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != DeviceInfoPath {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(sampleDeviceInfo))
+	}))
+	defer server.Close()
+
+	client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+	info, err := client.GetDeviceInfo(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("GetDeviceInfo() error = %v", err)
+	}
+	if info.SerialNumber != "DS-2CD2143G0-I20190101AAWRC12345678" {
+		t.Errorf("SerialNumber = %q", info.SerialNumber)
+	}
+}
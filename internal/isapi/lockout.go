@@ -0,0 +1,98 @@
+package isapi
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// UserCheckPath is the ISAPI endpoint that reports login lockout state
+const UserCheckPath = "/ISAPI/Security/userCheck"
+
+// ErrLockStatusUnavailable is returned when a device does not report its
+// lockout state, as older firmware does not
+var ErrLockStatusUnavailable = errors.New("device does not report lockout status")
+
+// userCheck is the document returned by /ISAPI/Security/userCheck. Devices
+// include it in the unauthenticated 401 reply too.
+type userCheck struct {
+	XMLName        xml.Name `xml:"userCheck"`
+	StatusValue    string   `xml:"statusValue"`
+	LockStatus     string   `xml:"lockStatus"`
+	UnlockTime     string   `xml:"unlockTime"`
+	RetryLoginTime string   `xml:"retryLoginTime"`
+}
+
+// LockStatus is a device's password lockout state
+type LockStatus struct {
+	Locked bool `json:"locked"`
+	// RemainingAttempts is the number of password attempts left before
+	// lockout, or -1 when the device does not say
+	RemainingAttempts int `json:"remainingAttempts"`
+	// UnlockIn is how long the lockout lasts, when reported
+	UnlockIn time.Duration `json:"unlockIn,omitempty"`
+}
+
+// LockoutRisk reports whether another password attempt could lock the device
+// out: it already is locked, or at most minRemaining attempts are left
+func (s *LockStatus) LockoutRisk(minRemaining int) bool {
+	if s.Locked {
+		return true
+	}
+	return s.RemainingAttempts >= 0 && s.RemainingAttempts <= minRemaining
+}
+
+// ParseLockStatus extracts the lockout state from a userCheck document. It
+// returns ErrLockStatusUnavailable when the document carries none.
+func ParseLockStatus(data []byte) (*LockStatus, error) {
+	var doc userCheck
+	if err := xml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("invalid userCheck XML: %w", err)
+	}
+
+	lock := strings.ToLower(strings.TrimSpace(doc.LockStatus))
+	retry := strings.TrimSpace(doc.RetryLoginTime)
+	unlock := strings.TrimSpace(doc.UnlockTime)
+	if lock == "" && retry == "" && unlock == "" {
+		return nil, ErrLockStatusUnavailable
+	}
+
+	status := &LockStatus{Locked: lock == "lock" || lock == "locked", RemainingAttempts: -1}
+	if n, err := strconv.Atoi(retry); err == nil && n >= 0 {
+		status.RemainingAttempts = n
+		if n == 0 {
+			status.Locked = true
+		}
+	}
+	if secs, err := strconv.Atoi(unlock); err == nil && secs > 0 {
+		status.UnlockIn = time.Duration(secs) * time.Second
+		status.Locked = true
+	}
+	return status, nil
+}
+
+// GetLockStatus queries the lockout state of the device at ipAddress. The
+// request carries no credentials, so it never spends a password attempt.
+func (c *Client) GetLockStatus(ipAddress string) (*LockStatus, error) {
+	resp, err := c.HTTP.Get(ipAddress, UserCheckPath)
+	if err != nil {
+		return nil, err
+	}
+
+	switch resp.StatusCode {
+	case 200, 401, 403:
+		status, err := ParseLockStatus(resp.Body)
+		if err != nil {
+			// No userCheck document, e.g. an HTML login page or empty body
+			return nil, ErrLockStatusUnavailable
+		}
+		return status, nil
+	case 404:
+		return nil, ErrLockStatusUnavailable
+	default:
+		return nil, fmt.Errorf("unexpected HTTP %d from %s%s", resp.StatusCode, ipAddress, UserCheckPath)
+	}
+}
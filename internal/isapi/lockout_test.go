@@ -0,0 +1,136 @@
+package isapi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+const sampleUserCheck = `<?xml version="1.0" encoding="UTF-8"?>
+<userCheck version="2.0" xmlns="http://www.hikvision.com/ver20/XMLSchema">
+  <statusValue>401</statusValue>
+  <statusString>Unauthorized</statusString>
+  <lockStatus>unlock</lockStatus>
+  <unlockTime>0</unlockTime>
+  <retryLoginTime>3</retryLoginTime>
+</userCheck>`
+
+func TestParseLockStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    LockStatus
+		wantErr error
+		anyErr  bool
+	}{
+		{name: "unlocked with attempts", data: sampleUserCheck, want: LockStatus{RemainingAttempts: 3}},
+		{
+			name: "locked with unlock time",
+			data: `<userCheck><lockStatus>lock</lockStatus><unlockTime>1800</unlockTime><retryLoginTime>0</retryLoginTime></userCheck>`,
+			want: LockStatus{Locked: true, RemainingAttempts: 0, UnlockIn: 30 * time.Minute},
+		},
+		{
+			name: "no attempts left",
+			data: `<userCheck><retryLoginTime>0</retryLoginTime></userCheck>`,
+			want: LockStatus{Locked: true, RemainingAttempts: 0},
+		},
+		{
+			name:    "no lockout fields",
+			data:    `<userCheck><statusValue>200</statusValue><statusString>OK</statusString></userCheck>`,
+			wantErr: ErrLockStatusUnavailable,
+		},
+		{name: "other document", data: `<ResponseStatus><statusCode>4</statusCode></ResponseStatus>`, anyErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseLockStatus([]byte(tt.data))
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("ParseLockStatus() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.anyErr:
+				if err == nil {
+					t.Fatal("ParseLockStatus() expected an error")
+				}
+				return
+			case err != nil:
+				t.Fatalf("ParseLockStatus() error = %v", err)
+			}
+			if *got != tt.want {
+				t.Errorf("ParseLockStatus() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLockStatusLockoutRisk(t *testing.T) {
+	tests := []struct {
+		name   string
+		status LockStatus
+		want   bool
+	}{
+		{name: "locked", status: LockStatus{Locked: true, RemainingAttempts: -1}, want: true},
+		{name: "at threshold", status: LockStatus{RemainingAttempts: 2}, want: true},
+		{name: "plenty left", status: LockStatus{RemainingAttempts: 5}, want: false},
+		{name: "unknown", status: LockStatus{RemainingAttempts: -1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.status.LockoutRisk(2); got != tt.want {
+				t.Errorf("LockoutRisk(2) = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientGetLockStatus(t *testing.T) {
+	tests := []struct {
+		name    string
+		code    int
+		body    string
+		want    int
+		wantErr error
+	}{
+		{name: "401 with userCheck", code: http.StatusUnauthorized, body: sampleUserCheck, want: 3},
+		{name: "login page", code: http.StatusUnauthorized, body: "<html>login</html>", wantErr: ErrLockStatusUnavailable},
+		{name: "not found", code: http.StatusNotFound, wantErr: ErrLockStatusUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != UserCheckPath || r.Header.Get("Authorization") != "" {
+					w.WriteHeader(http.StatusBadRequest)
+					return
+				}
+				w.WriteHeader(tt.code)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+			status, err := client.GetLockStatus(strings.TrimPrefix(server.URL, "http://"))
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("GetLockStatus() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GetLockStatus() error = %v", err)
+			}
+			if status.RemainingAttempts != tt.want {
+				t.Errorf("RemainingAttempts = %d, want %d", status.RemainingAttempts, tt.want)
+			}
+		})
+	}
+}
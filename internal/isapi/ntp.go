@@ -0,0 +1,108 @@
+package isapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// NTPServersPath is the ISAPI endpoint listing the device's NTP servers
+const NTPServersPath = "/ISAPI/System/time/ntpServers"
+
+// DefaultNTPPort and DefaultNTPSyncInterval (minutes) are used when an
+// NTPServer leaves them unset
+const (
+	DefaultNTPPort         = 123
+	DefaultNTPSyncInterval = 1440
+)
+
+// NTPServerList is the NTPServerList document of /ISAPI/System/time/ntpServers
+type NTPServerList struct {
+	XMLName xml.Name    `xml:"NTPServerList"`
+	Version string      `xml:"version,attr,omitempty"`
+	XMLNS   string      `xml:"xmlns,attr,omitempty"`
+	Servers []NTPServer `xml:"NTPServer"`
+}
+
+// NTPServer is one configured NTP server. AddressingFormatType is
+// "hostname" or "ipaddress" and selects which address field is used.
+type NTPServer struct {
+	ID                   string `xml:"id"`
+	AddressingFormatType string `xml:"addressingFormatType"`
+	HostName             string `xml:"hostName,omitempty"`
+	IPAddress            string `xml:"ipAddress,omitempty"`
+	PortNo               int    `xml:"portNo"`
+	SynchronizeInterval  int    `xml:"synchronizeInterval"`
+}
+
+// Address returns the server's host name or IP, whichever is in use
+func (s NTPServer) Address() string {
+	if s.AddressingFormatType == "ipaddress" {
+		return s.IPAddress
+	}
+	return s.HostName
+}
+
+// NewNTPServer builds the single-server entry for address, which may be a
+// host name or an IPv4 address
+func NewNTPServer(address string) (NTPServer, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return NTPServer{}, fmt.Errorf("NTP server address is required")
+	}
+
+	server := NTPServer{ID: "1", PortNo: DefaultNTPPort, SynchronizeInterval: DefaultNTPSyncInterval}
+	if ip := net.ParseIP(address); ip != nil {
+		if ip.To4() == nil {
+			return NTPServer{}, fmt.Errorf("NTP server %q: only IPv4 addresses are supported", address)
+		}
+		server.AddressingFormatType = "ipaddress"
+		server.IPAddress = ip.String()
+	} else {
+		server.AddressingFormatType = "hostname"
+		server.HostName = address
+	}
+	return server, nil
+}
+
+// ParseNTPServers unmarshals an NTPServerList XML document
+func ParseNTPServers(data []byte) ([]NTPServer, error) {
+	var list NTPServerList
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid NTP server XML: %w", err)
+	}
+	return list.Servers, nil
+}
+
+// GetNTPServers fetches the NTP servers configured on the device at ipAddress
+func (c *Client) GetNTPServers(ipAddress string) ([]NTPServer, error) {
+	body, err := c.get(ipAddress, NTPServersPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNTPServers(body)
+}
+
+// SetNTPServers replaces the NTP servers configured on the device at
+// ipAddress. The device only uses them while its time mode is NTP.
+func (c *Client) SetNTPServers(ipAddress string, servers []NTPServer) error {
+	for i := range servers {
+		if servers[i].PortNo == 0 {
+			servers[i].PortNo = DefaultNTPPort
+		}
+		if servers[i].SynchronizeInterval == 0 {
+			servers[i].SynchronizeInterval = DefaultNTPSyncInterval
+		}
+	}
+
+	body, err := xml.Marshal(NTPServerList{
+		Version: "2.0",
+		XMLNS:   "http://www.isapi.org/ver20/XMLSchema",
+		Servers: servers,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode NTP servers: %w", err)
+	}
+	return c.put(ipAddress, NTPServersPath, append([]byte(xml.Header), body...))
+}
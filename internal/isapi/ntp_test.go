@@ -0,0 +1,149 @@
+package isapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+const sampleNTPServers = `<?xml version="1.0" encoding="UTF-8"?>
+<NTPServerList version="2.0" xmlns="http://www.isapi.org/ver20/XMLSchema">
+  <NTPServer>
+    <id>1</id>
+    <addressingFormatType>hostname</addressingFormatType>
+    <hostName>time.windows.com</hostName>
+    <portNo>123</portNo>
+    <synchronizeInterval>1440</synchronizeInterval>
+  </NTPServer>
+</NTPServerList>`
+
+func TestNewNTPServer(t *testing.T) {
+	tests := []struct {
+		name       string
+		address    string
+		wantFormat string
+		wantAddr   string
+		wantErr    bool
+	}{
+		{name: "host name", address: "pool.ntp.org", wantFormat: "hostname", wantAddr: "pool.ntp.org"},
+		{name: "ipv4 address", address: " 10.0.0.1 ", wantFormat: "ipaddress", wantAddr: "10.0.0.1"},
+		{name: "ipv6 address", address: "2001:db8::1", wantErr: true},
+		{name: "empty", address: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, err := NewNTPServer(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("NewNTPServer() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if server.AddressingFormatType != tt.wantFormat || server.Address() != tt.wantAddr {
+				t.Errorf("NewNTPServer() = %+v, want %s %s", server, tt.wantFormat, tt.wantAddr)
+			}
+			if server.PortNo != DefaultNTPPort {
+				t.Errorf("PortNo = %d, want %d", server.PortNo, DefaultNTPPort)
+			}
+		})
+	}
+}
+
+func TestParseNTPServers(t *testing.T) {
+	servers, err := ParseNTPServers([]byte(sampleNTPServers))
+	if err != nil {
+		t.Fatalf("ParseNTPServers() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].Address() != "time.windows.com" || servers[0].PortNo != 123 {
+		t.Errorf("ParseNTPServers() = %+v", servers)
+	}
+
+	if _, err := ParseNTPServers([]byte("<NTPServerList>")); err == nil {
+		t.Error("ParseNTPServers() accepted malformed XML")
+	}
+}
+
+func TestClientSetNTPServers(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantErr  string
+	}{
+		{
+			name:     "accepted",
+			status:   http.StatusOK,
+			response: `<ResponseStatus><statusCode>1</statusCode><statusString>OK</statusString></ResponseStatus>`,
+		},
+		{
+			name:     "rejected",
+			status:   http.StatusBadRequest,
+			response: `<ResponseStatus><statusCode>4</statusCode><statusString>Invalid Content</statusString><subStatusCode>badXmlContent</subStatusCode></ResponseStatus>`,
+			wantErr:  "Invalid Content",
+		},
+		{
+			name:    "unauthorized",
+			status:  http.StatusUnauthorized,
+			wantErr: "authentication failed",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotBody string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.URL.Path != NTPServersPath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				data, _ := io.ReadAll(r.Body)
+				gotMethod, gotBody = r.Method, string(data)
+				if tt.status == http.StatusUnauthorized {
+					w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="n0nce", qop="auth"`)
+				}
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+			ntp, _ := NewNTPServer("pool.ntp.org")
+			err := client.SetNTPServers(strings.TrimPrefix(server.URL, "http://"), []NTPServer{ntp})
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("SetNTPServers() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("SetNTPServers() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if gotMethod != http.MethodPut {
+				t.Errorf("method = %q, want PUT", gotMethod)
+			}
+			if !strings.Contains(gotBody, "<hostName>pool.ntp.org</hostName>") ||
+				!strings.Contains(gotBody, "<addressingFormatType>hostname</addressingFormatType>") {
+				t.Errorf("body = %q, want the NTP server entry", gotBody)
+			}
+		})
+	}
+}
+
+func TestClientGetNTPServers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(sampleNTPServers))
+	}))
+	defer server.Close()
+
+	client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+	servers, err := client.GetNTPServers(strings.TrimPrefix(server.URL, "http://"))
+	if err != nil {
+		t.Fatalf("GetNTPServers() error = %v", err)
+	}
+	if len(servers) != 1 || servers[0].HostName != "time.windows.com" {
+		t.Errorf("GetNTPServers() = %+v", servers)
+	}
+}
@@ -0,0 +1,91 @@
+package isapi
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// UpdateFirmwarePath is the ISAPI endpoint that accepts a firmware image
+const UpdateFirmwarePath = "/ISAPI/System/updateFirmware"
+
+// UpgradeStatusPath is the ISAPI endpoint that reports firmware upgrade progress
+const UpgradeStatusPath = "/ISAPI/System/upgradeStatus"
+
+// statusRebootRequired is the ResponseStatus code for a change that only
+// applies after a reboot, as a written firmware image does
+const statusRebootRequired = 7
+
+// UpgradeStatus is the document returned by /ISAPI/System/upgradeStatus
+type UpgradeStatus struct {
+	XMLName   xml.Name `xml:"upgradeStatus"`
+	Upgrading bool     `xml:"upgrading"`
+	Percent   int      `xml:"percent"`
+}
+
+// ParseUpgradeStatus parses an upgradeStatus document
+func ParseUpgradeStatus(data []byte) (*UpgradeStatus, error) {
+	var status UpgradeStatus
+	if err := xml.Unmarshal(data, &status); err != nil {
+		return nil, fmt.Errorf("invalid upgradeStatus XML: %w", err)
+	}
+	return &status, nil
+}
+
+// GetUpgradeStatus fetches the firmware upgrade progress of the device at ipAddress
+func (c *Client) GetUpgradeStatus(ipAddress string) (*UpgradeStatus, error) {
+	body, err := c.get(ipAddress, UpgradeStatusPath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUpgradeStatus(body)
+}
+
+// UploadFirmware streams size bytes of firmware to the device at ipAddress,
+// allowing timeout for the upload and the device's reply. It reports whether
+// the device needs a reboot to run the new firmware.
+func (c *Client) UploadFirmware(ipAddress string, firmware io.Reader, size int64, timeout time.Duration) (bool, error) {
+	resp, err := c.HTTP.UploadWithDigest("PUT", ipAddress, UpdateFirmwarePath, UpgradeStatusPath,
+		"application/octet-stream", firmware, size, c.Username, c.Password, timeout)
+	if err != nil {
+		return false, err
+	}
+
+	var status ResponseStatus
+	_ = xml.Unmarshal(resp.Body, &status)
+
+	switch {
+	case resp.StatusCode == 200 && status.StatusCode == statusRebootRequired:
+		return true, nil
+	case resp.StatusCode == 200 && (status.StatusCode == 0 || status.StatusCode == 1):
+		return false, nil
+	case resp.StatusCode == 401:
+		return false, fmt.Errorf("authentication failed for %s", ipAddress)
+	case status.StatusString != "":
+		return false, fmt.Errorf("%s rejected the firmware: %s (%s)", ipAddress, status.StatusString, status.SubStatusCode)
+	default:
+		return false, fmt.Errorf("unexpected HTTP %d from %s%s", resp.StatusCode, ipAddress, UpdateFirmwarePath)
+	}
+}
+
+// WaitForUpgrade polls the upgrade status of the device at ipAddress every
+// interval until it no longer reports upgrading, calling progress with each
+// status or polling error. Errors do not end the wait, since a device may
+// stop answering while it writes or reboots; only timeout does.
+func (c *Client) WaitForUpgrade(ipAddress string, interval, timeout time.Duration, progress func(*UpgradeStatus, error)) error {
+	deadline := time.Now().Add(timeout)
+	for {
+		status, err := c.GetUpgradeStatus(ipAddress)
+		if progress != nil {
+			progress(status, err)
+		}
+		if err == nil && !status.Upgrading {
+			return nil
+		}
+		if time.Now().Add(interval).After(deadline) {
+			return fmt.Errorf("upgrade of %s did not finish within %s", ipAddress, timeout)
+		}
+		time.Sleep(interval)
+	}
+}
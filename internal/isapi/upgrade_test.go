@@ -0,0 +1,178 @@
+package isapi
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+func TestParseUpgradeStatus(t *testing.T) {
+	tests := []struct {
+		name          string
+		data          string
+		wantUpgrading bool
+		wantPercent   int
+		wantErr       bool
+	}{
+		{
+			name:          "in progress",
+			data:          `<upgradeStatus version="2.0" xmlns="http://www.isapi.org/ver20/XMLSchema"><upgrading>true</upgrading><percent>45</percent></upgradeStatus>`,
+			wantUpgrading: true,
+			wantPercent:   45,
+		},
+		{
+			name: "idle",
+			data: `<upgradeStatus><upgrading>false</upgrading><percent>0</percent></upgradeStatus>`,
+		},
+		{
+			name:    "other document",
+			data:    `<ResponseStatus><statusCode>1</statusCode></ResponseStatus>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			status, err := ParseUpgradeStatus([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseUpgradeStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if status.Upgrading != tt.wantUpgrading || status.Percent != tt.wantPercent {
+				t.Errorf("ParseUpgradeStatus() = %+v, want upgrading=%v percent=%d", status, tt.wantUpgrading, tt.wantPercent)
+			}
+		})
+	}
+}
+
+func TestClientUploadFirmware(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		response   string
+		wantReboot bool
+		wantErr    string
+	}{
+		{
+			name:       "reboot required",
+			status:     http.StatusOK,
+			response:   `<ResponseStatus><statusCode>7</statusCode><statusString>Reboot Required</statusString></ResponseStatus>`,
+			wantReboot: true,
+		},
+		{
+			name:     "ok",
+			status:   http.StatusOK,
+			response: `<ResponseStatus><statusCode>1</statusCode><statusString>OK</statusString></ResponseStatus>`,
+		},
+		{
+			name:     "bad image",
+			status:   http.StatusBadRequest,
+			response: `<ResponseStatus><statusCode>4</statusCode><statusString>Invalid Operation</statusString><subStatusCode>upgradeFailed</subStatusCode></ResponseStatus>`,
+			wantErr:  "Invalid Operation",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var puts int
+			var gotBody, gotType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "" {
+					w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="n0nce", qop="auth"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				if r.Method != http.MethodPut || r.URL.Path != UpdateFirmwarePath {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				puts++
+				data, _ := io.ReadAll(r.Body)
+				gotBody, gotType = string(data), r.Header.Get("Content-Type")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer server.Close()
+
+			client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+			firmware := "firmware-image"
+			reboot, err := client.UploadFirmware(strings.TrimPrefix(server.URL, "http://"), strings.NewReader(firmware), int64(len(firmware)), 5*time.Second)
+			if tt.wantErr == "" && err != nil {
+				t.Fatalf("UploadFirmware() error = %v", err)
+			}
+			if tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)) {
+				t.Fatalf("UploadFirmware() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if reboot != tt.wantReboot {
+				t.Errorf("UploadFirmware() reboot = %v, want %v", reboot, tt.wantReboot)
+			}
+			if puts != 1 {
+				t.Errorf("firmware was sent %d times, want once", puts)
+			}
+			if gotBody != firmware || gotType != "application/octet-stream" {
+				t.Errorf("body = %q (%s), want the firmware as application/octet-stream", gotBody, gotType)
+			}
+		})
+	}
+}
+
+func TestClientWaitForUpgrade(t *testing.T) {
+	replies := []string{
+		`<upgradeStatus><upgrading>true</upgrading><percent>10</percent></upgradeStatus>`,
+		"",
+		`<upgradeStatus><upgrading>true</upgrading><percent>90</percent></upgradeStatus>`,
+		`<upgradeStatus><upgrading>false</upgrading><percent>100</percent></upgradeStatus>`,
+	}
+	var mu sync.Mutex
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		reply := replies[polls]
+		polls++
+		mu.Unlock()
+		if reply == "" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = w.Write([]byte(reply))
+	}))
+	defer server.Close()
+
+	client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+	var percents []int
+	errs := 0
+	err := client.WaitForUpgrade(strings.TrimPrefix(server.URL, "http://"), time.Millisecond, 5*time.Second, func(status *UpgradeStatus, err error) {
+		if err != nil {
+			errs++
+			return
+		}
+		percents = append(percents, status.Percent)
+	})
+	if err != nil {
+		t.Fatalf("WaitForUpgrade() error = %v", err)
+	}
+	if len(percents) != 3 || percents[2] != 100 || errs != 1 {
+		t.Errorf("progress = %v with %d errors, want 10, 90, 100 and one error", percents, errs)
+	}
+}
+
+func TestClientWaitForUpgradeTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`<upgradeStatus><upgrading>true</upgrading><percent>5</percent></upgradeStatus>`))
+	}))
+	defer server.Close()
+
+	client := NewClient(network.NewHTTPClient("TestAgent", 5*time.Second), "admin", "secret")
+	err := client.WaitForUpgrade(strings.TrimPrefix(server.URL, "http://"), 10*time.Millisecond, 30*time.Millisecond, nil)
+	if err == nil || !strings.Contains(err.Error(), "did not finish") {
+		t.Errorf("WaitForUpgrade() error = %v, want a timeout", err)
+	}
+}
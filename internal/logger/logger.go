@@ -12,13 +12,35 @@ type Logger struct {
 	*zap.SugaredLogger
 }
 
-// New creates a new Logger with the specified debug level
+// TraceLevel is below zap's debug level, for per-packet detail that
+// --debug alone would drown in
+const TraceLevel = zapcore.DebugLevel - 1
+
+// VerbosityLevel maps a -v count to a level: none logs warnings and errors
+// only, -v adds info, -vv debug and -vvv or more trace
+func VerbosityLevel(verbosity int) zapcore.Level {
+	switch {
+	case verbosity <= 0:
+		return zapcore.WarnLevel
+	case verbosity == 1:
+		return zapcore.InfoLevel
+	case verbosity == 2:
+		return zapcore.DebugLevel
+	default:
+		return TraceLevel
+	}
+}
+
+// New creates a new Logger at debug level when debug is set, else info
 func New(debug bool) *Logger {
-	level := zapcore.InfoLevel
 	if debug {
-		level = zapcore.DebugLevel
+		return NewWithLevel(zapcore.DebugLevel)
 	}
+	return NewWithLevel(zapcore.InfoLevel)
+}
 
+// NewWithLevel creates a new Logger that writes entries at level and above
+func NewWithLevel(level zapcore.Level) *Logger {
 	encoderConfig := zapcore.EncoderConfig{
 		TimeKey:        "time",
 		LevelKey:       "level",
@@ -27,7 +49,7 @@ func New(debug bool) *Logger {
 		MessageKey:     "msg",
 		StacktraceKey:  "stacktrace",
 		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.CapitalColorLevelEncoder,
+		EncodeLevel:    encodeLevel,
 		EncodeTime:     zapcore.ISO8601TimeEncoder,
 		EncodeDuration: zapcore.StringDurationEncoder,
 		EncodeCaller:   zapcore.ShortCallerEncoder,
@@ -43,6 +65,15 @@ func New(debug bool) *Logger {
 	return &Logger{logger.Sugar()}
 }
 
+// encodeLevel is zap's colored level encoder, naming TraceLevel
+func encodeLevel(l zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+	if l == TraceLevel {
+		enc.AppendString("TRACE")
+		return
+	}
+	zapcore.CapitalColorLevelEncoder(l, enc)
+}
+
 // NewNop creates a no-op logger for testing
 func NewNop() *Logger {
 	return &Logger{zap.NewNop().Sugar()}
@@ -53,6 +84,11 @@ func NewFromZap(sugar *zap.SugaredLogger) *Logger {
 	return &Logger{sugar}
 }
 
+// Tracew logs a message with key-value pairs at TraceLevel
+func (l *Logger) Tracew(msg string, keysAndValues ...interface{}) {
+	l.SugaredLogger.Logw(TraceLevel, msg, keysAndValues...)
+}
+
 // With returns a logger with the specified key-value pairs
 func (l *Logger) With(args ...interface{}) *Logger {
 	return &Logger{l.SugaredLogger.With(args...)}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 func TestNew(t *testing.T) {
@@ -184,3 +185,67 @@ func TestLogMethods(t *testing.T) {
 		})
 	}
 }
+
+func TestVerbosityLevel(t *testing.T) {
+	tests := []struct {
+		name      string
+		verbosity int
+		want      zapcore.Level
+	}{
+		{name: "default is warnings only", verbosity: 0, want: zapcore.WarnLevel},
+		{name: "-v", verbosity: 1, want: zapcore.InfoLevel},
+		{name: "-vv", verbosity: 2, want: zapcore.DebugLevel},
+		{name: "-vvv", verbosity: 3, want: TraceLevel},
+		{name: "more than -vvv", verbosity: 5, want: TraceLevel},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := VerbosityLevel(tt.verbosity); got != tt.want {
+				t.Errorf("VerbosityLevel(%d) = %v, want %v", tt.verbosity, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewWithLevel(t *testing.T) {
+	tests := []struct {
+		name  string
+		level zapcore.Level
+		trace bool
+		debug bool
+	}{
+		{name: "warn", level: zapcore.WarnLevel},
+		{name: "debug", level: zapcore.DebugLevel, debug: true},
+		{name: "trace", level: TraceLevel, trace: true, debug: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewWithLevel(tt.level)
+			desugared := log.Desugar().Core()
+			if got := desugared.Enabled(TraceLevel); got != tt.trace {
+				t.Errorf("trace enabled = %v, want %v", got, tt.trace)
+			}
+			if got := desugared.Enabled(zapcore.DebugLevel); got != tt.debug {
+				t.Errorf("debug enabled = %v, want %v", got, tt.debug)
+			}
+		})
+	}
+}
+
+func TestEncodeLevel(t *testing.T) {
+	enc := &levelRecorder{}
+	encodeLevel(TraceLevel, enc)
+	if len(enc.values) != 1 || enc.values[0] != "TRACE" {
+		t.Errorf("encodeLevel(TraceLevel) = %v, want [TRACE]", enc.values)
+	}
+}
+
+// levelRecorder captures the strings a level encoder appends
+type levelRecorder struct {
+	zapcore.PrimitiveArrayEncoder
+	values []string
+}
+
+func (r *levelRecorder) AppendString(v string) { r.values = append(r.values, v) }
@@ -4,9 +4,11 @@ import (
 	"bufio"
 	"bytes"
 	"encoding/hex"
+	"io"
 	"net"
 	"os/exec"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 )
@@ -37,12 +39,54 @@ var HikvisionOUIs = []string{
 
 // GetARPTable reads the system ARP table
 func GetARPTable() (ARPTable, error) {
-	return getARPTableOS()
+	return getARPTableOS(nil)
 }
 
-func getARPTableOS() (ARPTable, error) {
-	arpTable := make(ARPTable)
+// GetARPTableFiltered reads the system ARP table keeping only the entries
+// within cidr (or equal to it, for a single IP; empty keeps any address)
+// and, when hikvisionOnly is set, with a Hikvision MAC. Entries are
+// filtered as the arp output is read, so a large table is never held whole.
+func GetARPTableFiltered(cidr string, hikvisionOnly bool) (ARPTable, error) {
+	keep, err := arpFilter(cidr, hikvisionOnly)
+	if err != nil {
+		return nil, err
+	}
+	return getARPTableOS(keep)
+}
+
+// arpFilter builds the entry filter for GetARPTableFiltered
+func arpFilter(cidr string, hikvisionOnly bool) (func(ip, mac string) bool, error) {
+	within := func(net.IP) bool { return true }
+	if cidr != "" {
+		var err error
+		if within, err = cidrMatcher(cidr); err != nil {
+			return nil, err
+		}
+	}
+	return func(ip, mac string) bool {
+		if hikvisionOnly && !IsHikvisionMAC(mac) {
+			return false
+		}
+		parsed := net.ParseIP(ip)
+		return parsed != nil && within(parsed)
+	}, nil
+}
+
+// cidrMatcher reports membership of cidr, or equality for a single IP
+func cidrMatcher(cidr string) (func(net.IP) bool, error) {
+	_, ipnet, err := net.ParseCIDR(cidr)
+	if err == nil {
+		return ipnet.Contains, nil
+	}
+	if single := net.ParseIP(cidr); single != nil {
+		return single.Equal, nil
+	}
+	return nil, err
+}
 
+// getARPTableOS runs the platform arp command, keeping the entries keep
+// accepts (all of them when keep is nil)
+func getARPTableOS(keep func(ip, mac string) bool) (ARPTable, error) {
 	var cmd *exec.Cmd
 	switch runtime.GOOS {
 	case "darwin":
@@ -52,24 +96,77 @@ func getARPTableOS() (ARPTable, error) {
 	case "windows":
 		cmd = exec.Command("arp", "-a")
 	default:
-		return arpTable, nil
+		return make(ARPTable), nil
 	}
 
-	output, err := cmd.Output()
+	stdout, err := cmd.StdoutPipe()
 	if err != nil {
 		return nil, err
 	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	arpTable, readErr := parseARPOutput(stdout, keep)
+	if readErr != nil {
+		// Drain the rest so arp is not left blocked on a full pipe
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		return nil, err
+	}
+	if readErr != nil {
+		return nil, readErr
+	}
+	return arpTable, nil
+}
 
-	scanner := bufio.NewScanner(bytes.NewReader(output))
+// parseARPOutput parses arp command output line by line, keeping the
+// entries keep accepts (all of them when keep is nil)
+func parseARPOutput(r io.Reader, keep func(ip, mac string) bool) (ARPTable, error) {
+	arpTable := make(ARPTable)
+	scanner := bufio.NewScanner(r)
 	for scanner.Scan() {
-		line := scanner.Text()
-		ip, mac := ParseARPLine(line)
-		if ip != "" && mac != "" {
-			arpTable[ip] = mac
+		ip, mac := ParseARPLine(scanner.Text())
+		if ip == "" || mac == "" || (keep != nil && !keep(ip, mac)) {
+			continue
 		}
+		arpTable[ip] = mac
 	}
+	return arpTable, scanner.Err()
+}
 
-	return arpTable, nil
+// HikvisionARPEntries returns the IPs in table that fall within cidr (or
+// equal it, for a single IP), are not excluded, and map to a Hikvision MAC,
+// in address order. Entries are taken as-is; nothing checks that the hosts
+// are still up.
+func HikvisionARPEntries(table ARPTable, cidr string, excludes []string) ([]string, error) {
+	within, err := cidrMatcher(cidr)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := newIPFilter(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	var ips []net.IP
+	for s, mac := range table {
+		ip := net.ParseIP(s)
+		if ip == nil || !within(ip) || filter.excluded(s) || !IsHikvisionMAC(mac) {
+			continue
+		}
+		ips = append(ips, ip)
+	}
+	sort.Slice(ips, func(i, j int) bool {
+		return bytes.Compare(ips[i].To16(), ips[j].To16()) < 0
+	})
+
+	result := make([]string, len(ips))
+	for i, ip := range ips {
+		result[i] = ip.String()
+	}
+	return result, nil
 }
 
 // ParseARPLine parses a single line from ARP output
@@ -115,6 +212,13 @@ func ParseARPLine(line string) (ip, mac string) {
 	return ip, mac
 }
 
+// NormalizeMAC returns mac in the upper-case, colon-separated form SADP
+// devices report, e.g. "4c-bd-8f-61-cc-5c" as "4C:BD:8F:61:CC:5C". It does
+// not validate mac; see IsValidMAC.
+func NormalizeMAC(mac string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(mac), "-", ":"))
+}
+
 // IsValidMAC checks if a string is a valid MAC address
 func IsValidMAC(s string) bool {
 	s = strings.ReplaceAll(s, "-", ":")
@@ -199,33 +303,44 @@ func PingHost(ip string, timeout time.Duration) bool {
 	}
 }
 
-// ExpandCIDR expands a CIDR range to a list of IP addresses
+// ExpandCIDR expands a CIDR range to a list of IP addresses. For large
+// ranges prefer NewIPIterator, which does not hold every address at once.
 func ExpandCIDR(cidr string) ([]string, error) {
-	ip, ipnet, err := net.ParseCIDR(cidr)
+	it, err := NewIPIterator(cidr)
 	if err != nil {
-		if net.ParseIP(cidr) != nil {
-			return []string{cidr}, nil
-		}
 		return nil, err
 	}
 
 	var ips []string
-	for ip := ip.Mask(ipnet.Mask); ipnet.Contains(ip); incrementIP(ip) {
-		ones, bits := ipnet.Mask.Size()
-		if bits-ones <= 8 {
-			lastOctet := ip[len(ip)-1]
-			if lastOctet == 0 || lastOctet == 255 {
-				continue
-			}
-		}
-		ipCopy := make(net.IP, len(ip))
-		copy(ipCopy, ip)
-		ips = append(ips, ipCopy.String())
+	for ip, ok := it.Next(); ok; ip, ok = it.Next() {
+		ips = append(ips, ip)
 	}
 
 	return ips, nil
 }
 
+// FilterIPs removes any address matching one of the excludes, which may be
+// individual IPs or CIDR blocks
+func FilterIPs(ips []string, excludes []string) ([]string, error) {
+	if len(excludes) == 0 {
+		return ips, nil
+	}
+
+	filter, err := newIPFilter(excludes)
+	if err != nil {
+		return nil, err
+	}
+
+	filtered := make([]string, 0, len(ips))
+	for _, s := range ips {
+		if !filter.excluded(s) {
+			filtered = append(filtered, s)
+		}
+	}
+
+	return filtered, nil
+}
+
 func incrementIP(ip net.IP) {
 	for j := len(ip) - 1; j >= 0; j-- {
 		ip[j]++
@@ -1,6 +1,8 @@
 package network
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 	"time"
 )
@@ -126,6 +128,27 @@ func TestIsValidMAC(t *testing.T) {
 	}
 }
 
+func TestNormalizeMAC(t *testing.T) {
+	tests := []struct {
+		name string
+		mac  string
+		want string
+	}{
+		{name: "lowercase dash separated", mac: "4c-bd-8f-61-cc-5c", want: "4C:BD:8F:61:CC:5C"},
+		{name: "already normalized", mac: "4C:BD:8F:61:CC:5C", want: "4C:BD:8F:61:CC:5C"},
+		{name: "surrounding space", mac: " 4c:bd:8f:61:cc:5c\n", want: "4C:BD:8F:61:CC:5C"},
+		{name: "empty", mac: "", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := NormalizeMAC(tt.mac); got != tt.want {
+				t.Errorf("NormalizeMAC(%q) = %q, want %q", tt.mac, got, tt.want)
+			}
+		})
+	}
+}
+
 func TestIsHikvisionMAC(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -312,6 +335,217 @@ func TestExpandCIDR(t *testing.T) {
 	}
 }
 
+func TestFilterIPs(t *testing.T) {
+	ips := []string{"192.168.1.1", "192.168.1.5", "192.168.1.20", "192.168.1.100"}
+
+	tests := []struct {
+		name     string
+		excludes []string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "no excludes",
+			excludes: nil,
+			expected: ips,
+		},
+		{
+			name:     "single IP",
+			excludes: []string{"192.168.1.1"},
+			expected: []string{"192.168.1.5", "192.168.1.20", "192.168.1.100"},
+		},
+		{
+			name:     "CIDR block",
+			excludes: []string{"192.168.1.0/28"},
+			expected: []string{"192.168.1.20", "192.168.1.100"},
+		},
+		{
+			name:     "IP and CIDR",
+			excludes: []string{"192.168.1.100", "192.168.1.0/28"},
+			expected: []string{"192.168.1.20"},
+		},
+		{
+			name:     "invalid IP",
+			excludes: []string{"not-an-ip"},
+			wantErr:  true,
+		},
+		{
+			name:     "invalid CIDR",
+			excludes: []string{"192.168.1.0/99"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := FilterIPs(ips, tt.excludes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FilterIPs() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("FilterIPs() = %v, want %v", result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("result[%d] = %q, want %q", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestHikvisionARPEntries(t *testing.T) {
+	table := ARPTable{
+		"192.168.1.64":  "4c:bd:8f:61:cc:5c",
+		"192.168.1.9":   "44:19:b6:00:00:01",
+		"192.168.1.1":   "00:11:22:33:44:55",
+		"192.168.2.64":  "4c:bd:8f:00:00:02",
+		"192.168.1.100": "c0:56:e3:00:00:03",
+	}
+
+	tests := []struct {
+		name     string
+		cidr     string
+		excludes []string
+		expected []string
+		wantErr  bool
+	}{
+		{
+			name:     "hikvision entries in range, address order",
+			cidr:     "192.168.1.0/24",
+			expected: []string{"192.168.1.9", "192.168.1.64", "192.168.1.100"},
+		},
+		{
+			name:     "with excludes",
+			cidr:     "192.168.1.0/24",
+			excludes: []string{"192.168.1.64", "192.168.1.96/28"},
+			expected: []string{"192.168.1.9"},
+		},
+		{
+			name:     "single IP",
+			cidr:     "192.168.2.64",
+			expected: []string{"192.168.2.64"},
+		},
+		{
+			name:     "no matches",
+			cidr:     "10.0.0.0/8",
+			expected: []string{},
+		},
+		{
+			name:    "invalid CIDR",
+			cidr:    "not-a-cidr",
+			wantErr: true,
+		},
+		{
+			name:     "invalid exclude",
+			cidr:     "192.168.1.0/24",
+			excludes: []string{"bogus"},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := HikvisionARPEntries(table, tt.cidr, tt.excludes)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("HikvisionARPEntries() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(result) != len(tt.expected) {
+				t.Fatalf("HikvisionARPEntries() = %v, want %v", result, tt.expected)
+			}
+			for i := range result {
+				if result[i] != tt.expected[i] {
+					t.Errorf("result[%d] = %q, want %q", i, result[i], tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+// sampleARPOutput mixes Linux, macOS and Windows arp lines
+const sampleARPOutput = `Address                  HWtype  HWaddress           Flags Mask            Iface
+192.168.1.64             ether   4c:bd:8f:61:cc:5c   C                     eth0
+192.168.1.1              ether   00:11:22:33:44:55   C                     eth0
+192.168.1.77                     (incomplete)                              eth0
+? (192.168.2.64) at 4c:bd:8f:00:00:02 on en0 ifscope [ethernet]
+  10.0.0.9              44-19-b6-00-00-01     dynamic
+`
+
+func TestParseARPOutputFiltered(t *testing.T) {
+	tests := []struct {
+		name          string
+		cidr          string
+		hikvisionOnly bool
+		expected      ARPTable
+		wantErr       bool
+	}{
+		{
+			name: "no filter",
+			expected: ARPTable{
+				"192.168.1.64": "4c:bd:8f:61:cc:5c",
+				"192.168.1.1":  "00:11:22:33:44:55",
+				"192.168.2.64": "4c:bd:8f:00:00:02",
+				"10.0.0.9":     "44:19:b6:00:00:01",
+			},
+		},
+		{
+			name:          "hikvision only",
+			hikvisionOnly: true,
+			expected: ARPTable{
+				"192.168.1.64": "4c:bd:8f:61:cc:5c",
+				"192.168.2.64": "4c:bd:8f:00:00:02",
+				"10.0.0.9":     "44:19:b6:00:00:01",
+			},
+		},
+		{
+			name:     "cidr only",
+			cidr:     "192.168.1.0/24",
+			expected: ARPTable{"192.168.1.64": "4c:bd:8f:61:cc:5c", "192.168.1.1": "00:11:22:33:44:55"},
+		},
+		{
+			name:          "cidr and hikvision",
+			cidr:          "192.168.0.0/16",
+			hikvisionOnly: true,
+			expected:      ARPTable{"192.168.1.64": "4c:bd:8f:61:cc:5c", "192.168.2.64": "4c:bd:8f:00:00:02"},
+		},
+		{
+			name:     "single IP",
+			cidr:     "10.0.0.9",
+			expected: ARPTable{"10.0.0.9": "44:19:b6:00:00:01"},
+		},
+		{
+			name:    "invalid CIDR",
+			cidr:    "not-a-cidr",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			keep, err := arpFilter(tt.cidr, tt.hikvisionOnly)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("arpFilter() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			table, err := parseARPOutput(strings.NewReader(sampleARPOutput), keep)
+			if err != nil {
+				t.Fatalf("parseARPOutput() error = %v", err)
+			}
+			if !reflect.DeepEqual(table, tt.expected) {
+				t.Errorf("parseARPOutput() = %v, want %v", table, tt.expected)
+			}
+		})
+	}
+}
+
 func TestHikvisionOUIs(t *testing.T) {
 	tests := []struct {
 		name string
@@ -0,0 +1,65 @@
+package network
+
+import (
+	"mime"
+	"regexp"
+	"strings"
+
+	"golang.org/x/text/encoding/htmlindex"
+)
+
+var (
+	xmlEncodingPattern = regexp.MustCompile(`^\s*<\?xml[^>]*\sencoding\s*=\s*["']([^"']+)["']`)
+	metaCharsetPattern = regexp.MustCompile(`(?i)<meta[^>]+charset\s*=\s*["']?([A-Za-z0-9._:-]+)`)
+)
+
+// Text returns the response body transcoded to UTF-8. See DecodeBody.
+func (r *HTTPResponse) Text() string {
+	return DecodeBody(r.Body, r.Headers["content-type"])
+}
+
+// DecodeBody transcodes body to UTF-8 using the charset named by the
+// Content-Type header, or failing that by an XML declaration or HTML meta
+// tag. Bodies with no or an unrecognized charset are returned as is, which
+// treats them as UTF-8.
+func DecodeBody(body []byte, contentType string) string {
+	name := BodyCharset(body, contentType)
+	if name == "" {
+		return string(body)
+	}
+
+	enc, err := htmlindex.Get(name)
+	if err != nil {
+		return string(body)
+	}
+	if canonical, _ := htmlindex.Name(enc); canonical == "utf-8" {
+		return string(body)
+	}
+
+	decoded, err := enc.NewDecoder().Bytes(body)
+	if err != nil {
+		return string(body)
+	}
+	return string(decoded)
+}
+
+// BodyCharset returns the charset declared for body, lowercased, or "" when
+// none is declared. The Content-Type header takes precedence over the body.
+func BodyCharset(body []byte, contentType string) string {
+	if _, params, err := mime.ParseMediaType(contentType); err == nil && params["charset"] != "" {
+		return strings.ToLower(params["charset"])
+	}
+
+	// Declarations are near the top, so only the start of the body is searched
+	head := body
+	if len(head) > 1024 {
+		head = head[:1024]
+	}
+	if m := xmlEncodingPattern.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	if m := metaCharsetPattern.FindSubmatch(head); m != nil {
+		return strings.ToLower(string(m[1]))
+	}
+	return ""
+}
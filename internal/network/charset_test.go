@@ -0,0 +1,86 @@
+package network
+
+import (
+	"testing"
+
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+func TestDecodeBody(t *testing.T) {
+	const name = "前门摄像机"
+	gbk, err := simplifiedchinese.GBK.NewEncoder().String("<deviceName>" + name + "</deviceName>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	latin1, err := charmap.ISO8859_1.NewEncoder().String("<deviceName>Café</deviceName>")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name        string
+		body        string
+		contentType string
+		want        string
+	}{
+		{
+			name:        "gb2312 header",
+			body:        gbk,
+			contentType: "text/xml; charset=gb2312",
+			want:        "<deviceName>" + name + "</deviceName>",
+		},
+		{
+			name:        "quoted uppercase header",
+			body:        gbk,
+			contentType: `text/xml; charset="GB2312"`,
+			want:        "<deviceName>" + name + "</deviceName>",
+		},
+		{
+			name: "xml declaration",
+			body: `<?xml version="1.0" encoding="GB2312"?>` + gbk,
+			want: `<?xml version="1.0" encoding="GB2312"?><deviceName>` + name + "</deviceName>",
+		},
+		{
+			name: "html meta",
+			body: `<html><head><meta charset="iso-8859-1"></head>` + latin1,
+			want: `<html><head><meta charset="iso-8859-1"></head><deviceName>Café</deviceName>`,
+		},
+		{
+			name:        "header wins over declaration",
+			body:        `<?xml version="1.0" encoding="utf-8"?>` + latin1,
+			contentType: "application/xml; charset=ISO-8859-1",
+			want:        `<?xml version="1.0" encoding="utf-8"?><deviceName>Café</deviceName>`,
+		},
+		{
+			name: "undeclared is utf-8",
+			body: "<deviceName>" + name + "</deviceName>",
+			want: "<deviceName>" + name + "</deviceName>",
+		},
+		{
+			name:        "unknown charset left as is",
+			body:        "<model>DS-2CD2042WD-I</model>",
+			contentType: "text/xml; charset=x-bogus",
+			want:        "<model>DS-2CD2042WD-I</model>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := DecodeBody([]byte(tt.body), tt.contentType); got != tt.want {
+				t.Errorf("DecodeBody() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPResponseText(t *testing.T) {
+	body, err := simplifiedchinese.GBK.NewEncoder().String("<deviceName>前门</deviceName>")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp := &HTTPResponse{Body: []byte(body), Headers: map[string]string{"content-type": "text/xml; charset=gb2312"}}
+	if got, want := resp.Text(), "<deviceName>前门</deviceName>"; got != want {
+		t.Errorf("Text() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,131 @@
+package network
+
+import (
+	"crypto/md5"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// DigestChallenge holds the parameters of a WWW-Authenticate Digest header
+type DigestChallenge struct {
+	Realm     string
+	Nonce     string
+	Opaque    string
+	QOP       string
+	Algorithm string
+}
+
+// ParseDigestChallenge parses a WWW-Authenticate header value
+func ParseDigestChallenge(header string) (*DigestChallenge, error) {
+	header = strings.TrimSpace(header)
+	if !strings.HasPrefix(strings.ToLower(header), "digest ") {
+		return nil, fmt.Errorf("unsupported authentication challenge: %q", header)
+	}
+
+	params := parseAuthParams(header[len("digest "):])
+	challenge := &DigestChallenge{
+		Realm:     params["realm"],
+		Nonce:     params["nonce"],
+		Opaque:    params["opaque"],
+		Algorithm: params["algorithm"],
+	}
+	if challenge.Nonce == "" {
+		return nil, fmt.Errorf("digest challenge missing nonce")
+	}
+
+	// Prefer qop=auth when the server offers a list
+	for _, qop := range strings.Split(params["qop"], ",") {
+		if strings.TrimSpace(qop) == "auth" {
+			challenge.QOP = "auth"
+			break
+		}
+	}
+
+	return challenge, nil
+}
+
+// Authorization builds the Authorization header value for a request
+func (c *DigestChallenge) Authorization(method, uri, username, password string) string {
+	return c.authorization(method, uri, username, password, newCNonce())
+}
+
+func (c *DigestChallenge) authorization(method, uri, username, password, cnonce string) string {
+	ha1 := md5Hex(username + ":" + c.Realm + ":" + password)
+	if strings.EqualFold(c.Algorithm, "MD5-sess") {
+		ha1 = md5Hex(ha1 + ":" + c.Nonce + ":" + cnonce)
+	}
+	ha2 := md5Hex(method + ":" + uri)
+
+	const nc = "00000001"
+	var response string
+	if c.QOP == "auth" {
+		response = md5Hex(ha1 + ":" + c.Nonce + ":" + nc + ":" + cnonce + ":" + c.QOP + ":" + ha2)
+	} else {
+		response = md5Hex(ha1 + ":" + c.Nonce + ":" + ha2)
+	}
+
+	parts := []string{
+		fmt.Sprintf(`username="%s"`, username),
+		fmt.Sprintf(`realm="%s"`, c.Realm),
+		fmt.Sprintf(`nonce="%s"`, c.Nonce),
+		fmt.Sprintf(`uri="%s"`, uri),
+		fmt.Sprintf(`response="%s"`, response),
+	}
+	if c.Algorithm != "" {
+		parts = append(parts, "algorithm="+c.Algorithm)
+	}
+	if c.Opaque != "" {
+		parts = append(parts, fmt.Sprintf(`opaque="%s"`, c.Opaque))
+	}
+	if c.QOP == "auth" {
+		parts = append(parts, "qop=auth", "nc="+nc, fmt.Sprintf(`cnonce="%s"`, cnonce))
+	}
+
+	return "Digest " + strings.Join(parts, ", ")
+}
+
+// parseAuthParams splits comma-separated key=value pairs, honouring quotes
+func parseAuthParams(s string) map[string]string {
+	params := make(map[string]string)
+	for len(s) > 0 {
+		s = strings.TrimLeft(s, " ,")
+		eq := strings.Index(s, "=")
+		if eq == -1 {
+			break
+		}
+		key := strings.ToLower(strings.TrimSpace(s[:eq]))
+		s = s[eq+1:]
+
+		var value string
+		if strings.HasPrefix(s, `"`) {
+			end := strings.Index(s[1:], `"`)
+			if end == -1 {
+				value, s = s[1:], ""
+			} else {
+				value, s = s[1:end+1], s[end+2:]
+			}
+		} else {
+			end := strings.Index(s, ",")
+			if end == -1 {
+				value, s = s, ""
+			} else {
+				value, s = s[:end], s[end:]
+			}
+		}
+		params[key] = strings.TrimSpace(value)
+	}
+	return params
+}
+
+func md5Hex(s string) string {
+	sum := md5.Sum([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func newCNonce() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
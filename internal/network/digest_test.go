@@ -0,0 +1,80 @@
+package network
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDigestChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		header  string
+		want    DigestChallenge
+		wantErr bool
+	}{
+		{
+			name:   "quoted params with qop list",
+			header: `Digest realm="IP Camera(C1234)", nonce="abc123", qop="auth,auth-int", opaque="xyz"`,
+			want:   DigestChallenge{Realm: "IP Camera(C1234)", Nonce: "abc123", Opaque: "xyz", QOP: "auth"},
+		},
+		{
+			name:   "unquoted algorithm",
+			header: `Digest realm="r", nonce="n", algorithm=MD5`,
+			want:   DigestChallenge{Realm: "r", Nonce: "n", Algorithm: "MD5"},
+		},
+		{
+			name:    "basic challenge",
+			header:  `Basic realm="r"`,
+			wantErr: true,
+		},
+		{
+			name:    "missing nonce",
+			header:  `Digest realm="r"`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDigestChallenge(tt.header)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDigestChallenge() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && *got != tt.want {
+				t.Errorf("ParseDigestChallenge() = %+v, want %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDigestAuthorization(t *testing.T) {
+	tests := []struct {
+		name         string
+		challenge    DigestChallenge
+		wantResponse string
+	}{
+		{
+			// RFC 2617 section 3.5 example
+			name: "rfc 2617 qop auth",
+			challenge: DigestChallenge{
+				Realm:  "testrealm@host.com",
+				Nonce:  "dcd98b7102dd2f0e8b11d0f600bfb0c093",
+				Opaque: "5ccc069c403ebaf9f0171e9517f40e41",
+				QOP:    "auth",
+			},
+			wantResponse: `response="6629fae49393a05397450978507c4ef1"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.challenge.authorization("GET", "/dir/index.html", "Mufasa", "Circle Of Life", "0a4f113b")
+			if !strings.HasPrefix(got, "Digest ") {
+				t.Errorf("authorization = %q, want Digest prefix", got)
+			}
+			if !strings.Contains(got, tt.wantResponse) {
+				t.Errorf("authorization = %q, want it to contain %s", got, tt.wantResponse)
+			}
+		})
+	}
+}
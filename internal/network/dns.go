@@ -0,0 +1,98 @@
+package network
+
+import (
+	"context"
+	"net"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultDNSTimeout bounds each reverse lookup
+const DefaultDNSTimeout = 2 * time.Second
+
+// DefaultDNSWorkers bounds concurrent reverse lookups
+const DefaultDNSWorkers = 8
+
+// HostnameResolver performs reverse DNS (PTR) lookups with a per-lookup
+// timeout and bounded concurrency, caching results for its lifetime
+type HostnameResolver struct {
+	Timeout time.Duration
+	Workers int
+
+	lookup func(ctx context.Context, addr string) ([]string, error)
+	mu     sync.Mutex
+	cache  map[string]string
+}
+
+// NewHostnameResolver creates a resolver using the system resolver
+func NewHostnameResolver(timeout time.Duration, workers int) *HostnameResolver {
+	return &HostnameResolver{
+		Timeout: timeout,
+		Workers: workers,
+		lookup:  net.DefaultResolver.LookupAddr,
+		cache:   make(map[string]string),
+	}
+}
+
+// Lookup returns the first PTR name for ip without the trailing dot, or ""
+// when there is none or the lookup fails
+func (r *HostnameResolver) Lookup(ip string) string {
+	r.mu.Lock()
+	name, ok := r.cache[ip]
+	r.mu.Unlock()
+	if ok {
+		return name
+	}
+
+	timeout := r.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDNSTimeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	if names, err := r.lookup(ctx, ip); err == nil && len(names) > 0 {
+		name = strings.TrimSuffix(names[0], ".")
+	}
+
+	r.mu.Lock()
+	r.cache[ip] = name
+	r.mu.Unlock()
+	return name
+}
+
+// LookupAll resolves every address concurrently and returns the names found,
+// keyed by IP. Addresses without a PTR record are omitted.
+func (r *HostnameResolver) LookupAll(ips []string) map[string]string {
+	workers := r.Workers
+	if workers <= 0 {
+		workers = DefaultDNSWorkers
+	}
+
+	var (
+		wg      sync.WaitGroup
+		mu      sync.Mutex
+		results = make(map[string]string)
+		sem     = make(chan struct{}, workers)
+	)
+	for _, ip := range ips {
+		if ip == "" {
+			continue
+		}
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(ip string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if name := r.Lookup(ip); name != "" {
+				mu.Lock()
+				results[ip] = name
+				mu.Unlock()
+			}
+		}(ip)
+	}
+	wg.Wait()
+
+	return results
+}
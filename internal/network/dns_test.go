@@ -0,0 +1,76 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestHostnameResolverLookupAll(t *testing.T) {
+	tests := []struct {
+		name    string
+		ips     []string
+		records map[string][]string
+		want    map[string]string
+	}{
+		{
+			name: "trailing dot trimmed and first name used",
+			ips:  []string{"192.168.1.64"},
+			records: map[string][]string{
+				"192.168.1.64": {"cam-lobby.example.com.", "alias.example.com."},
+			},
+			want: map[string]string{"192.168.1.64": "cam-lobby.example.com"},
+		},
+		{
+			name: "missing PTR left out",
+			ips:  []string{"192.168.1.64", "192.168.1.65", ""},
+			records: map[string][]string{
+				"192.168.1.65": {"nvr.example.com."},
+			},
+			want: map[string]string{"192.168.1.65": "nvr.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := NewHostnameResolver(time.Second, 2)
+			r.lookup = func(_ context.Context, addr string) ([]string, error) {
+				if names, ok := tt.records[addr]; ok {
+					return names, nil
+				}
+				return nil, errors.New("no such host")
+			}
+
+			got := r.LookupAll(tt.ips)
+			if len(got) != len(tt.want) {
+				t.Fatalf("LookupAll() = %v, want %v", got, tt.want)
+			}
+			for ip, name := range tt.want {
+				if got[ip] != name {
+					t.Errorf("LookupAll()[%s] = %q, want %q", ip, got[ip], name)
+				}
+			}
+		})
+	}
+}
+
+func TestHostnameResolverCaches(t *testing.T) {
+	var mu sync.Mutex
+	calls := 0
+
+	r := NewHostnameResolver(time.Second, 4)
+	r.lookup = func(context.Context, string) ([]string, error) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		return nil, errors.New("no such host")
+	}
+
+	r.LookupAll([]string{"10.0.0.1", "10.0.0.2"})
+	r.LookupAll([]string{"10.0.0.1", "10.0.0.2"})
+	if calls != 2 {
+		t.Errorf("lookup called %d times, want 2 (failures are cached too)", calls)
+	}
+}
@@ -11,10 +11,17 @@ import (
 	"time"
 )
 
+// DefaultMaxRedirects is the redirect hop limit used by probe --follow-redirects
+const DefaultMaxRedirects = 5
+
 // HTTPClient handles raw HTTP requests
 type HTTPClient struct {
 	UserAgent string
 	Timeout   time.Duration
+	// MaxRedirects is how many redirects Get and GetWithAuth follow. Only
+	// plain-HTTP redirects to the same host and port are followed. Zero
+	// returns redirect responses as is.
+	MaxRedirects int
 }
 
 // NewHTTPClient creates a new HTTP client
@@ -30,6 +37,9 @@ type HTTPResponse struct {
 	StatusCode int
 	Body       []byte
 	Headers    map[string]string
+	// Redirects lists the paths requested after following redirects, in
+	// order; the response came from the last one
+	Redirects []string
 }
 
 // Get performs an HTTP GET request
@@ -39,10 +49,142 @@ func (c *HTTPClient) Get(ipAddress, path string) (*HTTPResponse, error) {
 
 // GetWithAuth performs an HTTP GET request with an auth token
 func (c *HTTPClient) GetWithAuth(ipAddress, path, authToken string) (*HTTPResponse, error) {
-	fullURL := fmt.Sprintf("http://%s%s", ipAddress, path)
 	if authToken != "" {
-		fullURL += "?auth=" + authToken
+		path += "?auth=" + authToken
+	}
+
+	resp, err := c.Request("GET", ipAddress, path, nil)
+	for err == nil && len(resp.Redirects) < c.MaxRedirects && isRedirect(resp.StatusCode) {
+		next, ok := sameHostRedirect(ipAddress, path, resp.Headers["location"])
+		if !ok {
+			break
+		}
+		followed := append(resp.Redirects, next)
+		path = next
+		if resp, err = c.Request("GET", ipAddress, path, nil); err == nil {
+			resp.Redirects = followed
+		}
+	}
+	return resp, err
+}
+
+func isRedirect(statusCode int) bool {
+	switch statusCode {
+	case 301, 302, 303, 307, 308:
+		return true
+	}
+	return false
+}
+
+// sameHostRedirect resolves location against the request for path and
+// returns the path to request next. It refuses redirects to another host or
+// port, or to HTTPS, which this client does not speak.
+func sameHostRedirect(ipAddress, path, location string) (string, bool) {
+	if location == "" {
+		return "", false
+	}
+	base, err := url.Parse("http://" + ipAddress + path)
+	if err != nil {
+		return "", false
+	}
+	loc, err := url.Parse(location)
+	if err != nil {
+		return "", false
+	}
+
+	next := base.ResolveReference(loc)
+	if next.Scheme != "http" || !strings.EqualFold(next.Hostname(), base.Hostname()) || httpPort(next) != httpPort(base) {
+		return "", false
+	}
+	return next.RequestURI(), true
+}
+
+func httpPort(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	return "80"
+}
+
+// GetWithDigest performs an HTTP GET request, answering a Digest
+// authentication challenge with the given credentials
+func (c *HTTPClient) GetWithDigest(ipAddress, path, username, password string) (*HTTPResponse, error) {
+	return c.DoWithDigest("GET", ipAddress, path, "", nil, username, password)
+}
+
+// DoWithDigest performs an HTTP request with an optional body, answering a
+// Digest authentication challenge with the given credentials. The body is
+// sent again with the authenticated request.
+func (c *HTTPClient) DoWithDigest(method, ipAddress, path, contentType string, body []byte, username, password string) (*HTTPResponse, error) {
+	var headers map[string]string
+	if contentType != "" {
+		headers = map[string]string{"Content-Type": contentType}
+	}
+
+	resp, err := c.RequestWithBody(method, ipAddress, path, headers, body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != 401 {
+		return resp, nil
+	}
+
+	challenge, err := ParseDigestChallenge(resp.Headers["www-authenticate"])
+	if err != nil {
+		return nil, err
+	}
+
+	authHeaders := map[string]string{"Authorization": challenge.Authorization(method, path, username, password)}
+	for name, value := range headers {
+		authHeaders[name] = value
+	}
+	return c.RequestWithBody(method, ipAddress, path, authHeaders, body)
+}
+
+// UploadWithDigest performs an HTTP request that streams size bytes of body,
+// for payloads such as firmware images too large to send twice. The Digest
+// challenge is taken from a GET of challengePath first, a path that answers
+// GET, so the body is sent only once, with credentials. timeout bounds the
+// whole upload and the reply rather than the client's Timeout, which still
+// applies to connecting.
+func (c *HTTPClient) UploadWithDigest(method, ipAddress, path, challengePath, contentType string, body io.Reader, size int64, username, password string, timeout time.Duration) (*HTTPResponse, error) {
+	headers := map[string]string{}
+	if contentType != "" {
+		headers["Content-Type"] = contentType
+	}
+
+	resp, err := c.Request("GET", ipAddress, challengePath, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == 401 {
+		challenge, err := ParseDigestChallenge(resp.Headers["www-authenticate"])
+		if err != nil {
+			return nil, err
+		}
+		headers["Authorization"] = challenge.Authorization(method, path, username, password)
 	}
+	return c.do(method, ipAddress, path, headers, body, size, timeout)
+}
+
+// Request performs an HTTP request with the given method and extra headers
+func (c *HTTPClient) Request(method, ipAddress, path string, headers map[string]string) (*HTTPResponse, error) {
+	return c.RequestWithBody(method, ipAddress, path, headers, nil)
+}
+
+// RequestWithBody performs an HTTP request with the given method, extra
+// headers and body. A Content-Length header is added when body is non-nil.
+func (c *HTTPClient) RequestWithBody(method, ipAddress, path string, headers map[string]string, body []byte) (*HTTPResponse, error) {
+	if body == nil {
+		return c.do(method, ipAddress, path, headers, nil, -1, c.Timeout)
+	}
+	return c.do(method, ipAddress, path, headers, bytes.NewReader(body), int64(len(body)), c.Timeout)
+}
+
+// do sends one request, with a Content-Length of length unless it is
+// negative, and reads the whole response within timeout
+func (c *HTTPClient) do(method, ipAddress, path string, headers map[string]string, body io.Reader, length int64, timeout time.Duration) (*HTTPResponse, error) {
+	fullURL := fmt.Sprintf("http://%s%s", ipAddress, path)
 
 	parsedURL, err := url.Parse(fullURL)
 	if err != nil {
@@ -63,28 +205,44 @@ func (c *HTTPClient) GetWithAuth(ipAddress, path, authToken string) (*HTTPRespon
 	}
 	defer conn.Close()
 
-	_ = conn.SetDeadline(time.Now().Add(c.Timeout))
+	_ = conn.SetDeadline(time.Now().Add(timeout))
 
 	pathWithQuery := parsedURL.Path
 	if parsedURL.RawQuery != "" {
 		pathWithQuery += "?" + parsedURL.RawQuery
 	}
 
+	var extraHeaders strings.Builder
+	for name, value := range headers {
+		fmt.Fprintf(&extraHeaders, "%s: %s\r\n", name, value)
+	}
+	if length >= 0 {
+		fmt.Fprintf(&extraHeaders, "Content-Length: %d\r\n", length)
+	}
+
 	httpRequest := fmt.Sprintf(
-		"GET %s HTTP/1.1\r\n"+
+		"%s %s HTTP/1.1\r\n"+
 			"Host: %s\r\n"+
 			"User-Agent: %s\r\n"+
 			"Accept: */*\r\n"+
+			"%s"+
 			"Connection: close\r\n"+
 			"\r\n",
+		method,
 		pathWithQuery,
 		parsedURL.Hostname(),
 		c.UserAgent,
+		extraHeaders.String(),
 	)
 
 	if _, err := conn.Write([]byte(httpRequest)); err != nil {
 		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
+	if body != nil {
+		if _, err := io.CopyN(conn, body, length); err != nil {
+			return nil, fmt.Errorf("failed to send request body: %w", err)
+		}
+	}
 
 	var buf bytes.Buffer
 	if _, err := io.Copy(&buf, conn); err != nil {
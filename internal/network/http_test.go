@@ -2,6 +2,7 @@ package network
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"net/http/httptest"
@@ -392,3 +393,180 @@ func TestHTTPClientWithPort(t *testing.T) {
 		})
 	}
 }
+
+func TestHTTPClientGetWithDigest(t *testing.T) {
+	tests := []struct {
+		name       string
+		challenge  bool
+		wantStatus int
+		wantAuth   bool
+	}{
+		{name: "answers digest challenge", challenge: true, wantStatus: 200, wantAuth: true},
+		{name: "no challenge", challenge: false, wantStatus: 200, wantAuth: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var receivedAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				receivedAuth = r.Header.Get("Authorization")
+				if tt.challenge && receivedAuth == "" {
+					w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="n0nce", qop="auth"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			addr := strings.TrimPrefix(server.URL, "http://")
+
+			client := NewHTTPClient("TestAgent", 5*time.Second)
+			resp, err := client.GetWithDigest(addr, "/ISAPI/System/capabilities", "admin", "secret")
+			if err != nil {
+				t.Fatalf("GetWithDigest() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if gotAuth := strings.HasPrefix(receivedAuth, "Digest "); gotAuth != tt.wantAuth {
+				t.Errorf("Authorization = %q, wantAuth %v", receivedAuth, tt.wantAuth)
+			}
+		})
+	}
+}
+
+func TestHTTPClientDoWithDigest(t *testing.T) {
+	tests := []struct {
+		name        string
+		method      string
+		body        []byte
+		contentType string
+	}{
+		{name: "put with body", method: "PUT", body: []byte("<NTPServerList/>"), contentType: "application/xml"},
+		{name: "post without content type", method: "POST", body: []byte("a=b")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotMethod, gotBody, gotType, gotAuth string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "" {
+					w.Header().Set("WWW-Authenticate", `Digest realm="test", nonce="n0nce", qop="auth"`)
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				data, _ := io.ReadAll(r.Body)
+				gotMethod, gotBody, gotType, gotAuth = r.Method, string(data), r.Header.Get("Content-Type"), r.Header.Get("Authorization")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			addr := strings.TrimPrefix(server.URL, "http://")
+			client := NewHTTPClient("TestAgent", 5*time.Second)
+			resp, err := client.DoWithDigest(tt.method, addr, "/ISAPI/System/time/ntpServers", tt.contentType, tt.body, "admin", "secret")
+			if err != nil {
+				t.Fatalf("DoWithDigest() error = %v", err)
+			}
+			if resp.StatusCode != http.StatusOK {
+				t.Fatalf("StatusCode = %d, want 200", resp.StatusCode)
+			}
+			if gotMethod != tt.method {
+				t.Errorf("method = %q, want %q", gotMethod, tt.method)
+			}
+			if gotBody != string(tt.body) {
+				t.Errorf("body = %q, want %q", gotBody, tt.body)
+			}
+			if gotType != tt.contentType {
+				t.Errorf("Content-Type = %q, want %q", gotType, tt.contentType)
+			}
+			if !strings.Contains(gotAuth, `uri="/ISAPI/System/time/ntpServers"`) {
+				t.Errorf("Authorization = %q, want digest for the request path", gotAuth)
+			}
+		})
+	}
+}
+
+func TestHTTPClientFollowRedirects(t *testing.T) {
+	var addr string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/":
+			http.Redirect(w, r, "/doc/page/login.asp", http.StatusFound)
+		case "/doc/page/login.asp":
+			w.Header().Set("Location", "http://"+addr+"/doc/index.html")
+			w.WriteHeader(http.StatusMovedPermanently)
+		case "/doc/index.html":
+			_, _ = w.Write([]byte("<model>DS-2CD2042WD-I</model>"))
+		case "/loop":
+			http.Redirect(w, r, "/loop", http.StatusFound)
+		case "/https":
+			http.Redirect(w, r, "https://"+addr+"/", http.StatusFound)
+		case "/offsite":
+			http.Redirect(w, r, "http://example.com/", http.StatusFound)
+		}
+	}))
+	defer server.Close()
+	addr = strings.TrimPrefix(server.URL, "http://")
+
+	tests := []struct {
+		name          string
+		path          string
+		maxRedirects  int
+		wantStatus    int
+		wantRedirects []string
+	}{
+		{name: "not followed by default", path: "/", wantStatus: http.StatusFound},
+		{name: "relative and absolute", path: "/", maxRedirects: DefaultMaxRedirects, wantStatus: http.StatusOK,
+			wantRedirects: []string{"/doc/page/login.asp", "/doc/index.html"}},
+		{name: "hop limit", path: "/", maxRedirects: 1, wantStatus: http.StatusMovedPermanently,
+			wantRedirects: []string{"/doc/page/login.asp"}},
+		{name: "loop stops at limit", path: "/loop", maxRedirects: 3, wantStatus: http.StatusFound,
+			wantRedirects: []string{"/loop", "/loop", "/loop"}},
+		{name: "https not followed", path: "/https", maxRedirects: DefaultMaxRedirects, wantStatus: http.StatusFound},
+		{name: "other host not followed", path: "/offsite", maxRedirects: DefaultMaxRedirects, wantStatus: http.StatusFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := NewHTTPClient("TestAgent", 5*time.Second)
+			client.MaxRedirects = tt.maxRedirects
+			resp, err := client.Get(addr, tt.path)
+			if err != nil {
+				t.Fatalf("Get() error = %v", err)
+			}
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("StatusCode = %d, want %d", resp.StatusCode, tt.wantStatus)
+			}
+			if strings.Join(resp.Redirects, ",") != strings.Join(tt.wantRedirects, ",") {
+				t.Errorf("Redirects = %q, want %q", resp.Redirects, tt.wantRedirects)
+			}
+		})
+	}
+}
+
+func TestSameHostRedirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		location string
+		want     string
+		wantOK   bool
+	}{
+		{name: "absolute path", location: "/doc/index.html", want: "/doc/index.html", wantOK: true},
+		{name: "relative path", location: "index.html", want: "/doc/index.html", wantOK: true},
+		{name: "same host with default port", location: "http://192.168.1.64:80/x?a=1", want: "/x?a=1", wantOK: true},
+		{name: "other port", location: "http://192.168.1.64:8080/", wantOK: false},
+		{name: "other host", location: "http://192.168.1.65/", wantOK: false},
+		{name: "https", location: "https://192.168.1.64/", wantOK: false},
+		{name: "empty", location: "", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := sameHostRedirect("192.168.1.64", "/doc/login.asp", tt.location)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("sameHostRedirect() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,313 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// DefaultAlivePorts are the TCP ports tried when checking whether a host is up
+var DefaultAlivePorts = []string{"80", "443", "8000", "8080", "554"}
+
+// MinAliveTimeout is the floor for the adaptive per-host timeout
+const MinAliveTimeout = 150 * time.Millisecond
+
+// PingFallbackMaxHosts is the largest scan that falls back to ICMP ping for
+// hosts with no open or closed TCP port; on bigger ranges the ping
+// subprocess per dead host dominates the scan time
+const PingFallbackMaxHosts = 1024
+
+// IPIterator lazily yields the addresses of a CIDR block, in the same order
+// and with the same network/broadcast skipping as ExpandCIDR
+type IPIterator struct {
+	next  net.IP
+	ipnet *net.IPNet
+	// network and broadcast are skipped; both are nil for IPv6 blocks and
+	// for /31 and /32, which have no such addresses
+	network   net.IP
+	broadcast net.IP
+	single    string
+	done      bool
+}
+
+// NewIPIterator creates an iterator over cidr, which may also be a single IP
+func NewIPIterator(cidr string) (*IPIterator, error) {
+	ip, ipnet, err := net.ParseCIDR(cidr)
+	if err != nil {
+		if net.ParseIP(cidr) != nil {
+			return &IPIterator{single: cidr}, nil
+		}
+		return nil, err
+	}
+
+	start := ip.Mask(ipnet.Mask)
+	it := &IPIterator{next: start, ipnet: ipnet}
+	if ones, bits := ipnet.Mask.Size(); bits == 8*net.IPv4len && bits-ones >= 2 {
+		// Copied, since next is incremented in place
+		it.network, it.broadcast = append(net.IP(nil), start.To4()...), BroadcastAddr(start, ipnet.Mask)
+	}
+	return it, nil
+}
+
+// Next returns the next address, or false when the block is exhausted
+func (it *IPIterator) Next() (string, bool) {
+	if it.done {
+		return "", false
+	}
+	if it.ipnet == nil {
+		it.done = true
+		return it.single, true
+	}
+
+	for it.ipnet.Contains(it.next) {
+		ip := make(net.IP, len(it.next))
+		copy(ip, it.next)
+		incrementIP(it.next)
+		if isWrapped(it.next) {
+			// Incrementing past the top of the address space wraps to zero
+			it.done = true
+		}
+
+		if ip.Equal(it.network) || ip.Equal(it.broadcast) {
+			if it.done {
+				return "", false
+			}
+			continue
+		}
+		return ip.String(), true
+	}
+
+	it.done = true
+	return "", false
+}
+
+func isWrapped(ip net.IP) bool {
+	for _, b := range ip {
+		if b != 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// ipFilter matches addresses against exclude IPs and CIDR blocks
+type ipFilter struct {
+	nets []*net.IPNet
+	ips  map[string]bool
+}
+
+func newIPFilter(excludes []string) (*ipFilter, error) {
+	f := &ipFilter{ips: make(map[string]bool)}
+	for _, exclude := range excludes {
+		exclude = strings.TrimSpace(exclude)
+		if exclude == "" {
+			continue
+		}
+		if strings.Contains(exclude, "/") {
+			_, ipnet, err := net.ParseCIDR(exclude)
+			if err != nil {
+				return nil, fmt.Errorf("invalid exclude CIDR %q: %w", exclude, err)
+			}
+			f.nets = append(f.nets, ipnet)
+			continue
+		}
+		ip := net.ParseIP(exclude)
+		if ip == nil {
+			return nil, fmt.Errorf("invalid exclude IP %q", exclude)
+		}
+		f.ips[ip.String()] = true
+	}
+	return f, nil
+}
+
+// excluded reports whether s is excluded or not an IP at all
+func (f *ipFilter) excluded(s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil || f.ips[ip.String()] {
+		return true
+	}
+	for _, ipnet := range f.nets {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// AliveChecker decides whether hosts are up by connecting to a few TCP
+// ports at once. A refused connection counts: the host answered with a
+// reset. The per-host timeout adapts to the slowest answer seen so far, so
+// dead addresses on a fast LAN are given up on quickly.
+type AliveChecker struct {
+	Ports []string
+	// Timeout is the upper bound for a host; the adaptive timeout never
+	// exceeds it
+	Timeout time.Duration
+	// MinTimeout is the floor for the adaptive timeout
+	MinTimeout time.Duration
+	// Ping falls back to an ICMP ping when no TCP port answers
+	Ping bool
+
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+	ping func(ip string, timeout time.Duration) bool
+
+	mu     sync.Mutex
+	maxRTT time.Duration
+}
+
+// NewAliveChecker creates a checker for the default ports
+func NewAliveChecker(timeout time.Duration, ping bool) *AliveChecker {
+	var d net.Dialer
+	return &AliveChecker{
+		Ports:      DefaultAlivePorts,
+		Timeout:    timeout,
+		MinTimeout: MinAliveTimeout,
+		Ping:       ping,
+		dial:       d.DialContext,
+		ping:       PingHost,
+	}
+}
+
+// currentTimeout is four times the slowest answer seen, clamped to
+// [MinTimeout, Timeout]. Until a host answers it is Timeout.
+func (c *AliveChecker) currentTimeout() time.Duration {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxRTT == 0 {
+		return c.Timeout
+	}
+	t := 4 * c.maxRTT
+	if t < c.MinTimeout {
+		t = c.MinTimeout
+	}
+	if t > c.Timeout {
+		t = c.Timeout
+	}
+	return t
+}
+
+func (c *AliveChecker) observe(rtt time.Duration) {
+	c.mu.Lock()
+	if rtt > c.maxRTT {
+		c.maxRTT = rtt
+	}
+	c.mu.Unlock()
+}
+
+// IsAlive dials every port concurrently and returns as soon as one answers
+func (c *AliveChecker) IsAlive(ip string) bool {
+	timeout := c.currentTimeout()
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	answered := make(chan bool, len(c.Ports))
+	start := time.Now()
+	for _, port := range c.Ports {
+		go func(port string) {
+			conn, err := c.dial(ctx, "tcp", net.JoinHostPort(ip, port))
+			if err == nil {
+				conn.Close()
+				answered <- true
+				return
+			}
+			answered <- errors.Is(err, syscall.ECONNREFUSED)
+		}(port)
+	}
+
+	for range c.Ports {
+		if <-answered {
+			c.observe(time.Since(start))
+			return true
+		}
+	}
+
+	return c.Ping && c.ping(ip, timeout)
+}
+
+// ScanSubnet checks every address of cidr not matched by excludes, with the
+// given number of workers, and returns the hosts that are up. Addresses are
+// generated lazily, so large blocks do not need to fit in memory.
+func ScanSubnet(cidr string, excludes []string, workers int, checker *AliveChecker) ([]string, error) {
+	return ScanSubnetContext(context.Background(), cidr, excludes, workers, checker)
+}
+
+// ScanSubnetContext is ScanSubnet, stopping early when ctx is done. The
+// hosts found up to then are returned along with the context's error;
+// checks already in flight finish within the checker's timeout.
+func ScanSubnetContext(ctx context.Context, cidr string, excludes []string, workers int, checker *AliveChecker) ([]string, error) {
+	it, err := NewIPIterator(cidr)
+	if err != nil {
+		return nil, err
+	}
+	filter, err := newIPFilter(excludes)
+	if err != nil {
+		return nil, err
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	ipChan := make(chan string, workers)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		alive []string
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var ip string
+				var ok bool
+				select {
+				case <-ctx.Done():
+					return
+				case ip, ok = <-ipChan:
+				}
+				if !ok {
+					return
+				}
+				if checker.IsAlive(ip) {
+					mu.Lock()
+					alive = append(alive, ip)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+
+feed:
+	for ip, ok := it.Next(); ok; ip, ok = it.Next() {
+		if filter.excluded(ip) {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			break feed
+		case ipChan <- ip:
+		}
+	}
+	close(ipChan)
+	wg.Wait()
+
+	return alive, ctx.Err()
+}
+
+// CountCIDR returns the number of addresses NewIPIterator yields for cidr
+func CountCIDR(cidr string) (int, error) {
+	it, err := NewIPIterator(cidr)
+	if err != nil {
+		return 0, err
+	}
+	n := 0
+	for _, ok := it.Next(); ok; _, ok = it.Next() {
+		n++
+	}
+	return n, nil
+}
@@ -0,0 +1,221 @@
+package network
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestIPIteratorMatchesExpandCIDR(t *testing.T) {
+	tests := []struct {
+		name      string
+		cidr      string
+		wantCount int
+		wantFirst string
+		wantLast  string
+	}{
+		{name: "/30", cidr: "192.168.1.0/30", wantCount: 2, wantFirst: "192.168.1.1", wantLast: "192.168.1.2"},
+		{name: "/30 mid-octet", cidr: "192.168.1.4/30", wantCount: 2, wantFirst: "192.168.1.5", wantLast: "192.168.1.6"},
+		{name: "/31 has no network or broadcast", cidr: "192.168.1.4/31", wantCount: 2, wantFirst: "192.168.1.4", wantLast: "192.168.1.5"},
+		{name: "upper /25", cidr: "192.168.1.128/25", wantCount: 126, wantFirst: "192.168.1.129", wantLast: "192.168.1.254"},
+		{name: "/24", cidr: "192.168.1.0/24", wantCount: 254, wantFirst: "192.168.1.1", wantLast: "192.168.1.254"},
+		{name: "/23 keeps .255 and .0 inside", cidr: "192.168.2.0/23", wantCount: 510, wantFirst: "192.168.2.1", wantLast: "192.168.3.254"},
+		{name: "/16 keeps .0 and .255 inside", cidr: "10.1.0.0/16", wantCount: 65534, wantFirst: "10.1.0.1", wantLast: "10.1.255.254"},
+		{name: "top of address space", cidr: "255.255.255.0/24", wantCount: 254, wantFirst: "255.255.255.1", wantLast: "255.255.255.254"},
+		{name: "single IP", cidr: "192.168.1.100", wantCount: 1, wantFirst: "192.168.1.100", wantLast: "192.168.1.100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			it, err := NewIPIterator(tt.cidr)
+			if err != nil {
+				t.Fatalf("NewIPIterator() error = %v", err)
+			}
+			var got []string
+			for ip, ok := it.Next(); ok; ip, ok = it.Next() {
+				got = append(got, ip)
+			}
+			if len(got) != tt.wantCount {
+				t.Fatalf("got %d addresses, want %d", len(got), tt.wantCount)
+			}
+			if got[0] != tt.wantFirst || got[len(got)-1] != tt.wantLast {
+				t.Errorf("range = %s..%s, want %s..%s", got[0], got[len(got)-1], tt.wantFirst, tt.wantLast)
+			}
+			if n, _ := CountCIDR(tt.cidr); n != tt.wantCount {
+				t.Errorf("CountCIDR() = %d, want %d", n, tt.wantCount)
+			}
+		})
+	}
+}
+
+// fakeNetwork answers dials to alive hosts after rtt with a refused
+// connection and leaves dials to anything else hanging until the deadline
+type fakeNetwork struct {
+	alive map[string]bool
+	rtt   time.Duration
+
+	mu    sync.Mutex
+	dials int
+}
+
+func (n *fakeNetwork) dial(ctx context.Context, _, addr string) (net.Conn, error) {
+	n.mu.Lock()
+	n.dials++
+	n.mu.Unlock()
+
+	host, _, _ := net.SplitHostPort(addr)
+	if n.alive[host] {
+		select {
+		case <-time.After(n.rtt):
+			return nil, fmt.Errorf("dial %s: %w", addr, syscall.ECONNREFUSED)
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+	<-ctx.Done()
+	return nil, ctx.Err()
+}
+
+func newFakeChecker(n *fakeNetwork, timeout, minTimeout time.Duration) *AliveChecker {
+	return &AliveChecker{
+		Ports:      DefaultAlivePorts,
+		Timeout:    timeout,
+		MinTimeout: minTimeout,
+		dial:       n.dial,
+		ping:       func(string, time.Duration) bool { return false },
+	}
+}
+
+func TestScanSubnet(t *testing.T) {
+	fake := &fakeNetwork{
+		alive: map[string]bool{"192.168.1.10": true, "192.168.1.20": true, "192.168.1.30": true},
+		rtt:   time.Millisecond,
+	}
+
+	tests := []struct {
+		name     string
+		cidr     string
+		excludes []string
+		want     []string
+		wantErr  bool
+	}{
+		{name: "finds alive hosts", cidr: "192.168.1.0/27", want: []string{"192.168.1.10", "192.168.1.20", "192.168.1.30"}},
+		{name: "honours excludes", cidr: "192.168.1.0/27", excludes: []string{"192.168.1.20", "192.168.1.24/29"}, want: []string{"192.168.1.10"}},
+		{name: "invalid CIDR", cidr: "nope", wantErr: true},
+		{name: "invalid exclude", cidr: "192.168.1.0/27", excludes: []string{"nope"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ScanSubnet(tt.cidr, tt.excludes, 16, newFakeChecker(fake, 50*time.Millisecond, 5*time.Millisecond))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ScanSubnet() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			sort.Strings(got)
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ScanSubnet() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanSubnetContextCancelled(t *testing.T) {
+	fake := &fakeNetwork{alive: map[string]bool{}, rtt: time.Millisecond}
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(20*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := ScanSubnetContext(ctx, "10.0.0.0/16", nil, 4, newFakeChecker(fake, 50*time.Millisecond, 5*time.Millisecond))
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("ScanSubnetContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("ScanSubnetContext() took %v after cancel, want it to stop promptly", elapsed)
+	}
+}
+
+func TestAliveCheckerAdaptiveTimeout(t *testing.T) {
+	tests := []struct {
+		name   string
+		maxRTT time.Duration
+		want   time.Duration
+	}{
+		{name: "nothing answered yet", maxRTT: 0, want: time.Second},
+		{name: "fast LAN hits the floor", maxRTT: time.Millisecond, want: MinAliveTimeout},
+		{name: "scaled from slowest answer", maxRTT: 100 * time.Millisecond, want: 400 * time.Millisecond},
+		{name: "capped at Timeout", maxRTT: 500 * time.Millisecond, want: time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := NewAliveChecker(time.Second, false)
+			c.observe(tt.maxRTT)
+			if got := c.currentTimeout(); got != tt.want {
+				t.Errorf("currentTimeout() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// BenchmarkScanSubnet compares the original expand-then-probe path (every
+// address materialised, ports dialed one after another with the full
+// timeout) against ScanSubnet on a sparse /24 with 2% of hosts up.
+func BenchmarkScanSubnet(b *testing.B) {
+	const (
+		cidr    = "10.0.0.0/24"
+		workers = 64
+		timeout = 100 * time.Millisecond
+	)
+	fake := &fakeNetwork{alive: make(map[string]bool), rtt: time.Millisecond}
+	for i := 1; i <= 254; i += 50 {
+		fake.alive[fmt.Sprintf("10.0.0.%d", i)] = true
+	}
+	hosts, _ := CountCIDR(cidr)
+
+	b.Run("sequential", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			ips, _ := ExpandCIDR(cidr)
+			var wg sync.WaitGroup
+			ipChan := make(chan string, len(ips))
+			for _, ip := range ips {
+				ipChan <- ip
+			}
+			close(ipChan)
+			for w := 0; w < workers; w++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					for ip := range ipChan {
+						for _, port := range DefaultAlivePorts {
+							ctx, cancel := context.WithTimeout(context.Background(), timeout)
+							_, err := fake.dial(ctx, "tcp", net.JoinHostPort(ip, port))
+							cancel()
+							if err == nil {
+								break
+							}
+						}
+					}
+				}()
+			}
+			wg.Wait()
+		}
+		b.ReportMetric(float64(hosts*b.N)/b.Elapsed().Seconds(), "hosts/s")
+	})
+
+	b.Run("fast", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			checker := newFakeChecker(fake, timeout, 10*time.Millisecond)
+			if _, err := ScanSubnet(cidr, nil, workers, checker); err != nil {
+				b.Fatal(err)
+			}
+		}
+		b.ReportMetric(float64(hosts*b.N)/b.Elapsed().Seconds(), "hosts/s")
+	})
+}
@@ -0,0 +1,25 @@
+package network
+
+import "net"
+
+// BroadcastAddr returns the broadcast address of ip's subnet under mask, or
+// nil if ip is not IPv4 or mask is not an IPv4 mask. Masks in 16-byte form,
+// as net.ParseCIDR returns for IPv4 blocks, are accepted.
+func BroadcastAddr(ip net.IP, mask net.IPMask) net.IP {
+	ip = ip.To4()
+	if ip == nil {
+		return nil
+	}
+	if len(mask) == net.IPv6len {
+		mask = mask[12:]
+	}
+	if len(mask) != net.IPv4len {
+		return nil
+	}
+
+	bcast := make(net.IP, net.IPv4len)
+	for i := range bcast {
+		bcast[i] = ip[i] | ^mask[i]
+	}
+	return bcast
+}
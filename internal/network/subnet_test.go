@@ -0,0 +1,32 @@
+package network
+
+import (
+	"net"
+	"testing"
+)
+
+func TestBroadcastAddr(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		mask net.IPMask
+		want string
+	}{
+		{name: "/24", ip: "192.168.1.64", mask: net.CIDRMask(24, 32), want: "192.168.1.255"},
+		{name: "/23", ip: "192.168.2.10", mask: net.CIDRMask(23, 32), want: "192.168.3.255"},
+		{name: "/30", ip: "10.0.0.5", mask: net.CIDRMask(30, 32), want: "10.0.0.7"},
+		{name: "/16", ip: "172.16.4.20", mask: net.CIDRMask(16, 32), want: "172.16.255.255"},
+		{name: "/32", ip: "10.0.0.5", mask: net.CIDRMask(32, 32), want: "10.0.0.5"},
+		{name: "16-byte mask", ip: "192.168.2.10", mask: net.CIDRMask(119, 128), want: "192.168.3.255"},
+		{name: "IPv6 address", ip: "fe80::1", mask: net.CIDRMask(64, 128), want: "<nil>"},
+		{name: "no mask", ip: "192.168.1.64", mask: nil, want: "<nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := BroadcastAddr(net.ParseIP(tt.ip), tt.mask); got.String() != tt.want {
+				t.Errorf("BroadcastAddr(%s, %s) = %s, want %s", tt.ip, tt.mask, got, tt.want)
+			}
+		})
+	}
+}
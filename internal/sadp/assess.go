@@ -0,0 +1,108 @@
+package sadp
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+// Severity ranks how urgently a Finding needs attention
+type Severity int
+
+const (
+	// SeverityLow is worth knowing but often intended
+	SeverityLow Severity = iota + 1
+	// SeverityMedium widens the device's exposure
+	SeverityMedium
+	// SeverityHigh lets someone on the network take the device over
+	SeverityHigh
+)
+
+// String returns the lower-case severity name
+func (s Severity) String() string {
+	switch s {
+	case SeverityLow:
+		return "low"
+	case SeverityMedium:
+		return "medium"
+	case SeverityHigh:
+		return "high"
+	}
+	return "none"
+}
+
+// Finding IDs reported by SecurityAssessment
+const (
+	FindingInactive    = "inactive"
+	FindingLegacyReset = "legacy-reset"
+	FindingHikConnect  = "hik-connect"
+)
+
+// Finding is one security concern about a device
+type Finding struct {
+	ID       string
+	Severity Severity
+	Message  string
+}
+
+// SecurityAssessment flags the risks visible in a device's SADP reply:
+//   - not activated, so anyone who reaches it can set the admin password
+//   - firmware before 5.3.0, whose admin password can be reset with a code
+//     derived from the serial number and date (see crypto.GenerateResetCode)
+//   - Hik-Connect cloud access enabled
+//
+// Fields the device did not report are not flagged, nor is a
+// SoftwareVersion that ParseSoftwareVersion cannot read. Findings are in
+// descending severity.
+func SecurityAssessment(d *Device) []Finding {
+	var findings []Finding
+	if d.Activated == "false" {
+		findings = append(findings, Finding{
+			ID:       FindingInactive,
+			Severity: SeverityHigh,
+			Message:  "not activated: anyone on the network can set the admin password",
+		})
+	}
+	if _, _, _, ok := ParseSoftwareVersion(d.SoftwareVersion); ok && crypto.SelectResetAlgorithm(d.SoftwareVersion) == crypto.ResetAlgorithmLegacy {
+		findings = append(findings, Finding{
+			ID:       FindingLegacyReset,
+			Severity: SeverityHigh,
+			Message: fmt.Sprintf("firmware %s predates %s: the admin password can be reset with a code from the serial and date",
+				d.SoftwareVersion, crypto.LegacyResetMaxFirmware),
+		})
+	}
+	if d.HCPlatformEnable == "true" {
+		findings = append(findings, Finding{
+			ID:       FindingHikConnect,
+			Severity: SeverityMedium,
+			Message:  "Hik-Connect cloud access is enabled",
+		})
+	}
+	return findings
+}
+
+// Risk returns the highest severity among findings, or zero (which prints
+// as "none") when there are none
+func Risk(findings []Finding) Severity {
+	var risk Severity
+	for _, f := range findings {
+		if f.Severity > risk {
+			risk = f.Severity
+		}
+	}
+	return risk
+}
+
+// RiskSummary renders findings as the highest severity and the finding IDs,
+// e.g. "high: inactive, hik-connect", or "-" when there are none
+func RiskSummary(findings []Finding) string {
+	if len(findings) == 0 {
+		return "-"
+	}
+	ids := make([]string, len(findings))
+	for i, f := range findings {
+		ids[i] = f.ID
+	}
+	return Risk(findings).String() + ": " + strings.Join(ids, ", ")
+}
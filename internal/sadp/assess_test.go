@@ -0,0 +1,85 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecurityAssessment(t *testing.T) {
+	tests := []struct {
+		name     string
+		device   Device
+		wantIDs  []string
+		wantRisk Severity
+		summary  string
+	}{
+		{
+			name:    "activated current firmware",
+			device:  Device{Activated: "true", SoftwareVersion: "V5.5.80 build 190603", HCPlatformEnable: "false"},
+			summary: "-",
+		},
+		{
+			name:     "inactive legacy firmware",
+			device:   Device{Activated: "false", SoftwareVersion: "V5.2.5 build 141201"},
+			wantIDs:  []string{FindingInactive, FindingLegacyReset},
+			wantRisk: SeverityHigh,
+			summary:  "high: inactive, legacy-reset",
+		},
+		{
+			name:     "hik-connect only",
+			device:   Device{Activated: "true", SoftwareVersion: "V5.3.0 build 150513", HCPlatformEnable: "true"},
+			wantIDs:  []string{FindingHikConnect},
+			wantRisk: SeverityMedium,
+			summary:  "medium: hik-connect",
+		},
+		{
+			name:    "unparsable firmware is not flagged",
+			device:  Device{Activated: "true", SoftwareVersion: "NVR V4.x OEM build"},
+			summary: "-",
+		},
+		{
+			name:    "unreported fields are not flagged",
+			device:  Device{},
+			summary: "-",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			findings := SecurityAssessment(&tt.device)
+			var ids []string
+			for _, f := range findings {
+				ids = append(ids, f.ID)
+				if f.Message == "" {
+					t.Errorf("finding %s has no message", f.ID)
+				}
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Errorf("SecurityAssessment() IDs = %v, want %v", ids, tt.wantIDs)
+			}
+			if got := Risk(findings); got != tt.wantRisk {
+				t.Errorf("Risk() = %v, want %v", got, tt.wantRisk)
+			}
+			if got := RiskSummary(findings); got != tt.summary {
+				t.Errorf("RiskSummary() = %q, want %q", got, tt.summary)
+			}
+		})
+	}
+}
+
+func TestSeverityString(t *testing.T) {
+	tests := []struct {
+		severity Severity
+		want     string
+	}{
+		{severity: 0, want: "none"},
+		{severity: SeverityLow, want: "low"},
+		{severity: SeverityMedium, want: "medium"},
+		{severity: SeverityHigh, want: "high"},
+	}
+	for _, tt := range tests {
+		if got := tt.severity.String(); got != tt.want {
+			t.Errorf("Severity(%d).String() = %q, want %q", tt.severity, got, tt.want)
+		}
+	}
+}
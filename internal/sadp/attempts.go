@@ -0,0 +1,61 @@
+package sadp
+
+import (
+	"fmt"
+	"time"
+)
+
+// DiscoverMultiple runs Discover attempts times, gap apart, and returns the
+// union of every device seen, merged field by field. SADP runs over UDP, so
+// a single scan can easily miss a device that the next one catches.
+func (s *Scanner) DiscoverMultiple(attempts int, gap time.Duration) ([]*Device, error) {
+	devices, _, err := RepeatDiscover(s.Discover, attempts, gap)
+	return devices, err
+}
+
+// RepeatDiscover calls discover attempts times, sleeping gap between calls,
+// and merges the results by MAC. newPerAttempt[i] is the number of devices
+// first seen on attempt i+1, so everything after newPerAttempt[0] was only
+// found because of the retries.
+func RepeatDiscover(discover func() ([]*Device, error), attempts int, gap time.Duration) (devices []*Device, newPerAttempt []int, err error) {
+	if attempts < 1 {
+		return nil, nil, fmt.Errorf("attempts must be at least 1, got %d", attempts)
+	}
+
+	byMAC := make(map[string]*Device)
+	for i := 0; i < attempts; i++ {
+		if i > 0 && gap > 0 {
+			time.Sleep(gap)
+		}
+
+		found, err := discover()
+		if err != nil {
+			return nil, nil, err
+		}
+
+		added := 0
+		for _, dev := range found {
+			if existing, ok := byMAC[dev.MAC]; ok {
+				mergeDevice(existing, dev)
+				continue
+			}
+			merged := *dev
+			byMAC[dev.MAC] = &merged
+			devices = append(devices, &merged)
+			added++
+		}
+		newPerAttempt = append(newPerAttempt, added)
+	}
+
+	return devices, newPerAttempt, nil
+}
+
+// LateDiscoveries returns how many devices were only found after the
+// first attempt, given RepeatDiscover's per-attempt counts
+func LateDiscoveries(newPerAttempt []int) int {
+	late := 0
+	for i := 1; i < len(newPerAttempt); i++ {
+		late += newPerAttempt[i]
+	}
+	return late
+}
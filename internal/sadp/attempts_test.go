@@ -0,0 +1,131 @@
+package sadp
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestRepeatDiscover(t *testing.T) {
+	tests := []struct {
+		name     string
+		attempts int
+		runs     [][]*Device
+		failOn   int
+		wantMACs []string
+		wantNew  []int
+		wantErr  bool
+	}{
+		{
+			name:     "single attempt",
+			attempts: 1,
+			runs:     [][]*Device{{{MAC: "AA"}, {MAC: "BB"}}},
+			wantMACs: []string{"AA", "BB"},
+			wantNew:  []int{2},
+		},
+		{
+			name:     "later attempts add devices",
+			attempts: 3,
+			runs: [][]*Device{
+				{{MAC: "AA"}},
+				{{MAC: "AA"}, {MAC: "BB"}},
+				{{MAC: "CC"}},
+			},
+			wantMACs: []string{"AA", "BB", "CC"},
+			wantNew:  []int{1, 1, 1},
+		},
+		{
+			name:     "no new devices",
+			attempts: 2,
+			runs:     [][]*Device{{{MAC: "AA"}}, {{MAC: "AA"}}},
+			wantMACs: []string{"AA"},
+			wantNew:  []int{1, 0},
+		},
+		{
+			name:     "zero attempts",
+			attempts: 0,
+			wantErr:  true,
+		},
+		{
+			name:     "discover error",
+			attempts: 2,
+			runs:     [][]*Device{{{MAC: "AA"}}},
+			failOn:   2,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := 0
+			discover := func() ([]*Device, error) {
+				call++
+				if call == tt.failOn {
+					return nil, errors.New("no interfaces")
+				}
+				return tt.runs[call-1], nil
+			}
+
+			devices, newPerAttempt, err := RepeatDiscover(discover, tt.attempts, 0)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("RepeatDiscover() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			var macs []string
+			for _, d := range devices {
+				macs = append(macs, d.MAC)
+			}
+			if !reflect.DeepEqual(macs, tt.wantMACs) {
+				t.Errorf("MACs = %v, want %v", macs, tt.wantMACs)
+			}
+			if !reflect.DeepEqual(newPerAttempt, tt.wantNew) {
+				t.Errorf("newPerAttempt = %v, want %v", newPerAttempt, tt.wantNew)
+			}
+		})
+	}
+}
+
+func TestRepeatDiscoverMergesFields(t *testing.T) {
+	runs := [][]*Device{
+		{{MAC: "AA", DeviceType: "DS-2CD2042WD-I"}},
+		{{MAC: "AA", SoftwareVersion: "V5.5.0"}},
+	}
+	call := 0
+	discover := func() ([]*Device, error) {
+		call++
+		return runs[call-1], nil
+	}
+
+	devices, _, err := RepeatDiscover(discover, 2, 0)
+	if err != nil {
+		t.Fatalf("RepeatDiscover() error = %v", err)
+	}
+	if len(devices) != 1 {
+		t.Fatalf("got %d devices, want 1", len(devices))
+	}
+	if devices[0].DeviceType != "DS-2CD2042WD-I" || devices[0].SoftwareVersion != "V5.5.0" {
+		t.Errorf("merged device = %+v, want fields from both attempts", devices[0])
+	}
+	if runs[0][0].SoftwareVersion != "" {
+		t.Errorf("RepeatDiscover modified the first attempt's device")
+	}
+}
+
+func TestLateDiscoveries(t *testing.T) {
+	tests := []struct {
+		counts []int
+		want   int
+	}{
+		{counts: nil, want: 0},
+		{counts: []int{5}, want: 0},
+		{counts: []int{5, 2, 1}, want: 3},
+	}
+
+	for _, tt := range tests {
+		if got := LateDiscoveries(tt.counts); got != tt.want {
+			t.Errorf("LateDiscoveries(%v) = %d, want %d", tt.counts, got, tt.want)
+		}
+	}
+}
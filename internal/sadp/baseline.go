@@ -0,0 +1,106 @@
+package sadp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// BaselineEntry is one approved device in a baseline file
+type BaselineEntry struct {
+	MAC   string
+	Label string
+}
+
+// Baseline is a list of approved devices, keyed by MAC
+type Baseline []BaselineEntry
+
+// BaselineReport is the result of comparing discovered devices to a baseline
+type BaselineReport struct {
+	// Known devices were discovered and are in the baseline
+	Known []*Device
+	// Rogue devices were discovered but are not in the baseline
+	Rogue []*Device
+	// Missing entries are in the baseline but did not respond
+	Missing []BaselineEntry
+}
+
+// LoadBaseline reads a baseline file: one MAC per line, optionally followed
+// by whitespace and a label. Blank lines and lines starting with # are
+// ignored.
+func LoadBaseline(path string) (Baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return ParseBaseline(data)
+}
+
+// ParseBaseline parses the baseline file format described in LoadBaseline
+func ParseBaseline(data []byte) (Baseline, error) {
+	var baseline Baseline
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		mac := network.NormalizeMAC(fields[0])
+		if !network.IsValidMAC(mac) {
+			return nil, fmt.Errorf("baseline line %d: invalid MAC %q", line, fields[0])
+		}
+		baseline = append(baseline, BaselineEntry{
+			MAC:   mac,
+			Label: strings.TrimSpace(strings.TrimPrefix(text, fields[0])),
+		})
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+	return baseline, nil
+}
+
+// ClassifyAgainstBaseline splits devices into known and rogue, and lists
+// the baseline entries that were not discovered. Order follows the inputs.
+func ClassifyAgainstBaseline(devices []*Device, baseline Baseline) BaselineReport {
+	approved := make(map[string]bool, len(baseline))
+	for _, entry := range baseline {
+		approved[network.NormalizeMAC(entry.MAC)] = true
+	}
+
+	var report BaselineReport
+	seen := make(map[string]bool, len(devices))
+	for _, dev := range devices {
+		mac := network.NormalizeMAC(dev.MAC)
+		seen[mac] = true
+		if approved[mac] {
+			report.Known = append(report.Known, dev)
+		} else {
+			report.Rogue = append(report.Rogue, dev)
+		}
+	}
+
+	for _, entry := range baseline {
+		if !seen[network.NormalizeMAC(entry.MAC)] {
+			report.Missing = append(report.Missing, entry)
+		}
+	}
+
+	return report
+}
+
+// MissingDevices returns placeholder devices for the missing baseline
+// entries so they can be written with the normal output formats
+func (r BaselineReport) MissingDevices() []*Device {
+	devices := make([]*Device, len(r.Missing))
+	for i, entry := range r.Missing {
+		devices[i] = &Device{MAC: entry.MAC, DeviceDescription: entry.Label}
+	}
+	return devices
+}
@@ -0,0 +1,97 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseBaseline(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    Baseline
+		wantErr bool
+	}{
+		{
+			name: "macs with labels and comments",
+			data: "# approved cameras\n4c-bd-8f-61-cc-5c front door\n\nAA:BB:CC:DD:EE:FF\n",
+			want: Baseline{
+				{MAC: "4C:BD:8F:61:CC:5C", Label: "front door"},
+				{MAC: "AA:BB:CC:DD:EE:FF", Label: ""},
+			},
+		},
+		{name: "empty", data: "", want: nil},
+		{name: "invalid mac", data: "not-a-mac\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBaseline([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBaseline() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseBaseline() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClassifyAgainstBaseline(t *testing.T) {
+	known := &Device{MAC: "4C:BD:8F:61:CC:5C"}
+	knownDashes := &Device{MAC: "aa-bb-cc-dd-ee-ff"}
+	rogue := &Device{MAC: "11:22:33:44:55:66"}
+	baseline := Baseline{
+		{MAC: "4C:BD:8F:61:CC:5C", Label: "front door"},
+		{MAC: "AA:BB:CC:DD:EE:FF"},
+		{MAC: "00:00:00:00:00:01", Label: "warehouse"},
+	}
+
+	tests := []struct {
+		name        string
+		devices     []*Device
+		baseline    Baseline
+		wantKnown   []*Device
+		wantRogue   []*Device
+		wantMissing []BaselineEntry
+	}{
+		{
+			name:        "mixed",
+			devices:     []*Device{known, rogue, knownDashes},
+			baseline:    baseline,
+			wantKnown:   []*Device{known, knownDashes},
+			wantRogue:   []*Device{rogue},
+			wantMissing: []BaselineEntry{{MAC: "00:00:00:00:00:01", Label: "warehouse"}},
+		},
+		{
+			name:      "empty baseline makes everything rogue",
+			devices:   []*Device{known, rogue},
+			baseline:  nil,
+			wantRogue: []*Device{known, rogue},
+		},
+		{
+			name:        "nothing discovered",
+			devices:     nil,
+			baseline:    baseline[:1],
+			wantMissing: []BaselineEntry{{MAC: "4C:BD:8F:61:CC:5C", Label: "front door"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report := ClassifyAgainstBaseline(tt.devices, tt.baseline)
+			if !reflect.DeepEqual(report.Known, tt.wantKnown) {
+				t.Errorf("Known = %v, want %v", report.Known, tt.wantKnown)
+			}
+			if !reflect.DeepEqual(report.Rogue, tt.wantRogue) {
+				t.Errorf("Rogue = %v, want %v", report.Rogue, tt.wantRogue)
+			}
+			if !reflect.DeepEqual(report.Missing, tt.wantMissing) {
+				t.Errorf("Missing = %v, want %v", report.Missing, tt.wantMissing)
+			}
+			if got := report.MissingDevices(); len(got) != len(tt.wantMissing) {
+				t.Errorf("MissingDevices() returned %d, want %d", len(got), len(tt.wantMissing))
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package sadp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// DefaultMinRemainingAttempts is the remaining-attempts level at which a
+// batch stops retrying a device
+const DefaultMinRemainingAttempts = 2
+
+// BatchTarget is one device addressed by a batch send
+type BatchTarget struct {
+	IP  string
+	MAC string
+}
+
+// sendOptions returns base addressed to the target
+func (t BatchTarget) sendOptions(base SendOptions) SendOptions {
+	opts := base
+	opts.TargetIP = t.IP
+	if t.MAC != "" {
+		opts.TargetMAC = t.MAC
+	}
+	return opts
+}
+
+// BatchOptions controls retries in SendBatch
+type BatchOptions struct {
+	// Attempts is the maximum number of sends per device (minimum 1)
+	Attempts int
+	// Interval is the delay between attempts on the same device
+	Interval time.Duration
+	// MinRemainingAttempts stops retrying a device once it reports this many
+	// or fewer password attempts left
+	MinRemainingAttempts int
+}
+
+// BatchResult is the outcome of a batch send to one device
+type BatchResult struct {
+	Target   BatchTarget
+	Attempts int
+	// Result is the last parsed response, nil if the device never answered
+	Result *CommandResult
+	Err    error
+	// StoppedForLockout is set when retries were abandoned because the
+	// device is locked or close to locking
+	StoppedForLockout bool
+}
+
+// LoadBatchTargets reads a targets file: one IP per line, optionally
+// followed by whitespace and a MAC. Blank lines and lines starting with #
+// are ignored.
+func LoadBatchTargets(path string) ([]BatchTarget, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	return ParseBatchTargets(data)
+}
+
+// ParseBatchTargets parses the targets file format described in
+// LoadBatchTargets
+func ParseBatchTargets(data []byte) ([]BatchTarget, error) {
+	var targets []BatchTarget
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		if net.ParseIP(fields[0]) == nil {
+			return nil, fmt.Errorf("targets line %d: invalid IP %q", line, fields[0])
+		}
+		target := BatchTarget{IP: fields[0]}
+		if len(fields) > 1 {
+			target.MAC = network.NormalizeMAC(fields[1])
+			if !network.IsValidMAC(target.MAC) {
+				return nil, fmt.Errorf("targets line %d: invalid MAC %q", line, fields[1])
+			}
+		}
+		targets = append(targets, target)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read targets: %w", err)
+	}
+	return targets, nil
+}
+
+// SendBatch sends a command to each target in turn, retrying failures up to
+// BatchOptions.Attempts. Retries stop early when the device rejects the
+// password or reports that it is locked or low on attempts, so a wrong
+// password does not lock the device out.
+func (s *Scanner) SendBatch(cmdName string, base SendOptions, targets []BatchTarget, bopts BatchOptions) ([]BatchResult, error) {
+	// Surface bad arguments before anything is sent
+	for _, target := range targets {
+		if _, err := s.BuildCommandXML(cmdName, target.sendOptions(base)); err != nil {
+			return nil, fmt.Errorf("%s: %w", target.IP, err)
+		}
+	}
+	return s.sendBatch(targets, base, bopts, func(opts SendOptions) (string, error) {
+		return s.SendCommand(cmdName, opts)
+	}), nil
+}
+
+func (s *Scanner) sendBatch(targets []BatchTarget, base SendOptions, bopts BatchOptions, send func(SendOptions) (string, error)) []BatchResult {
+	attempts := bopts.Attempts
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	results := make([]BatchResult, 0, len(targets))
+	for _, target := range targets {
+		opts := target.sendOptions(base)
+		res := BatchResult{Target: target}
+		for res.Attempts < attempts {
+			if res.Attempts > 0 && bopts.Interval > 0 {
+				time.Sleep(bopts.Interval)
+			}
+			res.Attempts++
+
+			response, err := send(opts)
+			if err != nil {
+				res.Err = err
+				continue
+			}
+			res.Err = nil
+			res.Result = s.ParseCommandResult(response)
+			if res.Result.Success {
+				break
+			}
+			if res.Result.LockoutRisk(bopts.MinRemainingAttempts) {
+				res.StoppedForLockout = true
+				break
+			}
+			if res.Result.PasswordRejected() {
+				// Resending the same wrong password only burns attempts
+				break
+			}
+		}
+
+		s.log.Debugw("Batch target done", "ip", target.IP, "attempts", res.Attempts,
+			"stoppedForLockout", res.StoppedForLockout)
+		results = append(results, res)
+	}
+	return results
+}
@@ -0,0 +1,198 @@
+package sadp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func TestParseBatchTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []BatchTarget
+		wantErr bool
+	}{
+		{
+			name: "ip and optional mac",
+			data: "# cameras\n192.168.1.64 4c-bd-8f-61-cc-5c\n\n192.168.1.65\n",
+			want: []BatchTarget{
+				{IP: "192.168.1.64", MAC: "4C:BD:8F:61:CC:5C"},
+				{IP: "192.168.1.65"},
+			},
+		},
+		{
+			name:    "invalid ip",
+			data:    "camera-1\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid mac",
+			data:    "192.168.1.64 nope\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseBatchTargets([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseBatchTargets() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %+v, want %+v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("target[%d] = %+v, want %+v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseCommandResultLockout(t *testing.T) {
+	tests := []struct {
+		name          string
+		response      string
+		wantLocked    bool
+		wantRemaining int
+		wantRisk      bool
+	}{
+		{
+			name:          "remaining attempts",
+			response:      `<ProbeMatch><Result>failed</Result><PWErrorParse>1</PWErrorParse><RetryTimes>4</RetryTimes></ProbeMatch>`,
+			wantRemaining: 4,
+		},
+		{
+			name:          "low on attempts",
+			response:      `<ProbeMatch><Result>failed</Result><ResidualTimes>2</ResidualTimes></ProbeMatch>`,
+			wantRemaining: 2,
+			wantRisk:      true,
+		},
+		{
+			name:          "locked with unlock time",
+			response:      `<ProbeMatch><Result>failed</Result><LockStatus>lock</LockStatus><UnlockTime>1800</UnlockTime></ProbeMatch>`,
+			wantLocked:    true,
+			wantRemaining: -1,
+			wantRisk:      true,
+		},
+		{
+			name:          "no attempts left",
+			response:      `<ProbeMatch><Result>failed</Result><RetryTimes>0</RetryTimes></ProbeMatch>`,
+			wantLocked:    true,
+			wantRemaining: 0,
+			wantRisk:      true,
+		},
+		{
+			name:          "no lockout info",
+			response:      `<ProbeMatch><Result>success</Result></ProbeMatch>`,
+			wantRemaining: -1,
+		},
+	}
+
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.ParseCommandResult(tt.response)
+			if result.Locked != tt.wantLocked {
+				t.Errorf("Locked = %v, want %v", result.Locked, tt.wantLocked)
+			}
+			if result.RemainingAttempts != tt.wantRemaining {
+				t.Errorf("RemainingAttempts = %d, want %d", result.RemainingAttempts, tt.wantRemaining)
+			}
+			if got := result.LockoutRisk(DefaultMinRemainingAttempts); got != tt.wantRisk {
+				t.Errorf("LockoutRisk() = %v, want %v", got, tt.wantRisk)
+			}
+		})
+	}
+}
+
+func TestSendBatchStopsOnLockout(t *testing.T) {
+	const (
+		failed   = `<ProbeMatch><Result>failed</Result></ProbeMatch>`
+		lowLeft  = `<ProbeMatch><Result>failed</Result><RetryTimes>1</RetryTimes></ProbeMatch>`
+		pwError  = `<ProbeMatch><Result>failed</Result><PWErrorParse>1</PWErrorParse><RetryTimes>5</RetryTimes></ProbeMatch>`
+		success  = `<ProbeMatch><Result>success</Result></ProbeMatch>`
+		attempts = 3
+	)
+
+	tests := []struct {
+		name          string
+		responses     []string
+		errs          []error
+		wantAttempts  int
+		wantStopped   bool
+		wantSuccess   bool
+		wantErr       bool
+		wantNilResult bool
+	}{
+		{
+			name:         "success first time",
+			responses:    []string{success},
+			wantAttempts: 1,
+			wantSuccess:  true,
+		},
+		{
+			name:         "retries generic failure",
+			responses:    []string{failed, failed, success},
+			wantAttempts: 3,
+			wantSuccess:  true,
+		},
+		{
+			name:         "stops when low on attempts",
+			responses:    []string{lowLeft, success},
+			wantAttempts: 1,
+			wantStopped:  true,
+		},
+		{
+			name:         "does not resend a rejected password",
+			responses:    []string{pwError, success},
+			wantAttempts: 1,
+		},
+		{
+			name:          "no response on every attempt",
+			errs:          []error{errors.New("timeout"), errors.New("timeout"), errors.New("timeout")},
+			wantAttempts:  attempts,
+			wantErr:       true,
+			wantNilResult: true,
+		},
+	}
+
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			call := 0
+			send := func(SendOptions) (string, error) {
+				defer func() { call++ }()
+				if call < len(tt.errs) {
+					return "", tt.errs[call]
+				}
+				return tt.responses[call], nil
+			}
+
+			results := s.sendBatch([]BatchTarget{{IP: "192.168.1.64"}}, SendOptions{},
+				BatchOptions{Attempts: attempts, MinRemainingAttempts: DefaultMinRemainingAttempts}, send)
+			if len(results) != 1 {
+				t.Fatalf("got %d results, want 1", len(results))
+			}
+			res := results[0]
+			if res.Attempts != tt.wantAttempts {
+				t.Errorf("Attempts = %d, want %d", res.Attempts, tt.wantAttempts)
+			}
+			if res.StoppedForLockout != tt.wantStopped {
+				t.Errorf("StoppedForLockout = %v, want %v", res.StoppedForLockout, tt.wantStopped)
+			}
+			if (res.Err != nil) != tt.wantErr {
+				t.Errorf("Err = %v, wantErr %v", res.Err, tt.wantErr)
+			}
+			if (res.Result == nil) != tt.wantNilResult {
+				t.Fatalf("Result = %+v, wantNil %v", res.Result, tt.wantNilResult)
+			}
+			if res.Result != nil && res.Result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v", res.Result.Success, tt.wantSuccess)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package sadp
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+// ErrNoEncryptString is returned by DecryptChallenge for a reply that has
+// no <EncryptString>, such as a failure reply
+var ErrNoEncryptString = errors.New("response has no <EncryptString>")
+
+// ChallengeCommands are the commands whose replies carry an encrypted
+// challenge for DecryptChallenge
+var ChallengeCommands = map[string]bool{
+	"exchangecode":         true,
+	"getencryptstring":     true,
+	"getencryptstring_v31": true,
+}
+
+// DecryptChallenge returns the cleartext of the <EncryptString> in a
+// ProbeMatch, which devices send base64 encoded and AES-ECB encrypted with
+// the key aesKeyHex. The zero padding of the last block is removed.
+func DecryptChallenge(response, aesKeyHex string) (string, error) {
+	encoded := elementText(response, "EncryptString")
+	if encoded == "" {
+		return "", ErrNoEncryptString
+	}
+
+	data, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("malformed <EncryptString>: %w", err)
+	}
+	plaintext, err := crypto.DecryptAESString(data, aesKeyHex)
+	if err != nil {
+		return "", fmt.Errorf("<EncryptString>: %w (wrong AES key?)", err)
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,98 @@
+package sadp
+
+import (
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+const testAESKeyHex = "279977f62f6cfd2d91cd75b889ce0c9a"
+
+// encryptChallenge AES-ECB encrypts plaintext, zero padded to the block
+// size, and base64 encodes it as devices do
+func encryptChallenge(t *testing.T, plaintext string) string {
+	t.Helper()
+	key, _ := hex.DecodeString(testAESKeyHex)
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := []byte(plaintext)
+	for len(data)%aes.BlockSize != 0 {
+		data = append(data, 0)
+	}
+	out := make([]byte, len(data))
+	for i := 0; i < len(data); i += aes.BlockSize {
+		block.Encrypt(out[i:i+aes.BlockSize], data[i:i+aes.BlockSize])
+	}
+	return base64.StdEncoding.EncodeToString(out)
+}
+
+func TestDecryptChallenge(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		keyHex   string
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "decrypts and strips padding",
+			response: "<ProbeMatch><Types>getencryptstring</Types><EncryptString>" + encryptChallenge(t, "ABCD1234EFGH") + "</EncryptString></ProbeMatch>",
+			keyHex:   testAESKeyHex,
+			want:     "ABCD1234EFGH",
+		},
+		{
+			name:     "whole blocks",
+			response: "<ProbeMatch><EncryptString>" + encryptChallenge(t, "0123456789abcdef") + "</EncryptString></ProbeMatch>",
+			keyHex:   testAESKeyHex,
+			want:     "0123456789abcdef",
+		},
+		{
+			name:     "no encrypt string",
+			response: "<ProbeMatch><Types>exchangecode</Types><Result>failed</Result></ProbeMatch>",
+			keyHex:   testAESKeyHex,
+			wantErr:  ErrNoEncryptString,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecryptChallenge(tt.response, tt.keyHex)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("DecryptChallenge() error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("DecryptChallenge() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("DecryptChallenge() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecryptChallengeErrors(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		keyHex   string
+	}{
+		{name: "not base64", response: "<EncryptString>***</EncryptString>", keyHex: testAESKeyHex},
+		{name: "wrong key", response: "<EncryptString>" + encryptChallenge(t, "ABCD1234EFGH") + "</EncryptString>", keyHex: "00112233445566778899aabbccddeeff"},
+		{name: "bad key", response: "<EncryptString>" + encryptChallenge(t, "ABCD1234EFGH") + "</EncryptString>", keyHex: "zz"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DecryptChallenge(tt.response, tt.keyHex); err == nil || errors.Is(err, ErrNoEncryptString) {
+				t.Errorf("DecryptChallenge() error = %v, want a decryption error", err)
+			}
+		})
+	}
+}
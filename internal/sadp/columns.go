@@ -0,0 +1,108 @@
+package sadp
+
+import "strconv"
+
+// DeviceColumn is one column of the tabular exports (CSV and HTML)
+type DeviceColumn struct {
+	Name string
+	// FreeText marks user-supplied values that may contain separators, which
+	// CSV quotes
+	FreeText bool
+	// Value renders the column for the device at position index (0-based)
+	Value func(index int, dev *Device) string
+}
+
+// baseColumns are always exported, in CSV header order
+var baseColumns = []DeviceColumn{
+	{Name: "ID", Value: func(i int, _ *Device) string { return strconv.Itoa(i + 1) }},
+	{Name: "DeviceType", Value: func(_ int, d *Device) string { return d.DeviceType }},
+	{Name: "Activated", Value: func(_ int, d *Device) string { return d.Activated }},
+	{Name: "IPv4Address", Value: func(_ int, d *Device) string { return d.IPv4Address }},
+	{Name: "Port", Value: func(_ int, d *Device) string { return strconv.Itoa(int(d.CommandPort)) }},
+	{Name: "HttpPort", Value: func(_ int, d *Device) string { return strconv.Itoa(int(d.HttpPort)) }},
+	{Name: "SoftwareVersion", Value: func(_ int, d *Device) string { return d.SoftwareVersion }},
+	{Name: "IPv4Gateway", Value: func(_ int, d *Device) string { return d.IPv4Gateway }},
+	{Name: "SerialNumber", Value: func(_ int, d *Device) string { return d.DeviceSN }},
+	{Name: "IPv4SubnetMask", Value: func(_ int, d *Device) string { return d.IPv4SubnetMask }},
+	{Name: "MAC", Value: func(_ int, d *Device) string { return d.MAC }},
+	{Name: "ChannelNum", Value: func(_ int, d *Device) string { return strconv.Itoa(d.AnalogChannelNum + d.DigitalChannelNum) }},
+	{Name: "AnalogChannelNum", Value: func(_ int, d *Device) string { return strconv.Itoa(d.AnalogChannelNum) }},
+	{Name: "DigitalChannelNum", Value: func(_ int, d *Device) string { return strconv.Itoa(d.DigitalChannelNum) }},
+	{Name: "DSPVersion", Value: func(_ int, d *Device) string { return d.DSPVersion }},
+	{Name: "BootTime", Value: func(_ int, d *Device) string { return d.BootTime }},
+	{Name: "DHCP", Value: func(_ int, d *Device) string { return d.DHCP }},
+	{Name: "SDKOverTLSPort", Value: func(_ int, d *Device) string { return strconv.Itoa(int(d.SDKOverTLSPort)) }},
+	{Name: "SDKServerStatus", Value: func(_ int, d *Device) string { return d.SDKServerStatus }},
+	{Name: "ReceivedTime", Value: func(_ int, d *Device) string { return formatReceivedTime(d.ReceivedTime) }},
+}
+
+// adapterColumn is the local address the device answered on
+var adapterColumn = DeviceColumn{Name: "AdapterIP", Value: func(_ int, d *Device) string { return d.AdapterIP }}
+
+var hostnameColumn = DeviceColumn{Name: "Hostname", FreeText: true, Value: func(_ int, d *Device) string { return d.Hostname }}
+
+// arpStatusColumn is the result of cross-checking the device against ARP
+var arpStatusColumn = DeviceColumn{Name: "ARPStatus", Value: func(_ int, d *Device) string { return d.ARPStatus }}
+
+var annotationColumns = []DeviceColumn{
+	{Name: "Site", FreeText: true, Value: func(_ int, d *Device) string { return d.Site }},
+	{Name: "Tags", FreeText: true, Value: func(_ int, d *Device) string { return d.Tags.String() }},
+}
+
+// DeviceColumns returns the export columns for devices. AdapterIP is added
+// only when some device records the adapter it answered on, Hostname only
+// when some device was resolved, ARPStatus only when some device was checked
+// against ARP, and Site and Tags only when some device carries an
+// annotation, so files loaded from older exports keep the base columns.
+func DeviceColumns(devices []*Device) []DeviceColumn {
+	annotated, resolved, adapter, arp := false, false, false, false
+	for _, dev := range devices {
+		if dev.AdapterIP != "" {
+			adapter = true
+		}
+		if dev.Site != "" || len(dev.Tags) > 0 {
+			annotated = true
+		}
+		if dev.Hostname != "" {
+			resolved = true
+		}
+		if dev.ARPStatus != "" {
+			arp = true
+		}
+	}
+
+	columns := append([]DeviceColumn(nil), baseColumns...)
+	if adapter {
+		columns = append(columns, adapterColumn)
+	}
+	if resolved {
+		columns = append(columns, hostnameColumn)
+	}
+	if arp {
+		columns = append(columns, arpStatusColumn)
+	}
+	if annotated {
+		columns = append(columns, annotationColumns...)
+	}
+	return columns
+}
+
+// columnByName finds an export column by its header name
+func columnByName(name string) (DeviceColumn, bool) {
+	optional := append([]DeviceColumn{adapterColumn, hostnameColumn, arpStatusColumn}, annotationColumns...)
+	for _, col := range append(append([]DeviceColumn(nil), baseColumns...), optional...) {
+		if col.Name == name {
+			return col, true
+		}
+	}
+	return DeviceColumn{}, false
+}
+
+// csvValue renders the column for CSV, quoting free text
+func (c DeviceColumn) csvValue(index int, dev *Device) string {
+	value := c.Value(index, dev)
+	if c.FreeText {
+		value = csvQuote(value)
+	}
+	return value
+}
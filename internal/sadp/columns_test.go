@@ -0,0 +1,43 @@
+package sadp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDeviceColumns(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*Device
+		wantEnd string
+		wantLen int
+	}{
+		{name: "plain scan", devices: testDevices(), wantEnd: "ReceivedTime", wantLen: 20},
+		{name: "resolved", devices: []*Device{{Hostname: "cam1.local"}}, wantEnd: "Hostname", wantLen: 21},
+		{name: "ARP verified", devices: []*Device{{ARPStatus: "verified"}}, wantEnd: "ARPStatus", wantLen: 21},
+		{name: "live scan", devices: []*Device{{AdapterIP: "192.168.1.10"}}, wantEnd: "AdapterIP", wantLen: 21},
+		{name: "annotated", devices: []*Device{{Tags: Tags{{Key: "env", Value: "prod"}}}}, wantEnd: "Tags", wantLen: 22},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			columns := DeviceColumns(tt.devices)
+			if len(columns) != tt.wantLen {
+				t.Fatalf("got %d columns, want %d", len(columns), tt.wantLen)
+			}
+			if last := columns[len(columns)-1].Name; last != tt.wantEnd {
+				t.Errorf("last column = %q, want %q", last, tt.wantEnd)
+			}
+
+			names := make([]string, len(columns))
+			for i, col := range columns {
+				names[i] = col.Name
+			}
+			s := NewScanner(DefaultTimeout, nil)
+			header, _, _ := strings.Cut(s.ToCSV(tt.devices), "\n")
+			if got := strings.Join(names, ","); got != header {
+				t.Errorf("columns %q do not match CSV header %q", got, header)
+			}
+		})
+	}
+}
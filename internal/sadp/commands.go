@@ -1,13 +1,17 @@
 package sadp
 
 import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
 	"sync"
 	"time"
 
-	"github.com/google/uuid"
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
 )
 
 // Command represents a SADP command template
@@ -17,6 +21,38 @@ type Command struct {
 	Template    string
 	NeedsMAC    bool
 	NeedsPass   bool
+	// VerifyCodeTemplate is used instead of Template when the caller supplies
+	// the Hik-Connect/EZVIZ verification code (the sticker code). Only the
+	// binding-related commands accept it.
+	VerifyCodeTemplate string
+	// DefaultTimeout is how long to wait for a reply when the caller does
+	// not set SendOptions.Timeout. Zero means DefaultCommandTimeout.
+	DefaultTimeout time.Duration
+}
+
+// ErrNoResponse is wrapped by the error of a command that timed out waiting
+// for a reply
+var ErrNoResponse = errors.New("no response")
+
+// DefaultCommandTimeout is the reply timeout of commands without their own
+// DefaultTimeout
+const DefaultCommandTimeout = 5 * time.Second
+
+// ResponseTimeout returns how long to wait for a reply to c
+func (c Command) ResponseTimeout() time.Duration {
+	if c.DefaultTimeout > 0 {
+		return c.DefaultTimeout
+	}
+	return DefaultCommandTimeout
+}
+
+// commandTimeout returns override, or the default timeout of cmdName when
+// override is zero
+func commandTimeout(cmdName string, override time.Duration) time.Duration {
+	if override > 0 {
+		return override
+	}
+	return Commands[cmdName].ResponseTimeout()
 }
 
 // Commands is the list of available SADP commands
@@ -62,41 +98,51 @@ var Commands = map[string]Command{
 		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>activate</Types><Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 15 * time.Second,
 	},
 	"update": {
 		Name:        "update",
 		Description: "Update device network parameters",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><Types>update</Types><PWErrorParse>true</PWErrorParse><MAC>%s</MAC><Password>%s</Password><IPv4Address>%s</IPv4Address><CommandPort>%d</CommandPort><IPv4SubnetMask>%s</IPv4SubnetMask><IPv4Gateway>%s</IPv4Gateway><DHCP>%s</DHCP></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><Types>update</Types><PWErrorParse>true</PWErrorParse><MAC>%s</MAC>%s<Password>%s</Password><IPv4Address>%s</IPv4Address><CommandPort>%d</CommandPort><IPv4SubnetMask>%s</IPv4SubnetMask><IPv4Gateway>%s</IPv4Gateway><DHCP>%s</DHCP>%s</Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 15 * time.Second,
 	},
 	"reboot": {
 		Name:        "reboot",
 		Description: "Reboot the device",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>reboot</Types><Password>%s</Password></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>reboot</Types>%s<Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 10 * time.Second,
 	},
 	"restore": {
 		Name:        "restore",
 		Description: "Restore device to factory defaults",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>restore</Types><Password>%s</Password></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>restore</Types>%s<Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 30 * time.Second,
 	},
 	"setmailbox": {
 		Name:        "setmailbox",
 		Description: "Set recovery email address",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>SetMailBox</Types><MailBox>%s</MailBox><Password>%s</Password></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>SetMailBox</Types><MailBox>%s</MailBox>%s<Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
 	},
 	"ezvizunbind": {
 		Name:        "ezvizunbind",
 		Description: "Unbind device from Ezviz cloud",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>ezvizUnbind</Types><Password>%s</Password></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>ezvizUnbind</Types>%s<Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		VerifyCodeTemplate: `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>ezvizUnbind</Types><VerifyCode>%s</VerifyCode></Probe>`,
 	},
 	"getbindlist": {
 		Name:        "getbindlist",
@@ -104,6 +150,8 @@ var Commands = map[string]Command{
 		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>getBindList</Types></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   false,
+
+		VerifyCodeTemplate: `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>getBindList</Types><VerifyCode>%s</VerifyCode></Probe>`,
 	},
 	"getqrcodes": {
 		Name:        "getqrcodes",
@@ -118,13 +166,17 @@ var Commands = map[string]Command{
 		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>resetPassword</Types><Code>%s</Code><Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 15 * time.Second,
 	},
 	"securitycode": {
 		Name:        "securitycode",
 		Description: "Submit security code for password reset",
-		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>securityCode</Types><SecurityCode>%s</SecurityCode><Password>%s</Password></Probe>`,
+		Template:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><MAC>%s</MAC><Types>securityCode</Types><SecurityCode>%s</SecurityCode>%s<Password>%s</Password></Probe>`,
 		NeedsMAC:    true,
 		NeedsPass:   true,
+
+		DefaultTimeout: 15 * time.Second,
 	},
 }
 
@@ -139,37 +191,92 @@ type SendOptions struct {
 	NewGateway string
 	NewPort    int
 	DHCP       bool
+	// DNS1 and DNS2 are only sent with update when set
+	DNS1       string
+	DNS2       string
 	Email      string
-	Timeout    time.Duration
+	VerifyCode string
+	// Answers are the security question answers for securitycode, in
+	// question order
+	Answers []string
+	// ResetMode is the device's reset method, used to check that
+	// securitycode has the fields it needs
+	ResetMode ResetMode
+	// Username is the account that authenticates update, reboot, restore,
+	// setmailbox and ezvizunbind. Empty means DefaultUsername.
+	Username string
+	// ProbeUUID replaces the random <Uuid> in the command when set
+	ProbeUUID string
+	// AllowWeakPassword sends activate without checking the password with
+	// ValidatePassword, for firmware whose rules differ
+	AllowWeakPassword bool
+	Timeout           time.Duration
 }
 
-// BuildCommandXML builds the XML for a SADP command
+// BuildCommandXML builds the XML for a SADP command. Every value from opts
+// is escaped, so a password or email containing & or < cannot break out of
+// its element.
 func (s *Scanner) BuildCommandXML(cmdName string, opts SendOptions) (string, error) {
 	cmd, ok := Commands[cmdName]
 	if !ok {
 		return "", fmt.Errorf("unknown command: %s", cmdName)
 	}
 
-	probeUUID := uuid.New().String()
+	probeUUID := newProbeUUID(opts.ProbeUUID, "")
+
+	if opts.VerifyCode != "" && cmd.VerifyCodeTemplate != "" {
+		if opts.TargetMAC == "" {
+			return "", fmt.Errorf("MAC address required for %s command", cmdName)
+		}
+		return fmt.Sprintf(cmd.VerifyCodeTemplate, xmlText(probeUUID), xmlText(opts.TargetMAC), xmlText(opts.VerifyCode)), nil
+	}
 
 	var xmlCmd string
 	switch cmdName {
 	case "inquiry", "inquiry_v32":
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID)
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID))
 	case "exchangecode", "getencryptstring", "getencryptstring_v31", "getbindlist", "getqrcodes":
 		if opts.TargetMAC == "" {
 			return "", fmt.Errorf("MAC address required for %s command", cmdName)
 		}
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID, opts.TargetMAC)
-	case "activate", "reboot", "restore", "ezvizunbind":
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC))
+	case "activate":
+		if opts.TargetMAC == "" {
+			return "", fmt.Errorf("MAC address required for %s command", cmdName)
+		}
+		if opts.Password == "" {
+			return "", fmt.Errorf("password required for %s command", cmdName)
+		}
+		if !opts.AllowWeakPassword {
+			if err := ValidatePassword(opts.Password); err != nil {
+				return "", err
+			}
+		}
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), xmlText(opts.Password))
+	case "reboot", "restore", "ezvizunbind":
 		if opts.TargetMAC == "" {
 			return "", fmt.Errorf("MAC address required for %s command", cmdName)
 		}
 		if opts.Password == "" {
 			return "", fmt.Errorf("password required for %s command", cmdName)
 		}
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID, opts.TargetMAC, opts.Password)
-	case "resetpassword", "securitycode":
+		user, err := userElement(opts.Username)
+		if err != nil {
+			return "", err
+		}
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), user, xmlText(opts.Password))
+	case "securitycode":
+		if opts.TargetMAC == "" {
+			return "", fmt.Errorf("MAC address required for %s command", cmdName)
+		}
+		if err := validateSecurityCodeOptions(opts); err != nil {
+			return "", err
+		}
+		if opts.Password == "" {
+			return "", fmt.Errorf("new password required for %s command", cmdName)
+		}
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), xmlText(opts.Code), answerElements(opts.Answers), xmlText(opts.Password))
+	case "resetpassword":
 		if opts.TargetMAC == "" {
 			return "", fmt.Errorf("MAC address required for %s command", cmdName)
 		}
@@ -179,12 +286,16 @@ func (s *Scanner) BuildCommandXML(cmdName string, opts SendOptions) (string, err
 		if opts.Password == "" {
 			return "", fmt.Errorf("new password required for %s command", cmdName)
 		}
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID, opts.TargetMAC, opts.Code, opts.Password)
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), xmlText(opts.Code), xmlText(opts.Password))
 	case "setmailbox":
 		if opts.TargetMAC == "" || opts.Password == "" || opts.Email == "" {
 			return "", fmt.Errorf("MAC, password, and email required for setmailbox command")
 		}
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID, opts.TargetMAC, opts.Email, opts.Password)
+		user, err := userElement(opts.Username)
+		if err != nil {
+			return "", err
+		}
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), xmlText(opts.Email), user, xmlText(opts.Password))
 	case "update":
 		if opts.TargetMAC == "" || opts.Password == "" {
 			return "", fmt.Errorf("MAC and password required for update command")
@@ -202,8 +313,16 @@ func (s *Scanner) BuildCommandXML(cmdName string, opts SendOptions) (string, err
 		if opts.NewPort == 0 {
 			opts.NewPort = 8000
 		}
-		xmlCmd = fmt.Sprintf(cmd.Template, probeUUID, opts.TargetMAC, opts.Password,
-			opts.NewIP, opts.NewPort, opts.NewMask, opts.NewGateway, dhcpStr)
+		dns, err := dnsElements(opts.DNS1, opts.DNS2)
+		if err != nil {
+			return "", err
+		}
+		user, err := userElement(opts.Username)
+		if err != nil {
+			return "", err
+		}
+		xmlCmd = fmt.Sprintf(cmd.Template, xmlText(probeUUID), xmlText(opts.TargetMAC), user, xmlText(opts.Password),
+			xmlText(opts.NewIP), opts.NewPort, xmlText(opts.NewMask), xmlText(opts.NewGateway), dhcpStr, dns)
 	default:
 		return "", fmt.Errorf("command %s not implemented", cmdName)
 	}
@@ -211,58 +330,247 @@ func (s *Scanner) BuildCommandXML(cmdName string, opts SendOptions) (string, err
 	return xmlCmd, nil
 }
 
+// DefaultUsername is the account password-bearing commands authenticate as
+// when SendOptions.Username is empty
+const DefaultUsername = "admin"
+
+// userElement builds the <UserName> element for username. Devices assume
+// admin when it is absent, so it is omitted for the default account and
+// existing firmware sees unchanged probes.
+func userElement(username string) (string, error) {
+	if username == "" || username == DefaultUsername {
+		return "", nil
+	}
+	if strings.TrimSpace(username) == "" {
+		return "", fmt.Errorf("username must not be blank")
+	}
+	return "<UserName>" + xmlText(username) + "</UserName>", nil
+}
+
+// xmlText escapes s for use as XML character data
+func xmlText(s string) string {
+	var b bytes.Buffer
+	_ = xml.EscapeText(&b, []byte(s))
+	return b.String()
+}
+
+// dnsElements builds the optional DNS server elements of an update probe
+func dnsElements(dns1, dns2 string) (string, error) {
+	var b strings.Builder
+	for i, dns := range []string{dns1, dns2} {
+		if dns == "" {
+			continue
+		}
+		ip := net.ParseIP(dns)
+		if ip == nil || ip.To4() == nil {
+			return "", fmt.Errorf("invalid DNS%d address %q: must be IPv4", i+1, dns)
+		}
+		fmt.Fprintf(&b, "<IPv4DNS%d>%s</IPv4DNS%d>", i+1, ip.To4(), i+1)
+	}
+	return b.String(), nil
+}
+
 // SendCommand sends a SADP command to a device and returns the response
 func (s *Scanner) SendCommand(cmdName string, opts SendOptions) (string, error) {
+	return s.SendCommandContext(context.Background(), cmdName, opts)
+}
+
+// SendCommandContext is SendCommand, giving up when ctx is done. A context
+// deadline earlier than the command timeout shortens the wait for a reply.
+func (s *Scanner) SendCommandContext(ctx context.Context, cmdName string, opts SendOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
 	xmlCmd, err := s.BuildCommandXML(cmdName, opts)
 	if err != nil {
 		return "", err
 	}
 
+	opts.Timeout = commandTimeout(cmdName, opts.Timeout)
+
 	if opts.TargetIP == "0.0.0.0" || opts.TargetIP == "" {
 		if opts.TargetMAC == "" {
 			return "", fmt.Errorf("MAC address required when target IP is 0.0.0.0")
 		}
-		return s.sendCommandBroadcastWithMAC(xmlCmd, opts)
+		return s.sendCommandBroadcastWithMAC(ctx, xmlCmd, opts)
 	}
 
 	s.log.Debugw("Sending command", "target", opts.TargetIP, "port", Port)
-	s.log.Debugw("XML command", "xml", xmlCmd)
+	s.log.Tracew("XML command", "xml", xmlCmd)
 
-	conn, err := net.DialUDP("udp4", nil, &net.UDPAddr{
-		IP:   net.ParseIP(opts.TargetIP),
-		Port: Port,
-	})
+	target := &net.UDPAddr{IP: net.ParseIP(opts.TargetIP), Port: Port}
+	if target.IP == nil {
+		return "", fmt.Errorf("invalid target IP %q", opts.TargetIP)
+	}
+
+	// An unconnected socket also receives replies from a source other than
+	// the target, e.g. a NATed device or one answering from a secondary IP,
+	// which a connected socket would silently drop
+	conn, err := listenUDP(net.IPv4zero, "", s.opts.LocalPortRange)
 	if err != nil {
-		return "", fmt.Errorf("failed to connect: %w", err)
+		return "", fmt.Errorf("failed to open socket: %w", err)
 	}
 	defer conn.Close()
+	stopCancel := context.AfterFunc(ctx, func() { _ = conn.SetDeadline(time.Now()) })
+	defer stopCancel()
 
-	timeout := opts.Timeout
-	if timeout == 0 {
-		timeout = 5 * time.Second
+	_ = conn.SetDeadline(replyDeadline(ctx, opts.Timeout))
+	if err := ctx.Err(); err != nil {
+		return "", err
 	}
-	_ = conn.SetDeadline(time.Now().Add(timeout))
 
-	_, err = conn.Write([]byte(xmlCmd))
+	_, err = conn.WriteToUDP([]byte(xmlCmd), target)
 	if err != nil {
 		return "", fmt.Errorf("failed to send command: %w", err)
 	}
 
-	buf := make([]byte, MaxPacketSize)
-	n, err := conn.Read(buf)
-	if err != nil {
-		if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
-			return "", fmt.Errorf("no response (timeout)")
+	probeUUID := elementText(xmlCmd, "Uuid")
+	buf := make([]byte, s.opts.bufferSize())
+	for {
+		n, from, err := conn.ReadFromUDP(buf)
+		if err != nil {
+			if ctxErr := ctx.Err(); ctxErr != nil {
+				return "", fmt.Errorf("no response: %w", ctxErr)
+			}
+			if netErr, ok := err.(net.Error); ok && netErr.Timeout() {
+				return "", fmt.Errorf("%w (timeout)", ErrNoResponse)
+			}
+			return "", fmt.Errorf("failed to read response: %w", err)
+		}
+
+		response := string(buf[:n])
+		if !from.IP.Equal(target.IP) {
+			if !answersCommand(response, probeUUID, opts.TargetMAC) {
+				s.log.Tracew("Ignoring unrelated packet", "from", from.String())
+				continue
+			}
+			s.log.Infow("Response came from a different address than the target",
+				"target", opts.TargetIP, "from", from.IP.String())
 		}
-		return "", fmt.Errorf("failed to read response: %w", err)
+		if possiblyTruncated(n, buf) {
+			return "", fmt.Errorf("response filled the %d byte read buffer and may be truncated", len(buf))
+		}
+
+		return response, nil
+	}
+}
+
+// replyDeadline is timeout from now, or the deadline of ctx if that is
+// sooner
+func replyDeadline(ctx context.Context, timeout time.Duration) time.Time {
+	deadline := time.Now().Add(timeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		return ctxDeadline
+	}
+	return deadline
+}
+
+// answersCommand reports whether a reply from an unexpected address belongs
+// to our command: it echoes the command UUID or carries the target MAC
+func answersCommand(response, probeUUID, targetMAC string) bool {
+	if uuid := elementText(response, "Uuid"); uuid != "" && strings.EqualFold(uuid, probeUUID) {
+		return true
+	}
+	if targetMAC == "" {
+		return false
+	}
+	mac := network.NormalizeMAC(elementText(response, "MAC"))
+	return mac != "" && mac == network.NormalizeMAC(targetMAC)
+}
+
+// elementText returns the text of the first <name> element in data
+func elementText(data, name string) string {
+	start := strings.Index(data, "<"+name+">")
+	if start == -1 {
+		return ""
+	}
+	start += len(name) + 2
+	end := strings.Index(data[start:], "</"+name+">")
+	if end == -1 {
+		return ""
+	}
+	return strings.TrimSpace(data[start : start+end])
+}
+
+// InquireDevice sends a unicast inquiry to targetIP and parses the reply
+func (s *Scanner) InquireDevice(targetIP string) (*Device, error) {
+	response, err := s.SendCommand("inquiry", SendOptions{TargetIP: targetIP, Timeout: s.timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	device := s.parseResponse(response)
+	if device == nil {
+		return nil, fmt.Errorf("unrecognized inquiry response from %s", targetIP)
+	}
+	return device, nil
+}
+
+// InquireByMAC sends an inquiry by broadcast on every interface and returns
+// the details of the device with the given MAC, so one device can be
+// refreshed, e.g. after an update changed its IP, without a full discovery
+func (s *Scanner) InquireByMAC(mac string) (*Device, error) {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil {
+		return nil, fmt.Errorf("invalid MAC address %q", mac)
 	}
+	want := strings.ToUpper(hw.String())
 
-	return string(buf[:n]), nil
+	response, err := s.SendCommand("inquiry", SendOptions{TargetMAC: want, Timeout: s.timeout})
+	if err != nil {
+		return nil, err
+	}
+
+	device := s.parseResponse(response)
+	if device == nil {
+		return nil, fmt.Errorf("unrecognized inquiry response from %s", want)
+	}
+	// The broadcast path matches the MAC anywhere in a reply, so check it
+	// is the device's own
+	if got := network.NormalizeMAC(device.MAC); got != want {
+		return nil, fmt.Errorf("inquiry for %s was answered by %s", want, got)
+	}
+	return device, nil
 }
 
-func (s *Scanner) sendCommandBroadcastWithMAC(xmlCmd string, opts SendOptions) (string, error) {
+// SendCommandUntil resends a command every interval until a response
+// arrives or ctx is done, returning the first response. It is meant for
+// polling a device that is rebooting or still coming up. Each attempt waits
+// up to opts.Timeout (or the command's default), cut short by the context
+// deadline.
+func (s *Scanner) SendCommandUntil(ctx context.Context, cmdName string, opts SendOptions, interval time.Duration) (string, error) {
+	// Surface bad arguments immediately rather than retrying them
+	if _, err := s.BuildCommandXML(cmdName, opts); err != nil {
+		return "", err
+	}
+
+	var lastErr error
+	for attempt := 1; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			if lastErr == nil {
+				lastErr = err
+			}
+			return "", fmt.Errorf("no response after %d attempt(s): %w", attempt-1, lastErr)
+		}
+
+		// The context deadline caps each attempt's wait
+		response, err := s.SendCommandContext(ctx, cmdName, opts)
+		if err == nil && response != "" {
+			return response, nil
+		}
+		lastErr = err
+		s.log.Debugw("Command attempt failed, retrying", "attempt", attempt, "error", err)
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(interval):
+		}
+	}
+}
+
+func (s *Scanner) sendCommandBroadcastWithMAC(ctx context.Context, xmlCmd string, opts SendOptions) (string, error) {
 	s.log.Debugw("Sending command via broadcast", "targetMAC", opts.TargetMAC)
-	s.log.Debugw("XML command", "xml", xmlCmd)
+	s.log.Tracew("XML command", "xml", xmlCmd)
 
 	targetMAC := strings.ToUpper(strings.ReplaceAll(opts.TargetMAC, "-", ":"))
 
@@ -272,11 +580,8 @@ func (s *Scanner) sendCommandBroadcastWithMAC(xmlCmd string, opts SendOptions) (
 	}
 
 	timeout := opts.Timeout
-	if timeout == 0 {
-		timeout = 5 * time.Second
-	}
 
-	responseChan := make(chan string, 10)
+	replies := newReplyCollector(messageTypes(xmlCmd))
 	var wg sync.WaitGroup
 
 	for _, iface := range interfaces {
@@ -306,11 +611,14 @@ func (s *Scanner) sendCommandBroadcastWithMAC(xmlCmd string, opts SendOptions) (
 
 				s.log.Debugw("Sending on interface", "interface", ifaceName, "ip", localIP.String())
 
-				conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: localIP, Port: 0})
+				conn, err := listenUDP(localIP, "", s.opts.LocalPortRange)
 				if err != nil {
+					s.log.Debugw("Failed to bind", "ip", localIP.String(), "error", err)
 					return
 				}
 				defer conn.Close()
+				stopCancel := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+				defer stopCancel()
 
 				multicastAddr := &net.UDPAddr{IP: net.ParseIP(MulticastAddr), Port: Port}
 				_, _ = conn.WriteToUDP([]byte(xmlCmd), multicastAddr)
@@ -318,46 +626,128 @@ func (s *Scanner) sendCommandBroadcastWithMAC(xmlCmd string, opts SendOptions) (
 				broadcastAddr := &net.UDPAddr{IP: net.IPv4bcast, Port: Port}
 				_, _ = conn.WriteToUDP([]byte(xmlCmd), broadcastAddr)
 
-				subnetBcast := make(net.IP, 4)
-				for i := 0; i < 4; i++ {
-					subnetBcast[i] = localIP[i] | ^ipNet.Mask[i]
+				if subnetBcast := subnetBroadcast(localIP, ipNet.Mask); subnetBcast != nil {
+					subnetBcastAddr := &net.UDPAddr{IP: subnetBcast, Port: Port}
+					_, _ = conn.WriteToUDP([]byte(xmlCmd), subnetBcastAddr)
 				}
-				subnetBcastAddr := &net.UDPAddr{IP: subnetBcast, Port: Port}
-				_, _ = conn.WriteToUDP([]byte(xmlCmd), subnetBcastAddr)
 
-				_ = conn.SetReadDeadline(time.Now().Add(timeout))
+				_ = conn.SetReadDeadline(replyDeadline(ctx, timeout))
+				if ctx.Err() != nil {
+					return
+				}
 
-				buf := make([]byte, MaxPacketSize)
+				buf := make([]byte, s.opts.bufferSize())
 				for {
 					n, _, err := conn.ReadFromUDP(buf)
 					if err != nil {
 						break
 					}
+					if possiblyTruncated(n, buf) {
+						s.log.Warnw("Dropping response that filled the read buffer and may be truncated", "bytes", n)
+						continue
+					}
 
 					response := string(buf[:n])
 
 					if strings.Contains(strings.ToUpper(response), targetMAC) ||
 						strings.Contains(strings.ToUpper(response), strings.ReplaceAll(targetMAC, ":", "-")) {
-						select {
-						case responseChan <- response:
-						default:
-						}
+						replies.add(response)
 					}
 				}
 			}(ip, iface.Name, ipNet)
 		}
 	}
 
+	allDone := make(chan struct{})
 	go func() {
 		wg.Wait()
-		close(responseChan)
+		close(allDone)
 	}()
 
-	for response := range responseChan {
+	// Stop at the first reply to the command itself; otherwise wait out the
+	// timeout on every interface and settle for any reply from the device
+	select {
+	case <-replies.answered:
+	case <-allDone:
+	case <-ctx.Done():
+	}
+
+	if response, ok := replies.best(); ok {
 		return response, nil
 	}
+	if err := ctx.Err(); err != nil {
+		return "", fmt.Errorf("no response from device with MAC %s: %w", opts.TargetMAC, err)
+	}
+	return "", fmt.Errorf("%w from device with MAC %s (timeout)", ErrNoResponse, opts.TargetMAC)
+}
+
+// replyCollector gathers the replies from a target device that arrive on
+// every interface of a broadcast send. Unlike a fixed-size channel it never
+// drops a reply, however many interfaces are listening.
+type replyCollector struct {
+	// types is the <Types> of the command sent
+	types string
+
+	mu      sync.Mutex
+	replies []string
+	// answered is closed once the reply to the command itself arrives
+	answered chan struct{}
+	closed   bool
+}
 
-	return "", fmt.Errorf("no response from device with MAC %s (timeout)", opts.TargetMAC)
+func newReplyCollector(types string) *replyCollector {
+	return &replyCollector{types: types, answered: make(chan struct{})}
+}
+
+// add records a reply; it is safe to call from several goroutines
+func (c *replyCollector) add(reply string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.replies = append(c.replies, reply)
+	if !c.closed && c.answers(reply) {
+		c.closed = true
+		close(c.answered)
+	}
+}
+
+// best returns the first reply to the command itself, or failing that the
+// first reply received, such as an unsolicited inquiry reply
+func (c *replyCollector) best() (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, reply := range c.replies {
+		if c.answers(reply) {
+			return reply, true
+		}
+	}
+	if len(c.replies) > 0 {
+		return c.replies[0], true
+	}
+	return "", false
+}
+
+// answers reports whether reply is the device's reply to the command: it
+// has the command's type or carries a <Result>
+func (c *replyCollector) answers(reply string) bool {
+	var msg commandResponse
+	if err := xml.Unmarshal([]byte(reply), &msg); err != nil {
+		return false
+	}
+	if strings.TrimSpace(msg.Result) != "" {
+		return true
+	}
+	return c.types != "" && strings.EqualFold(strings.TrimSpace(msg.Types), c.types)
+}
+
+// messageTypes returns the <Types> of a SADP message, or "" if it has none
+func messageTypes(message string) string {
+	var msg commandResponse
+	if err := xml.Unmarshal([]byte(message), &msg); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(msg.Types)
 }
 
 // ListCommands prints the list of available commands
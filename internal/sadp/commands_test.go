@@ -1,7 +1,12 @@
 package sadp
 
 import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"net"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -91,10 +96,25 @@ func TestBuildCommandXML(t *testing.T) {
 		{
 			name:    "activate with MAC and password",
 			cmdName: "activate",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "Test1234"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "activate") && strings.Contains(xml, "Test1234")
+			},
+		},
+		{
+			name:    "activate with weak password",
+			cmdName: "activate",
 			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "test123"},
+			wantErr: true,
+		},
+		{
+			name:    "activate with weak password allowed",
+			cmdName: "activate",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "test123", AllowWeakPassword: true},
 			wantErr: false,
 			check: func(xml string) bool {
-				return strings.Contains(xml, "activate") && strings.Contains(xml, "test123")
+				return strings.Contains(xml, "test123")
 			},
 		},
 		{
@@ -115,6 +135,39 @@ func TestBuildCommandXML(t *testing.T) {
 			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin"},
 			wantErr: false,
 		},
+		{
+			name:    "ezvizunbind with verify code",
+			cmdName: "ezvizunbind",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", VerifyCode: "ABCDEF"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<VerifyCode>ABCDEF</VerifyCode>") && !strings.Contains(xml, "<Password>")
+			},
+		},
+		{
+			name:    "ezvizunbind with verify code without MAC",
+			cmdName: "ezvizunbind",
+			opts:    SendOptions{VerifyCode: "ABCDEF"},
+			wantErr: true,
+		},
+		{
+			name:    "getbindlist with verify code",
+			cmdName: "getbindlist",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", VerifyCode: "ABCDEF"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "getBindList") && strings.Contains(xml, "<VerifyCode>ABCDEF</VerifyCode>")
+			},
+		},
+		{
+			name:    "verify code ignored for non-binding command",
+			cmdName: "reboot",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin", VerifyCode: "ABCDEF"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<Password>admin</Password>") && !strings.Contains(xml, "VerifyCode")
+			},
+		},
 		{
 			name:    "resetpassword without code",
 			cmdName: "resetpassword",
@@ -133,6 +186,49 @@ func TestBuildCommandXML(t *testing.T) {
 			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "newpass", Code: "ABC123"},
 			wantErr: false,
 		},
+		{
+			name:    "securitycode with answers",
+			cmdName: "securitycode",
+			opts: SendOptions{
+				TargetMAC: "AA:BB:CC:DD:EE:FF",
+				Password:  "newpass",
+				Answers:   []string{"Rex", "Paris", "Blue & Gold"},
+				ResetMode: ResetModeSecurityQuestion,
+			},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<SecurityCode></SecurityCode><Answer1>Rex</Answer1><Answer2>Paris</Answer2><Answer3>Blue &amp; Gold</Answer3><Password>")
+			},
+		},
+		{
+			name:    "securitycode question mode missing an answer",
+			cmdName: "securitycode",
+			opts: SendOptions{
+				TargetMAC: "AA:BB:CC:DD:EE:FF",
+				Password:  "newpass",
+				Code:      "ABC123",
+				Answers:   []string{"Rex", "", "Blue"},
+				ResetMode: ResetModeSecurityQuestion,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "securitycode code mode without code",
+			cmdName: "securitycode",
+			opts: SendOptions{
+				TargetMAC: "AA:BB:CC:DD:EE:FF",
+				Password:  "newpass",
+				Answers:   []string{"Rex", "Paris", "Blue"},
+				ResetMode: ResetModeSecurityCode,
+			},
+			wantErr: true,
+		},
+		{
+			name:    "securitycode without code or answers",
+			cmdName: "securitycode",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "newpass"},
+			wantErr: true,
+		},
 		{
 			name:    "setmailbox missing email",
 			cmdName: "setmailbox",
@@ -188,6 +284,83 @@ func TestBuildCommandXML(t *testing.T) {
 				return strings.Contains(xml, "10.0.0.100") && strings.Contains(xml, "9000")
 			},
 		},
+		{
+			name:    "update without DNS sends no DNS elements",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return !strings.Contains(xml, "DNS")
+			},
+		},
+		{
+			name:    "update with DNS",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin", DNS1: "8.8.8.8", DNS2: "1.1.1.1"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<DHCP>false</DHCP><IPv4DNS1>8.8.8.8</IPv4DNS1><IPv4DNS2>1.1.1.1</IPv4DNS2></Probe>")
+			},
+		},
+		{
+			name:    "update with secondary DNS only",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin", DNS2: "1.1.1.1"},
+			wantErr: false,
+			check: func(xml string) bool {
+				return !strings.Contains(xml, "IPv4DNS1") && strings.Contains(xml, "<IPv4DNS2>1.1.1.1</IPv4DNS2>")
+			},
+		},
+		{
+			name:    "update with invalid DNS",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin", DNS1: "dns.local"},
+			wantErr: true,
+		},
+		{
+			name:    "update with IPv6 DNS",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "admin", DNS1: "2001:4860:4860::8888"},
+			wantErr: true,
+		},
+		{
+			name:    "reboot as default user omits UserName",
+			cmdName: "reboot",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "secret", Username: "admin"},
+			check: func(xml string) bool {
+				return !strings.Contains(xml, "UserName") && strings.Contains(xml, "<Types>reboot</Types><Password>secret</Password>")
+			},
+		},
+		{
+			name:    "reboot as operator",
+			cmdName: "reboot",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "secret", Username: "operator"},
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<UserName>operator</UserName><Password>secret</Password>")
+			},
+		},
+		{
+			name:    "update as operator",
+			cmdName: "update",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "secret", Username: "op&1"},
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<MAC>AA:BB:CC:DD:EE:FF</MAC><UserName>op&amp;1</UserName><Password>secret</Password>")
+			},
+		},
+		{
+			name:    "setmailbox as operator",
+			cmdName: "setmailbox",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "secret", Email: "a@b.c", Username: "operator"},
+			check: func(xml string) bool {
+				return strings.Contains(xml, "<MailBox>a@b.c</MailBox><UserName>operator</UserName><Password>secret</Password>")
+			},
+		},
+		{
+			name:    "blank username",
+			cmdName: "restore",
+			opts:    SendOptions{TargetMAC: "AA:BB:CC:DD:EE:FF", Password: "secret", Username: "  "},
+			wantErr: true,
+		},
 		{
 			name:    "unknown command",
 			cmdName: "nonexistent",
@@ -372,3 +545,387 @@ func TestCommandStruct(t *testing.T) {
 		})
 	}
 }
+
+func TestCommandTimeout(t *testing.T) {
+	tests := []struct {
+		name     string
+		cmdName  string
+		override time.Duration
+		want     time.Duration
+	}{
+		{name: "fast command uses the global default", cmdName: "inquiry", want: DefaultCommandTimeout},
+		{name: "slow command uses its own default", cmdName: "restore", want: 30 * time.Second},
+		{name: "override wins over command default", cmdName: "restore", override: 2 * time.Second, want: 2 * time.Second},
+		{name: "override wins over global default", cmdName: "inquiry", override: 20 * time.Second, want: 20 * time.Second},
+		{name: "unknown command", cmdName: "nonexistent", want: DefaultCommandTimeout},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := commandTimeout(tt.cmdName, tt.override); got != tt.want {
+				t.Errorf("commandTimeout(%q, %v) = %v, want %v", tt.cmdName, tt.override, got, tt.want)
+			}
+		})
+	}
+
+	if Commands["restore"].ResponseTimeout() <= Commands["inquiry"].ResponseTimeout() {
+		t.Error("restore should wait longer than inquiry by default")
+	}
+}
+
+func TestSendCommandUntil(t *testing.T) {
+	tests := []struct {
+		name    string
+		cmdName string
+		opts    SendOptions
+		wait    time.Duration
+		wantErr string
+	}{
+		{
+			name:    "invalid command fails without retrying",
+			cmdName: "nonexistent",
+			opts:    SendOptions{TargetIP: "127.0.0.1"},
+			wait:    time.Second,
+			wantErr: "unknown command",
+		},
+		{
+			name:    "missing MAC fails without retrying",
+			cmdName: "exchangecode",
+			opts:    SendOptions{TargetIP: "127.0.0.1"},
+			wait:    time.Second,
+			wantErr: "MAC address required",
+		},
+		{
+			name:    "deadline expires with no response",
+			cmdName: "inquiry",
+			opts:    SendOptions{TargetIP: "192.0.2.1", Timeout: 50 * time.Millisecond},
+			wait:    200 * time.Millisecond,
+			wantErr: "no response after",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, logger.NewNop())
+			ctx, cancel := context.WithTimeout(context.Background(), tt.wait)
+			defer cancel()
+
+			start := time.Now()
+			_, err := s.SendCommandUntil(ctx, tt.cmdName, tt.opts, 20*time.Millisecond)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("SendCommandUntil() error = %v, want containing %q", err, tt.wantErr)
+			}
+			if elapsed := time.Since(start); elapsed > tt.wait+time.Second {
+				t.Errorf("SendCommandUntil() took %v, want at most about %v", elapsed, tt.wait)
+			}
+		})
+	}
+}
+
+func TestSendCommandUntilRetriesUntilResponse(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: Port})
+	if err != nil {
+		t.Skipf("cannot bind SADP port for test: %v", err)
+	}
+	defer conn.Close()
+
+	// Ignore the first two probes, answer the third
+	go func() {
+		buf := make([]byte, MaxPacketSize)
+		for i := 1; ; i++ {
+			_, addr, err := conn.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			if i >= 3 {
+				_, _ = conn.WriteToUDP([]byte("<ProbeMatch><Types>inquiry</Types></ProbeMatch>"), addr)
+			}
+		}
+	}()
+
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	response, err := s.SendCommandUntil(ctx, "inquiry", SendOptions{TargetIP: "127.0.0.1", Timeout: 100 * time.Millisecond}, 10*time.Millisecond)
+	if err != nil {
+		t.Fatalf("SendCommandUntil() error = %v", err)
+	}
+	if !strings.Contains(response, "ProbeMatch") {
+		t.Errorf("response = %q, want ProbeMatch", response)
+	}
+}
+
+func TestInquireDevice(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: Port})
+	if err != nil {
+		t.Skipf("cannot bind SADP port for test: %v", err)
+	}
+	defer conn.Close()
+
+	tests := []struct {
+		name    string
+		reply   string
+		wantMAC string
+		wantErr bool
+	}{
+		{
+			name:    "probe match",
+			reply:   "<ProbeMatch><MAC>4c-bd-8f-61-cc-5c</MAC><IPv4Address>127.0.0.1</IPv4Address></ProbeMatch>",
+			wantMAC: "4C:BD:8F:61:CC:5C",
+		},
+		{
+			name:    "unrecognized reply",
+			reply:   "<Other/>",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			go func(reply string) {
+				buf := make([]byte, MaxPacketSize)
+				_, addr, err := conn.ReadFromUDP(buf)
+				if err != nil {
+					return
+				}
+				_, _ = conn.WriteToUDP([]byte(reply), addr)
+			}(tt.reply)
+
+			s := NewScanner(time.Second, logger.NewNop())
+			device, err := s.InquireDevice("127.0.0.1")
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("InquireDevice() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && device.MAC != tt.wantMAC {
+				t.Errorf("MAC = %q, want %q", device.MAC, tt.wantMAC)
+			}
+		})
+	}
+}
+
+func TestSendCommandDifferentSource(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: Port})
+	if err != nil {
+		t.Skipf("cannot bind SADP port for test: %v", err)
+	}
+	defer conn.Close()
+
+	// The device answers from a secondary address
+	other, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 2)})
+	if err != nil {
+		t.Skipf("cannot bind secondary loopback address: %v", err)
+	}
+	defer other.Close()
+
+	tests := []struct {
+		name    string
+		reply   func(uuid string) string
+		mac     string
+		wantErr bool
+	}{
+		{
+			name:  "matching uuid",
+			reply: func(uuid string) string { return "<ProbeMatch><Uuid>" + uuid + "</Uuid></ProbeMatch>" },
+		},
+		{
+			name:  "matching mac",
+			reply: func(string) string { return "<ProbeMatch><MAC>4c-bd-8f-61-cc-5c</MAC></ProbeMatch>" },
+			mac:   "4C:BD:8F:61:CC:5C",
+		},
+		{
+			name:    "unrelated reply ignored",
+			reply:   func(string) string { return "<ProbeMatch><Uuid>SOMEONE-ELSE</Uuid></ProbeMatch>" },
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			go func(replyFor func(uuid string) string) {
+				buf := make([]byte, MaxPacketSize)
+				n, addr, err := conn.ReadFromUDP(buf)
+				if err != nil {
+					return
+				}
+				reply := replyFor(elementText(string(buf[:n]), "Uuid"))
+				_, _ = other.WriteToUDP([]byte(reply), addr)
+			}(tt.reply)
+
+			s := NewScanner(DefaultTimeout, logger.NewNop())
+			response, err := s.SendCommand("inquiry", SendOptions{TargetIP: "127.0.0.1", TargetMAC: tt.mac, Timeout: 300 * time.Millisecond})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("SendCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !strings.Contains(response, "ProbeMatch") {
+				t.Errorf("response = %q, want ProbeMatch", response)
+			}
+		})
+	}
+}
+
+func TestBuildCommandXMLProbeUUID(t *testing.T) {
+	tests := []struct {
+		name      string
+		probeUUID string
+	}{
+		{name: "fixed uuid", probeUUID: "deadbeef-0000-0000-0000-000000000001"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, logger.NewNop())
+			xmlCmd, err := s.BuildCommandXML("inquiry", SendOptions{ProbeUUID: tt.probeUUID})
+			if err != nil {
+				t.Fatalf("BuildCommandXML() error = %v", err)
+			}
+			if !strings.Contains(xmlCmd, "<Uuid>"+tt.probeUUID+"</Uuid>") {
+				t.Errorf("BuildCommandXML() = %q, want Uuid %s", xmlCmd, tt.probeUUID)
+			}
+		})
+	}
+}
+
+func TestBuildCommandXMLEscapesValues(t *testing.T) {
+	const mac = "4C:BD:8F:61:CC:5C"
+	tests := []struct {
+		name string
+		cmd  string
+		opts SendOptions
+	}{
+		{name: "password and email", cmd: "setmailbox", opts: SendOptions{TargetMAC: mac, Password: "a&b<c>d", Email: "ops&<x>@example.com"}},
+		{name: "injected element", cmd: "reboot", opts: SendOptions{TargetMAC: mac, Password: "x</Password><Types>restore</Types><Password>y"}},
+		{name: "code", cmd: "resetpassword", opts: SendOptions{TargetMAC: mac, Code: "<1&2>", Password: "N3w&Pass<"}},
+		{name: "verify code", cmd: "ezvizunbind", opts: SendOptions{TargetMAC: mac, VerifyCode: "AB&<CD"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, logger.NewNop())
+			xmlCmd, err := s.BuildCommandXML(tt.cmd, tt.opts)
+			if err != nil {
+				t.Fatalf("BuildCommandXML() error = %v", err)
+			}
+			var probe struct {
+				Types      []string
+				Password   string
+				MailBox    string
+				Code       string
+				VerifyCode string
+			}
+			if err := xml.Unmarshal([]byte(xmlCmd), &probe); err != nil {
+				t.Fatalf("BuildCommandXML() = %q, not well-formed: %v", xmlCmd, err)
+			}
+			if len(probe.Types) != 1 {
+				t.Errorf("Types = %q, want one element", probe.Types)
+			}
+			if probe.Password != tt.opts.Password || probe.MailBox != tt.opts.Email || probe.Code != tt.opts.Code || probe.VerifyCode != tt.opts.VerifyCode {
+				t.Errorf("decoded %+v, want the values from %+v", probe, tt.opts)
+			}
+		})
+	}
+}
+
+func TestReplyCollectorBest(t *testing.T) {
+	const (
+		hello  = `<?xml version="1.0" encoding="utf-8"?><ProbeMatch><Types>inquiry</Types><MAC>4c-bd-8f-61-cc-5c</MAC></ProbeMatch>`
+		reply  = `<?xml version="1.0" encoding="utf-8"?><ProbeMatch><Types>reboot</Types><MAC>4c-bd-8f-61-cc-5c</MAC></ProbeMatch>`
+		result = `<?xml version="1.0" encoding="utf-8"?><ProbeMatch><MAC>4c-bd-8f-61-cc-5c</MAC><Result>failed</Result></ProbeMatch>`
+	)
+
+	tests := []struct {
+		name         string
+		replies      []string
+		want         string
+		wantOK       bool
+		wantAnswered bool
+	}{
+		{name: "none", wantOK: false},
+		{name: "command reply preferred", replies: []string{hello, reply}, want: reply, wantOK: true, wantAnswered: true},
+		{name: "result counts as reply", replies: []string{hello, result}, want: result, wantOK: true, wantAnswered: true},
+		{name: "falls back to first", replies: []string{hello, "garbage"}, want: hello, wantOK: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := newReplyCollector("reboot")
+			for _, r := range tt.replies {
+				c.add(r)
+			}
+			got, ok := c.best()
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("best() = %q, %v, want %q, %v", got, ok, tt.want, tt.wantOK)
+			}
+			select {
+			case <-c.answered:
+				if !tt.wantAnswered {
+					t.Error("answered closed without a command reply")
+				}
+			default:
+				if tt.wantAnswered {
+					t.Error("answered not closed after a command reply")
+				}
+			}
+		})
+	}
+}
+
+func TestReplyCollectorConcurrent(t *testing.T) {
+	const interfaces, perInterface = 20, 50
+	c := newReplyCollector("reboot")
+	reply := `<ProbeMatch><Types>reboot</Types></ProbeMatch>`
+
+	var wg sync.WaitGroup
+	for i := 0; i < interfaces; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < perInterface; j++ {
+				c.add(reply)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(c.replies) != interfaces*perInterface {
+		t.Errorf("collected %d replies, want %d", len(c.replies), interfaces*perInterface)
+	}
+}
+
+func TestMessageTypes(t *testing.T) {
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	xmlCmd, err := s.BuildCommandXML("exchangecode", SendOptions{TargetMAC: "4c-bd-8f-61-cc-5c"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := messageTypes(xmlCmd); got != "exchangecode" {
+		t.Errorf("messageTypes() = %q, want exchangecode", got)
+	}
+	if got := messageTypes("not xml"); got != "" {
+		t.Errorf("messageTypes(invalid) = %q, want empty", got)
+	}
+}
+
+func TestInquireByMACInvalid(t *testing.T) {
+	s := NewScanner(time.Second, logger.NewNop())
+	for _, mac := range []string{"", "not-a-mac", "4C:BD:8F"} {
+		if _, err := s.InquireByMAC(mac); err == nil || !strings.Contains(err.Error(), "invalid MAC address") {
+			t.Errorf("InquireByMAC(%q) error = %v, want invalid MAC address", mac, err)
+		}
+	}
+}
+
+func TestSendCommandContextCancel(t *testing.T) {
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := s.SendCommandContext(ctx, "inquiry", SendOptions{TargetIP: "192.0.2.1", Timeout: 5 * time.Second})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("SendCommandContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("SendCommandContext() took %v after cancel, want it to return promptly", elapsed)
+	}
+}
@@ -0,0 +1,107 @@
+package sadp
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// ChangeEvent is the kind of a DeviceChange
+type ChangeEvent string
+
+// Change events reported by DiffDevices
+const (
+	DeviceAppeared    ChangeEvent = "appeared"
+	DeviceDisappeared ChangeEvent = "disappeared"
+	DeviceChanged     ChangeEvent = "changed"
+)
+
+// FieldChange is one setting that differs between two scans of a device
+type FieldChange struct {
+	Field string `json:"field"`
+	From  string `json:"from"`
+	To    string `json:"to"`
+}
+
+// DeviceChange is one difference between two scans. Device is the current
+// state, or the last one seen for DeviceDisappeared; Changes is set for
+// DeviceChanged.
+type DeviceChange struct {
+	Event   ChangeEvent
+	Device  *Device
+	Changes []FieldChange
+}
+
+// trackedFields are the settings whose change DiffDevices reports, named
+// as in the JSON export. Per-reply values such as ReceivedTime and the
+// probe UUID are left out.
+var trackedFields = []struct {
+	name  string
+	value func(*Device) string
+}{
+	{"activated", func(d *Device) string { return d.Activated }},
+	{"ipv4Address", func(d *Device) string { return d.IPv4Address }},
+	{"ipv4SubnetMask", func(d *Device) string { return d.IPv4SubnetMask }},
+	{"ipv4Gateway", func(d *Device) string { return d.IPv4Gateway }},
+	{"dhcp", func(d *Device) string { return d.DHCP }},
+	{"commandPort", func(d *Device) string { return strconv.Itoa(int(d.CommandPort)) }},
+	{"httpPort", func(d *Device) string { return strconv.Itoa(int(d.HttpPort)) }},
+	{"deviceType", func(d *Device) string { return d.DeviceType }},
+	{"softwareVersion", func(d *Device) string { return d.SoftwareVersion }},
+	{"bootTime", func(d *Device) string { return d.BootTime }},
+	{"hcPlatformEnable", func(d *Device) string { return d.HCPlatformEnable }},
+	{"sdkServerStatus", func(d *Device) string { return d.SDKServerStatus }},
+}
+
+// DiffDevices compares two scans by MAC address and returns the devices
+// that appeared, disappeared or changed a tracked setting, ordered by MAC.
+// Devices without a MAC cannot be matched and are skipped.
+func DiffDevices(previous, current []*Device) []DeviceChange {
+	before := devicesByMAC(previous)
+	after := devicesByMAC(current)
+
+	var changes []DeviceChange
+	for mac, dev := range after {
+		old, ok := before[mac]
+		if !ok {
+			changes = append(changes, DeviceChange{Event: DeviceAppeared, Device: dev})
+			continue
+		}
+		if fields := diffFields(old, dev); len(fields) > 0 {
+			changes = append(changes, DeviceChange{Event: DeviceChanged, Device: dev, Changes: fields})
+		}
+	}
+	for mac, dev := range before {
+		if _, ok := after[mac]; !ok {
+			changes = append(changes, DeviceChange{Event: DeviceDisappeared, Device: dev})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return network.NormalizeMAC(changes[i].Device.MAC) < network.NormalizeMAC(changes[j].Device.MAC)
+	})
+	return changes
+}
+
+// devicesByMAC indexes devices by normalized MAC, the last one winning
+func devicesByMAC(devices []*Device) map[string]*Device {
+	byMAC := make(map[string]*Device, len(devices))
+	for _, dev := range devices {
+		if mac := network.NormalizeMAC(dev.MAC); mac != "" {
+			byMAC[mac] = dev
+		}
+	}
+	return byMAC
+}
+
+// diffFields lists the tracked settings that differ between old and cur
+func diffFields(old, cur *Device) []FieldChange {
+	var changes []FieldChange
+	for _, f := range trackedFields {
+		if from, to := f.value(old), f.value(cur); from != to {
+			changes = append(changes, FieldChange{Field: f.name, From: from, To: to})
+		}
+	}
+	return changes
+}
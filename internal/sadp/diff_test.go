@@ -0,0 +1,57 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+func TestDiffDevices(t *testing.T) {
+	previous := []*Device{
+		{MAC: "4c-bd-8f-00-00-01", IPv4Address: "192.168.1.64", Activated: "false"},
+		{MAC: "4C:BD:8F:00:00:02", IPv4Address: "192.168.1.65", Activated: "true"},
+		{MAC: "4C:BD:8F:00:00:03", IPv4Address: "192.168.1.66", Activated: "true"},
+		{IPv4Address: "192.168.1.99"},
+	}
+	current := []*Device{
+		{MAC: "4C:BD:8F:00:00:01", IPv4Address: "192.168.1.64", Activated: "true"},
+		{MAC: "4C:BD:8F:00:00:02", IPv4Address: "192.168.1.65", Activated: "true"},
+		{MAC: "4C:BD:8F:00:00:04", IPv4Address: "192.168.1.67", Activated: "false"},
+	}
+
+	changes := DiffDevices(previous, current)
+
+	type summary struct {
+		event ChangeEvent
+		mac   string
+	}
+	var got []summary
+	for _, c := range changes {
+		got = append(got, summary{c.Event, network.NormalizeMAC(c.Device.MAC)})
+	}
+	want := []summary{
+		{DeviceChanged, "4C:BD:8F:00:00:01"},
+		{DeviceDisappeared, "4C:BD:8F:00:00:03"},
+		{DeviceAppeared, "4C:BD:8F:00:00:04"},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("DiffDevices() = %v, want %v", got, want)
+	}
+
+	wantFields := []FieldChange{{Field: "activated", From: "false", To: "true"}}
+	if !reflect.DeepEqual(changes[0].Changes, wantFields) {
+		t.Errorf("changed fields = %+v, want %+v", changes[0].Changes, wantFields)
+	}
+	if changes[1].Device.IPv4Address != "192.168.1.66" {
+		t.Errorf("disappeared device = %+v, want the last state seen", changes[1].Device)
+	}
+}
+
+func TestDiffDevicesIgnoresUntrackedFields(t *testing.T) {
+	previous := []*Device{{MAC: "4C:BD:8F:00:00:01", Uuid: "a", AdapterIP: "10.0.0.1"}}
+	current := []*Device{{MAC: "4C:BD:8F:00:00:01", Uuid: "b", AdapterIP: "10.0.0.2"}}
+	if changes := DiffDevices(previous, current); len(changes) != 0 {
+		t.Errorf("DiffDevices() = %+v, want no changes", changes)
+	}
+}
@@ -0,0 +1,66 @@
+package sadp
+
+import (
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// ScanTimeLayout is the timestamp format of the ScanTime column/field
+const ScanTimeLayout = time.RFC3339
+
+// ToScanCSV generates CSV rows prefixed with a ScanTime column, for
+// appending successive scans to one file. header is the header line of the
+// file being appended to: when it is nil (a new file) a header is written
+// for devices' columns, otherwise only rows are written, laid out in the
+// header's column order so optional columns stay aligned. Header columns
+// that are not known export columns are left blank.
+func (s *Scanner) ToScanCSV(devices []*Device, scanTime time.Time, header []string) string {
+	var sb strings.Builder
+	if header == nil {
+		header = []string{"ScanTime"}
+		for _, col := range DeviceColumns(devices) {
+			header = append(header, col.Name)
+		}
+		sb.WriteString(strings.Join(header, ",") + "\n")
+	}
+
+	stamp := scanTime.Format(ScanTimeLayout)
+	for i, dev := range devices {
+		for j, name := range header {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			if name == "ScanTime" {
+				sb.WriteString(stamp)
+			} else if col, ok := columnByName(name); ok {
+				sb.WriteString(col.csvValue(i, dev))
+			}
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// scanRecord is one JSONL line: a device plus the time of the scan
+type scanRecord struct {
+	ScanTime string `json:"scanTime"`
+	*Device
+}
+
+// ToJSONL generates one JSON object per device per line, each carrying the
+// scan time, so scans can be appended to the same file
+func (s *Scanner) ToJSONL(devices []*Device, scanTime time.Time) (string, error) {
+	stamp := scanTime.Format(ScanTimeLayout)
+
+	var sb strings.Builder
+	for _, dev := range devices {
+		line, err := json.Marshal(scanRecord{ScanTime: stamp, Device: dev})
+		if err != nil {
+			return "", err
+		}
+		sb.Write(line)
+		sb.WriteString("\n")
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,85 @@
+package sadp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToScanCSV(t *testing.T) {
+	scanTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	devices := []*Device{{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "10.0.0.5", Site: "Depot, North"}}
+
+	tests := []struct {
+		name       string
+		header     []string
+		wantHeader string
+		wantRow    string
+	}{
+		{name: "new file gets header", wantHeader: "ScanTime,ID,", wantRow: "2024-03-01T12:00:00Z,1,"},
+		{name: "existing file rows only", header: []string{"ScanTime", "ID", "MAC"}, wantRow: "2024-03-01T12:00:00Z,1,AA:BB:CC:DD:EE:FF"},
+		{name: "rows follow the header order", header: []string{"ScanTime", "Site", "Legacy", "IPv4Address"},
+			wantRow: `2024-03-01T12:00:00Z,"Depot, North",,10.0.0.5`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, nil)
+			out := s.ToScanCSV(devices, scanTime, tt.header)
+			lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+			wantLines := 1
+			if tt.header == nil {
+				wantLines = 2
+			}
+			if len(lines) != wantLines {
+				t.Fatalf("got %d lines, want %d:\n%s", len(lines), wantLines, out)
+			}
+			if tt.header == nil && !strings.HasPrefix(lines[0], tt.wantHeader) {
+				t.Errorf("header = %q, want prefix %q", lines[0], tt.wantHeader)
+			}
+			if row := lines[len(lines)-1]; !strings.HasPrefix(row, tt.wantRow) {
+				t.Errorf("row = %q, want prefix %q", row, tt.wantRow)
+			}
+		})
+	}
+}
+
+func TestToJSONL(t *testing.T) {
+	scanTime := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	devices := []*Device{
+		{MAC: "AA:BB:CC:DD:EE:01", IPv4Address: "10.0.0.1"},
+		{MAC: "AA:BB:CC:DD:EE:02", IPv4Address: "10.0.0.2"},
+	}
+
+	s := NewScanner(DefaultTimeout, nil)
+	out, err := s.ToJSONL(devices, scanTime)
+	if err != nil {
+		t.Fatalf("ToJSONL() error = %v", err)
+	}
+
+	lines := strings.Split(strings.TrimSuffix(out, "\n"), "\n")
+	if len(lines) != len(devices) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(devices))
+	}
+	for i, line := range lines {
+		var record map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &record); err != nil {
+			t.Fatalf("line %d is not JSON: %v", i, err)
+		}
+		if record["scanTime"] != "2024-03-01T12:00:00Z" {
+			t.Errorf("line %d scanTime = %v", i, record["scanTime"])
+		}
+		if record["mac"] != devices[i].MAC {
+			t.Errorf("line %d mac = %v, want %s", i, record["mac"], devices[i].MAC)
+		}
+	}
+
+	loaded, err := LoadDevicesFromJSONL([]byte(out + "\n" + out))
+	if err != nil {
+		t.Fatalf("LoadDevicesFromJSONL() error = %v", err)
+	}
+	if len(loaded) != 2*len(devices) {
+		t.Errorf("loaded %d devices, want %d", len(loaded), 2*len(devices))
+	}
+}
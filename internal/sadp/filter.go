@@ -0,0 +1,81 @@
+package sadp
+
+import (
+	"strings"
+	"time"
+)
+
+// WithSDKOverTLS returns the devices that report an SDK-over-TLS port, i.e.
+// those an SDK integration can reach over the secure SDK channel
+func WithSDKOverTLS(devices []*Device) []*Device {
+	result := make([]*Device, 0, len(devices))
+	for _, dev := range devices {
+		if dev.SDKOverTLSPort > 0 {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// WithActivation returns the devices whose activation state matches
+// activated. Devices that do not report a state count as inactive, since
+// they still need checking before use.
+func WithActivation(devices []*Device, activated bool) []*Device {
+	result := make([]*Device, 0, len(devices))
+	for _, dev := range devices {
+		if (dev.Activated == "true") == activated {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// DeviceFilter selects devices for FilterDevices. Every criterion that is
+// set must match; the zero value keeps every device.
+type DeviceFilter struct {
+	// OnlyInactive keeps devices whose Activated is not "true", which
+	// includes those that do not report it; OnlyActive keeps the rest
+	OnlyInactive bool
+	OnlyActive   bool
+	// TypeContains keeps devices whose DeviceType contains it, ignoring case
+	TypeContains string
+}
+
+// matches reports whether dev meets every criterion of f
+func (f DeviceFilter) matches(dev *Device) bool {
+	activated := dev.Activated == "true"
+	if f.OnlyInactive && activated {
+		return false
+	}
+	if f.OnlyActive && !activated {
+		return false
+	}
+	if f.TypeContains != "" && !strings.Contains(strings.ToLower(dev.DeviceType), strings.ToLower(f.TypeContains)) {
+		return false
+	}
+	return true
+}
+
+// FilterDevices returns the devices that match f, in their original order
+func FilterDevices(devices []*Device, f DeviceFilter) []*Device {
+	result := make([]*Device, 0, len(devices))
+	for _, dev := range devices {
+		if f.matches(dev) {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// ReceivedSince returns the devices whose ReceivedTime is at or after
+// cutoff. Devices with no ReceivedTime, such as those loaded from exports
+// that predate the field, are dropped since they cannot be shown to be recent.
+func ReceivedSince(devices []*Device, cutoff time.Time) []*Device {
+	result := make([]*Device, 0, len(devices))
+	for _, dev := range devices {
+		if !dev.ReceivedTime.IsZero() && !dev.ReceivedTime.Before(cutoff) {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWithSDKOverTLS(t *testing.T) {
+	secure := &Device{MAC: "AA:BB:CC:DD:EE:01", SDKOverTLSPort: 8443}
+	plain := &Device{MAC: "AA:BB:CC:DD:EE:02"}
+
+	tests := []struct {
+		name    string
+		devices []*Device
+		want    []*Device
+	}{
+		{name: "keeps TLS devices", devices: []*Device{plain, secure}, want: []*Device{secure}},
+		{name: "none enabled", devices: []*Device{plain}, want: []*Device{}},
+		{name: "empty", devices: nil, want: []*Device{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithSDKOverTLS(tt.devices); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithSDKOverTLS() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWithActivation(t *testing.T) {
+	active := &Device{MAC: "AA:BB:CC:DD:EE:01", Activated: "true"}
+	inactive := &Device{MAC: "AA:BB:CC:DD:EE:02", Activated: "false"}
+	unknown := &Device{MAC: "AA:BB:CC:DD:EE:03"}
+	all := []*Device{active, inactive, unknown}
+
+	tests := []struct {
+		name      string
+		activated bool
+		want      []*Device
+	}{
+		{name: "only active", activated: true, want: []*Device{active}},
+		{name: "only inactive", activated: false, want: []*Device{inactive, unknown}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := WithActivation(all, tt.activated); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("WithActivation(%v) = %v, want %v", tt.activated, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterDevices(t *testing.T) {
+	inactiveNVR := &Device{MAC: "AA:BB:CC:DD:EE:01", Activated: "false", DeviceType: "DS-7608NI-K2"}
+	activeNVR := &Device{MAC: "AA:BB:CC:DD:EE:02", Activated: "true", DeviceType: "ds-7616ni-i2 nvr"}
+	activeCamera := &Device{MAC: "AA:BB:CC:DD:EE:03", Activated: "true", DeviceType: "DS-2CD2143G0-I"}
+	unknown := &Device{MAC: "AA:BB:CC:DD:EE:04"}
+	all := []*Device{inactiveNVR, activeNVR, activeCamera, unknown}
+
+	tests := []struct {
+		name   string
+		filter DeviceFilter
+		want   []*Device
+	}{
+		{name: "zero filter keeps all", filter: DeviceFilter{}, want: all},
+		{name: "only inactive", filter: DeviceFilter{OnlyInactive: true}, want: []*Device{inactiveNVR, unknown}},
+		{name: "only active", filter: DeviceFilter{OnlyActive: true}, want: []*Device{activeNVR, activeCamera}},
+		{name: "type ignores case", filter: DeviceFilter{TypeContains: "NI-"}, want: []*Device{inactiveNVR, activeNVR}},
+		{name: "type and activation combine", filter: DeviceFilter{OnlyActive: true, TypeContains: "ni-"}, want: []*Device{activeNVR}},
+		{name: "active and inactive match nothing", filter: DeviceFilter{OnlyActive: true, OnlyInactive: true}, want: []*Device{}},
+		{name: "no type match", filter: DeviceFilter{TypeContains: "thermal"}, want: []*Device{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := FilterDevices(all, tt.filter); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FilterDevices(%+v) = %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReceivedSince(t *testing.T) {
+	cutoff := time.Date(2024, 1, 15, 10, 0, 0, 0, time.UTC)
+	recent := &Device{MAC: "AA:BB:CC:DD:EE:01", ReceivedTime: cutoff.Add(time.Minute)}
+	atCutoff := &Device{MAC: "AA:BB:CC:DD:EE:02", ReceivedTime: cutoff}
+	old := &Device{MAC: "AA:BB:CC:DD:EE:03", ReceivedTime: cutoff.Add(-time.Minute)}
+	unknown := &Device{MAC: "AA:BB:CC:DD:EE:04"}
+
+	tests := []struct {
+		name    string
+		devices []*Device
+		want    []*Device
+	}{
+		{name: "drops older and unknown", devices: []*Device{recent, atCutoff, old, unknown}, want: []*Device{recent, atCutoff}},
+		{name: "none recent", devices: []*Device{old}, want: []*Device{}},
+		{name: "empty", devices: nil, want: []*Device{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ReceivedSince(tt.devices, cutoff); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReceivedSince() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,99 @@
+package sadp
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// GroupKey selects the field GroupDevices sections devices by
+type GroupKey string
+
+// Supported group keys
+const (
+	GroupByOUI    GroupKey = "oui"
+	GroupByType   GroupKey = "type"
+	GroupBySubnet GroupKey = "subnet"
+)
+
+// UnknownGroup holds devices that lack the field being grouped by
+const UnknownGroup = "unknown"
+
+// ParseGroupKey validates a --group-by value
+func ParseGroupKey(s string) (GroupKey, error) {
+	switch key := GroupKey(strings.ToLower(strings.TrimSpace(s))); key {
+	case GroupByOUI, GroupByType, GroupBySubnet:
+		return key, nil
+	default:
+		return "", fmt.Errorf("unknown group key %q (use %s, %s or %s)", s, GroupByOUI, GroupByType, GroupBySubnet)
+	}
+}
+
+// GroupDevices sections devices by key. Devices keep their input order
+// within each group; use GroupNames for a stable order of the groups.
+func GroupDevices(devices []*Device, key GroupKey) map[string][]*Device {
+	groups := make(map[string][]*Device)
+	for _, dev := range devices {
+		name := groupName(dev, key)
+		groups[name] = append(groups[name], dev)
+	}
+	return groups
+}
+
+// GroupNames returns the group names sorted, with UnknownGroup last
+func GroupNames(groups map[string][]*Device) []string {
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if (names[i] == UnknownGroup) != (names[j] == UnknownGroup) {
+			return names[j] == UnknownGroup
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+// groupName returns the group dev belongs to under key
+func groupName(dev *Device, key GroupKey) string {
+	var name string
+	switch key {
+	case GroupByOUI:
+		name = macOUI(dev.MAC)
+	case GroupByType:
+		name = strings.TrimSpace(dev.DeviceType)
+	case GroupBySubnet:
+		name = deviceSubnet(dev)
+	}
+	if name == "" {
+		return UnknownGroup
+	}
+	return name
+}
+
+// macOUI returns the first three octets of mac in upper case, colon
+// separated, or "" if mac is not a MAC address
+func macOUI(mac string) string {
+	hw, err := net.ParseMAC(strings.TrimSpace(mac))
+	if err != nil || len(hw) != 6 {
+		return ""
+	}
+	return strings.ToUpper(hw[:3].String())
+}
+
+// deviceSubnet returns the network of the device's IPv4 address in CIDR
+// form. A missing or invalid subnet mask is treated as /24.
+func deviceSubnet(dev *Device) string {
+	ip := net.ParseIP(strings.TrimSpace(dev.IPv4Address)).To4()
+	if ip == nil {
+		return ""
+	}
+	mask := net.IPMask(net.ParseIP(strings.TrimSpace(dev.IPv4SubnetMask)).To4())
+	if ones, bits := mask.Size(); bits == 0 || ones == 0 {
+		mask = net.CIDRMask(24, 32)
+	}
+	network := &net.IPNet{IP: ip.Mask(mask), Mask: mask}
+	return network.String()
+}
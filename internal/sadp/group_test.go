@@ -0,0 +1,95 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseGroupKey(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    GroupKey
+		wantErr bool
+	}{
+		{input: "oui", want: GroupByOUI},
+		{input: "Type", want: GroupByType},
+		{input: " subnet ", want: GroupBySubnet},
+		{input: "vendor", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseGroupKey(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseGroupKey(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseGroupKey(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGroupDevices(t *testing.T) {
+	devices := []*Device{
+		{IPv4Address: "192.168.1.64", IPv4SubnetMask: "255.255.255.0", MAC: "4c:bd:8f:61:cc:5c", DeviceType: "DS-2CD2042WD-I"},
+		{IPv4Address: "10.0.5.20", IPv4SubnetMask: "255.255.0.0", MAC: "44-19-B6-00-00-01", DeviceType: "DS-7616NI-I2"},
+		{IPv4Address: "192.168.1.65", IPv4SubnetMask: "", MAC: "4C:BD:8F:61:CC:5D", DeviceType: "DS-2CD2042WD-I"},
+		{IPv4Address: "", MAC: "not-a-mac", DeviceType: ""},
+	}
+
+	tests := []struct {
+		name string
+		key  GroupKey
+		want map[string][]int
+	}{
+		{
+			name: "oui",
+			key:  GroupByOUI,
+			want: map[string][]int{"4C:BD:8F": {0, 2}, "44:19:B6": {1}, UnknownGroup: {3}},
+		},
+		{
+			name: "type",
+			key:  GroupByType,
+			want: map[string][]int{"DS-2CD2042WD-I": {0, 2}, "DS-7616NI-I2": {1}, UnknownGroup: {3}},
+		},
+		{
+			name: "subnet",
+			key:  GroupBySubnet,
+			want: map[string][]int{"192.168.1.0/24": {0, 2}, "10.0.0.0/16": {1}, UnknownGroup: {3}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make(map[string][]*Device)
+			for name, idx := range tt.want {
+				for _, i := range idx {
+					want[name] = append(want[name], devices[i])
+				}
+			}
+
+			got := GroupDevices(devices, tt.key)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("GroupDevices() = %v, want %v", got, want)
+			}
+		})
+	}
+}
+
+func TestGroupNames(t *testing.T) {
+	groups := map[string][]*Device{
+		UnknownGroup:     nil,
+		"DS-7616NI-I2":   nil,
+		"AA-first-alpha": nil,
+		"DS-2CD2042WD-I": nil,
+	}
+	want := []string{"AA-first-alpha", "DS-2CD2042WD-I", "DS-7616NI-I2", UnknownGroup}
+
+	for i := 0; i < 5; i++ {
+		if got := GroupNames(groups); !reflect.DeepEqual(got, want) {
+			t.Fatalf("GroupNames() = %v, want %v", got, want)
+		}
+	}
+}
@@ -0,0 +1,149 @@
+package sadp
+
+import (
+	"html/template"
+	"sort"
+	"strings"
+	"time"
+)
+
+// htmlReport is the data rendered by reportTemplate
+type htmlReport struct {
+	Generated string
+	Total     int
+	Active    int
+	Inactive  int
+	Types     []htmlTypeCount
+	Columns   []string
+	Rows      [][]string
+}
+
+type htmlTypeCount struct {
+	Type  string
+	Count int
+}
+
+// reportTemplate is a self-contained page: styles and the column sort
+// script are inline so the file can be mailed or opened offline
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>SADP device report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 2em; color: #222; }
+h1 { font-size: 1.4em; margin-bottom: 0.2em; }
+.generated { color: #666; margin-top: 0; }
+.summary { display: flex; flex-wrap: wrap; gap: 1em; margin: 1em 0 1.5em; }
+.card { border: 1px solid #ddd; border-radius: 6px; padding: 0.6em 1em; min-width: 8em; }
+.card .n { font-size: 1.6em; font-weight: bold; }
+.card ul { margin: 0.3em 0 0; padding-left: 1.2em; }
+table { border-collapse: collapse; font-size: 0.9em; }
+th, td { border: 1px solid #ddd; padding: 0.35em 0.6em; text-align: left; white-space: nowrap; }
+th { background: #f3f3f3; cursor: pointer; user-select: none; }
+th.asc::after { content: " \25B2"; }
+th.desc::after { content: " \25BC"; }
+tr:nth-child(even) td { background: #fafafa; }
+</style>
+</head>
+<body>
+<h1>SADP device report</h1>
+<p class="generated">Generated {{.Generated}}</p>
+<div class="summary">
+<div class="card"><div class="n">{{.Total}}</div>device(s)</div>
+<div class="card"><div class="n">{{.Active}}</div>activated</div>
+<div class="card"><div class="n">{{.Inactive}}</div>not activated</div>
+{{- if .Types}}
+<div class="card">By type<ul>
+{{- range .Types}}
+<li>{{.Type}}: {{.Count}}</li>
+{{- end}}
+</ul></div>
+{{- end}}
+</div>
+<table id="devices">
+<thead><tr>{{range .Columns}}<th>{{.}}</th>{{end}}</tr></thead>
+<tbody>
+{{- range .Rows}}
+<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{- end}}
+</tbody>
+</table>
+<script>
+(function () {
+  var table = document.getElementById("devices");
+  var headers = table.tHead.rows[0].cells;
+  function key(row, col) { return row.cells[col].textContent; }
+  function compare(a, b) {
+    var x = parseFloat(a), y = parseFloat(b);
+    if (!isNaN(x) && !isNaN(y) && String(x) === a && String(y) === b) { return x - y; }
+    return a.localeCompare(b, undefined, { numeric: true });
+  }
+  Array.prototype.forEach.call(headers, function (th, col) {
+    th.addEventListener("click", function () {
+      var asc = !th.classList.contains("asc");
+      Array.prototype.forEach.call(headers, function (h) { h.classList.remove("asc", "desc"); });
+      th.classList.add(asc ? "asc" : "desc");
+      var body = table.tBodies[0];
+      var rows = Array.prototype.slice.call(body.rows);
+      rows.sort(function (a, b) {
+        var c = compare(key(a, col), key(b, col));
+        return asc ? c : -c;
+      });
+      rows.forEach(function (r) { body.appendChild(r); });
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
+
+// ToHTML generates a standalone HTML report: summary counts followed by a
+// click-to-sort table with the same columns as ToCSV
+func (s *Scanner) ToHTML(devices []*Device, generated time.Time) (string, error) {
+	columns := DeviceColumns(devices)
+	report := htmlReport{
+		Generated: generated.Format(time.RFC1123),
+		Total:     len(devices),
+		Columns:   make([]string, len(columns)),
+		Rows:      make([][]string, len(devices)),
+	}
+	for i, col := range columns {
+		report.Columns[i] = col.Name
+	}
+
+	types := map[string]int{}
+	for i, dev := range devices {
+		if dev.Activated == "true" {
+			report.Active++
+		} else {
+			report.Inactive++
+		}
+		if dev.DeviceType != "" {
+			types[dev.DeviceType]++
+		}
+
+		row := make([]string, len(columns))
+		for j, col := range columns {
+			row[j] = col.Value(i, dev)
+		}
+		report.Rows[i] = row
+	}
+
+	for name, n := range types {
+		report.Types = append(report.Types, htmlTypeCount{Type: name, Count: n})
+	}
+	sort.Slice(report.Types, func(i, j int) bool {
+		if report.Types[i].Count != report.Types[j].Count {
+			return report.Types[i].Count > report.Types[j].Count
+		}
+		return report.Types[i].Type < report.Types[j].Type
+	})
+
+	var sb strings.Builder
+	if err := reportTemplate.Execute(&sb, report); err != nil {
+		return "", err
+	}
+	return sb.String(), nil
+}
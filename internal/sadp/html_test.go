@@ -0,0 +1,68 @@
+package sadp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestToHTML(t *testing.T) {
+	generated := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		devices  []*Device
+		want     []string
+		wantNone []string
+	}{
+		{
+			name:    "summary counts",
+			devices: testDevices(),
+			want: []string{
+				`<div class="n">2</div>device(s)`,
+				`<div class="n">1</div>activated`,
+				`<div class="n">1</div>not activated`,
+				"<li>DS-2CD2042WD-I: 1</li>",
+				"<td>192.168.1.101</td>",
+				"Generated Fri, 01 Mar 2024 12:00:00 UTC",
+			},
+		},
+		{
+			name:    "columns match CSV header",
+			devices: []*Device{{MAC: "AA:BB:CC:DD:EE:FF", Site: "HQ"}},
+			want:    []string{"<th>ID</th><th>DeviceType</th>", "<th>ReceivedTime</th><th>Site</th><th>Tags</th></tr>"},
+		},
+		{
+			name:     "device values are escaped",
+			devices:  []*Device{{MAC: "AA:BB:CC:DD:EE:FF", DeviceType: "<script>x</script>"}},
+			want:     []string{"&lt;script&gt;x&lt;/script&gt;"},
+			wantNone: []string{"<td><script>"},
+		},
+		{
+			name:     "no devices",
+			devices:  nil,
+			want:     []string{`<div class="n">0</div>device(s)`, "<tbody>\n</tbody>"},
+			wantNone: []string{"By type"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, nil)
+			out, err := s.ToHTML(tt.devices, generated)
+			if err != nil {
+				t.Fatalf("ToHTML() error = %v", err)
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("report missing %q", w)
+				}
+			}
+			for _, w := range tt.wantNone {
+				if strings.Contains(out, w) {
+					t.Errorf("report unexpectedly contains %q", w)
+				}
+			}
+		})
+	}
+}
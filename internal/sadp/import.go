@@ -0,0 +1,217 @@
+package sadp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// LoadDevicesFromFile loads devices from a file previously written by one of
+// the exporters, choosing the format from the file extension. A trailing .gz
+// is decompressed first, so devices.xml.gz loads as XML. XML files exported
+// from the official SADP tool are recognised by their root element.
+func LoadDevicesFromFile(path string) ([]*Device, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device file: %w", err)
+	}
+
+	if strings.EqualFold(filepath.Ext(path), ".gz") {
+		zr, err := gzip.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress device file: %w", err)
+		}
+		data, err = io.ReadAll(zr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress device file: %w", err)
+		}
+		path = strings.TrimSuffix(path, filepath.Ext(path))
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		return LoadDevicesFromCSV(data)
+	case ".xml":
+		return loadDevicesFromAnyXML(data)
+	case ".json":
+		return LoadDevicesFromJSON(data)
+	case ".jsonl":
+		return LoadDevicesFromJSONL(data)
+	default:
+		return nil, fmt.Errorf("unsupported device file extension %q (use .csv, .xml, .json, or .jsonl)", filepath.Ext(path))
+	}
+}
+
+// LoadDevicesFromXML parses the SADPDeviceList format produced by ToXML
+func LoadDevicesFromXML(data []byte) ([]*Device, error) {
+	var list struct {
+		XMLName xml.Name `xml:"SADPDeviceList"`
+		Devices []Device `xml:"ProbeMatch"`
+	}
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid device XML: %w", err)
+	}
+
+	devices := make([]*Device, len(list.Devices))
+	for i := range list.Devices {
+		devices[i] = &list.Devices[i]
+	}
+	return devices, nil
+}
+
+// LoadDevicesFromJSON parses a JSON device list, either wrapped as
+// {"devices": [...]} or as a bare array
+func LoadDevicesFromJSON(data []byte) ([]*Device, error) {
+	data = bytes.TrimSpace(data)
+
+	var devices []*Device
+	if len(data) > 0 && data[0] == '[' {
+		if err := json.Unmarshal(data, &devices); err != nil {
+			return nil, fmt.Errorf("invalid device JSON: %w", err)
+		}
+		return devices, nil
+	}
+
+	var list struct {
+		Devices []*Device `json:"devices"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid device JSON: %w", err)
+	}
+	if list.Devices == nil {
+		return []*Device{}, nil
+	}
+	return list.Devices, nil
+}
+
+// LoadDevicesFromJSONL parses one JSON device per line, as written by ToJSONL.
+// Blank lines are skipped.
+func LoadDevicesFromJSONL(data []byte) ([]*Device, error) {
+	devices := []*Device{}
+	for i, line := range bytes.Split(data, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+		dev := &Device{}
+		if err := json.Unmarshal(line, dev); err != nil {
+			return nil, fmt.Errorf("invalid device JSONL on line %d: %w", i+1, err)
+		}
+		devices = append(devices, dev)
+	}
+	return devices, nil
+}
+
+// LoadDevicesFromCSV parses the CSV format produced by ToCSV. Columns are
+// matched by header name so files with extra or reordered columns load too.
+func LoadDevicesFromCSV(data []byte) ([]*Device, error) {
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return []*Device{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("invalid device CSV: %w", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+
+	devices := []*Device{}
+	for row := 2; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid device CSV: %w", err)
+		}
+
+		field := func(name string) string {
+			if i, ok := columns[name]; ok && i < len(record) {
+				return record[i]
+			}
+			return ""
+		}
+		intField := func(name string) (int, error) {
+			v := strings.TrimSpace(field(name))
+			if v == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return 0, fmt.Errorf("row %d: invalid %s %q", row, name, v)
+			}
+			return n, nil
+		}
+
+		dev := &Device{
+			DeviceType:      field("DeviceType"),
+			Activated:       field("Activated"),
+			IPv4Address:     field("IPv4Address"),
+			SoftwareVersion: field("SoftwareVersion"),
+			IPv4Gateway:     field("IPv4Gateway"),
+			DeviceSN:        field("SerialNumber"),
+			IPv4SubnetMask:  field("IPv4SubnetMask"),
+			MAC:             field("MAC"),
+			DSPVersion:      field("DSPVersion"),
+			BootTime:        field("BootTime"),
+			DHCP:            field("DHCP"),
+			SDKServerStatus: field("SDKServerStatus"),
+			AdapterIP:       field("AdapterIP"),
+			Hostname:        field("Hostname"),
+			ARPStatus:       field("ARPStatus"),
+			Site:            field("Site"),
+			Tags:            parseTagString(field("Tags")),
+		}
+
+		commandPort, err := intField("Port")
+		if err != nil {
+			return nil, err
+		}
+		httpPort, err := intField("HttpPort")
+		if err != nil {
+			return nil, err
+		}
+		sdkTLSPort, err := intField("SDKOverTLSPort")
+		if err != nil {
+			return nil, err
+		}
+		if v := strings.TrimSpace(field("ReceivedTime")); v != "" {
+			if dev.ReceivedTime, err = time.Parse(time.RFC3339, v); err != nil {
+				return nil, fmt.Errorf("row %d: invalid ReceivedTime %q", row, v)
+			}
+		}
+		dev.CommandPort, dev.HttpPort = PortNumber(commandPort), PortNumber(httpPort)
+		dev.SDKOverTLSPort = PortNumber(sdkTLSPort)
+		if _, ok := columns["DigitalChannelNum"]; ok {
+			if dev.AnalogChannelNum, err = intField("AnalogChannelNum"); err != nil {
+				return nil, err
+			}
+			if dev.DigitalChannelNum, err = intField("DigitalChannelNum"); err != nil {
+				return nil, err
+			}
+		} else if dev.DigitalChannelNum, err = intField("ChannelNum"); err != nil {
+			// Older exports only carry the analog+digital sum, so it can
+			// only be restored as a single count
+			return nil, err
+		}
+
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
@@ -0,0 +1,254 @@
+package sadp
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func testDevices() []*Device {
+	return []*Device{
+		{
+			Uuid:              "uuid-1",
+			Types:             "inquiry",
+			DeviceType:        "DS-2CD2042WD-I",
+			DeviceDescription: "Network Camera",
+			DeviceSN:          "SN123456789",
+			MAC:               "AA:BB:CC:DD:EE:FF",
+			IPv4Address:       "192.168.1.100",
+			IPv4SubnetMask:    "255.255.255.0",
+			IPv4Gateway:       "192.168.1.1",
+			DHCP:              "false",
+			CommandPort:       8000,
+			HttpPort:          80,
+			DSPVersion:        "V7.3 build 191126",
+			BootTime:          "2024-01-01 10:00:00",
+			SoftwareVersion:   "V5.5.0 build 191126",
+			Activated:         "true",
+			DigitalChannelNum: 1,
+			SDKOverTLSPort:    8443,
+			SDKServerStatus:   "true",
+			ReceivedTime:      time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC),
+		},
+		{
+			MAC:               "11:22:33:44:55:66",
+			IPv4Address:       "192.168.1.101",
+			DeviceType:        "DS-7616NI-I2",
+			Activated:         "false",
+			CommandPort:       8000,
+			AnalogChannelNum:  8,
+			DigitalChannelNum: 8,
+		},
+	}
+}
+
+func TestLoadDevicesFromXMLRoundTrip(t *testing.T) {
+	scanner := NewScanner(5*time.Second, logger.NewNop())
+	withAdapter := testDevices()
+	withAdapter[0].AdapterIP = "192.168.1.10"
+
+	tests := []struct {
+		name    string
+		devices []*Device
+	}{
+		{name: "multiple devices", devices: testDevices()},
+		{name: "with adapter", devices: withAdapter},
+		{name: "empty list", devices: []*Device{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := scanner.ToXML(tt.devices)
+			if err != nil {
+				t.Fatalf("ToXML() error = %v", err)
+			}
+
+			loaded, err := LoadDevicesFromXML([]byte(out))
+			if err != nil {
+				t.Fatalf("LoadDevicesFromXML() error = %v", err)
+			}
+			if len(loaded) != len(tt.devices) {
+				t.Fatalf("loaded %d devices, want %d", len(loaded), len(tt.devices))
+			}
+			for i := range loaded {
+				loaded[i].XMLName = xml.Name{}
+				if !reflect.DeepEqual(loaded[i], tt.devices[i]) {
+					t.Errorf("device %d = %+v, want %+v", i, loaded[i], tt.devices[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDevicesFromCSVRoundTrip(t *testing.T) {
+	scanner := NewScanner(5*time.Second, logger.NewNop())
+	devices := testDevices()
+	devices[0].AdapterIP = "192.168.1.10"
+
+	loaded, err := LoadDevicesFromCSV([]byte(scanner.ToCSV(devices)))
+	if err != nil {
+		t.Fatalf("LoadDevicesFromCSV() error = %v", err)
+	}
+	if len(loaded) != len(devices) {
+		t.Fatalf("loaded %d devices, want %d", len(loaded), len(devices))
+	}
+
+	for i, want := range devices {
+		got := loaded[i]
+		checks := []struct {
+			field     string
+			got, want interface{}
+		}{
+			{"DeviceType", got.DeviceType, want.DeviceType},
+			{"Activated", got.Activated, want.Activated},
+			{"IPv4Address", got.IPv4Address, want.IPv4Address},
+			{"CommandPort", got.CommandPort, want.CommandPort},
+			{"HttpPort", got.HttpPort, want.HttpPort},
+			{"SoftwareVersion", got.SoftwareVersion, want.SoftwareVersion},
+			{"IPv4Gateway", got.IPv4Gateway, want.IPv4Gateway},
+			{"DeviceSN", got.DeviceSN, want.DeviceSN},
+			{"IPv4SubnetMask", got.IPv4SubnetMask, want.IPv4SubnetMask},
+			{"MAC", got.MAC, want.MAC},
+			{"AnalogChannelNum", got.AnalogChannelNum, want.AnalogChannelNum},
+			{"DigitalChannelNum", got.DigitalChannelNum, want.DigitalChannelNum},
+			{"DSPVersion", got.DSPVersion, want.DSPVersion},
+			{"BootTime", got.BootTime, want.BootTime},
+			{"DHCP", got.DHCP, want.DHCP},
+			{"SDKOverTLSPort", got.SDKOverTLSPort, want.SDKOverTLSPort},
+			{"SDKServerStatus", got.SDKServerStatus, want.SDKServerStatus},
+			{"AdapterIP", got.AdapterIP, want.AdapterIP},
+			{"ReceivedTime", got.ReceivedTime.Format(time.RFC3339Nano), want.ReceivedTime.Format(time.RFC3339Nano)},
+		}
+		for _, c := range checks {
+			if c.got != c.want {
+				t.Errorf("device %d %s = %v, want %v", i, c.field, c.got, c.want)
+			}
+		}
+	}
+}
+
+func TestLoadDevicesFromCSVErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		wantLen int
+		wantErr bool
+	}{
+		{name: "empty input", data: "", wantLen: 0},
+		{name: "header only", data: "ID,DeviceType,MAC\n", wantLen: 0},
+		{name: "invalid port", data: "ID,MAC,Port\n1,AA:BB:CC:DD:EE:FF,abc\n", wantErr: true},
+		{name: "reordered columns", data: "MAC,ID,Port\nAA:BB:CC:DD:EE:FF,1,8000\n", wantLen: 1},
+		{name: "legacy combined channel count", data: "ID,MAC,ChannelNum\n1,AA:BB:CC:DD:EE:FF,16\n", wantLen: 1},
+		{name: "invalid analog channel count", data: "ID,MAC,AnalogChannelNum,DigitalChannelNum\n1,AA:BB:CC:DD:EE:FF,x,4\n", wantErr: true},
+		{name: "invalid received time", data: "ID,MAC,ReceivedTime\n1,AA:BB:CC:DD:EE:FF,yesterday\n", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			devices, err := LoadDevicesFromCSV([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDevicesFromCSV() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(devices) != tt.wantLen {
+				t.Errorf("loaded %d devices, want %d", len(devices), tt.wantLen)
+			}
+		})
+	}
+}
+
+func TestLoadDevicesFromJSONRoundTrip(t *testing.T) {
+	devices := testDevices()
+	devices[0].AdapterIP = "192.168.1.10"
+	devices[0].ReceivedTime = time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+
+	wrapped, err := json.Marshal(map[string]interface{}{"version": "2.0", "devices": devices})
+	if err != nil {
+		t.Fatalf("failed to marshal wrapped list: %v", err)
+	}
+	bare, err := json.Marshal(devices)
+	if err != nil {
+		t.Fatalf("failed to marshal bare list: %v", err)
+	}
+
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{name: "wrapped list", data: wrapped},
+		{name: "bare array", data: bare},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			loaded, err := LoadDevicesFromJSON(tt.data)
+			if err != nil {
+				t.Fatalf("LoadDevicesFromJSON() error = %v", err)
+			}
+			if !reflect.DeepEqual(loaded, devices) {
+				t.Errorf("LoadDevicesFromJSON() = %+v, want %+v", loaded, devices)
+			}
+		})
+	}
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		if _, err := LoadDevicesFromJSON([]byte(`{"devices": [`)); err == nil {
+			t.Error("expected error for invalid JSON")
+		}
+	})
+}
+
+func TestLoadDevicesFromFile(t *testing.T) {
+	dir := t.TempDir()
+	scanner := NewScanner(5*time.Second, logger.NewNop())
+	xmlOut, err := scanner.ToXML(testDevices())
+	if err != nil {
+		t.Fatalf("ToXML() error = %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		filename string
+		content  string
+		gzip     bool
+		wantLen  int
+		wantErr  bool
+	}{
+		{name: "xml by extension", filename: "devices.xml", content: xmlOut, wantLen: 2},
+		{name: "csv by extension", filename: "devices.csv", content: scanner.ToCSV(testDevices()), wantLen: 2},
+		{name: "json by extension", filename: "devices.json", content: `{"devices":[{"mac":"AA:BB:CC:DD:EE:FF"}]}`, wantLen: 1},
+		{name: "gzipped xml", filename: "devices.xml.gz", content: xmlOut, gzip: true, wantLen: 2},
+		{name: "unknown extension", filename: "devices.txt", content: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, tt.filename)
+			content := []byte(tt.content)
+			if tt.gzip {
+				var buf bytes.Buffer
+				zw := gzip.NewWriter(&buf)
+				_, _ = zw.Write(content)
+				_ = zw.Close()
+				content = buf.Bytes()
+			}
+			if err := os.WriteFile(path, content, 0644); err != nil {
+				t.Fatalf("failed to write file: %v", err)
+			}
+			devices, err := LoadDevicesFromFile(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDevicesFromFile() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(devices) != tt.wantLen {
+				t.Errorf("loaded %d devices, want %d", len(devices), tt.wantLen)
+			}
+		})
+	}
+}
@@ -0,0 +1,200 @@
+package sadp
+
+import (
+	"net"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// InterfaceInfo describes a local address that SADP probes can be sent from:
+// IPv4, or IPv6 link-local when DiscoverOptions.IPv6 is set
+type InterfaceInfo struct {
+	Name         string
+	IP           net.IP
+	Network      *net.IPNet
+	Flags        net.Flags
+	HardwareAddr net.HardwareAddr
+}
+
+// virtualInterfacePrefixes are name prefixes of container, hypervisor, and
+// VPN adapters that are rarely the network a camera is on
+var virtualInterfacePrefixes = []string{
+	"docker", "veth", "br-", "virbr", "vmnet", "vboxnet", "vethernet",
+	"tun", "tap", "utun", "wg", "zt", "tailscale", "ppp", "ipsec", "awdl", "llw",
+}
+
+// Interfaces returns the usable IPv4 addresses of all up, non-loopback interfaces
+func Interfaces() ([]InterfaceInfo, error) {
+	return interfaceAddrs(func(_ net.Interface, ip net.IP) net.IP { return ip.To4() })
+}
+
+// IPv6Interfaces returns the IPv6 link-local addresses of all up,
+// non-loopback, multicast-capable interfaces. The interface name is the
+// zone each address is scoped to.
+func IPv6Interfaces() ([]InterfaceInfo, error) {
+	return interfaceAddrs(func(iface net.Interface, ip net.IP) net.IP {
+		if ip.To4() != nil || !ip.IsLinkLocalUnicast() || iface.Flags&net.FlagMulticast == 0 {
+			return nil
+		}
+		return ip
+	})
+}
+
+// interfaceAddrs lists the addresses of up, non-loopback interfaces that
+// pick accepts. pick returns the address to record, or nil to skip it.
+func interfaceAddrs(pick func(iface net.Interface, ip net.IP) net.IP) ([]InterfaceInfo, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []InterfaceInfo
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok {
+				continue
+			}
+
+			ip := pick(iface, ipNet.IP)
+			if ip == nil {
+				continue
+			}
+
+			result = append(result, InterfaceInfo{
+				Name:         iface.Name,
+				IP:           ip,
+				Network:      ipNet,
+				Flags:        iface.Flags,
+				HardwareAddr: iface.HardwareAddr,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// allowedInterfaces returns the candidates named in names, when it is not
+// empty, whose address is source, when it is set
+func allowedInterfaces(candidates []InterfaceInfo, names []string, source net.IP) []InterfaceInfo {
+	var allowed []InterfaceInfo
+	for _, c := range candidates {
+		if len(names) > 0 && !containsName(names, c.Name) {
+			continue
+		}
+		if source != nil && !c.IP.Equal(source) {
+			continue
+		}
+		allowed = append(allowed, c)
+	}
+	return allowed
+}
+
+func containsName(names []string, name string) bool {
+	for _, n := range names {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// describeAllowList names the interface restrictions for error messages
+func describeAllowList(names []string, source net.IP) string {
+	var parts []string
+	if len(names) > 0 {
+		parts = append(parts, "name "+strings.Join(names, ", "))
+	}
+	if source != nil {
+		parts = append(parts, "address "+source.String())
+	}
+	return strings.Join(parts, " and ")
+}
+
+// InterfaceForIP returns the candidate whose address is ip
+func InterfaceForIP(candidates []InterfaceInfo, ip net.IP) (InterfaceInfo, bool) {
+	for _, c := range candidates {
+		if c.IP.Equal(ip) {
+			return c, true
+		}
+	}
+	return InterfaceInfo{}, false
+}
+
+// BestInterface picks the interface most likely to be the "real" LAN
+// adapter. Ties keep the earliest candidate.
+func BestInterface(candidates []InterfaceInfo) (InterfaceInfo, bool) {
+	if len(candidates) == 0 {
+		return InterfaceInfo{}, false
+	}
+
+	best := candidates[0]
+	bestScore := scoreInterface(best)
+	for _, c := range candidates[1:] {
+		if score := scoreInterface(c); score > bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best, true
+}
+
+// scoreInterface prefers physical, multicast-capable adapters with a private
+// RFC1918 address, and penalises virtual, VPN, and link-local ones
+func scoreInterface(i InterfaceInfo) int {
+	score := 0
+	if isVirtualInterface(i.Name) {
+		score -= 100
+	}
+	if i.Flags&net.FlagPointToPoint != 0 {
+		score -= 20
+	}
+	if i.IP.IsPrivate() {
+		score += 10
+	}
+	if i.IP.IsLinkLocalUnicast() {
+		score -= 5
+	}
+	if len(i.HardwareAddr) == 6 {
+		score += 5
+	}
+	if i.Flags&net.FlagMulticast != 0 {
+		score += 2
+	}
+	return score
+}
+
+// linkLocalMask is the mask of the IPv4 link-local range 169.254.0.0/16
+var linkLocalMask = net.CIDRMask(16, 32)
+
+// subnetBroadcast returns the broadcast address of ip's subnet under mask,
+// or nil if ip is not IPv4. Link-local addresses always use the /16 of RFC
+// 3927 whatever mask the OS reports, since every 169.254 host shares one
+// link.
+func subnetBroadcast(ip net.IP, mask net.IPMask) net.IP {
+	if v4 := ip.To4(); v4 != nil && v4.IsLinkLocalUnicast() {
+		mask = linkLocalMask
+	}
+	return network.BroadcastAddr(ip, mask)
+}
+
+func isVirtualInterface(name string) bool {
+	lower := strings.ToLower(name)
+	for _, prefix := range virtualInterfacePrefixes {
+		if strings.HasPrefix(lower, prefix) {
+			return true
+		}
+	}
+	return strings.Contains(lower, "vpn") || strings.Contains(lower, "wsl") ||
+		strings.Contains(lower, "hyper-v") || strings.Contains(lower, "vmware") ||
+		strings.Contains(lower, "virtualbox")
+}
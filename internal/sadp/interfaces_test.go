@@ -0,0 +1,237 @@
+package sadp
+
+import (
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBestInterface(t *testing.T) {
+	mac := net.HardwareAddr{0x00, 0x11, 0x22, 0x33, 0x44, 0x55}
+	physical := net.FlagUp | net.FlagBroadcast | net.FlagMulticast
+
+	tests := []struct {
+		name       string
+		candidates []InterfaceInfo
+		wantName   string
+		wantOK     bool
+	}{
+		{
+			name:       "no candidates",
+			candidates: nil,
+			wantOK:     false,
+		},
+		{
+			name: "physical beats docker bridge",
+			candidates: []InterfaceInfo{
+				{Name: "docker0", IP: net.ParseIP("172.17.0.1").To4(), Flags: physical, HardwareAddr: mac},
+				{Name: "eth0", IP: net.ParseIP("192.168.1.10").To4(), Flags: physical, HardwareAddr: mac},
+			},
+			wantName: "eth0",
+			wantOK:   true,
+		},
+		{
+			name: "physical beats VPN tunnel",
+			candidates: []InterfaceInfo{
+				{Name: "utun3", IP: net.ParseIP("10.8.0.2").To4(), Flags: net.FlagUp | net.FlagPointToPoint},
+				{Name: "en0", IP: net.ParseIP("192.168.50.185").To4(), Flags: physical, HardwareAddr: mac},
+			},
+			wantName: "en0",
+			wantOK:   true,
+		},
+		{
+			name: "Hyper-V and WSL adapters are deprioritised",
+			candidates: []InterfaceInfo{
+				{Name: "vEthernet (WSL)", IP: net.ParseIP("172.20.48.1").To4(), Flags: physical, HardwareAddr: mac},
+				{Name: "Ethernet", IP: net.ParseIP("192.168.1.20").To4(), Flags: physical, HardwareAddr: mac},
+			},
+			wantName: "Ethernet",
+			wantOK:   true,
+		},
+		{
+			name: "private address beats public",
+			candidates: []InterfaceInfo{
+				{Name: "eth1", IP: net.ParseIP("203.0.113.5").To4(), Flags: physical, HardwareAddr: mac},
+				{Name: "eth0", IP: net.ParseIP("10.0.0.5").To4(), Flags: physical, HardwareAddr: mac},
+			},
+			wantName: "eth0",
+			wantOK:   true,
+		},
+		{
+			name: "tie keeps first candidate",
+			candidates: []InterfaceInfo{
+				{Name: "eth0", IP: net.ParseIP("192.168.1.10").To4(), Flags: physical, HardwareAddr: mac},
+				{Name: "eth1", IP: net.ParseIP("192.168.2.10").To4(), Flags: physical, HardwareAddr: mac},
+			},
+			wantName: "eth0",
+			wantOK:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			best, ok := BestInterface(tt.candidates)
+			if ok != tt.wantOK {
+				t.Fatalf("BestInterface() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && best.Name != tt.wantName {
+				t.Errorf("BestInterface() = %q, want %q", best.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestInterfaceForIP(t *testing.T) {
+	candidates := []InterfaceInfo{
+		{Name: "eth0", IP: net.ParseIP("192.168.1.10").To4()},
+		{Name: "en0", IP: net.ParseIP("192.168.50.185").To4()},
+	}
+
+	tests := []struct {
+		name     string
+		ip       string
+		wantName string
+		wantOK   bool
+	}{
+		{name: "matches second interface", ip: "192.168.50.185", wantName: "en0", wantOK: true},
+		{name: "matches 16-byte form", ip: "::ffff:192.168.1.10", wantName: "eth0", wantOK: true},
+		{name: "no interface owns the address", ip: "10.0.0.1", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := InterfaceForIP(candidates, net.ParseIP(tt.ip))
+			if ok != tt.wantOK {
+				t.Fatalf("InterfaceForIP() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if got.Name != tt.wantName {
+				t.Errorf("InterfaceForIP() = %q, want %q", got.Name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestIsVirtualInterface(t *testing.T) {
+	tests := []struct {
+		name     string
+		iface    string
+		expected bool
+	}{
+		{name: "docker bridge", iface: "docker0", expected: true},
+		{name: "veth pair", iface: "veth1a2b3c", expected: true},
+		{name: "wireguard", iface: "wg0", expected: true},
+		{name: "OpenVPN adapter", iface: "OpenVPN TAP-Windows6", expected: true},
+		{name: "Linux ethernet", iface: "eth0", expected: false},
+		{name: "macOS ethernet", iface: "en0", expected: false},
+		{name: "Windows Wi-Fi", iface: "Wi-Fi", expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isVirtualInterface(tt.iface); got != tt.expected {
+				t.Errorf("isVirtualInterface(%q) = %v, want %v", tt.iface, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSubnetBroadcast(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   string
+		mask net.IPMask
+		want string
+	}{
+		{name: "/24", ip: "192.168.1.64", mask: net.CIDRMask(24, 32), want: "192.168.1.255"},
+		{name: "/23", ip: "10.0.4.20", mask: net.CIDRMask(23, 32), want: "10.0.5.255"},
+		{name: "16-byte mask", ip: "192.168.1.64", mask: net.CIDRMask(120, 128), want: "192.168.1.255"},
+		{name: "link-local /16", ip: "169.254.12.34", mask: net.CIDRMask(16, 32), want: "169.254.255.255"},
+		{name: "link-local reported as /24", ip: "169.254.12.34", mask: net.CIDRMask(24, 32), want: "169.254.255.255"},
+		{name: "link-local without mask", ip: "169.254.0.1", mask: nil, want: "169.254.255.255"},
+		{name: "no mask", ip: "192.168.1.64", mask: nil, want: "<nil>"},
+		{name: "IPv6", ip: "fe80::1", mask: net.CIDRMask(64, 128), want: "<nil>"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := subnetBroadcast(net.ParseIP(tt.ip), tt.mask)
+			if got.String() != tt.want {
+				t.Errorf("subnetBroadcast(%s, %s) = %s, want %s", tt.ip, tt.mask, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIPv6Interfaces(t *testing.T) {
+	interfaces, err := IPv6Interfaces()
+	if err != nil {
+		t.Fatalf("IPv6Interfaces() error = %v", err)
+	}
+	for _, iface := range interfaces {
+		if iface.IP.To4() != nil || !iface.IP.IsLinkLocalUnicast() {
+			t.Errorf("%s: %s is not an IPv6 link-local address", iface.Name, iface.IP)
+		}
+		if iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+			t.Errorf("%s: flags %v, want a multicast non-loopback interface", iface.Name, iface.Flags)
+		}
+	}
+}
+
+func TestAllowedInterfaces(t *testing.T) {
+	candidates := []InterfaceInfo{
+		{Name: "eth0", IP: net.ParseIP("192.168.1.10").To4()},
+		{Name: "eth0", IP: net.ParseIP("10.0.0.5").To4()},
+		{Name: "vEthernet (WSL)", IP: net.ParseIP("172.20.0.1").To4()},
+		{Name: "en0", IP: net.ParseIP("192.168.50.185").To4()},
+	}
+
+	tests := []struct {
+		name   string
+		names  []string
+		source string
+		want   []string
+	}{
+		{name: "one name keeps all its addresses", names: []string{"eth0"}, want: []string{"192.168.1.10", "10.0.0.5"}},
+		{name: "several names", names: []string{"en0", "eth0"}, want: []string{"192.168.1.10", "10.0.0.5", "192.168.50.185"}},
+		{name: "source address", source: "192.168.50.185", want: []string{"192.168.50.185"}},
+		{name: "name and source together", names: []string{"eth0"}, source: "10.0.0.5", want: []string{"10.0.0.5"}},
+		{name: "source on another interface", names: []string{"eth0"}, source: "192.168.50.185", want: nil},
+		{name: "unknown name", names: []string{"wlan0"}, want: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var source net.IP
+			if tt.source != "" {
+				source = net.ParseIP(tt.source)
+			}
+			var got []string
+			for _, c := range allowedInterfaces(candidates, tt.names, source) {
+				got = append(got, c.IP.String())
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("allowedInterfaces() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiscoverOnInterfacesErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		names []string
+	}{
+		{name: "no names", names: nil},
+		{name: "no such interface", names: []string{"sadp-test-missing0"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(100*time.Millisecond, nil)
+			if _, err := s.DiscoverOnInterfaces(tt.names); err == nil {
+				t.Errorf("DiscoverOnInterfaces(%v) error = nil, want an error", tt.names)
+			}
+		})
+	}
+}
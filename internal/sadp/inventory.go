@@ -0,0 +1,127 @@
+package sadp
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// InventoryGroup is the group/variable name used in generated inventories
+const InventoryGroup = "hikvision"
+
+var inventoryNameInvalid = regexp.MustCompile(`[^a-z0-9_]+`)
+
+// InventoryHostname returns a stable, inventory-safe host name for a device,
+// derived from its MAC address (falling back to serial number, then IP)
+func InventoryHostname(dev *Device) string {
+	id := dev.MAC
+	if id == "" {
+		id = dev.DeviceSN
+	}
+	if id == "" {
+		id = dev.IPv4Address
+	}
+	id = strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "_").Replace(id))
+	return "hik_" + strings.Trim(inventoryNameInvalid.ReplaceAllString(id, "_"), "_")
+}
+
+// inventoryHostVars returns the host variables emitted for each device
+func inventoryHostVars(dev *Device) map[string]string {
+	return map[string]string{
+		"model":    dev.DeviceType,
+		"serial":   dev.DeviceSN,
+		"mac":      dev.MAC,
+		"firmware": dev.SoftwareVersion,
+		"site":     dev.Site,
+	}
+}
+
+// ToAnsibleInventory generates an INI Ansible inventory with one host per
+// device in the hikvision group
+func (s *Scanner) ToAnsibleInventory(devices []*Device) string {
+	var sb strings.Builder
+	sb.WriteString("[" + InventoryGroup + "]\n")
+
+	byName := inventoryByName(devices)
+	for _, name := range sortedInventoryNames(byName) {
+		dev := byName[name]
+		vars := inventoryHostVars(dev)
+		sb.WriteString(fmt.Sprintf("%s ansible_host=%s", name, dev.IPv4Address))
+		for _, key := range []string{"model", "serial", "mac", "firmware", "site"} {
+			if vars[key] == "" {
+				continue
+			}
+			sb.WriteString(fmt.Sprintf(" %s=%s", key, ansibleQuote(vars[key])))
+		}
+		sb.WriteString("\n")
+	}
+
+	return sb.String()
+}
+
+// terraformDevice is a single device entry in the Terraform variables map
+type terraformDevice struct {
+	IP       string `json:"ip"`
+	Model    string `json:"model"`
+	Serial   string `json:"serial"`
+	MAC      string `json:"mac"`
+	Firmware string `json:"firmware"`
+	HTTPPort int    `json:"http_port"`
+	SDKPort  int    `json:"sdk_port"`
+	Site     string `json:"site,omitempty"`
+	Tags     Tags   `json:"tags,omitempty"`
+}
+
+// ToTerraformJSON generates a .tfvars.json document mapping host names to
+// device attributes under a hikvision_devices variable
+func (s *Scanner) ToTerraformJSON(devices []*Device) (string, error) {
+	entries := make(map[string]terraformDevice, len(devices))
+	for name, dev := range inventoryByName(devices) {
+		entries[name] = terraformDevice{
+			IP:       dev.IPv4Address,
+			Model:    dev.DeviceType,
+			Serial:   dev.DeviceSN,
+			MAC:      dev.MAC,
+			Firmware: dev.SoftwareVersion,
+			HTTPPort: int(dev.HttpPort),
+			SDKPort:  int(dev.CommandPort),
+			Site:     dev.Site,
+			Tags:     dev.Tags,
+		}
+	}
+
+	output, err := json.MarshalIndent(map[string]interface{}{
+		InventoryGroup + "_devices": entries,
+	}, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(output), nil
+}
+
+func inventoryByName(devices []*Device) map[string]*Device {
+	byName := make(map[string]*Device, len(devices))
+	for _, dev := range devices {
+		byName[InventoryHostname(dev)] = dev
+	}
+	return byName
+}
+
+func sortedInventoryNames(byName map[string]*Device) []string {
+	names := make([]string, 0, len(byName))
+	for name := range byName {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ansibleQuote quotes INI values containing whitespace or quotes
+func ansibleQuote(v string) string {
+	if !strings.ContainsAny(v, " \t\"'=") {
+		return v
+	}
+	return `"` + strings.ReplaceAll(v, `"`, `\"`) + `"`
+}
@@ -0,0 +1,99 @@
+package sadp
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestInventoryHostname(t *testing.T) {
+	tests := []struct {
+		name   string
+		device *Device
+		want   string
+	}{
+		{name: "from MAC", device: &Device{MAC: "4C:BD:8F:61:CC:5C", DeviceSN: "SN1"}, want: "hik_4cbd8f61cc5c"},
+		{name: "from serial", device: &Device{DeviceSN: "DS-2CD2143G0-I2021"}, want: "hik_ds2cd2143g0i2021"},
+		{name: "from IP", device: &Device{IPv4Address: "10.0.0.5"}, want: "hik_10_0_0_5"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := InventoryHostname(tt.device); got != tt.want {
+				t.Errorf("InventoryHostname() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToAnsibleInventory(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*Device
+		want    string
+	}{
+		{
+			name:    "empty",
+			devices: nil,
+			want:    "[hikvision]\n",
+		},
+		{
+			name: "sorted hosts with vars",
+			devices: []*Device{
+				{MAC: "BB:00:00:00:00:02", IPv4Address: "10.0.0.2", DeviceType: "DS-7608NI-K2", SoftwareVersion: "V4.0 build 1"},
+				{MAC: "AA:00:00:00:00:01", IPv4Address: "10.0.0.1", DeviceType: "DS-2CD2143G0-I", DeviceSN: "SN1"},
+			},
+			want: "[hikvision]\n" +
+				"hik_aa0000000001 ansible_host=10.0.0.1 model=DS-2CD2143G0-I serial=SN1 mac=AA:00:00:00:00:01\n" +
+				"hik_bb0000000002 ansible_host=10.0.0.2 model=DS-7608NI-K2 mac=BB:00:00:00:00:02 firmware=\"V4.0 build 1\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, nil)
+			if got := s.ToAnsibleInventory(tt.devices); got != tt.want {
+				t.Errorf("ToAnsibleInventory() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestToTerraformJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*Device
+		wantKey string
+	}{
+		{
+			name:    "single device",
+			devices: []*Device{{MAC: "AA:00:00:00:00:01", IPv4Address: "10.0.0.1", HttpPort: 80, CommandPort: 8000}},
+			wantKey: "hik_aa0000000001",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScanner(DefaultTimeout, nil)
+			output, err := s.ToTerraformJSON(tt.devices)
+			if err != nil {
+				t.Fatalf("ToTerraformJSON() error = %v", err)
+			}
+
+			var parsed map[string]map[string]terraformDevice
+			if err := json.Unmarshal([]byte(output), &parsed); err != nil {
+				t.Fatalf("output is not valid JSON: %v", err)
+			}
+			dev, ok := parsed["hikvision_devices"][tt.wantKey]
+			if !ok {
+				t.Fatalf("missing %s in %s", tt.wantKey, output)
+			}
+			if dev.IP != tt.devices[0].IPv4Address || dev.SDKPort != int(tt.devices[0].CommandPort) {
+				t.Errorf("entry = %+v", dev)
+			}
+			if !strings.Contains(output, `"http_port": 80`) {
+				t.Errorf("output missing http_port: %s", output)
+			}
+		})
+	}
+}
@@ -0,0 +1,84 @@
+package sadp
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// ListField selects the value FieldList prints per device
+type ListField string
+
+// Supported list fields
+const (
+	ListIP     ListField = "ip"
+	ListMAC    ListField = "mac"
+	ListIPPort ListField = "ip:port"
+)
+
+// ParseListField validates a --list value
+func ParseListField(s string) (ListField, error) {
+	switch field := ListField(strings.ToLower(strings.TrimSpace(s))); field {
+	case ListIP, ListMAC, ListIPPort:
+		return field, nil
+	default:
+		return "", fmt.Errorf("unknown list field %q (use %s, %s or %s)", s, ListIP, ListMAC, ListIPPort)
+	}
+}
+
+// FieldList returns field for each device, deduplicated and sorted:
+// addresses numerically, MACs alphabetically. MACs are upper case and colon
+// separated; ip:port uses the SDK (command) port. Devices lacking the field
+// are left out.
+func FieldList(devices []*Device, field ListField) []string {
+	type entry struct {
+		value string
+		ip    net.IP
+		port  int
+	}
+	seen := make(map[string]bool)
+	var entries []entry
+	for _, dev := range devices {
+		e := entry{port: int(dev.CommandPort)}
+		switch field {
+		case ListMAC:
+			e.value = network.NormalizeMAC(dev.MAC)
+		case ListIP, ListIPPort:
+			e.ip = net.ParseIP(strings.TrimSpace(dev.IPv4Address))
+			if e.ip == nil || (field == ListIPPort && e.port == 0) {
+				continue
+			}
+			e.value = e.ip.String()
+			if field == ListIPPort {
+				e.value = net.JoinHostPort(e.value, strconv.Itoa(e.port))
+			}
+		}
+		if e.value == "" || seen[e.value] {
+			continue
+		}
+		seen[e.value] = true
+		entries = append(entries, e)
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		a, b := entries[i], entries[j]
+		if a.ip == nil {
+			return a.value < b.value
+		}
+		if c := bytes.Compare(a.ip.To16(), b.ip.To16()); c != 0 {
+			return c < 0
+		}
+		return a.port < b.port
+	})
+
+	values := make([]string, len(entries))
+	for i, e := range entries {
+		values[i] = e.value
+	}
+	return values
+}
@@ -0,0 +1,51 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseListField(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    ListField
+		wantErr bool
+	}{
+		{in: "ip", want: ListIP},
+		{in: " MAC ", want: ListMAC},
+		{in: "ip:port", want: ListIPPort},
+		{in: "serial", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseListField(tt.in)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseListField(%q) = %q, %v; want %q, wantErr %v", tt.in, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestFieldList(t *testing.T) {
+	devices := []*Device{
+		{IPv4Address: "192.168.1.100", MAC: "4c-bd-8f-61-cc-5c", CommandPort: 8000},
+		{IPv4Address: "192.168.1.9", MAC: "4C:BD:8F:00:00:01", CommandPort: 8000},
+		{IPv4Address: "192.168.1.100", MAC: "4C:BD:8F:61:CC:5C", CommandPort: 8000},
+		{IPv4Address: "192.168.1.9", MAC: "4C:BD:8F:00:00:02", CommandPort: 8001},
+		{MAC: "AA:BB:CC:00:00:01"},
+	}
+
+	tests := []struct {
+		field ListField
+		want  []string
+	}{
+		{field: ListIP, want: []string{"192.168.1.9", "192.168.1.100"}},
+		{field: ListMAC, want: []string{"4C:BD:8F:00:00:01", "4C:BD:8F:00:00:02", "4C:BD:8F:61:CC:5C", "AA:BB:CC:00:00:01"}},
+		{field: ListIPPort, want: []string{"192.168.1.9:8000", "192.168.1.9:8001", "192.168.1.100:8000"}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.field), func(t *testing.T) {
+			if got := FieldList(devices, tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FieldList(%s) = %v, want %v", tt.field, got, tt.want)
+			}
+		})
+	}
+}
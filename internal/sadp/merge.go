@@ -0,0 +1,29 @@
+package sadp
+
+import "reflect"
+
+// mergeDevice folds a later response for the same device into existing.
+// Non-empty fields of update replace those of existing; empty fields never
+// clear a value. inquiry and inquiry_v32 replies carry different field sets,
+// so merging makes the result complete whichever arrives first.
+// ReceivedTime is the exception: the earliest reply's time is kept, so it
+// stays the time the device was first received.
+func mergeDevice(existing, update *Device) {
+	if existing == nil || update == nil {
+		return
+	}
+
+	first := existing.ReceivedTime
+	dst := reflect.ValueOf(existing).Elem()
+	src := reflect.ValueOf(update).Elem()
+	for i := 0; i < src.NumField(); i++ {
+		field := src.Field(i)
+		if !dst.Field(i).CanSet() || field.IsZero() {
+			continue
+		}
+		dst.Field(i).Set(field)
+	}
+	if !first.IsZero() && first.Before(existing.ReceivedTime) {
+		existing.ReceivedTime = first
+	}
+}
@@ -0,0 +1,125 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMergeDevice(t *testing.T) {
+	received := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	inquiry := func() *Device {
+		return &Device{
+			Types:           "inquiry",
+			MAC:             "4C:BD:8F:61:CC:5C",
+			IPv4Address:     "192.168.1.64",
+			DeviceType:      "DS-2CD2143G0-I",
+			SoftwareVersion: "V5.5.0build 170725",
+			CommandPort:     8000,
+		}
+	}
+	v32 := func() *Device {
+		return &Device{
+			Types:             "inquiry_v32",
+			MAC:               "4C:BD:8F:61:CC:5C",
+			IPv4Address:       "192.168.1.64",
+			DeviceSN:          "DS-2CD2143G0-I20190101AAWRC12345678",
+			HttpPort:          80,
+			PasswordResetMode: "1",
+			Tags:              Tags{{Key: "zone", Value: "a"}},
+			ReceivedTime:      received,
+		}
+	}
+
+	tests := []struct {
+		name     string
+		existing *Device
+		update   *Device
+		check    func(t *testing.T, d *Device)
+	}{
+		{
+			name:     "v32 after inquiry fills missing fields",
+			existing: inquiry(),
+			update:   v32(),
+			check: func(t *testing.T, d *Device) {
+				if d.DeviceSN == "" || d.HttpPort != 80 || d.PasswordResetMode != "1" {
+					t.Errorf("v32 fields not merged: %+v", d)
+				}
+				if d.SoftwareVersion == "" || d.CommandPort != 8000 || d.DeviceType == "" {
+					t.Errorf("inquiry fields lost: %+v", d)
+				}
+				if len(d.Tags) != 1 || !d.ReceivedTime.Equal(received) {
+					t.Errorf("slice/time fields not merged: %+v", d)
+				}
+			},
+		},
+		{
+			name:     "inquiry after v32 gives the same fields",
+			existing: v32(),
+			update:   inquiry(),
+			check: func(t *testing.T, d *Device) {
+				if d.DeviceSN == "" || d.HttpPort != 80 || d.SoftwareVersion == "" || d.CommandPort != 8000 {
+					t.Errorf("fields missing after merge: %+v", d)
+				}
+			},
+		},
+		{
+			name:     "newer non-empty value wins",
+			existing: &Device{MAC: "4C:BD:8F:61:CC:5C", IPv4Address: "192.168.1.64"},
+			update:   &Device{MAC: "4C:BD:8F:61:CC:5C", IPv4Address: "192.168.1.65"},
+			check: func(t *testing.T, d *Device) {
+				if d.IPv4Address != "192.168.1.65" {
+					t.Errorf("IPv4Address = %q, want 192.168.1.65", d.IPv4Address)
+				}
+			},
+		},
+		{
+			name:     "empty values do not clear",
+			existing: inquiry(),
+			update:   &Device{MAC: "4C:BD:8F:61:CC:5C"},
+			check: func(t *testing.T, d *Device) {
+				if !reflect.DeepEqual(d, inquiry()) {
+					t.Errorf("device changed: %+v", d)
+				}
+			},
+		},
+		{
+			name:     "earliest received time is kept",
+			existing: &Device{MAC: "4C:BD:8F:61:CC:5C", ReceivedTime: received},
+			update:   &Device{MAC: "4C:BD:8F:61:CC:5C", ReceivedTime: received.Add(time.Minute)},
+			check: func(t *testing.T, d *Device) {
+				if !d.ReceivedTime.Equal(received) {
+					t.Errorf("ReceivedTime = %v, want the first reply's %v", d.ReceivedTime, received)
+				}
+			},
+		},
+		{
+			name:     "earlier received time replaces a later one",
+			existing: &Device{MAC: "4C:BD:8F:61:CC:5C", ReceivedTime: received.Add(time.Minute)},
+			update:   &Device{MAC: "4C:BD:8F:61:CC:5C", ReceivedTime: received},
+			check: func(t *testing.T, d *Device) {
+				if !d.ReceivedTime.Equal(received) {
+					t.Errorf("ReceivedTime = %v, want %v", d.ReceivedTime, received)
+				}
+			},
+		},
+		{
+			name:     "nil update is ignored",
+			existing: inquiry(),
+			update:   nil,
+			check: func(t *testing.T, d *Device) {
+				if d.DeviceType != "DS-2CD2143G0-I" {
+					t.Errorf("device changed: %+v", d)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mergeDevice(tt.existing, tt.update)
+			tt.check(t, tt.existing)
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package sadp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"time"
+)
+
+// multicastCheckTimeout bounds how long CheckMulticast waits for its own
+// looped-back packet
+const multicastCheckTimeout = 500 * time.Millisecond
+
+// CheckMulticast reports whether SADP multicast is usable from localIP: the
+// owning interface must support multicast, accept a join of the SADP group,
+// and deliver a packet sent to the group back to the joined socket. Some
+// cloud VMs and VPN adapters fail one of these, in which case discovery
+// would silently find nothing.
+func CheckMulticast(localIP net.IP) error {
+	if localIP == nil || localIP.To4() == nil {
+		return fmt.Errorf("invalid interface IP %v", localIP)
+	}
+
+	iface, err := interfaceByIP(localIP)
+	if err != nil {
+		return err
+	}
+	if err := checkMulticastFlags(iface.Flags); err != nil {
+		return fmt.Errorf("interface %s: %w", iface.Name, err)
+	}
+
+	group := net.ParseIP(MulticastAddr)
+	recv, err := net.ListenMulticastUDP("udp4", iface, &net.UDPAddr{IP: group})
+	if err != nil {
+		return fmt.Errorf("interface %s: cannot join multicast group %s: %w", iface.Name, MulticastAddr, err)
+	}
+	defer recv.Close()
+
+	send, err := net.ListenUDP("udp4", &net.UDPAddr{IP: localIP})
+	if err != nil {
+		return fmt.Errorf("interface %s: cannot open send socket: %w", iface.Name, err)
+	}
+	defer send.Close()
+
+	token := []byte(fmt.Sprintf("sadp-multicast-check-%d", time.Now().UnixNano()))
+	target := &net.UDPAddr{IP: group, Port: recv.LocalAddr().(*net.UDPAddr).Port}
+	if _, err := send.WriteToUDP(token, target); err != nil {
+		return fmt.Errorf("interface %s: cannot send to multicast group %s: %w", iface.Name, MulticastAddr, err)
+	}
+
+	_ = recv.SetReadDeadline(time.Now().Add(multicastCheckTimeout))
+	buf := make([]byte, len(token)+1)
+	for {
+		n, _, err := recv.ReadFromUDP(buf)
+		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				return fmt.Errorf("interface %s: multicast packets to %s are not delivered", iface.Name, MulticastAddr)
+			}
+			return fmt.Errorf("interface %s: multicast receive failed: %w", iface.Name, err)
+		}
+		if string(buf[:n]) == string(token) {
+			return nil
+		}
+	}
+}
+
+// checkMulticastFlags rejects interfaces that are down or lack multicast
+func checkMulticastFlags(flags net.Flags) error {
+	if flags&net.FlagUp == 0 {
+		return fmt.Errorf("interface is down")
+	}
+	if flags&net.FlagMulticast == 0 {
+		return fmt.Errorf("interface does not support multicast")
+	}
+	return nil
+}
+
+// interfaceByIP returns the system interface that has ip assigned
+func interfaceByIP(ip net.IP) (*net.Interface, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
+	}
+	for i := range ifaces {
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return &ifaces[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no interface has address %s", ip)
+}
@@ -0,0 +1,45 @@
+package sadp
+
+import (
+	"net"
+	"testing"
+)
+
+func TestCheckMulticastFlags(t *testing.T) {
+	tests := []struct {
+		name    string
+		flags   net.Flags
+		wantErr bool
+	}{
+		{name: "up with multicast", flags: net.FlagUp | net.FlagMulticast},
+		{name: "down", flags: net.FlagMulticast, wantErr: true},
+		{name: "no multicast", flags: net.FlagUp | net.FlagPointToPoint, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := checkMulticastFlags(tt.flags); (err != nil) != tt.wantErr {
+				t.Errorf("checkMulticastFlags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCheckMulticastInvalidIP(t *testing.T) {
+	tests := []struct {
+		name string
+		ip   net.IP
+	}{
+		{name: "nil", ip: nil},
+		{name: "ipv6", ip: net.ParseIP("fe80::1")},
+		{name: "not assigned locally", ip: net.ParseIP("203.0.113.254")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckMulticast(tt.ip); err == nil {
+				t.Errorf("CheckMulticast(%v) succeeded, want error", tt.ip)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package sadp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// officialDevice is one <Device> row of the official SADP tool's XML export.
+// Element names follow the tool's column headings with spaces and
+// punctuation removed.
+type officialDevice struct {
+	DeviceType             string `xml:"DeviceType"`
+	Status                 string `xml:"Status"`
+	IPv4Address            string `xml:"IPv4Address"`
+	Port                   string `xml:"Port"`
+	EnhancedSDKServicePort string `xml:"EnhancedSDKServicePort"`
+	SoftwareVersion        string `xml:"SoftwareVersion"`
+	IPv4Gateway            string `xml:"IPv4Gateway"`
+	HTTPPort               string `xml:"HTTPPort"`
+	DeviceSerialNo         string `xml:"DeviceSerialNo"`
+	SubnetMask             string `xml:"SubnetMask"`
+	MACAddress             string `xml:"MACAddress"`
+	EncodingChannels       string `xml:"EncodingChannels"`
+	DSPVersion             string `xml:"DSPVersion"`
+	StartTime              string `xml:"StartTime"`
+	IPv6Address            string `xml:"IPv6Address"`
+	IPv6Gateway            string `xml:"IPv6Gateway"`
+	IPv6PrefixLength       string `xml:"IPv6PrefixLength"`
+	IPv4DHCP               string `xml:"IPv4DHCP"`
+	SupportHikConnect      string `xml:"SupportHikConnect"`
+	HikConnect             string `xml:"HikConnect"`
+}
+
+// ParseOfficialSADPExport parses a device list exported from the official
+// SADP tool. Values the tool shows as "N/A" or leaves blank are left empty.
+func ParseOfficialSADPExport(data []byte) ([]*Device, error) {
+	var list struct {
+		Devices []officialDevice `xml:"Device"`
+	}
+	if err := xml.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("invalid SADP export XML: %w", err)
+	}
+
+	devices := make([]*Device, 0, len(list.Devices))
+	for i, d := range list.Devices {
+		dev := &Device{
+			DeviceType:        officialValue(d.DeviceType),
+			Activated:         officialBool(d.Status, "active"),
+			IPv4Address:       officialValue(d.IPv4Address),
+			SoftwareVersion:   officialValue(d.SoftwareVersion),
+			IPv4Gateway:       officialValue(d.IPv4Gateway),
+			DeviceSN:          officialValue(d.DeviceSerialNo),
+			IPv4SubnetMask:    officialValue(d.SubnetMask),
+			MAC:               strings.ToUpper(strings.ReplaceAll(officialValue(d.MACAddress), "-", ":")),
+			DSPVersion:        officialValue(d.DSPVersion),
+			BootTime:          officialValue(d.StartTime),
+			IPv6Address:       officialValue(d.IPv6Address),
+			IPv6Gateway:       officialValue(d.IPv6Gateway),
+			DHCP:              officialBool(d.IPv4DHCP, "on"),
+			SupportHCPlatform: officialBool(d.SupportHikConnect, "yes"),
+			HCPlatformEnable:  officialBool(d.HikConnect, "on"),
+		}
+
+		var commandPort, sdkTLSPort, httpPort int
+		ints := []struct {
+			name  string
+			value string
+			dst   *int
+		}{
+			{"Port", d.Port, &commandPort},
+			{"EnhancedSDKServicePort", d.EnhancedSDKServicePort, &sdkTLSPort},
+			{"HTTPPort", d.HTTPPort, &httpPort},
+			{"EncodingChannels", d.EncodingChannels, &dev.DigitalChannelNum},
+			{"IPv6PrefixLength", d.IPv6PrefixLength, &dev.IPv6MaskLen},
+		}
+		for _, f := range ints {
+			v := officialValue(f.value)
+			if v == "" {
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("device %d: invalid %s %q", i+1, f.name, v)
+			}
+			*f.dst = n
+		}
+		dev.CommandPort = PortNumber(commandPort)
+		dev.SDKOverTLSPort = PortNumber(sdkTLSPort)
+		dev.HttpPort = PortNumber(httpPort)
+
+		devices = append(devices, dev)
+	}
+
+	return devices, nil
+}
+
+// officialValue trims a cell and treats the tool's placeholders as empty
+func officialValue(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.EqualFold(s, "N/A") {
+		return ""
+	}
+	return s
+}
+
+// officialBool maps the tool's wording (Active/Inactive, ON/OFF, Yes/No)
+// onto the "true"/"false" strings SADP replies use
+func officialBool(s, truthy string) string {
+	s = officialValue(s)
+	if s == "" {
+		return ""
+	}
+	if strings.EqualFold(s, truthy) {
+		return "true"
+	}
+	return "false"
+}
+
+// xmlRootElement returns the name of the document's root element
+func xmlRootElement(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := dec.Token()
+		if errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("invalid device XML: no root element")
+		}
+		if err != nil {
+			return "", fmt.Errorf("invalid device XML: %w", err)
+		}
+		if start, ok := tok.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
+
+// loadDevicesFromAnyXML detects whether data is this tool's SADPDeviceList
+// or an official SADP tool export and parses it accordingly
+func loadDevicesFromAnyXML(data []byte) ([]*Device, error) {
+	root, err := xmlRootElement(data)
+	if err != nil {
+		return nil, err
+	}
+	if root == "SADPDeviceList" {
+		return LoadDevicesFromXML(data)
+	}
+	return ParseOfficialSADPExport(data)
+}
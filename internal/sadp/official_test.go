@@ -0,0 +1,162 @@
+package sadp
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+const officialExportSample = `<?xml version="1.0" encoding="UTF-8"?>
+<DeviceList>
+	<Device>
+		<ID>001</ID>
+		<DeviceType>DS-2CD2042WD-I</DeviceType>
+		<Status>Active</Status>
+		<IPv4Address>192.168.1.64</IPv4Address>
+		<Port>8000</Port>
+		<EnhancedSDKServicePort>8443</EnhancedSDKServicePort>
+		<SoftwareVersion>V5.5.0build 191126</SoftwareVersion>
+		<IPv4Gateway>192.168.1.1</IPv4Gateway>
+		<HTTPPort>80</HTTPPort>
+		<DeviceSerialNo>DS-2CD2042WD-I20160101AAWR123456789</DeviceSerialNo>
+		<SubnetMask>255.255.255.0</SubnetMask>
+		<MACAddress>4c-bd-8f-61-cc-5c</MACAddress>
+		<EncodingChannels>1</EncodingChannels>
+		<DSPVersion>V7.3 build 191126</DSPVersion>
+		<StartTime>2024-01-01 10:00:00</StartTime>
+		<IPv6Address>::</IPv6Address>
+		<IPv6Gateway>::</IPv6Gateway>
+		<IPv6PrefixLength>64</IPv6PrefixLength>
+		<SupportIPv6>Yes</SupportIPv6>
+		<IPv6Modifiable>No</IPv6Modifiable>
+		<SupportDHCP>Yes</SupportDHCP>
+		<IPv4DHCP>OFF</IPv4DHCP>
+		<SupportHikConnect>Yes</SupportHikConnect>
+		<HikConnect>ON</HikConnect>
+	</Device>
+	<Device>
+		<ID>002</ID>
+		<DeviceType>DS-7616NI-I2</DeviceType>
+		<Status>Inactive</Status>
+		<IPv4Address>192.168.1.101</IPv4Address>
+		<Port>8000</Port>
+		<EnhancedSDKServicePort>N/A</EnhancedSDKServicePort>
+		<HTTPPort>N/A</HTTPPort>
+		<MACAddress>11-22-33-44-55-66</MACAddress>
+		<IPv4DHCP>ON</IPv4DHCP>
+	</Device>
+</DeviceList>`
+
+func TestParseOfficialSADPExport(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []*Device
+		wantErr bool
+	}{
+		{
+			name: "sample export",
+			data: officialExportSample,
+			want: []*Device{
+				{
+					DeviceType:        "DS-2CD2042WD-I",
+					Activated:         "true",
+					IPv4Address:       "192.168.1.64",
+					CommandPort:       8000,
+					SDKOverTLSPort:    8443,
+					SoftwareVersion:   "V5.5.0build 191126",
+					IPv4Gateway:       "192.168.1.1",
+					HttpPort:          80,
+					DeviceSN:          "DS-2CD2042WD-I20160101AAWR123456789",
+					IPv4SubnetMask:    "255.255.255.0",
+					MAC:               "4C:BD:8F:61:CC:5C",
+					DigitalChannelNum: 1,
+					DSPVersion:        "V7.3 build 191126",
+					BootTime:          "2024-01-01 10:00:00",
+					IPv6Address:       "::",
+					IPv6Gateway:       "::",
+					IPv6MaskLen:       64,
+					DHCP:              "false",
+					SupportHCPlatform: "true",
+					HCPlatformEnable:  "true",
+				},
+				{
+					DeviceType:  "DS-7616NI-I2",
+					Activated:   "false",
+					IPv4Address: "192.168.1.101",
+					CommandPort: 8000,
+					MAC:         "11:22:33:44:55:66",
+					DHCP:        "true",
+				},
+			},
+		},
+		{
+			name: "empty export",
+			data: `<DeviceList></DeviceList>`,
+			want: []*Device{},
+		},
+		{
+			name:    "invalid port",
+			data:    `<DeviceList><Device><Port>abc</Port></Device></DeviceList>`,
+			wantErr: true,
+		},
+		{
+			name:    "malformed XML",
+			data:    `<DeviceList><Device>`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseOfficialSADPExport([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseOfficialSADPExport() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseOfficialSADPExport() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadDevicesFromFileDetectsXMLSchema(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     string
+		wantMACs []string
+	}{
+		{
+			name:     "official export",
+			data:     officialExportSample,
+			wantMACs: []string{"4C:BD:8F:61:CC:5C", "11:22:33:44:55:66"},
+		},
+		{
+			name:     "native device list",
+			data:     `<SADPDeviceList version="1.0"><ProbeMatch><MAC>AA:BB:CC:DD:EE:FF</MAC></ProbeMatch></SADPDeviceList>`,
+			wantMACs: []string{"AA:BB:CC:DD:EE:FF"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "devices.xml")
+			if err := os.WriteFile(path, []byte(tt.data), 0o644); err != nil {
+				t.Fatal(err)
+			}
+
+			devices, err := LoadDevicesFromFile(path)
+			if err != nil {
+				t.Fatalf("LoadDevicesFromFile() error = %v", err)
+			}
+			var macs []string
+			for _, d := range devices {
+				macs = append(macs, d.MAC)
+			}
+			if !reflect.DeepEqual(macs, tt.wantMACs) {
+				t.Errorf("MACs = %v, want %v", macs, tt.wantMACs)
+			}
+		})
+	}
+}
@@ -0,0 +1,48 @@
+package sadp
+
+import (
+	"fmt"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Activation password limits enforced by Hikvision firmware
+const (
+	MinPasswordLength = 8
+	MaxPasswordLength = 16
+)
+
+// ValidatePassword checks pw against the rules devices apply on
+// activation: 8 to 16 characters from at least two of lowercase letters,
+// uppercase letters, digits and special characters. Devices reject weaker
+// passwords with an unhelpful failure, so activate checks first.
+func ValidatePassword(pw string) error {
+	if n := utf8.RuneCountInString(pw); n < MinPasswordLength || n > MaxPasswordLength {
+		return fmt.Errorf("password must be %d to %d characters, got %d", MinPasswordLength, MaxPasswordLength, n)
+	}
+
+	var lower, upper, digit, special bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			special = true
+		}
+	}
+
+	classes := 0
+	for _, has := range []bool{lower, upper, digit, special} {
+		if has {
+			classes++
+		}
+	}
+	if classes < 2 {
+		return fmt.Errorf("password must contain at least two character types (lowercase, uppercase, digits, special characters)")
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package sadp
+
+import "testing"
+
+func TestValidatePassword(t *testing.T) {
+	tests := []struct {
+		name    string
+		pw      string
+		wantErr bool
+	}{
+		{name: "7 characters", pw: "abcde12", wantErr: true},
+		{name: "8 characters", pw: "abcdef12", wantErr: false},
+		{name: "16 characters", pw: "abcdefghijklmn12", wantErr: false},
+		{name: "17 characters", pw: "abcdefghijklmno12", wantErr: true},
+		{name: "empty", pw: "", wantErr: true},
+		{name: "lowercase only", pw: "abcdefgh", wantErr: true},
+		{name: "digits only", pw: "12345678", wantErr: true},
+		{name: "special only", pw: "!@#$%^&*", wantErr: true},
+		{name: "upper and lower", pw: "ABCDefgh", wantErr: false},
+		{name: "digits and special", pw: "1234567!", wantErr: false},
+		{name: "all four types", pw: "N3w-Passw0rd!", wantErr: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePassword(tt.pw)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidatePassword(%q) error = %v, wantErr %v", tt.pw, err, tt.wantErr)
+			}
+		})
+	}
+}
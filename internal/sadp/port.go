@@ -0,0 +1,26 @@
+package sadp
+
+import (
+	"encoding/xml"
+	"strconv"
+	"strings"
+)
+
+// PortNumber is a port field of a ProbeMatch reply. Some firmware sends an
+// empty or non-numeric value such as N/A; rather than failing the whole
+// reply, such values decode as zero (unknown).
+type PortNumber int
+
+// UnmarshalXML decodes the element text, treating unparsable values as zero
+func (p *PortNumber) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var s string
+	if err := d.DecodeElement(&s, &start); err != nil {
+		return err
+	}
+	n, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || n < 0 || n > 65535 {
+		n = 0
+	}
+	*p = PortNumber(n)
+	return nil
+}
@@ -0,0 +1,78 @@
+package sadp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrPortRangeExhausted is returned when no port in a PortRange can be bound
+var ErrPortRangeExhausted = errors.New("no port in the local port range could be bound")
+
+// PortRange is an inclusive range of local UDP source ports. The zero value
+// lets the OS choose an ephemeral port.
+type PortRange struct {
+	Low  int
+	High int
+}
+
+// ParsePortRange parses "40000-40100" or a single port "40000"
+func ParsePortRange(s string) (PortRange, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return PortRange{}, nil
+	}
+
+	lowStr, highStr, found := strings.Cut(s, "-")
+	if !found {
+		highStr = lowStr
+	}
+	low, err := strconv.Atoi(strings.TrimSpace(lowStr))
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	high, err := strconv.Atoi(strings.TrimSpace(highStr))
+	if err != nil {
+		return PortRange{}, fmt.Errorf("invalid port range %q", s)
+	}
+	if low < 1 || high > 65535 || low > high {
+		return PortRange{}, fmt.Errorf("invalid port range %q: ports must be 1-65535, low to high", s)
+	}
+	return PortRange{Low: low, High: high}, nil
+}
+
+// IsZero reports whether the range is unset
+func (r PortRange) IsZero() bool {
+	return r.Low == 0 && r.High == 0
+}
+
+// String formats the range as "low-high"
+func (r PortRange) String() string {
+	return fmt.Sprintf("%d-%d", r.Low, r.High)
+}
+
+// listenUDP binds a UDP socket on ip, using the first free port in r, or an
+// ephemeral port when r is unset. zone scopes an IPv6 link-local ip to its
+// interface and is ignored for IPv4.
+func listenUDP(ip net.IP, zone string, r PortRange) (*net.UDPConn, error) {
+	network := "udp4"
+	if ip.To4() == nil {
+		network = "udp6"
+	} else {
+		zone = ""
+	}
+	if r.IsZero() {
+		return net.ListenUDP(network, &net.UDPAddr{IP: ip, Port: 0, Zone: zone})
+	}
+	var lastErr error
+	for port := r.Low; port <= r.High; port++ {
+		conn, err := net.ListenUDP(network, &net.UDPAddr{IP: ip, Port: port, Zone: zone})
+		if err == nil {
+			return conn, nil
+		}
+		lastErr = err
+	}
+	return nil, fmt.Errorf("%w (%s on %s): %v", ErrPortRangeExhausted, r, ip, lastErr)
+}
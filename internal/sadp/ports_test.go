@@ -0,0 +1,117 @@
+package sadp
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestParsePortRange(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    PortRange
+		wantErr bool
+	}{
+		{name: "empty", input: "", want: PortRange{}},
+		{name: "range", input: "40000-40100", want: PortRange{Low: 40000, High: 40100}},
+		{name: "single port", input: "40000", want: PortRange{Low: 40000, High: 40000}},
+		{name: "spaces", input: " 40000 - 40100 ", want: PortRange{Low: 40000, High: 40100}},
+		{name: "reversed", input: "40100-40000", wantErr: true},
+		{name: "out of range", input: "0-70000", wantErr: true},
+		{name: "not a number", input: "high-low", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParsePortRange(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParsePortRange(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParsePortRange(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListenUDPPortRange(t *testing.T) {
+	loopback := net.IPv4(127, 0, 0, 1)
+
+	occupied, err := net.ListenUDP("udp4", &net.UDPAddr{IP: loopback, Port: 0})
+	if err != nil {
+		t.Skipf("cannot bind loopback: %v", err)
+	}
+	defer occupied.Close()
+	busy := occupied.LocalAddr().(*net.UDPAddr).Port
+
+	tests := []struct {
+		name      string
+		portRange PortRange
+		wantErr   error
+	}{
+		{name: "ephemeral when unset", portRange: PortRange{}},
+		{name: "only busy port", portRange: PortRange{Low: busy, High: busy}, wantErr: ErrPortRangeExhausted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := listenUDP(loopback, "", tt.portRange)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("listenUDP() error = %v, want %v", err, tt.wantErr)
+			}
+			if conn != nil {
+				conn.Close()
+			}
+		})
+	}
+
+	// The busy port is skipped and the next free one in the range is used
+	free, err := net.ListenUDP("udp4", &net.UDPAddr{IP: loopback, Port: 0})
+	if err != nil {
+		t.Fatalf("cannot bind loopback: %v", err)
+	}
+	freePort := free.LocalAddr().(*net.UDPAddr).Port
+	free.Close()
+
+	r := PortRange{Low: busy, High: busy}
+	if freePort > busy {
+		r.High = freePort
+	} else {
+		r.Low = freePort
+	}
+	conn, err := listenUDP(loopback, "", r)
+	if err != nil {
+		t.Fatalf("listenUDP(%s) error = %v", r, err)
+	}
+	defer conn.Close()
+	if got := conn.LocalAddr().(*net.UDPAddr).Port; got == busy || got < r.Low || got > r.High {
+		t.Errorf("bound port %d, want a free port in %s other than %d", got, r, busy)
+	}
+}
+
+func TestListenUDPFamily(t *testing.T) {
+	tests := []struct {
+		name   string
+		ip     net.IP
+		zone   string
+		wantV4 bool
+	}{
+		{name: "IPv4 ignores zone", ip: net.IPv4(127, 0, 0, 1), zone: "lo", wantV4: true},
+		{name: "IPv6 loopback", ip: net.IPv6loopback, wantV4: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, err := listenUDP(tt.ip, tt.zone, PortRange{})
+			if err != nil {
+				t.Skipf("cannot bind %s: %v", tt.ip, err)
+			}
+			defer conn.Close()
+			local := conn.LocalAddr().(*net.UDPAddr)
+			if gotV4 := local.IP.To4() != nil; gotV4 != tt.wantV4 {
+				t.Errorf("bound %s, want IPv4 = %v", local, tt.wantV4)
+			}
+		})
+	}
+}
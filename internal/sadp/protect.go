@@ -0,0 +1,108 @@
+package sadp
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// minSerialSuffix is the shortest protect-list serial matched against the
+// end of a device serial. The serial on device labels is typically the last
+// nine characters of the full one SADP reports.
+const minSerialSuffix = 9
+
+// ProtectEntry is one device that must not receive state-changing
+// commands. Exactly one of MAC and Serial is set.
+type ProtectEntry struct {
+	MAC    string
+	Serial string
+	Label  string
+}
+
+// String names the entry for error messages
+func (e ProtectEntry) String() string {
+	id := "MAC " + e.MAC
+	if e.Serial != "" {
+		id = "serial " + e.Serial
+	}
+	if e.Label != "" {
+		id += " (" + e.Label + ")"
+	}
+	return id
+}
+
+// ProtectList is a denylist of devices, by MAC or serial number
+type ProtectList []ProtectEntry
+
+// LoadProtectList reads a protect-list file: one MAC or serial number per
+// line, optionally followed by whitespace and a label. Blank lines and
+// lines starting with # are ignored.
+func LoadProtectList(path string) (ProtectList, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read protect list: %w", err)
+	}
+	return ParseProtectList(data)
+}
+
+// ParseProtectList parses the file format described in LoadProtectList.
+// Anything that is not a MAC address is taken as a serial number.
+func ParseProtectList(data []byte) (ProtectList, error) {
+	var list ProtectList
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		fields := strings.Fields(text)
+		entry := ProtectEntry{Label: strings.TrimSpace(strings.TrimPrefix(text, fields[0]))}
+		if mac := network.NormalizeMAC(fields[0]); network.IsValidMAC(mac) {
+			entry.MAC = mac
+		} else {
+			entry.Serial = strings.ToUpper(fields[0])
+		}
+		list = append(list, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read protect list: %w", err)
+	}
+	return list, nil
+}
+
+// HasSerials reports whether any entry is a serial number, which can only
+// be checked once the device's serial is known
+func (p ProtectList) HasSerials() bool {
+	for _, entry := range p {
+		if entry.Serial != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// Match returns the entry protecting the device with the given MAC and
+// serial, either of which may be empty. Serials compare case-insensitively,
+// and an entry of at least minSerialSuffix characters also matches the end
+// of a longer serial.
+func (p ProtectList) Match(mac, serial string) (ProtectEntry, bool) {
+	mac = network.NormalizeMAC(mac)
+	serial = strings.ToUpper(strings.TrimSpace(serial))
+	for _, entry := range p {
+		switch {
+		case entry.MAC != "" && entry.MAC == mac:
+			return entry, true
+		case entry.Serial == "" || serial == "":
+		case entry.Serial == serial:
+			return entry, true
+		case len(entry.Serial) >= minSerialSuffix && strings.HasSuffix(serial, entry.Serial):
+			return entry, true
+		}
+	}
+	return ProtectEntry{}, false
+}
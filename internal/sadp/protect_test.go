@@ -0,0 +1,71 @@
+package sadp
+
+import "testing"
+
+const sampleProtectList = `# Production NVRs
+4c-bd-8f-61-cc-5c  Core NVR
+DS-7616NI-I20190101AAWRC12345678 Lobby NVR
+d98765432
+
+`
+
+func TestParseProtectList(t *testing.T) {
+	list, err := ParseProtectList([]byte(sampleProtectList))
+	if err != nil {
+		t.Fatalf("ParseProtectList() error = %v", err)
+	}
+
+	want := ProtectList{
+		{MAC: "4C:BD:8F:61:CC:5C", Label: "Core NVR"},
+		{Serial: "DS-7616NI-I20190101AAWRC12345678", Label: "Lobby NVR"},
+		{Serial: "D98765432"},
+	}
+	if len(list) != len(want) {
+		t.Fatalf("got %d entries, want %d: %+v", len(list), len(want), list)
+	}
+	for i := range want {
+		if list[i] != want[i] {
+			t.Errorf("entry %d = %+v, want %+v", i, list[i], want[i])
+		}
+	}
+	if !list.HasSerials() {
+		t.Error("HasSerials() = false, want true")
+	}
+	if (ProtectList{{MAC: "4C:BD:8F:61:CC:5C"}}).HasSerials() {
+		t.Error("HasSerials() = true for a MAC-only list")
+	}
+}
+
+func TestProtectListMatch(t *testing.T) {
+	list, err := ParseProtectList([]byte(sampleProtectList))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name      string
+		mac       string
+		serial    string
+		wantMatch bool
+		wantLabel string
+	}{
+		{name: "MAC in any format", mac: "4c:bd:8f:61:cc:5c", wantMatch: true, wantLabel: "Core NVR"},
+		{name: "full serial", serial: "ds-7616ni-i20190101aawrc12345678", wantMatch: true, wantLabel: "Lobby NVR"},
+		{name: "short serial matches tail", serial: "DS-2CD2143G0-I20200101AAWRD98765432", wantMatch: true},
+		{name: "no partial middle match", serial: "D987654321", wantMatch: false},
+		{name: "unlisted device", mac: "4C:BD:8F:00:00:01", serial: "DS-2CD2042WD-I20180101AAWR111111111", wantMatch: false},
+		{name: "nothing known", wantMatch: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry, ok := list.Match(tt.mac, tt.serial)
+			if ok != tt.wantMatch {
+				t.Fatalf("Match() = %v, want %v", ok, tt.wantMatch)
+			}
+			if ok && entry.Label != tt.wantLabel {
+				t.Errorf("Label = %q, want %q", entry.Label, tt.wantLabel)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package sadp
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"strings"
+)
+
+// SecurityQuestionCount is the number of security questions a device asks
+const SecurityQuestionCount = 3
+
+// ResetMode is a device's password recovery method, as reported in
+// PasswordResetModeSecond
+type ResetMode string
+
+const (
+	// ResetModeUnknown is used when the device did not report a mode
+	ResetModeUnknown ResetMode = ""
+	// ResetModeSecurityCode recovers with a code from Hikvision or the
+	// reset generator
+	ResetModeSecurityCode ResetMode = "securityCode"
+	// ResetModeSecurityQuestion recovers by answering the security
+	// questions set at activation
+	ResetModeSecurityQuestion ResetMode = "securityQuestion"
+)
+
+// ParseResetMode interprets a PasswordResetModeSecond value
+func ParseResetMode(value string) ResetMode {
+	v := strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case v == "":
+		return ResetModeUnknown
+	case strings.Contains(v, "question"):
+		return ResetModeSecurityQuestion
+	default:
+		return ResetModeSecurityCode
+	}
+}
+
+// validateSecurityCodeOptions checks the fields the reset mode needs
+func validateSecurityCodeOptions(opts SendOptions) error {
+	answered := 0
+	for _, a := range opts.Answers {
+		if strings.TrimSpace(a) != "" {
+			answered++
+		}
+	}
+	if len(opts.Answers) > SecurityQuestionCount {
+		return fmt.Errorf("at most %d security answers are accepted", SecurityQuestionCount)
+	}
+
+	switch opts.ResetMode {
+	case ResetModeSecurityQuestion:
+		if answered < SecurityQuestionCount {
+			return fmt.Errorf("device uses security questions: all %d answers are required", SecurityQuestionCount)
+		}
+	case ResetModeSecurityCode:
+		if opts.Code == "" {
+			return fmt.Errorf("device uses a security code: --code is required")
+		}
+	default:
+		if opts.Code == "" && answered == 0 {
+			return fmt.Errorf("security code or security answers required for securitycode command")
+		}
+	}
+	return nil
+}
+
+// answerElements builds the <AnswerN> elements for the answers that are set
+func answerElements(answers []string) string {
+	var b bytes.Buffer
+	for i, answer := range answers {
+		if strings.TrimSpace(answer) == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "<Answer%d>", i+1)
+		_ = xml.EscapeText(&b, []byte(answer))
+		fmt.Fprintf(&b, "</Answer%d>", i+1)
+	}
+	return b.String()
+}
@@ -0,0 +1,25 @@
+package sadp
+
+import "testing"
+
+func TestParseResetMode(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  ResetMode
+	}{
+		{name: "not reported", value: "", want: ResetModeUnknown},
+		{name: "security question", value: "securityQuestion", want: ResetModeSecurityQuestion},
+		{name: "question case-insensitive", value: " SecurityQuestion ", want: ResetModeSecurityQuestion},
+		{name: "security code", value: "securityCode", want: ResetModeSecurityCode},
+		{name: "other methods need a code", value: "GUID", want: ResetModeSecurityCode},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseResetMode(tt.value); got != tt.want {
+				t.Errorf("ParseResetMode(%q) = %q, want %q", tt.value, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,227 @@
+package sadp
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/network"
+)
+
+// CommandResult is the outcome of a SADP command parsed from its response
+type CommandResult struct {
+	Success   bool
+	ErrorCode string
+	Message   string
+	// Device is set when the response carries device details, as inquiry
+	// replies do
+	Device *Device
+	// Devices lists every ProbeMatch in the response, merged by MAC, for
+	// the rare reply that carries more than one; Device is the first
+	Devices []*Device
+	// Locked is set when the device reports it has locked out password
+	// attempts
+	Locked bool
+	// RemainingAttempts is the number of password attempts left before
+	// lockout, or -1 when the device does not say
+	RemainingAttempts int
+	// UnlockIn is how long the lockout lasts, when reported
+	UnlockIn time.Duration
+	// Raw is the unparsed response
+	Raw string
+}
+
+// LockoutRisk reports whether another password attempt could lock the device
+// out: it already is locked, or at most minRemaining attempts are left
+func (r *CommandResult) LockoutRisk(minRemaining int) bool {
+	if r.Locked {
+		return true
+	}
+	return r.RemainingAttempts >= 0 && r.RemainingAttempts <= minRemaining
+}
+
+// Err returns a *CommandError describing a failed command, or nil when the
+// command succeeded, so callers can branch with errors.As
+func (r *CommandResult) Err() error {
+	if r.Success {
+		return nil
+	}
+	return &CommandError{ErrorCode: r.ErrorCode, Message: r.Message, Result: r}
+}
+
+// CommandError is a command the device answered with a failure, such as
+// <Result>failed</Result>, a non-zero <ErrorCode> or a <PWErrorParse>
+type CommandError struct {
+	ErrorCode string
+	Message   string
+	// Result is the full parsed reply, including the raw response
+	Result *CommandResult
+}
+
+func (e *CommandError) Error() string {
+	if e.ErrorCode != "" && !strings.Contains(e.Message, e.ErrorCode) {
+		return fmt.Sprintf("command failed: %s (error code %s)", e.Message, e.ErrorCode)
+	}
+	return "command failed: " + e.Message
+}
+
+// PasswordRejected reports whether the device refused the supplied password
+func (r *CommandResult) PasswordRejected() bool {
+	return strings.HasPrefix(r.Message, "password error")
+}
+
+// commandResponse holds the status elements devices put in command replies
+type commandResponse struct {
+	Types        string `xml:"Types"`
+	Result       string `xml:"Result"`
+	ErrorCode    string `xml:"ErrorCode"`
+	PWErrorParse string `xml:"PWErrorParse"`
+	Description  string `xml:"Description"`
+	// Lockout details; firmwares report remaining attempts under either
+	// name
+	LockStatus    string `xml:"LockStatus"`
+	RetryTimes    string `xml:"RetryTimes"`
+	ResidualTimes string `xml:"ResidualTimes"`
+	UnlockTime    string `xml:"UnlockTime"`
+}
+
+// successResults are the <Result> values firmwares use for success
+var successResults = map[string]bool{
+	"success": true,
+	"succ":    true,
+	"ok":      true,
+	"true":    true,
+}
+
+// SendCommandParsed sends a command and parses the response into a
+// CommandResult. A device that answers with a failure is not an error; check
+// CommandResult.Success, or use CommandResult.Err for a *CommandError.
+func (s *Scanner) SendCommandParsed(cmdName string, opts SendOptions) (*CommandResult, error) {
+	response, err := s.SendCommand(cmdName, opts)
+	if err != nil {
+		return nil, err
+	}
+	return s.ParseCommandResult(response), nil
+}
+
+// ParseCommandResult interprets a raw command response. An explicit
+// <Result>, non-zero <ErrorCode>, or <PWErrorParse> decides the outcome; a
+// bare ProbeMatch (e.g. an inquiry reply) counts as success. PWErrorParse
+// is only a failure when the Result is not a success, as devices may echo
+// the <PWErrorParse>true</PWErrorParse> that update sends.
+func (s *Scanner) ParseCommandResult(response string) *CommandResult {
+	result := &CommandResult{Raw: response, RemainingAttempts: -1}
+
+	var resp commandResponse
+	if err := xml.Unmarshal([]byte(response), &resp); err != nil {
+		result.Message = "unrecognized response: " + err.Error()
+		return result
+	}
+
+	if devices := decodeProbeMatches(response); len(devices) > 0 {
+		result.Device, result.Devices = devices[0], devices
+	}
+
+	parseLockout(result, resp)
+
+	status := strings.TrimSpace(resp.Result)
+	code := strings.TrimSpace(resp.ErrorCode)
+	switch {
+	case result.Locked && !successResults[strings.ToLower(status)]:
+		result.ErrorCode = code
+		result.Message = "device locked"
+	case !successResults[strings.ToLower(status)] && isPasswordError(resp.PWErrorParse):
+		result.ErrorCode = code
+		result.Message = "password error: " + strings.TrimSpace(resp.PWErrorParse)
+	case code != "" && code != "0":
+		result.ErrorCode = code
+		result.Message = firstNonEmpty(strings.TrimSpace(resp.Description), status, "error code "+code)
+	case status != "":
+		result.Success = successResults[strings.ToLower(status)]
+		result.Message = firstNonEmpty(strings.TrimSpace(resp.Description), status)
+	case result.Device != nil:
+		result.Success = true
+		result.Message = "device responded"
+	default:
+		result.Message = "response has no result status"
+	}
+
+	return result
+}
+
+// isPasswordError reports whether a <PWErrorParse> value signals an error,
+// rather than being empty, "false" or "0"
+func isPasswordError(value string) bool {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "", "false", "0":
+		return false
+	}
+	return true
+}
+
+// parseLockout fills the lockout fields of result from the response
+func parseLockout(result *CommandResult, resp commandResponse) {
+	switch strings.ToLower(strings.TrimSpace(resp.LockStatus)) {
+	case "lock", "locked", "true", "1":
+		result.Locked = true
+	}
+
+	remaining := firstNonEmpty(strings.TrimSpace(resp.RetryTimes), strings.TrimSpace(resp.ResidualTimes))
+	if n, err := strconv.Atoi(remaining); err == nil && n >= 0 {
+		result.RemainingAttempts = n
+		if n == 0 {
+			result.Locked = true
+		}
+	}
+
+	if secs, err := strconv.Atoi(strings.TrimSpace(resp.UnlockTime)); err == nil && secs > 0 {
+		result.UnlockIn = time.Duration(secs) * time.Second
+		result.Locked = true
+	}
+}
+
+// decodeProbeMatches returns every ProbeMatch element in data that names a
+// MAC, merging repeats of the same device. Parsing stops at the first
+// malformed element, keeping the devices before it.
+func decodeProbeMatches(data string) []*Device {
+	var devices []*Device
+	byMAC := make(map[string]*Device)
+
+	decoder := xml.NewDecoder(strings.NewReader(data))
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return devices
+		}
+		start, ok := token.(xml.StartElement)
+		if !ok || start.Name.Local != "ProbeMatch" {
+			continue
+		}
+
+		dev := &Device{}
+		if err := decoder.DecodeElement(dev, &start); err != nil {
+			return devices
+		}
+		dev.MAC = network.NormalizeMAC(dev.MAC)
+		if dev.MAC == "" {
+			continue
+		}
+		if existing, ok := byMAC[dev.MAC]; ok {
+			mergeDevice(existing, dev)
+			continue
+		}
+		byMAC[dev.MAC] = dev
+		devices = append(devices, dev)
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
@@ -0,0 +1,178 @@
+package sadp
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func TestParseCommandResult(t *testing.T) {
+	tests := []struct {
+		name        string
+		response    string
+		wantSuccess bool
+		wantCode    string
+		wantMessage string
+		wantDevice  bool
+	}{
+		{
+			name:        "explicit success",
+			response:    `<?xml version="1.0" encoding="UTF-8"?><ProbeMatch><Uuid>u</Uuid><MAC>4c-bd-8f-61-cc-5c</MAC><Types>activate</Types><Result>success</Result></ProbeMatch>`,
+			wantSuccess: true,
+			wantMessage: "success",
+			wantDevice:  true,
+		},
+		{
+			name:        "explicit failure",
+			response:    `<ProbeMatch><Types>update</Types><Result>failed</Result></ProbeMatch>`,
+			wantSuccess: false,
+			wantMessage: "failed",
+		},
+		{
+			name:        "password error",
+			response:    `<ProbeMatch><Types>reboot</Types><Result>failed</Result><PWErrorParse>1</PWErrorParse></ProbeMatch>`,
+			wantSuccess: false,
+			wantMessage: "password error: 1",
+		},
+		{
+			name:        "success echoing PWErrorParse true",
+			response:    `<ProbeMatch><Types>update</Types><Result>success</Result><PWErrorParse>true</PWErrorParse></ProbeMatch>`,
+			wantSuccess: true,
+			wantMessage: "success",
+		},
+		{
+			name:        "success with PWErrorParse false",
+			response:    `<ProbeMatch><Types>update</Types><Result>success</Result><PWErrorParse>false</PWErrorParse></ProbeMatch>`,
+			wantSuccess: true,
+			wantMessage: "success",
+		},
+		{
+			name:        "failure with PWErrorParse false",
+			response:    `<ProbeMatch><Types>update</Types><Result>failed</Result><PWErrorParse>false</PWErrorParse></ProbeMatch>`,
+			wantSuccess: false,
+			wantMessage: "failed",
+		},
+		{
+			name:        "nonzero error code",
+			response:    `<ProbeMatch><Types>activate</Types><ErrorCode>2001</ErrorCode></ProbeMatch>`,
+			wantSuccess: false,
+			wantCode:    "2001",
+			wantMessage: "error code 2001",
+		},
+		{
+			name:        "zero error code with success",
+			response:    `<ProbeMatch><Result>SUCCESS</Result><ErrorCode>0</ErrorCode></ProbeMatch>`,
+			wantSuccess: true,
+			wantMessage: "SUCCESS",
+		},
+		{
+			name:        "inquiry reply",
+			response:    `<ProbeMatch><Types>inquiry</Types><MAC>4c-bd-8f-61-cc-5c</MAC><IPv4Address>192.168.1.64</IPv4Address></ProbeMatch>`,
+			wantSuccess: true,
+			wantMessage: "device responded",
+			wantDevice:  true,
+		},
+		{
+			name:        "no status",
+			response:    `<ProbeMatch><Types>exchangecode</Types></ProbeMatch>`,
+			wantSuccess: false,
+			wantMessage: "response has no result status",
+		},
+		{
+			name:        "not xml",
+			response:    "garbage",
+			wantSuccess: false,
+		},
+	}
+
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := s.ParseCommandResult(tt.response)
+			if result.Success != tt.wantSuccess {
+				t.Errorf("Success = %v, want %v (message %q)", result.Success, tt.wantSuccess, result.Message)
+			}
+			if result.ErrorCode != tt.wantCode {
+				t.Errorf("ErrorCode = %q, want %q", result.ErrorCode, tt.wantCode)
+			}
+			if tt.wantMessage != "" && result.Message != tt.wantMessage {
+				t.Errorf("Message = %q, want %q", result.Message, tt.wantMessage)
+			}
+			if (result.Device != nil) != tt.wantDevice {
+				t.Errorf("Device = %+v, wantDevice %v", result.Device, tt.wantDevice)
+			}
+			if result.Raw != tt.response {
+				t.Error("Raw does not hold the response")
+			}
+		})
+	}
+}
+
+func TestParseCommandResultMultipleProbeMatches(t *testing.T) {
+	response := `<ProbeMatchList>` +
+		`<ProbeMatch><MAC>4c-bd-8f-00-00-01</MAC><IPv4Address>192.168.1.64</IPv4Address></ProbeMatch>` +
+		`<ProbeMatch><MAC>4C:BD:8F:00:00:02</MAC><IPv4Address>192.168.1.65</IPv4Address></ProbeMatch>` +
+		`<ProbeMatch><MAC>4C-BD-8F-00-00-01</MAC><SoftwareVersion>V5.5.0 build 191126</SoftwareVersion></ProbeMatch>` +
+		`</ProbeMatchList>`
+
+	result := NewScanner(DefaultTimeout, logger.NewNop()).ParseCommandResult(response)
+	if len(result.Devices) != 2 {
+		t.Fatalf("got %d devices, want 2", len(result.Devices))
+	}
+	first := result.Devices[0]
+	if result.Device != first || first.MAC != "4C:BD:8F:00:00:01" {
+		t.Errorf("Device = %+v, want the first ProbeMatch", result.Device)
+	}
+	if first.IPv4Address != "192.168.1.64" || first.SoftwareVersion != "V5.5.0 build 191126" {
+		t.Errorf("first device = %+v, want its two ProbeMatches merged", first)
+	}
+}
+
+func TestCommandResultErr(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  string
+		wantCode string
+	}{
+		{
+			name:     "success",
+			response: `<ProbeMatch><Types>reboot</Types><Result>success</Result></ProbeMatch>`,
+		},
+		{
+			name:     "failed result",
+			response: `<ProbeMatch><Types>update</Types><Result>failed</Result></ProbeMatch>`,
+			wantErr:  "command failed: failed",
+		},
+		{
+			name:     "password error",
+			response: `<ProbeMatch><Types>reboot</Types><ErrorCode>2007</ErrorCode><PWErrorParse>wrong password</PWErrorParse></ProbeMatch>`,
+			wantErr:  "command failed: password error: wrong password (error code 2007)",
+			wantCode: "2007",
+		},
+	}
+
+	s := NewScanner(DefaultTimeout, logger.NewNop())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.ParseCommandResult(tt.response).Err()
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Fatalf("Err() = %v, want nil", err)
+				}
+				return
+			}
+			var cmdErr *CommandError
+			if !errors.As(err, &cmdErr) {
+				t.Fatalf("Err() = %v, want a *CommandError", err)
+			}
+			if err.Error() != tt.wantErr || cmdErr.ErrorCode != tt.wantCode {
+				t.Errorf("Err() = %q (code %q), want %q (code %q)", err, cmdErr.ErrorCode, tt.wantErr, tt.wantCode)
+			}
+			if cmdErr.Result.Raw != tt.response {
+				t.Error("CommandError.Result does not hold the raw response")
+			}
+		})
+	}
+}
@@ -1,11 +1,16 @@
 package sadp
 
 import (
+	"context"
+	"encoding/json"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"math/rand"
 	"net"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/cameronnewman/hikvision-tooling/internal/logger"
@@ -17,111 +22,439 @@ const (
 	Port           = 37020
 	MaxPacketSize  = 65535
 	DefaultTimeout = 5 * time.Second
+
+	// MulticastAddrIPv6 is the link-local group IPv6 probes are sent to
+	MulticastAddrIPv6 = "ff02::c"
+
+	// DefaultProbeJitter is small enough not to slow scans noticeably but
+	// keeps devices from answering every probe in the same instant
+	DefaultProbeJitter = 20 * time.Millisecond
+
+	// DefaultProbeRetries and DefaultProbeRetryInterval resend the probes
+	// twice in the first second of a scan, enough to ride out a dropped
+	// datagram on congested wireless
+	DefaultProbeRetries       = 2
+	DefaultProbeRetryInterval = 500 * time.Millisecond
 )
 
+// DefaultProbeTypes are the discovery probes sent when
+// DiscoverOptions.ProbeTypes is nil. The two elicit different field sets.
+var DefaultProbeTypes = []string{"inquiry", "inquiry_v32"}
+
+// ContentionHint is shown when another application appears to be holding
+// the SADP port or consuming replies
+const ContentionHint = "Another SADP-capable application may be running; close it and retry."
+
 // Device represents a discovered Hikvision device via SADP protocol
 type Device struct {
-	XMLName           xml.Name `xml:"ProbeMatch" json:"-"`
-	Uuid              string   `xml:"Uuid" json:"uuid"`
-	Types             string   `xml:"Types" json:"types"`
-	DeviceType        string   `xml:"DeviceType" json:"deviceType"`
-	DeviceDescription string   `xml:"DeviceDescription" json:"deviceDescription"`
-	DeviceSN          string   `xml:"DeviceSN" json:"serialNumber"`
-	MAC               string   `xml:"MAC" json:"mac"`
-	IPv4Address       string   `xml:"IPv4Address" json:"ipv4Address"`
-	IPv4SubnetMask    string   `xml:"IPv4SubnetMask" json:"ipv4SubnetMask"`
-	IPv4Gateway       string   `xml:"IPv4Gateway" json:"ipv4Gateway"`
-	IPv6Address       string   `xml:"IPv6Address" json:"ipv6Address"`
-	IPv6Gateway       string   `xml:"IPv6Gateway" json:"ipv6Gateway"`
-	IPv6MaskLen       int      `xml:"IPv6MaskLen" json:"ipv6MaskLen"`
-	DHCP              string   `xml:"DHCP" json:"dhcp"`
-	CommandPort       int      `xml:"CommandPort" json:"commandPort"`
-	HttpPort          int      `xml:"HttpPort" json:"httpPort"`
-	DSPVersion        string   `xml:"DSPVersion" json:"dspVersion"`
-	BootTime          string   `xml:"BootTime" json:"bootTime"`
-	SoftwareVersion   string   `xml:"SoftwareVersion" json:"softwareVersion"`
-	Activated         string   `xml:"Activated" json:"activated"`
-	PasswordResetMode string   `xml:"PasswordResetModeSecond" json:"passwordResetMode"`
-	SupportHCPlatform string   `xml:"SupportHCPlatform" json:"supportHCPlatform"`
-	HCPlatformEnable  string   `xml:"HCPlatformEnable" json:"hcPlatformEnable"`
-	SupportReset      string   `xml:"Support" json:"supportReset"`
-	Encoder           string   `xml:"Encoder" json:"encoder"`
-	OEMInfo           string   `xml:"OEMInfo" json:"oemInfo"`
-	AnalogChannelNum  int      `xml:"AnalogChannelNum" json:"analogChannelNum"`
-	DigitalChannelNum int      `xml:"DigitalChannelNum" json:"digitalChannelNum"`
-	SDKOverTLSPort    int      `xml:"SDKOverTLSPort" json:"sdkOverTLSPort"`
-	SDKServerStatus   string   `xml:"SDKServerStatus" json:"sdkServerStatus"`
-	AdapterIP         string   `xml:"-" json:"adapterIP"`
-	ReceivedTime      time.Time `xml:"-" json:"receivedTime"`
-}
-
-// DeviceList represents the XML output format
+	XMLName           xml.Name   `xml:"ProbeMatch" json:"-"`
+	Uuid              string     `xml:"Uuid" json:"uuid"`
+	Types             string     `xml:"Types" json:"types"`
+	DeviceType        string     `xml:"DeviceType" json:"deviceType"`
+	DeviceDescription string     `xml:"DeviceDescription" json:"deviceDescription"`
+	DeviceSN          string     `xml:"DeviceSN" json:"serialNumber"`
+	MAC               string     `xml:"MAC" json:"mac"`
+	IPv4Address       string     `xml:"IPv4Address" json:"ipv4Address"`
+	IPv4SubnetMask    string     `xml:"IPv4SubnetMask" json:"ipv4SubnetMask"`
+	IPv4Gateway       string     `xml:"IPv4Gateway" json:"ipv4Gateway"`
+	IPv6Address       string     `xml:"IPv6Address" json:"ipv6Address"`
+	IPv6Gateway       string     `xml:"IPv6Gateway" json:"ipv6Gateway"`
+	IPv6MaskLen       int        `xml:"IPv6MaskLen" json:"ipv6MaskLen"`
+	DHCP              string     `xml:"DHCP" json:"dhcp"`
+	CommandPort       PortNumber `xml:"CommandPort" json:"commandPort"`
+	HttpPort          PortNumber `xml:"HttpPort" json:"httpPort"`
+	DSPVersion        string     `xml:"DSPVersion" json:"dspVersion"`
+	BootTime          string     `xml:"BootTime" json:"bootTime"`
+	SoftwareVersion   string     `xml:"SoftwareVersion" json:"softwareVersion"`
+	Activated         string     `xml:"Activated" json:"activated"`
+	PasswordResetMode string     `xml:"PasswordResetModeSecond" json:"passwordResetMode"`
+	SupportHCPlatform string     `xml:"SupportHCPlatform" json:"supportHCPlatform"`
+	HCPlatformEnable  string     `xml:"HCPlatformEnable" json:"hcPlatformEnable"`
+	SupportReset      string     `xml:"Support" json:"supportReset"`
+	Encoder           string     `xml:"Encoder" json:"encoder"`
+	OEMInfo           string     `xml:"OEMInfo" json:"oemInfo"`
+	AnalogChannelNum  int        `xml:"AnalogChannelNum" json:"analogChannelNum"`
+	DigitalChannelNum int        `xml:"DigitalChannelNum" json:"digitalChannelNum"`
+	SDKOverTLSPort    PortNumber `xml:"SDKOverTLSPort" json:"sdkOverTLSPort"`
+	SDKServerStatus   string     `xml:"SDKServerStatus" json:"sdkServerStatus"`
+	AdapterIP         string     `xml:"AdapterIP,omitempty" json:"adapterIP"`
+	ReceivedTime      time.Time  `xml:"ReceivedTime" json:"receivedTime"`
+	Hostname          string     `xml:"Hostname,omitempty" json:"hostname,omitempty"`
+	ARPStatus         string     `xml:"ARPStatus,omitempty" json:"arpStatus,omitempty"`
+	Site              string     `xml:"Site,omitempty" json:"site,omitempty"`
+	Tags              Tags       `xml:"Tags,omitempty" json:"tags,omitempty"`
+}
+
+// DeviceList represents the XML and JSON output formats
 type DeviceList struct {
-	XMLName xml.Name `xml:"SADPDeviceList"`
-	Version string   `xml:"version,attr"`
-	Devices []Device `xml:"Device"`
+	XMLName xml.Name `xml:"SADPDeviceList" json:"-"`
+	Version string   `xml:"version,attr" json:"version"`
+	Devices []Device `xml:"Device" json:"devices"`
+}
+
+// DiscoverOptions tunes how Discover probes the network
+type DiscoverOptions struct {
+	// AutoInterface probes only the single best-scoring interface
+	// (see BestInterface) instead of every interface
+	AutoInterface bool
+
+	// ProbeJitter is the upper bound of a random delay inserted between
+	// probe sends. Spreading the probes out spreads the replies out, so a
+	// busy network is less likely to overflow the read loop; the cost is
+	// up to a few multiples of ProbeJitter added to each scan. Zero sends
+	// all probes back to back.
+	ProbeJitter time.Duration
+
+	// ProbeRetries is how many more times each interface resends its
+	// probes, ProbeRetryInterval apart, while it reads replies. Replies to
+	// a resend merge with earlier ones by MAC, so retries only add devices
+	// a dropped probe or reply would have missed. Resends due after the
+	// read deadline are not sent.
+	ProbeRetries       int
+	ProbeRetryInterval time.Duration
+
+	// GraceWindow extends the read deadline once after the initial timeout
+	// when at least one device has answered on the interface, catching late
+	// responders such as NVRs that are still booting. Zero disables it.
+	GraceWindow time.Duration
+
+	// DirectedBroadcasts are extra broadcast addresses (e.g. 10.0.5.255)
+	// that receive the probes in addition to the local multicast and
+	// broadcast, for routers that forward directed broadcasts to a remote
+	// subnet. Replies come back unicast to the probing socket.
+	DirectedBroadcasts []net.IP
+
+	// ProbeUUID, when set, is sent as the <Uuid> of every probe instead of
+	// a random one, so requests and responses can be matched in captures
+	ProbeUUID string
+
+	// ProbeUUIDPrefix replaces the start of each random probe UUID, keeping
+	// probes unique while still easy to filter on. Ignored if ProbeUUID is set.
+	ProbeUUIDPrefix string
+
+	// StrictUUID discards ProbeMatch replies whose <Uuid> is not the one
+	// sent on that interface, e.g. answers to another tool's concurrent
+	// scan. Off by default because some firmware does not echo the UUID.
+	StrictUUID bool
+
+	// BufferSize is the read buffer for each reply, in bytes. Zero uses
+	// MaxPacketSize; smaller values save memory on constrained hosts but
+	// replies that fill the buffer are treated as truncated and dropped.
+	BufferSize int
+
+	// ProbeTypes lists the probe <Types> sent on each interface, in order.
+	// Nil sends DefaultProbeTypes. Some legacy firmware answers inquiry_v32
+	// with garbage or even reboots, so it can be dropped for such networks.
+	ProbeTypes []string
+
+	// RequireMulticast runs CheckMulticast on every probed interface before
+	// sending, and fails discovery if any of them cannot use multicast
+	RequireMulticast bool
+
+	// LocalPortRange restricts the local UDP source port of probes and
+	// commands, for firewalls that only allow SADP from certain ports. The
+	// first free port in the range is used. Zero uses an ephemeral port.
+	LocalPortRange PortRange
+
+	// IPv6 also probes MulticastAddrIPv6 from every interface with an IPv6
+	// link-local address (see IPv6Interfaces), finding devices on IPv6-only
+	// links. Replies merge by MAC with those received over IPv4.
+	IPv6 bool
+
+	// Interfaces limits probing to the interfaces with these names, e.g.
+	// eth0, keeping probes off virtual adapters on multi-homed hosts.
+	// SourceIP limits it to the one local address. Either applies before
+	// AutoInterface picks among what is left.
+	Interfaces []string
+	SourceIP   net.IP
+}
+
+// DefaultDiscoverOptions returns the options used by NewScanner
+func DefaultDiscoverOptions() DiscoverOptions {
+	return DiscoverOptions{
+		ProbeJitter:        DefaultProbeJitter,
+		ProbeRetries:       DefaultProbeRetries,
+		ProbeRetryInterval: DefaultProbeRetryInterval,
+	}
+}
+
+// bufferSize returns the read buffer size to use
+func (o DiscoverOptions) bufferSize() int {
+	if o.BufferSize <= 0 || o.BufferSize > MaxPacketSize {
+		return MaxPacketSize
+	}
+	return o.BufferSize
+}
+
+// possiblyTruncated reports whether a read of n bytes filled the buffer, in
+// which case the datagram may have been cut short
+func possiblyTruncated(n int, buf []byte) bool {
+	return n >= len(buf)
+}
+
+// probeDelay returns a random delay in [0, ProbeJitter)
+func (o DiscoverOptions) probeDelay() time.Duration {
+	if o.ProbeJitter <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(o.ProbeJitter)))
+}
+
+// newProbeUUID returns the fixed UUID if set, otherwise a random UUID whose
+// leading characters are replaced by prefix
+func newProbeUUID(fixed, prefix string) string {
+	if fixed != "" {
+		return fixed
+	}
+	id := uuid.New().String()
+	if len(prefix) >= len(id) {
+		return prefix
+	}
+	return prefix + id[len(prefix):]
+}
+
+// acceptsUUID reports whether a reply carrying uuid answers our probe
+func (o DiscoverOptions) acceptsUUID(replyUUID, probeUUID string) bool {
+	return !o.StrictUUID || strings.EqualFold(strings.TrimSpace(replyUUID), probeUUID)
+}
+
+// probeTargets returns the addresses probes from localIP are sent to, in
+// send order. A link-local source also probes 169.254.255.255: some hosts
+// route the limited broadcast out of the default-route interface instead of
+// the link-local one a direct-connected device is on. An IPv6 source only
+// probes MulticastAddrIPv6, scoped to zone, as IPv6 has no broadcast.
+func (o DiscoverOptions) probeTargets(localIP net.IP, zone string) []*net.UDPAddr {
+	if localIP.To4() == nil {
+		return []*net.UDPAddr{{IP: net.ParseIP(MulticastAddrIPv6), Port: Port, Zone: zone}}
+	}
+	targets := []*net.UDPAddr{
+		{IP: net.ParseIP(MulticastAddr), Port: Port},
+		{IP: net.IPv4bcast, Port: Port},
+	}
+	if localIP.IsLinkLocalUnicast() {
+		targets = append(targets, &net.UDPAddr{IP: subnetBroadcast(localIP, nil), Port: Port})
+	}
+	for _, ip := range o.DirectedBroadcasts {
+		targets = append(targets, &net.UDPAddr{IP: ip, Port: Port})
+	}
+	return targets
+}
+
+// probeTypes returns the probe types to send
+func (o DiscoverOptions) probeTypes() []string {
+	if o.ProbeTypes == nil {
+		return DefaultProbeTypes
+	}
+	return o.ProbeTypes
+}
+
+// ParseProbeTypes parses a comma-separated probe list such as
+// "inquiry,inquiry_v32". Only the discovery probes are accepted.
+func ParseProbeTypes(s string) ([]string, error) {
+	var types []string
+	seen := make(map[string]bool)
+	for _, t := range strings.Split(s, ",") {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		valid := false
+		for _, d := range DefaultProbeTypes {
+			if t == d {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("unknown probe type %q (use %s)", t, strings.Join(DefaultProbeTypes, ", "))
+		}
+		seen[t] = true
+		types = append(types, t)
+	}
+	if len(types) == 0 {
+		return nil, fmt.Errorf("at least one probe type is required")
+	}
+	return types, nil
+}
+
+// graceExtension returns how long to keep reading after a read deadline
+// expires, or zero when discovery on the interface should stop
+func (o DiscoverOptions) graceExtension(found int, extended bool) time.Duration {
+	if o.GraceWindow <= 0 || extended || found == 0 {
+		return 0
+	}
+	return o.GraceWindow
 }
 
 // Scanner handles SADP protocol discovery
 type Scanner struct {
 	timeout     time.Duration
 	log         *logger.Logger
+	opts        DiscoverOptions
 	devices     map[string]*Device
 	deviceMutex sync.RWMutex
+
+	warnings     []Warning
+	warningMutex sync.Mutex
+
+	stats      []InterfaceStats
+	statsMutex sync.Mutex
 }
 
 // NewScanner creates a new SADP scanner
 func NewScanner(timeout time.Duration, log *logger.Logger) *Scanner {
+	return NewScannerWithOptions(timeout, log, DefaultDiscoverOptions())
+}
+
+// NewScannerWithOptions creates a new SADP scanner with custom discovery options
+func NewScannerWithOptions(timeout time.Duration, log *logger.Logger, opts DiscoverOptions) *Scanner {
 	if log == nil {
 		log = logger.NewNop()
 	}
 	return &Scanner{
 		timeout: timeout,
 		log:     log,
+		opts:    opts,
 		devices: make(map[string]*Device),
 	}
 }
 
-// Discover performs SADP multicast discovery
-func (s *Scanner) Discover() ([]*Device, error) {
-	interfaces, err := net.Interfaces()
+// Reset forgets all devices recorded by previous discoveries
+func (s *Scanner) Reset() {
+	s.deviceMutex.Lock()
+	defer s.deviceMutex.Unlock()
+	s.devices = make(map[string]*Device)
+}
+
+// ProbeInterfaces returns the interfaces Discover will send probes from
+func (s *Scanner) ProbeInterfaces() ([]InterfaceInfo, error) {
+	return probeInterfaces(s.opts)
+}
+
+// probeInterfaces returns the interfaces opts selects for probing
+func probeInterfaces(opts DiscoverOptions) ([]InterfaceInfo, error) {
+	interfaces, err := Interfaces()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
 	}
+	var v6 []InterfaceInfo
+	if opts.IPv6 {
+		if v6, err = IPv6Interfaces(); err != nil {
+			return nil, fmt.Errorf("failed to get network interfaces: %w", err)
+		}
+	}
 
-	var wg sync.WaitGroup
-
-	for _, iface := range interfaces {
-		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
-			continue
+	if len(opts.Interfaces) > 0 || opts.SourceIP != nil {
+		interfaces = allowedInterfaces(interfaces, opts.Interfaces, opts.SourceIP)
+		v6 = allowedInterfaces(v6, opts.Interfaces, opts.SourceIP)
+		if len(interfaces)+len(v6) == 0 {
+			return nil, fmt.Errorf("no up interface matches %s", describeAllowList(opts.Interfaces, opts.SourceIP))
 		}
+	}
 
-		addrs, err := iface.Addrs()
-		if err != nil {
-			continue
+	if opts.AutoInterface {
+		best, ok := BestInterface(interfaces)
+		if !ok {
+			return nil, fmt.Errorf("no usable network interface found")
 		}
+		interfaces = []InterfaceInfo{best}
+		v6 = allowedInterfaces(v6, []string{best.Name}, nil)
+	}
 
-		for _, addr := range addrs {
-			ipNet, ok := addr.(*net.IPNet)
-			if !ok {
-				continue
-			}
+	return append(interfaces, v6...), nil
+}
 
-			ip := ipNet.IP.To4()
-			if ip == nil {
-				continue
-			}
+// Discover performs SADP multicast discovery
+func (s *Scanner) Discover() ([]*Device, error) {
+	return s.DiscoverContext(context.Background())
+}
 
-			wg.Add(1)
-			go func(localIP net.IP, ifaceName string) {
-				defer wg.Done()
-				s.discoverOnInterface(localIP, ifaceName)
-			}(ip, iface.Name)
-		}
+// DiscoverContext is Discover, cut short when ctx is done: probing stops
+// and pending reads return at once. The devices found up to then are
+// returned along with the context's error.
+func (s *Scanner) DiscoverContext(ctx context.Context) ([]*Device, error) {
+	stream, err := s.DiscoverStreamContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for range stream {
+	}
+
+	// The recorded devices, unlike the streamed snapshots, include fields
+	// merged from later replies
+	return s.recordedDevices(), ctx.Err()
+}
+
+// DiscoverOnInterfaces performs SADP discovery from only the interfaces
+// named, in place of any DiscoverOptions.Interfaces. The other options,
+// such as IPv6, still apply.
+func (s *Scanner) DiscoverOnInterfaces(names []string) ([]*Device, error) {
+	if len(names) == 0 {
+		return nil, fmt.Errorf("no interface names given")
+	}
+	opts := s.opts
+	opts.Interfaces = names
+	interfaces, err := probeInterfaces(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	stream, err := s.discoverStream(context.Background(), interfaces)
+	if err != nil {
+		return nil, err
+	}
+	for range stream {
+	}
+	return s.recordedDevices(), nil
+}
+
+// DiscoverOnIP performs SADP discovery on the single interface that owns
+// localIP, for reproducing interface-specific discovery problems
+func (s *Scanner) DiscoverOnIP(localIP string) ([]*Device, error) {
+	ip := net.ParseIP(localIP)
+	if ip == nil || ip.To4() == nil {
+		return nil, fmt.Errorf("invalid interface IP %q", localIP)
+	}
+
+	interfaces, err := Interfaces()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get network interfaces: %w", err)
+	}
+	iface, ok := InterfaceForIP(interfaces, ip)
+	if !ok {
+		return nil, fmt.Errorf("no up interface has address %s", localIP)
 	}
 
-	wg.Wait()
+	if err := s.checkMulticast([]InterfaceInfo{iface}); err != nil {
+		return nil, err
+	}
+
+	s.resetWarnings()
+	s.resetStats()
+	s.discoverOnInterface(context.Background(), iface.IP, iface.Name, nil)
+
+	return s.recordedDevices(), nil
+}
+
+// checkMulticast applies the RequireMulticast pre-flight check
+func (s *Scanner) checkMulticast(interfaces []InterfaceInfo) error {
+	if !s.opts.RequireMulticast {
+		return nil
+	}
+	for _, iface := range interfaces {
+		// CheckMulticast only tests the IPv4 group
+		if iface.IP.To4() == nil {
+			continue
+		}
+		if err := CheckMulticast(iface.IP); err != nil {
+			return fmt.Errorf("multicast pre-flight check failed: %w", err)
+		}
+	}
+	return nil
+}
 
+// recordedDevices returns the devices found so far
+func (s *Scanner) recordedDevices() []*Device {
 	s.deviceMutex.RLock()
 	defer s.deviceMutex.RUnlock()
 
@@ -129,82 +462,243 @@ func (s *Scanner) Discover() ([]*Device, error) {
 	for _, dev := range s.devices {
 		result = append(result, dev)
 	}
-
-	return result, nil
+	return result
 }
 
-func (s *Scanner) discoverOnInterface(localIP net.IP, ifaceName string) {
+// discoverOnInterface probes from localIP and records the replies. When
+// newDevices is not nil, a snapshot of each device is sent on it the first
+// time its MAC is recorded.
+func (s *Scanner) discoverOnInterface(ctx context.Context, localIP net.IP, ifaceName string, newDevices chan<- *Device) {
 	s.log.Debugw("Scanning on interface", "interface", ifaceName, "ip", localIP.String())
 
-	localAddr := &net.UDPAddr{IP: localIP, Port: 0}
-	conn, err := net.ListenUDP("udp4", localAddr)
+	stats := InterfaceStats{Interface: ifaceName, IP: localIP.String()}
+	defer func() { s.recordStats(stats) }()
+
+	conn, err := listenUDP(localIP, ifaceName, s.opts.LocalPortRange)
 	if err != nil {
+		s.recordWarning(Warning{Kind: WarningBind, Interface: ifaceName, IP: localIP.String(), Err: err})
+		if errors.Is(err, ErrPortRangeExhausted) {
+			s.log.Warnw("Failed to bind", "ip", localIP.String(), "error", err)
+			return
+		}
+		if isAddrInUse(err) {
+			s.log.Warnw("Failed to bind: "+ContentionHint, "ip", localIP.String(), "error", err)
+			return
+		}
 		s.log.Debugw("Failed to bind", "ip", localIP.String(), "error", err)
 		return
 	}
 	defer conn.Close()
+	stats.Bound = true
 
-	multicastAddr := &net.UDPAddr{IP: net.ParseIP(MulticastAddr), Port: Port}
-	probeUUID := uuid.New().String()
+	// Cancelling ctx unblocks the read loop below
+	stopCancel := context.AfterFunc(ctx, func() { _ = conn.SetReadDeadline(time.Now()) })
+	defer stopCancel()
 
-	probePackets := []string{
-		fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><Types>inquiry</Types></Probe>`, probeUUID),
-		fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><Types>inquiry_v32</Types></Probe>`, probeUUID),
-	}
+	probeUUID := newProbeUUID(s.opts.ProbeUUID, s.opts.ProbeUUIDPrefix)
 
-	for _, probe := range probePackets {
-		_, err = conn.WriteToUDP([]byte(probe), multicastAddr)
-		if err != nil {
-			s.log.Debugw("Failed to send probe", "ip", localIP.String(), "error", err)
-		}
+	var probePackets []string
+	for _, probeType := range s.opts.probeTypes() {
+		probePackets = append(probePackets,
+			fmt.Sprintf(`<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>%s</Uuid><Types>%s</Types></Probe>`, probeUUID, probeType))
 	}
 
-	broadcastAddr := &net.UDPAddr{IP: net.IPv4bcast, Port: Port}
-	for _, probe := range probePackets {
-		_, _ = conn.WriteToUDP([]byte(probe), broadcastAddr)
+	// sendProbes sends every probe to every target once, returning how many
+	// sends were attempted and how many failed
+	sendProbes := func() (sent, failed int) {
+		for _, target := range s.opts.probeTargets(localIP, ifaceName) {
+			for _, probe := range probePackets {
+				if ctx.Err() != nil {
+					return sent, failed
+				}
+				s.log.Tracew("Sending probe", "ip", localIP.String(), "target", target.String(), "uuid", probeUUID)
+				_, err := conn.WriteToUDP([]byte(probe), target)
+				sent++
+				if err != nil {
+					failed++
+					s.log.Debugw("Failed to send probe", "ip", localIP.String(), "target", target.String(), "error", err)
+					s.recordWarning(Warning{Kind: WarningSend, Interface: ifaceName, IP: localIP.String(),
+						Err: fmt.Errorf("probe to %s: %w", target, err)})
+				}
+				time.Sleep(s.opts.probeDelay())
+			}
+		}
+		return sent, failed
 	}
 
+	stats.ProbesSent, stats.SendErrors = sendProbes()
+	// Checked after setting the deadline so a cancel in between, whose
+	// deadline would be overwritten, is still noticed
 	_ = conn.SetReadDeadline(time.Now().Add(s.timeout))
+	if ctx.Err() != nil {
+		return
+	}
 
-	buf := make([]byte, MaxPacketSize)
+	// Resend alongside the read loop below; the counts are handed over
+	// when retried is closed
+	stopRetries := make(chan struct{})
+	retried := make(chan struct{})
+	var retrySent, retryFailed int
+	go func() {
+		defer close(retried)
+		for i := 0; i < s.opts.ProbeRetries; i++ {
+			select {
+			case <-stopRetries:
+				return
+			case <-ctx.Done():
+				return
+			case <-time.After(s.opts.ProbeRetryInterval):
+			}
+			s.log.Tracew("Resending probes", "ip", localIP.String(), "retry", i+1)
+			sent, failed := sendProbes()
+			retrySent += sent
+			retryFailed += failed
+		}
+	}()
+	defer func() {
+		close(stopRetries)
+		<-retried
+		stats.ProbesSent += retrySent
+		stats.SendErrors += retryFailed
+	}()
+
+	buf := make([]byte, s.opts.bufferSize())
+	found := 0
+	extended := false
 	for {
 		n, remoteAddr, err := conn.ReadFromUDP(buf)
 		if err != nil {
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				if grace := s.opts.graceExtension(found, extended); grace > 0 {
+					s.log.Debugw("Extending read for late responders", "ip", localIP.String(), "grace", grace)
+					_ = conn.SetReadDeadline(time.Now().Add(grace))
+					extended = true
+					if ctx.Err() == nil {
+						continue
+					}
+				}
+			}
 			break
 		}
+		stats.Responses++
+
+		if possiblyTruncated(n, buf) {
+			stats.Discarded++
+			s.log.Warnw("Dropping response that filled the read buffer and may be truncated",
+				"from", remoteAddr.String(), "bytes", n)
+			s.recordWarning(Warning{Kind: WarningTruncated, Interface: ifaceName, IP: localIP.String(),
+				From: remoteAddr.String(), Err: fmt.Errorf("reply filled the %d byte read buffer", len(buf))})
+			continue
+		}
 
 		response := string(buf[:n])
-		s.log.Debugw("Received response", "bytes", n, "from", remoteAddr.String())
+		s.log.Tracew("Received response", "bytes", n, "from", remoteAddr.String())
 
-		device := s.parseResponse(response)
-		if device != nil {
+		device, err := decodeResponse(response)
+		if err != nil {
+			s.log.Debugw("Failed to parse response", "from", remoteAddr.String(), "error", err)
+			s.recordWarning(Warning{Kind: WarningParse, Interface: ifaceName, IP: localIP.String(),
+				From: remoteAddr.String(), Err: err})
+		}
+		if device != nil && !s.opts.acceptsUUID(device.Uuid, probeUUID) {
+			s.log.Debugw("Discarding reply to another probe", "from", remoteAddr.String(), "uuid", device.Uuid, "want", probeUUID)
+			device = nil
+		}
+		if device == nil {
+			stats.Discarded++
+		} else {
+			found++
+			stats.addDevice(device.MAC)
 			device.AdapterIP = localIP.String()
 			device.ReceivedTime = time.Now()
 
+			var snapshot *Device
 			s.deviceMutex.Lock()
-			if _, exists := s.devices[device.MAC]; !exists {
+			if existing, exists := s.devices[device.MAC]; exists {
+				mergeDevice(existing, device)
+				s.log.Tracew("Merged response", "mac", device.MAC, "types", device.Types)
+			} else {
 				s.devices[device.MAC] = device
-				s.log.Debugw("Found device", "ip", device.IPv4Address, "mac", device.MAC, "type", device.DeviceType)
+				s.log.Debugw("Found device", "ip", device.IPv4Address, "mac", device.MAC, "type", device.DeviceType,
+					"uuid", device.Uuid, "matchesProbe", strings.EqualFold(device.Uuid, probeUUID))
+				copied := *device
+				snapshot = &copied
 			}
 			s.deviceMutex.Unlock()
+			if snapshot != nil && newDevices != nil {
+				select {
+				case newDevices <- snapshot:
+				case <-ctx.Done():
+					return
+				}
+			}
 		}
 	}
 }
 
-func (s *Scanner) parseResponse(data string) *Device {
-	if !strings.Contains(data, "<ProbeMatch") && !strings.Contains(data, "ProbeMatch>") {
-		return nil
+// SADPPortInUse reports whether another process is bound to the SADP port,
+// which usually means the official SADP tool (or a similar application) is
+// running and may be consuming replies
+func SADPPortInUse() bool {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: Port})
+	if err != nil {
+		return isAddrInUse(err)
 	}
+	conn.Close()
+	return false
+}
 
-	device := &Device{}
-	err := xml.Unmarshal([]byte(data), device)
+func isAddrInUse(err error) bool {
+	if errors.Is(err, syscall.EADDRINUSE) {
+		return true
+	}
+	// Windows reports WSAEADDRINUSE, which does not match syscall.EADDRINUSE
+	msg := strings.ToLower(err.Error())
+	return strings.Contains(msg, "address already in use") ||
+		strings.Contains(msg, "only one usage of each socket address")
+}
+
+func (s *Scanner) parseResponse(data string) *Device {
+	device, err := decodeResponse(data)
 	if err != nil {
 		s.log.Debugw("Failed to parse response", "error", err)
 		return nil
 	}
+	return device
+}
+
+// decodeResponse parses a ProbeMatch reply. Packets that are not a
+// ProbeMatch return nil without an error.
+func decodeResponse(data string) (*Device, error) {
+	if !strings.Contains(data, "<ProbeMatch") && !strings.Contains(data, "ProbeMatch>") {
+		return nil, nil
+	}
+
+	device := &Device{}
+	if err := xml.Unmarshal([]byte(data), device); err != nil {
+		return nil, fmt.Errorf("invalid ProbeMatch XML: %w", err)
+	}
 
 	device.MAC = strings.ToUpper(strings.ReplaceAll(device.MAC, "-", ":"))
-	return device
+	return device, nil
+}
+
+// MarshalXML encodes d, leaving out ReceivedTime when it is unknown rather
+// than writing the zero time. It comes after the other fields.
+func (d Device) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	// plain has Device's fields without this method
+	type plain Device
+	out := struct {
+		plain
+		ReceivedTime *time.Time `xml:"ReceivedTime,omitempty"`
+	}{plain: plain(d)}
+	if !d.ReceivedTime.IsZero() {
+		out.ReceivedTime = &d.ReceivedTime
+	}
+	// The XMLName tag names the element, as it does without this method
+	start.Name = xml.Name{Local: "ProbeMatch"}
+	return e.EncodeElement(out, start)
 }
 
 // ToXML generates SADP-compatible XML output
@@ -226,35 +720,70 @@ func (s *Scanner) ToXML(devices []*Device) (string, error) {
 	return xml.Header + string(output), nil
 }
 
-// ToCSV generates CSV output
+// ToJSON generates the ToXML device list as indented JSON, in the
+// {"devices": [...]} form LoadDevicesFromJSON reads. No devices yields an
+// empty array rather than null.
+func (s *Scanner) ToJSON(devices []*Device) (string, error) {
+	list := DeviceList{
+		Version: "2.0",
+		Devices: make([]Device, len(devices)),
+	}
+
+	for i, dev := range devices {
+		list.Devices[i] = *dev
+	}
+
+	output, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	return string(output), nil
+}
+
+// ToCSV generates CSV output. Site and Tags columns are appended only when
+// some device carries an annotation, so unannotated output is unchanged.
 func (s *Scanner) ToCSV(devices []*Device) string {
+	columns := DeviceColumns(devices)
+
 	var sb strings.Builder
-	sb.WriteString("ID,DeviceType,Activated,IPv4Address,Port,HttpPort,SoftwareVersion,IPv4Gateway,SerialNumber,IPv4SubnetMask,MAC,ChannelNum,DSPVersion,BootTime,DHCP\n")
+	for i, col := range columns {
+		if i > 0 {
+			sb.WriteString(",")
+		}
+		sb.WriteString(col.Name)
+	}
+	sb.WriteString("\n")
 
 	for i, dev := range devices {
-		channelNum := dev.AnalogChannelNum + dev.DigitalChannelNum
-		sb.WriteString(fmt.Sprintf("%d,%s,%s,%s,%d,%d,%s,%s,%s,%s,%s,%d,%s,%s,%s\n",
-			i+1,
-			dev.DeviceType,
-			dev.Activated,
-			dev.IPv4Address,
-			dev.CommandPort,
-			dev.HttpPort,
-			dev.SoftwareVersion,
-			dev.IPv4Gateway,
-			dev.DeviceSN,
-			dev.IPv4SubnetMask,
-			dev.MAC,
-			channelNum,
-			dev.DSPVersion,
-			dev.BootTime,
-			dev.DHCP,
-		))
+		for j, col := range columns {
+			if j > 0 {
+				sb.WriteString(",")
+			}
+			sb.WriteString(col.csvValue(i, dev))
+		}
+		sb.WriteString("\n")
 	}
 
 	return sb.String()
 }
 
+// formatReceivedTime formats t for CSV, leaving unknown times blank
+func formatReceivedTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// csvQuote quotes a free-text CSV field when it contains separators
+func csvQuote(v string) string {
+	if !strings.ContainsAny(v, ",\"\n") {
+		return v
+	}
+	return `"` + strings.ReplaceAll(v, `"`, `""`) + `"`
+}
+
 // Truncate truncates a string to a maximum length
 func Truncate(s string, maxLen int) string {
 	if len(s) <= maxLen {
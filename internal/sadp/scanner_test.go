@@ -1,6 +1,9 @@
 package sadp
 
 import (
+	"context"
+	"errors"
+	"net"
 	"strings"
 	"testing"
 	"time"
@@ -48,6 +51,37 @@ func TestNewScanner(t *testing.T) {
 	}
 }
 
+func TestScannerReset(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*Device
+	}{
+		{
+			name:    "clears recorded devices",
+			devices: []*Device{{MAC: "AA:BB:CC:DD:EE:FF"}, {MAC: "11:22:33:44:55:66"}},
+		},
+		{
+			name:    "empty scanner",
+			devices: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scanner := NewScanner(5*time.Second, logger.NewNop())
+			for _, dev := range tt.devices {
+				scanner.devices[dev.MAC] = dev
+			}
+
+			scanner.Reset()
+
+			if len(scanner.devices) != 0 {
+				t.Errorf("devices = %d after Reset, want 0", len(scanner.devices))
+			}
+		})
+	}
+}
+
 func TestParseResponse(t *testing.T) {
 	log := logger.NewNop()
 	scanner := NewScanner(5*time.Second, log)
@@ -104,6 +138,57 @@ func TestParseResponse(t *testing.T) {
 	}
 }
 
+func TestParseResponseTolerantPorts(t *testing.T) {
+	scanner := NewScanner(5*time.Second, logger.NewNop())
+
+	tests := []struct {
+		name            string
+		ports           string
+		wantCommandPort PortNumber
+		wantHttpPort    PortNumber
+	}{
+		{
+			name:            "numeric ports",
+			ports:           "<CommandPort>8000</CommandPort><HttpPort>80</HttpPort>",
+			wantCommandPort: 8000,
+			wantHttpPort:    80,
+		},
+		{
+			name:         "empty command port",
+			ports:        "<CommandPort></CommandPort><HttpPort>80</HttpPort>",
+			wantHttpPort: 80,
+		},
+		{
+			name:            "non-numeric http port",
+			ports:           "<CommandPort> 8000 </CommandPort><HttpPort>N/A</HttpPort>",
+			wantCommandPort: 8000,
+		},
+		{
+			name:  "out of range port",
+			ports: "<CommandPort>70000</CommandPort><HttpPort>-1</HttpPort>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := "<ProbeMatch><MAC>aa-bb-cc-dd-ee-ff</MAC>" + tt.ports + "<DeviceType>DS-2CD2042WD-I</DeviceType></ProbeMatch>"
+			device := scanner.parseResponse(data)
+			if device == nil {
+				t.Fatal("device was dropped")
+			}
+			if device.DeviceType != "DS-2CD2042WD-I" {
+				t.Errorf("DeviceType = %q, want fields after the ports parsed", device.DeviceType)
+			}
+			if device.CommandPort != tt.wantCommandPort {
+				t.Errorf("CommandPort = %d, want %d", device.CommandPort, tt.wantCommandPort)
+			}
+			if device.HttpPort != tt.wantHttpPort {
+				t.Errorf("HttpPort = %d, want %d", device.HttpPort, tt.wantHttpPort)
+			}
+		})
+	}
+}
+
 func TestToXML(t *testing.T) {
 	log := logger.NewNop()
 	scanner := NewScanner(5*time.Second, log)
@@ -112,6 +197,7 @@ func TestToXML(t *testing.T) {
 		name           string
 		devices        []*Device
 		wantContains   []string
+		wantMissing    []string
 		wantErr        bool
 	}{
 		{
@@ -141,6 +227,15 @@ func TestToXML(t *testing.T) {
 			wantContains: []string{"SADPDeviceList"},
 			wantErr:      false,
 		},
+		{
+			name: "received time only when known",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", ReceivedTime: time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)},
+				{MAC: "11:22:33:44:55:66"},
+			},
+			wantContains: []string{"<ProbeMatch>", "<ReceivedTime>2024-01-02T03:04:05Z</ReceivedTime>"},
+			wantMissing:  []string{"0001-01-01"},
+		},
 	}
 
 	for _, tt := range tests {
@@ -155,6 +250,64 @@ func TestToXML(t *testing.T) {
 					t.Errorf("XML should contain %q", want)
 				}
 			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(xml, missing) {
+					t.Errorf("XML should not contain %q", missing)
+				}
+			}
+		})
+	}
+}
+
+func TestToJSON(t *testing.T) {
+	scanner := NewScanner(5*time.Second, logger.NewNop())
+
+	tests := []struct {
+		name         string
+		devices      []*Device
+		wantContains []string
+		wantDevices  int
+	}{
+		{
+			name: "multiple devices",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "192.168.1.100", DeviceSN: "DS-2CD2143G0-I20200101AAWR000000001"},
+				{MAC: "11:22:33:44:55:66", IPv4Address: "192.168.1.101", DeviceSN: "DS-7608NI-K220200101AAWR000000002"},
+			},
+			wantContains: []string{`"version": "2.0"`, `"serialNumber": "DS-2CD2143G0-I20200101AAWR000000001"`, `"ipv4Address": "192.168.1.101"`},
+			wantDevices:  2,
+		},
+		{
+			name:         "empty device list",
+			devices:      []*Device{},
+			wantContains: []string{`"devices": []`},
+		},
+		{
+			name:         "nil device list",
+			devices:      nil,
+			wantContains: []string{`"devices": []`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := scanner.ToJSON(tt.devices)
+			if err != nil {
+				t.Fatalf("ToJSON() error = %v", err)
+			}
+			for _, want := range tt.wantContains {
+				if !strings.Contains(out, want) {
+					t.Errorf("JSON should contain %q, got:\n%s", want, out)
+				}
+			}
+
+			loaded, err := LoadDevicesFromJSON([]byte(out))
+			if err != nil {
+				t.Fatalf("LoadDevicesFromJSON() error = %v", err)
+			}
+			if len(loaded) != tt.wantDevices {
+				t.Errorf("LoadDevicesFromJSON() loaded %d device(s), want %d", len(loaded), tt.wantDevices)
+			}
 		})
 	}
 }
@@ -189,7 +342,35 @@ func TestToCSV(t *testing.T) {
 					DHCP:              "false",
 				},
 			},
-			wantContains: []string{"ID,DeviceType", "192.168.1.100", "Camera"},
+			wantContains: []string{"ID,DeviceType", "192.168.1.100", "Camera", "ChannelNum,AnalogChannelNum,DigitalChannelNum", ",4,0,4,"},
+		},
+		{
+			name: "resolved hostname",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "192.168.1.100", Hostname: "cam-lobby.example.com"},
+			},
+			wantContains: []string{",SDKServerStatus,ReceivedTime,Hostname\n", ",cam-lobby.example.com\n"},
+		},
+		{
+			name: "ARP status",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "192.168.1.100", ARPStatus: "mismatch"},
+			},
+			wantContains: []string{",ReceivedTime,ARPStatus\n", ",mismatch\n"},
+		},
+		{
+			name: "SDK over TLS",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", DHCP: "false", SDKOverTLSPort: 8443, SDKServerStatus: "true"},
+			},
+			wantContains: []string{",DHCP,SDKOverTLSPort,SDKServerStatus,ReceivedTime\n", ",false,8443,true,\n"},
+		},
+		{
+			name: "received time",
+			devices: []*Device{
+				{MAC: "AA:BB:CC:DD:EE:FF", ReceivedTime: time.Date(2024, 1, 15, 10, 30, 0, 0, time.UTC)},
+			},
+			wantContains: []string{",2024-01-15T10:30:00Z\n"},
 		},
 		{
 			name:         "empty device list",
@@ -279,8 +460,8 @@ func TestDeviceStruct(t *testing.T) {
 		ipv4SubnetMask    string
 		ipv4Gateway       string
 		dhcp              string
-		commandPort       int
-		httpPort          int
+		commandPort       PortNumber
+		httpPort          PortNumber
 		softwareVersion   string
 		activated         string
 		analogChannelNum  int
@@ -376,3 +557,309 @@ func TestConstants(t *testing.T) {
 		})
 	}
 }
+
+func TestIsAddrInUse(t *testing.T) {
+	first, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 0})
+	if err != nil {
+		t.Fatalf("failed to bind test socket: %v", err)
+	}
+	defer first.Close()
+
+	_, bindErr := net.ListenUDP("udp4", first.LocalAddr().(*net.UDPAddr))
+	if bindErr == nil {
+		t.Fatal("expected second bind to the same port to fail")
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected bool
+	}{
+		{name: "real address in use error", err: bindErr, expected: true},
+		{name: "windows message", err: errors.New("bind: Only one usage of each socket address is normally permitted."), expected: true},
+		{name: "unrelated error", err: errors.New("permission denied"), expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAddrInUse(tt.err); got != tt.expected {
+				t.Errorf("isAddrInUse(%v) = %v, want %v", tt.err, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestSADPPortInUse(t *testing.T) {
+	conn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4zero, Port: Port})
+	if err != nil {
+		t.Skipf("SADP port unavailable for test: %v", err)
+	}
+	defer conn.Close()
+
+	if !SADPPortInUse() {
+		t.Error("SADPPortInUse() = false while the port is held")
+	}
+}
+
+func TestProbeDelay(t *testing.T) {
+	tests := []struct {
+		name   string
+		jitter time.Duration
+	}{
+		{name: "disabled", jitter: 0},
+		{name: "negative treated as disabled", jitter: -time.Millisecond},
+		{name: "default jitter", jitter: DefaultProbeJitter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiscoverOptions{ProbeJitter: tt.jitter}
+			for i := 0; i < 100; i++ {
+				d := opts.probeDelay()
+				if d < 0 {
+					t.Fatalf("probeDelay() = %v, want >= 0", d)
+				}
+				if tt.jitter <= 0 && d != 0 {
+					t.Fatalf("probeDelay() = %v, want 0 when jitter disabled", d)
+				}
+				if tt.jitter > 0 && d >= tt.jitter {
+					t.Fatalf("probeDelay() = %v, want < %v", d, tt.jitter)
+				}
+			}
+		})
+	}
+}
+
+func TestGraceExtension(t *testing.T) {
+	tests := []struct {
+		name     string
+		grace    time.Duration
+		found    int
+		extended bool
+		want     time.Duration
+	}{
+		{name: "disabled", grace: 0, found: 3, want: 0},
+		{name: "nothing found", grace: 2 * time.Second, found: 0, want: 0},
+		{name: "extends once", grace: 2 * time.Second, found: 1, want: 2 * time.Second},
+		{name: "already extended", grace: 2 * time.Second, found: 1, extended: true, want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiscoverOptions{GraceWindow: tt.grace}
+			if got := opts.graceExtension(tt.found, tt.extended); got != tt.want {
+				t.Errorf("graceExtension(%d, %v) = %v, want %v", tt.found, tt.extended, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeTargets(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    DiscoverOptions
+		localIP string
+		want    []string
+	}{
+		{
+			name:    "default targets",
+			opts:    DiscoverOptions{},
+			localIP: "192.168.1.10",
+			want:    []string{"239.255.255.250:37020", "255.255.255.255:37020"},
+		},
+		{
+			name:    "with directed broadcast",
+			opts:    DiscoverOptions{DirectedBroadcasts: []net.IP{net.ParseIP("10.0.5.255")}},
+			localIP: "192.168.1.10",
+			want:    []string{"239.255.255.250:37020", "255.255.255.255:37020", "10.0.5.255:37020"},
+		},
+		{
+			name:    "link-local source",
+			opts:    DiscoverOptions{},
+			localIP: "169.254.12.34",
+			want:    []string{"239.255.255.250:37020", "255.255.255.255:37020", "169.254.255.255:37020"},
+		},
+		{
+			name:    "IPv6 link-local source",
+			opts:    DiscoverOptions{DirectedBroadcasts: []net.IP{net.ParseIP("10.0.5.255")}},
+			localIP: "fe80::1",
+			want:    []string{"[ff02::c%eth0]:37020"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			targets := tt.opts.probeTargets(net.ParseIP(tt.localIP), "eth0")
+			if len(targets) != len(tt.want) {
+				t.Fatalf("got %d targets, want %d", len(targets), len(tt.want))
+			}
+			for i, target := range targets {
+				if target.String() != tt.want[i] {
+					t.Errorf("targets[%d] = %s, want %s", i, target, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseProbeTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    []string
+		wantErr bool
+	}{
+		{name: "both", input: "inquiry,inquiry_v32", want: []string{"inquiry", "inquiry_v32"}},
+		{name: "inquiry only", input: "inquiry", want: []string{"inquiry"}},
+		{name: "case and spaces", input: " INQUIRY_V32 , inquiry ", want: []string{"inquiry_v32", "inquiry"}},
+		{name: "duplicates dropped", input: "inquiry,inquiry", want: []string{"inquiry"}},
+		{name: "unknown type", input: "inquiry,activate", wantErr: true},
+		{name: "empty", input: " , ", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseProbeTypes(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProbeTypes(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseProbeTypes(%q) = %v, want %v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeTypes(t *testing.T) {
+	tests := []struct {
+		name string
+		opts DiscoverOptions
+		want []string
+	}{
+		{name: "default probes", opts: DiscoverOptions{}, want: DefaultProbeTypes},
+		{name: "v32 disabled", opts: DiscoverOptions{ProbeTypes: []string{"inquiry"}}, want: []string{"inquiry"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.opts.probeTypes(); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("probeTypes() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewProbeUUID(t *testing.T) {
+	tests := []struct {
+		name       string
+		fixed      string
+		prefix     string
+		wantExact  string
+		wantPrefix string
+	}{
+		{name: "fixed", fixed: "11111111-2222-3333-4444-555555555555", prefix: "ignored", wantExact: "11111111-2222-3333-4444-555555555555"},
+		{name: "prefix", prefix: "cafe", wantPrefix: "cafe"},
+		{name: "random", wantPrefix: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newProbeUUID(tt.fixed, tt.prefix)
+			if tt.wantExact != "" {
+				if got != tt.wantExact {
+					t.Errorf("newProbeUUID() = %q, want %q", got, tt.wantExact)
+				}
+				return
+			}
+			if len(got) != 36 {
+				t.Errorf("newProbeUUID() = %q, want 36 characters", got)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("newProbeUUID() = %q, want prefix %q", got, tt.wantPrefix)
+			}
+			if other := newProbeUUID(tt.fixed, tt.prefix); other == got {
+				t.Errorf("newProbeUUID() returned %q twice, want unique values", got)
+			}
+		})
+	}
+}
+
+func TestAcceptsUUID(t *testing.T) {
+	const sent = "cafe0000-1111-2222-3333-444444444444"
+
+	tests := []struct {
+		name   string
+		strict bool
+		reply  string
+		want   bool
+	}{
+		{name: "lenient accepts mismatch", strict: false, reply: "other", want: true},
+		{name: "lenient accepts missing", strict: false, reply: "", want: true},
+		{name: "strict accepts match", strict: true, reply: sent, want: true},
+		{name: "strict accepts case difference", strict: true, reply: strings.ToUpper(sent), want: true},
+		{name: "strict rejects mismatch", strict: true, reply: "11111111-1111-1111-1111-111111111111", want: false},
+		{name: "strict rejects missing", strict: true, reply: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiscoverOptions{StrictUUID: tt.strict}
+			if got := opts.acceptsUUID(tt.reply, sent); got != tt.want {
+				t.Errorf("acceptsUUID(%q) = %v, want %v", tt.reply, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBufferSize(t *testing.T) {
+	tests := []struct {
+		name       string
+		bufferSize int
+		read       int
+		wantSize   int
+		wantTrunc  bool
+	}{
+		{name: "default", bufferSize: 0, read: 1200, wantSize: MaxPacketSize},
+		{name: "too large falls back", bufferSize: MaxPacketSize + 1, read: 1200, wantSize: MaxPacketSize},
+		{name: "custom size", bufferSize: 4096, read: 1200, wantSize: 4096},
+		{name: "read fills buffer", bufferSize: 1024, read: 1024, wantSize: 1024, wantTrunc: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			opts := DiscoverOptions{BufferSize: tt.bufferSize}
+			size := opts.bufferSize()
+			if size != tt.wantSize {
+				t.Errorf("bufferSize() = %d, want %d", size, tt.wantSize)
+			}
+			if got := possiblyTruncated(tt.read, make([]byte, size)); got != tt.wantTrunc {
+				t.Errorf("possiblyTruncated(%d) = %v, want %v", tt.read, got, tt.wantTrunc)
+			}
+		})
+	}
+}
+
+func TestDefaultDiscoverOptionsRetries(t *testing.T) {
+	opts := DefaultDiscoverOptions()
+	if opts.ProbeRetries != 2 {
+		t.Errorf("ProbeRetries = %d, want 2", opts.ProbeRetries)
+	}
+	if opts.ProbeRetryInterval != 500*time.Millisecond {
+		t.Errorf("ProbeRetryInterval = %v, want 500ms", opts.ProbeRetryInterval)
+	}
+}
+
+func TestDiscoverContextCancel(t *testing.T) {
+	s := NewScannerWithOptions(5*time.Second, logger.NewNop(), DiscoverOptions{})
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, err := s.DiscoverContext(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("DiscoverContext() error = %v, want context.Canceled", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("DiscoverContext() took %v after cancel, want it to return promptly", elapsed)
+	}
+}
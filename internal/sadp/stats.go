@@ -0,0 +1,95 @@
+package sadp
+
+import "sort"
+
+// InterfaceStats counts what one interface sent and received during a
+// discovery, for telling interface-specific problems from network-wide ones
+type InterfaceStats struct {
+	Interface string
+	IP        string
+	// Bound is false when the socket could not be bound, so nothing was
+	// sent or received on the interface
+	Bound      bool
+	ProbesSent int
+	SendErrors int
+	// Responses counts every packet received, Discarded those that were
+	// not used: truncated, unparsable, not a ProbeMatch, or a reply to
+	// another probe
+	Responses int
+	Discarded int
+	// Devices is the number of distinct devices that answered on the
+	// interface, and OnlyHere how many of them no other interface heard
+	Devices  int
+	OnlyHere int
+
+	macs map[string]bool
+}
+
+// addDevice records a reply from mac
+func (st *InterfaceStats) addDevice(mac string) {
+	if st.macs == nil {
+		st.macs = make(map[string]bool)
+	}
+	st.macs[mac] = true
+	st.Devices = len(st.macs)
+}
+
+// recordStats stores st for InterfaceStats
+func (s *Scanner) recordStats(st InterfaceStats) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	s.stats = append(s.stats, st)
+}
+
+// resetStats clears the stats of a previous discovery
+func (s *Scanner) resetStats() {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	s.stats = nil
+}
+
+// InterfaceStats returns the per-interface stats of the most recent
+// Discover or DiscoverOnIP call, ordered by interface name and IP. Like
+// warnings they do not accumulate across calls.
+func (s *Scanner) InterfaceStats() []InterfaceStats {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	return summarizeStats(s.stats)
+}
+
+// summarizeStats sorts a copy of stats and fills in OnlyHere
+func summarizeStats(stats []InterfaceStats) []InterfaceStats {
+	heardBy := make(map[string]int)
+	for _, st := range stats {
+		for mac := range st.macs {
+			heardBy[mac]++
+		}
+	}
+
+	out := append([]InterfaceStats(nil), stats...)
+	for i := range out {
+		out[i].OnlyHere = 0
+		for mac := range out[i].macs {
+			if heardBy[mac] == 1 {
+				out[i].OnlyHere++
+			}
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Interface != out[j].Interface {
+			return out[i].Interface < out[j].Interface
+		}
+		return out[i].IP < out[j].IP
+	})
+	return out
+}
+
+// DiscoverWithStats performs Discover and also returns the per-interface
+// stats it recorded
+func (s *Scanner) DiscoverWithStats() ([]*Device, []InterfaceStats, error) {
+	devices, err := s.Discover()
+	if err != nil {
+		return nil, nil, err
+	}
+	return devices, s.InterfaceStats(), nil
+}
@@ -0,0 +1,63 @@
+package sadp
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func statsWith(iface, ip string, macs ...string) InterfaceStats {
+	st := InterfaceStats{Interface: iface, IP: ip, Bound: true}
+	for _, mac := range macs {
+		st.addDevice(mac)
+	}
+	return st
+}
+
+func TestSummarizeStats(t *testing.T) {
+	stats := []InterfaceStats{
+		statsWith("wlan0", "10.0.0.5", "aa", "bb"),
+		statsWith("eth0", "192.168.1.10", "aa", "cc", "dd"),
+		{Interface: "eth0", IP: "169.254.1.1"},
+	}
+
+	got := summarizeStats(stats)
+	want := []struct {
+		ip       string
+		devices  int
+		onlyHere int
+	}{
+		{ip: "169.254.1.1", devices: 0, onlyHere: 0},
+		{ip: "192.168.1.10", devices: 3, onlyHere: 2},
+		{ip: "10.0.0.5", devices: 2, onlyHere: 1},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %d stats, want %d", len(got), len(want))
+	}
+	for i, w := range want {
+		if got[i].IP != w.ip || got[i].Devices != w.devices || got[i].OnlyHere != w.onlyHere {
+			t.Errorf("stats[%d] = %s devices=%d onlyHere=%d, want %s devices=%d onlyHere=%d",
+				i, got[i].IP, got[i].Devices, got[i].OnlyHere, w.ip, w.devices, w.onlyHere)
+		}
+	}
+}
+
+func TestScannerInterfaceStats(t *testing.T) {
+	s := NewScanner(time.Second, logger.NewNop())
+	s.recordStats(statsWith("eth0", "192.168.1.10", "aa"))
+
+	stats := s.InterfaceStats()
+	if len(stats) != 1 || stats[0].OnlyHere != 1 {
+		t.Fatalf("InterfaceStats() = %+v, want one interface with one device only there", stats)
+	}
+	stats[0].Interface = "changed"
+	if s.InterfaceStats()[0].Interface != "eth0" {
+		t.Errorf("InterfaceStats() returned the scanner's own slice")
+	}
+
+	s.resetStats()
+	if n := len(s.InterfaceStats()); n != 0 {
+		t.Errorf("got %d stats after reset, want 0", n)
+	}
+}
@@ -0,0 +1,92 @@
+package sadp
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// DiscoverStream starts a discovery and returns a channel that receives
+// each device as soon as its MAC is first recorded, closed once every
+// interface has finished listening. Later replies from the same device are
+// merged into the scanner's record but not sent again, nor are devices
+// recorded by an earlier discovery; call Reset first to see those too. Each
+// device sent is a snapshot the caller may keep.
+func (s *Scanner) DiscoverStream() (<-chan *Device, error) {
+	return s.DiscoverStreamContext(context.Background())
+}
+
+// DiscoverStreamContext is DiscoverStream, closing the channel early when
+// ctx is done
+func (s *Scanner) DiscoverStreamContext(ctx context.Context) (<-chan *Device, error) {
+	interfaces, err := s.ProbeInterfaces()
+	if err != nil {
+		return nil, err
+	}
+	return s.discoverStream(ctx, interfaces)
+}
+
+// discoverStream probes from each of interfaces, streaming new devices as
+// DiscoverStreamContext does
+func (s *Scanner) discoverStream(ctx context.Context, interfaces []InterfaceInfo) (<-chan *Device, error) {
+	s.resetWarnings()
+	s.resetStats()
+
+	if err := s.checkMulticast(interfaces); err != nil {
+		return nil, err
+	}
+
+	found := make(chan *Device)
+	var wg sync.WaitGroup
+	for _, iface := range interfaces {
+		wg.Add(1)
+		go func(localIP net.IP, ifaceName string) {
+			defer wg.Done()
+			s.discoverOnInterface(ctx, localIP, ifaceName, found)
+		}(iface.IP, iface.Name)
+	}
+	go func() {
+		wg.Wait()
+		close(found)
+	}()
+
+	out := make(chan *Device)
+	go relayDevices(ctx, found, out)
+	return out, nil
+}
+
+// Devices returns every device recorded so far, with the fields of later
+// replies merged in
+func (s *Scanner) Devices() []*Device {
+	return s.recordedDevices()
+}
+
+// relayDevices forwards in to out through an unbounded queue, so a slow
+// reader never stalls the interface read loops, and closes out once in is
+// closed and drained. When ctx is done the queue is dropped and out closed
+// at once, so a reader that has stopped does not leave the relay blocked.
+func relayDevices(ctx context.Context, in <-chan *Device, out chan<- *Device) {
+	defer close(out)
+
+	var queue []*Device
+	for in != nil || len(queue) > 0 {
+		var send chan<- *Device
+		var next *Device
+		if len(queue) > 0 {
+			send, next = out, queue[0]
+		}
+
+		select {
+		case dev, ok := <-in:
+			if !ok {
+				in = nil
+				continue
+			}
+			queue = append(queue, dev)
+		case send <- next:
+			queue = queue[1:]
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package sadp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func TestRelayDevices(t *testing.T) {
+	tests := []struct {
+		name string
+		macs []string
+	}{
+		{name: "none", macs: nil},
+		{name: "one", macs: []string{"AA:BB:CC:00:00:01"}},
+		{name: "keeps order", macs: []string{"AA:BB:CC:00:00:03", "AA:BB:CC:00:00:01", "AA:BB:CC:00:00:02"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			in := make(chan *Device)
+			out := make(chan *Device)
+			go relayDevices(context.Background(), in, out)
+
+			// Nothing reads out until every device is sent, so the relay
+			// must queue them rather than block the sender
+			for _, mac := range tt.macs {
+				select {
+				case in <- &Device{MAC: mac}:
+				case <-time.After(time.Second):
+					t.Fatalf("relay blocked accepting %s", mac)
+				}
+			}
+			close(in)
+
+			var got []string
+			for dev := range out {
+				got = append(got, dev.MAC)
+			}
+			if len(got) != len(tt.macs) {
+				t.Fatalf("relayed %v, want %v", got, tt.macs)
+			}
+			for i := range got {
+				if got[i] != tt.macs[i] {
+					t.Errorf("relayed[%d] = %s, want %s", i, got[i], tt.macs[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRelayDevicesStopsWhenContextDone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	in := make(chan *Device)
+	out := make(chan *Device)
+	done := make(chan struct{})
+	go func() {
+		relayDevices(ctx, in, out)
+		close(done)
+	}()
+
+	// The reader abandons out with devices still queued and in still open
+	in <- &Device{MAC: "AA:BB:CC:00:00:01"}
+	in <- &Device{MAC: "AA:BB:CC:00:00:02"}
+	cancel()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("relay still blocked after the context was cancelled")
+	}
+	if _, ok := <-out; ok {
+		t.Error("out not closed after the context was cancelled")
+	}
+}
+
+func TestDiscoverOnInterfaceEmitsEachMACOnce(t *testing.T) {
+	device, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: Port})
+	if err != nil {
+		t.Skipf("cannot bind SADP port for test: %v", err)
+	}
+	defer device.Close()
+
+	// Answer every probe twice, as a device replying to both the
+	// multicast and broadcast copies would
+	go func() {
+		buf := make([]byte, MaxPacketSize)
+		for {
+			_, addr, err := device.ReadFromUDP(buf)
+			if err != nil {
+				return
+			}
+			reply := "<ProbeMatch><Types>inquiry</Types><MAC>aa-bb-cc-00-00-01</MAC><IPv4Address>127.0.0.1</IPv4Address></ProbeMatch>"
+			_, _ = device.WriteToUDP([]byte(reply), addr)
+			_, _ = device.WriteToUDP([]byte(reply), addr)
+		}
+	}()
+
+	s := NewScannerWithOptions(300*time.Millisecond, logger.NewNop(), DiscoverOptions{
+		DirectedBroadcasts: []net.IP{net.IPv4(127, 0, 0, 1)},
+		ProbeRetries:       1,
+		ProbeRetryInterval: 50 * time.Millisecond,
+	})
+
+	found := make(chan *Device, 10)
+	s.discoverOnInterface(context.Background(), net.IPv4(127, 0, 0, 1), "lo", found)
+	close(found)
+
+	var macs []string
+	for dev := range found {
+		macs = append(macs, dev.MAC)
+	}
+	if len(macs) != 1 {
+		t.Fatalf("emitted %v, want the device once", macs)
+	}
+	if got := len(s.Devices()); got != 1 {
+		t.Errorf("Devices() has %d device(s), want 1", got)
+	}
+}
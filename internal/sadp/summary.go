@@ -0,0 +1,70 @@
+package sadp
+
+import (
+	"sort"
+	"strings"
+)
+
+// Summary is a fleet rollup of a device list
+type Summary struct {
+	Total    int `json:"total"`
+	Active   int `json:"active"`
+	Inactive int `json:"inactive"`
+	DHCP     int `json:"dhcp"`
+	// ByType and ByFirmware count devices per DeviceType and
+	// SoftwareVersion, most common first. Devices without one are counted
+	// under UnknownGroup.
+	ByType     []SummaryCount `json:"byType"`
+	ByFirmware []SummaryCount `json:"byFirmware"`
+}
+
+// SummaryCount is the number of devices sharing one value
+type SummaryCount struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+// Summarize counts devices by model and firmware, and how many are active
+// and use DHCP
+func Summarize(devices []*Device) Summary {
+	summary := Summary{Total: len(devices)}
+	types := make(map[string]int)
+	firmware := make(map[string]int)
+	for _, dev := range devices {
+		if dev.Activated == "true" {
+			summary.Active++
+		} else {
+			summary.Inactive++
+		}
+		if strings.EqualFold(strings.TrimSpace(dev.DHCP), "true") {
+			summary.DHCP++
+		}
+		types[summaryName(dev.DeviceType)]++
+		firmware[summaryName(dev.SoftwareVersion)]++
+	}
+	summary.ByType = sortedCounts(types)
+	summary.ByFirmware = sortedCounts(firmware)
+	return summary
+}
+
+func summaryName(value string) string {
+	if value = strings.TrimSpace(value); value != "" {
+		return value
+	}
+	return UnknownGroup
+}
+
+// sortedCounts orders counts largest first, then by name
+func sortedCounts(counts map[string]int) []SummaryCount {
+	result := make([]SummaryCount, 0, len(counts))
+	for name, n := range counts {
+		result = append(result, SummaryCount{Name: name, Count: n})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
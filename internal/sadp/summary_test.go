@@ -0,0 +1,54 @@
+package sadp
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSummarize(t *testing.T) {
+	tests := []struct {
+		name    string
+		devices []*Device
+		want    Summary
+	}{
+		{
+			name:    "no devices",
+			devices: nil,
+			want:    Summary{ByType: []SummaryCount{}, ByFirmware: []SummaryCount{}},
+		},
+		{
+			name: "two models",
+			devices: []*Device{
+				{DeviceType: "DS-2CD2143G0-IS", SoftwareVersion: "V5.5.80 build 190603", Activated: "true", DHCP: "true"},
+				{DeviceType: "DS-2CD2143G0-IS", SoftwareVersion: "V5.5.80 build 190603", Activated: "true", DHCP: "false"},
+				{DeviceType: "DS-2CD2143G0-IS", SoftwareVersion: "V5.4.5 build 170124", Activated: "false", DHCP: "TRUE"},
+				{DeviceType: "DS-7616NI-I2", SoftwareVersion: "V4.1.0 build 170301", Activated: "true", DHCP: "false"},
+				{DeviceType: "DS-7616NI-I2", SoftwareVersion: "", Activated: "", DHCP: ""},
+			},
+			want: Summary{
+				Total:    5,
+				Active:   3,
+				Inactive: 2,
+				DHCP:     2,
+				ByType: []SummaryCount{
+					{Name: "DS-2CD2143G0-IS", Count: 3},
+					{Name: "DS-7616NI-I2", Count: 2},
+				},
+				ByFirmware: []SummaryCount{
+					{Name: "V5.5.80 build 190603", Count: 2},
+					{Name: "V4.1.0 build 170301", Count: 1},
+					{Name: "V5.4.5 build 170124", Count: 1},
+					{Name: UnknownGroup, Count: 1},
+				},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Summarize(tt.devices); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Summarize() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,119 @@
+package sadp
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Tag is a user-supplied key=value annotation attached to exported devices
+type Tag struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// Tags is an ordered list of tags. It marshals to a JSON object and to
+// <Tag key="...">value</Tag> elements in XML.
+type Tags []Tag
+
+// ParseTags parses key=value strings, as given to --tag
+func ParseTags(values []string) (Tags, error) {
+	var tags Tags
+	for _, v := range values {
+		key, value, ok := strings.Cut(v, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, fmt.Errorf("invalid tag %q (want key=value)", v)
+		}
+		tags = append(tags, Tag{Key: key, Value: strings.TrimSpace(value)})
+	}
+	return tags, nil
+}
+
+// String renders tags as key=value pairs joined by semicolons, as used in
+// the CSV Tags column
+func (t Tags) String() string {
+	pairs := make([]string, len(t))
+	for i, tag := range t {
+		pairs[i] = tag.Key + "=" + tag.Value
+	}
+	return strings.Join(pairs, ";")
+}
+
+// MarshalJSON encodes tags as a JSON object
+func (t Tags) MarshalJSON() ([]byte, error) {
+	m := make(map[string]string, len(t))
+	for _, tag := range t {
+		m[tag.Key] = tag.Value
+	}
+	return json.Marshal(m)
+}
+
+// MarshalXML encodes tags as <Tag key="...">value</Tag> children
+func (t Tags) MarshalXML(e *xml.Encoder, start xml.StartElement) error {
+	var wrapper struct {
+		Tags []Tag `xml:"Tag"`
+	}
+	wrapper.Tags = t
+	return e.EncodeElement(wrapper, start)
+}
+
+// UnmarshalXML decodes <Tag> children
+func (t *Tags) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	var wrapper struct {
+		Tags []Tag `xml:"Tag"`
+	}
+	if err := d.DecodeElement(&wrapper, &start); err != nil {
+		return err
+	}
+	*t = wrapper.Tags
+	return nil
+}
+
+// UnmarshalJSON decodes tags from a JSON object, sorted by key
+func (t *Tags) UnmarshalJSON(data []byte) error {
+	var m map[string]string
+	if err := json.Unmarshal(data, &m); err != nil {
+		return err
+	}
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	*t = nil
+	for _, k := range keys {
+		*t = append(*t, Tag{Key: k, Value: m[k]})
+	}
+	return nil
+}
+
+// parseTagString is the inverse of Tags.String
+func parseTagString(s string) Tags {
+	var tags Tags
+	for _, pair := range strings.Split(s, ";") {
+		key, value, _ := strings.Cut(pair, "=")
+		if key = strings.TrimSpace(key); key != "" {
+			tags = append(tags, Tag{Key: key, Value: value})
+		}
+	}
+	return tags
+}
+
+// Annotate sets the site and tags on every device in place
+func Annotate(devices []*Device, site string, tags Tags) {
+	if site == "" && len(tags) == 0 {
+		return
+	}
+	for _, dev := range devices {
+		if site != "" {
+			dev.Site = site
+		}
+		if len(tags) > 0 {
+			dev.Tags = append(Tags(nil), tags...)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package sadp
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseTags(t *testing.T) {
+	tests := []struct {
+		name    string
+		values  []string
+		want    Tags
+		wantErr bool
+	}{
+		{name: "none", values: nil, want: nil},
+		{name: "pairs", values: []string{"env=prod", " rack = 4 "}, want: Tags{{Key: "env", Value: "prod"}, {Key: "rack", Value: "4"}}},
+		{name: "empty value", values: []string{"note="}, want: Tags{{Key: "note", Value: ""}}},
+		{name: "missing equals", values: []string{"prod"}, wantErr: true},
+		{name: "missing key", values: []string{"=prod"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseTags(tt.values)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseTags() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ParseTags() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTagsJSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     Tags
+		wantJSON string
+	}{
+		{name: "object encoding", tags: Tags{{Key: "env", Value: "prod"}, {Key: "rack", Value: "4"}}, wantJSON: `{"env":"prod","rack":"4"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, err := json.Marshal(tt.tags)
+			if err != nil {
+				t.Fatalf("Marshal() error = %v", err)
+			}
+			if string(data) != tt.wantJSON {
+				t.Errorf("Marshal() = %s, want %s", data, tt.wantJSON)
+			}
+
+			var decoded Tags
+			if err := json.Unmarshal(data, &decoded); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(decoded, tt.tags) {
+				t.Errorf("Unmarshal() = %v, want %v", decoded, tt.tags)
+			}
+		})
+	}
+}
+
+func TestAnnotateExports(t *testing.T) {
+	devices := []*Device{{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "10.0.0.5"}}
+	Annotate(devices, "Building A, Floor 2", Tags{{Key: "env", Value: "prod"}})
+
+	s := NewScanner(DefaultTimeout, nil)
+
+	tests := []struct {
+		name   string
+		output func() string
+		want   []string
+	}{
+		{
+			name:   "csv",
+			output: func() string { return s.ToCSV(devices) },
+			want:   []string{",Site,Tags\n", `,"Building A, Floor 2",env=prod`},
+		},
+		{
+			name: "xml",
+			output: func() string {
+				out, _ := s.ToXML(devices)
+				return out
+			},
+			want: []string{"<Site>Building A, Floor 2</Site>", `<Tag key="env">prod</Tag>`},
+		},
+		{
+			name: "json",
+			output: func() string {
+				out, _ := json.Marshal(devices[0])
+				return string(out)
+			},
+			want: []string{`"site":"Building A, Floor 2"`, `"tags":{"env":"prod"}`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := tt.output()
+			for _, w := range tt.want {
+				if !strings.Contains(out, w) {
+					t.Errorf("output missing %q:\n%s", w, out)
+				}
+			}
+		})
+	}
+}
+
+func TestAnnotateRoundTrip(t *testing.T) {
+	devices := []*Device{{MAC: "AA:BB:CC:DD:EE:FF", IPv4Address: "10.0.0.5"}}
+	Annotate(devices, "Building A", Tags{{Key: "env", Value: "prod"}, {Key: "rack", Value: "4"}})
+	s := NewScanner(DefaultTimeout, nil)
+
+	xmlOut, _ := s.ToXML(devices)
+	fromXML, err := LoadDevicesFromXML([]byte(xmlOut))
+	if err != nil {
+		t.Fatalf("LoadDevicesFromXML() error = %v", err)
+	}
+	fromCSV, err := LoadDevicesFromCSV([]byte(s.ToCSV(devices)))
+	if err != nil {
+		t.Fatalf("LoadDevicesFromCSV() error = %v", err)
+	}
+
+	for name, got := range map[string]*Device{"xml": fromXML[0], "csv": fromCSV[0]} {
+		if got.Site != "Building A" {
+			t.Errorf("%s: Site = %q", name, got.Site)
+		}
+		if !reflect.DeepEqual(got.Tags, devices[0].Tags) {
+			t.Errorf("%s: Tags = %v, want %v", name, got.Tags, devices[0].Tags)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package sadp
+
+import (
+	"fmt"
+	"strings"
+)
+
+// templatePlaceholders lists, in template order, the labeled placeholder
+// that stands in for each value of a command's Template. Optional elements
+// such as <UserName> and the DNS servers are shown in full so the template
+// documents them; they are left out on the wire when unset.
+var templatePlaceholders = map[string][]string{
+	"inquiry":              {"{UUID}"},
+	"inquiry_v32":          {"{UUID}"},
+	"exchangecode":         {"{UUID}", "{MAC}"},
+	"getencryptstring":     {"{UUID}", "{MAC}"},
+	"getencryptstring_v31": {"{UUID}", "{MAC}"},
+	"getbindlist":          {"{UUID}", "{MAC}"},
+	"getqrcodes":           {"{UUID}", "{MAC}"},
+	"activate":             {"{UUID}", "{MAC}", "{PASSWORD}"},
+	"reboot":               {"{UUID}", "{MAC}", "<UserName>{USERNAME}</UserName>", "{PASSWORD}"},
+	"restore":              {"{UUID}", "{MAC}", "<UserName>{USERNAME}</UserName>", "{PASSWORD}"},
+	"ezvizunbind":          {"{UUID}", "{MAC}", "<UserName>{USERNAME}</UserName>", "{PASSWORD}"},
+	"setmailbox":           {"{UUID}", "{MAC}", "{EMAIL}", "<UserName>{USERNAME}</UserName>", "{PASSWORD}"},
+	"resetpassword":        {"{UUID}", "{MAC}", "{CODE}", "{PASSWORD}"},
+	"securitycode": {"{UUID}", "{MAC}", "{CODE}",
+		"<Answer1>{ANSWER1}</Answer1><Answer2>{ANSWER2}</Answer2><Answer3>{ANSWER3}</Answer3>", "{PASSWORD}"},
+	"update": {"{UUID}", "{MAC}", "<UserName>{USERNAME}</UserName>", "{PASSWORD}",
+		"{IPV4_ADDRESS}", "{PORT}", "{SUBNET_MASK}", "{GATEWAY}", "{DHCP}",
+		"<IPv4DNS1>{DNS1}</IPv4DNS1><IPv4DNS2>{DNS2}</IPv4DNS2>"},
+}
+
+// verifyCodePlaceholders fills a command's VerifyCodeTemplate
+var verifyCodePlaceholders = []string{"{UUID}", "{MAC}", "{VERIFY_CODE}"}
+
+// ProbeTemplate returns the XML sent for cmdName with each value replaced by
+// a labeled placeholder such as {MAC}. With verifyCode set it renders the
+// command's verification-code variant instead.
+func ProbeTemplate(cmdName string, verifyCode bool) (string, error) {
+	cmd, ok := Commands[cmdName]
+	if !ok {
+		return "", fmt.Errorf("unknown command: %s", cmdName)
+	}
+
+	tmpl, placeholders := cmd.Template, templatePlaceholders[cmdName]
+	if verifyCode {
+		if cmd.VerifyCodeTemplate == "" {
+			return "", fmt.Errorf("command %s does not accept a verification code", cmdName)
+		}
+		tmpl, placeholders = cmd.VerifyCodeTemplate, verifyCodePlaceholders
+	}
+	if placeholders == nil {
+		return "", fmt.Errorf("no probe template for command %s", cmdName)
+	}
+
+	// Numeric values such as the port are placeholders here too
+	tmpl = strings.ReplaceAll(tmpl, "%d", "%s")
+	args := make([]interface{}, len(placeholders))
+	for i, p := range placeholders {
+		args[i] = p
+	}
+	return fmt.Sprintf(tmpl, args...), nil
+}
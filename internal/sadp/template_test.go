@@ -0,0 +1,76 @@
+package sadp
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestProbeTemplate(t *testing.T) {
+	tests := []struct {
+		name       string
+		command    string
+		verifyCode bool
+		want       string
+		wantErr    bool
+	}{
+		{
+			name:    "inquiry",
+			command: "inquiry",
+			want:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>{UUID}</Uuid><Types>inquiry</Types></Probe>`,
+		},
+		{
+			name:    "reboot shows optional user",
+			command: "reboot",
+			want:    `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>{UUID}</Uuid><MAC>{MAC}</MAC><Types>reboot</Types><UserName>{USERNAME}</UserName><Password>{PASSWORD}</Password></Probe>`,
+		},
+		{
+			name:       "verification code variant",
+			command:    "getbindlist",
+			verifyCode: true,
+			want:       `<?xml version="1.0" encoding="utf-8"?><Probe><Uuid>{UUID}</Uuid><MAC>{MAC}</MAC><Types>getBindList</Types><VerifyCode>{VERIFY_CODE}</VerifyCode></Probe>`,
+		},
+		{
+			name:       "no verification code variant",
+			command:    "reboot",
+			verifyCode: true,
+			wantErr:    true,
+		},
+		{
+			name:    "unknown command",
+			command: "bogus",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ProbeTemplate(tt.command, tt.verifyCode)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProbeTemplate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ProbeTemplate() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestProbeTemplateCoversAllCommands(t *testing.T) {
+	for name, cmd := range Commands {
+		variants := []bool{false}
+		if cmd.VerifyCodeTemplate != "" {
+			variants = append(variants, true)
+		}
+		for _, verifyCode := range variants {
+			got, err := ProbeTemplate(name, verifyCode)
+			if err != nil {
+				t.Errorf("ProbeTemplate(%q, %v) error = %v", name, verifyCode, err)
+				continue
+			}
+			// fmt marks missing or extra arguments with %!
+			if strings.Contains(got, "%") {
+				t.Errorf("ProbeTemplate(%q, %v) = %s, placeholders do not match the template", name, verifyCode, got)
+			}
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package sadp
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// DeviceTypeMap maps raw DeviceType strings to canonical display names
+type DeviceTypeMap map[string]string
+
+// LoadDeviceTypeMap reads a JSON object of raw DeviceType to canonical name
+func LoadDeviceTypeMap(path string) (DeviceTypeMap, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read type map: %w", err)
+	}
+
+	m := DeviceTypeMap{}
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid type map %s: %w", path, err)
+	}
+	return m, nil
+}
+
+// Lookup returns the canonical name for a device type, or the type itself
+// when it is not mapped
+func (m DeviceTypeMap) Lookup(deviceType string) string {
+	if name, ok := m[deviceType]; ok {
+		return name
+	}
+	return deviceType
+}
+
+// Apply renames the DeviceType of each device in place
+func (m DeviceTypeMap) Apply(devices []*Device) {
+	if len(m) == 0 {
+		return
+	}
+	for _, dev := range devices {
+		dev.DeviceType = m.Lookup(dev.DeviceType)
+	}
+}
@@ -0,0 +1,106 @@
+package sadp
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceTypeMapLookup(t *testing.T) {
+	m := DeviceTypeMap{"DS-2CD2042WD-I": "Outdoor Bullet 4MP"}
+
+	tests := []struct {
+		name       string
+		deviceType string
+		expected   string
+	}{
+		{name: "mapped type", deviceType: "DS-2CD2042WD-I", expected: "Outdoor Bullet 4MP"},
+		{name: "unmapped type passes through", deviceType: "DS-7616NI-I2", expected: "DS-7616NI-I2"},
+		{name: "empty type", deviceType: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := m.Lookup(tt.deviceType); got != tt.expected {
+				t.Errorf("Lookup(%q) = %q, want %q", tt.deviceType, got, tt.expected)
+			}
+		})
+	}
+}
+
+func TestDeviceTypeMapApply(t *testing.T) {
+	tests := []struct {
+		name     string
+		m        DeviceTypeMap
+		devices  []*Device
+		expected []string
+	}{
+		{
+			name:     "renames mapped devices only",
+			m:        DeviceTypeMap{"DS-2CD2042WD-I": "Outdoor Bullet 4MP"},
+			devices:  []*Device{{DeviceType: "DS-2CD2042WD-I"}, {DeviceType: "DS-7616NI-I2"}},
+			expected: []string{"Outdoor Bullet 4MP", "DS-7616NI-I2"},
+		},
+		{
+			name:     "nil map is a no-op",
+			m:        nil,
+			devices:  []*Device{{DeviceType: "DS-2CD2042WD-I"}},
+			expected: []string{"DS-2CD2042WD-I"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.m.Apply(tt.devices)
+			for i, dev := range tt.devices {
+				if dev.DeviceType != tt.expected[i] {
+					t.Errorf("devices[%d].DeviceType = %q, want %q", i, dev.DeviceType, tt.expected[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLoadDeviceTypeMap(t *testing.T) {
+	dir := t.TempDir()
+
+	tests := []struct {
+		name    string
+		content string
+		wantLen int
+		wantErr bool
+	}{
+		{
+			name:    "valid map",
+			content: `{"DS-2CD2042WD-I": "Outdoor Bullet 4MP", "DS-7616NI-I2": "16ch NVR"}`,
+			wantLen: 2,
+		},
+		{
+			name:    "invalid JSON",
+			content: `{"DS-2CD2042WD-I": }`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(dir, "types.json")
+			if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("failed to write type map: %v", err)
+			}
+			m, err := LoadDeviceTypeMap(path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("LoadDeviceTypeMap() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && len(m) != tt.wantLen {
+				t.Errorf("len = %d, want %d", len(m), tt.wantLen)
+			}
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := LoadDeviceTypeMap(filepath.Join(dir, "missing.json")); err == nil {
+			t.Error("expected error for missing file")
+		}
+	})
+}
@@ -0,0 +1,82 @@
+package sadp
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/crypto"
+)
+
+// softwareVersionPattern matches Hikvision SoftwareVersion values such as
+// "V5.5.0 build 191126", "V5.4.5build 170123" and "V4.0.1", with an
+// optional trailing build number of digits
+var softwareVersionPattern = regexp.MustCompile(`(?i)^v?(\d+(?:\.\d+)*)\s*(?:build\s*(\d+))?`)
+
+// ParseSoftwareVersion splits a SoftwareVersion into its version ("5.5.0")
+// and build ("191126"). A six-digit build is a YYMMDD date and an
+// eight-digit one YYYYMMDD; buildDate is zero for missing or other builds.
+// ok is false when s does not start with a version number.
+func ParseSoftwareVersion(s string) (version string, build string, buildDate time.Time, ok bool) {
+	m := softwareVersionPattern.FindStringSubmatch(strings.TrimSpace(s))
+	if m == nil {
+		return "", "", time.Time{}, false
+	}
+	version, build = m[1], m[2]
+
+	layout := ""
+	switch len(build) {
+	case 6:
+		layout = "060102"
+	case 8:
+		layout = "20060102"
+	}
+	if layout != "" {
+		if t, err := time.Parse(layout, build); err == nil {
+			buildDate = t
+		}
+	}
+	return version, build, buildDate, true
+}
+
+// FirmwareVersion returns the version part of the device's SoftwareVersion,
+// e.g. "5.5.0", or "" when it cannot be parsed
+func (d *Device) FirmwareVersion() string {
+	version, _, _, _ := ParseSoftwareVersion(d.SoftwareVersion)
+	return version
+}
+
+// BuildDate returns the firmware build date from the device's
+// SoftwareVersion, or the zero time when it carries none
+func (d *Device) BuildDate() time.Time {
+	_, _, date, _ := ParseSoftwareVersion(d.SoftwareVersion)
+	return date
+}
+
+// WithMinFirmware returns the devices whose firmware version is at least
+// min, e.g. "5.5.0". Devices whose version cannot be parsed are dropped
+// since they cannot be shown to qualify.
+func WithMinFirmware(devices []*Device, min string) []*Device {
+	result := make([]*Device, 0, len(devices))
+	for _, dev := range devices {
+		version := dev.FirmwareVersion()
+		if version != "" && crypto.CompareFirmwareVersion(version, min) >= 0 {
+			result = append(result, dev)
+		}
+	}
+	return result
+}
+
+// SortByBuildDate orders devices by firmware build date, oldest first, so
+// the most out-of-date firmware leads. Devices without a build date go
+// last, keeping their order.
+func SortByBuildDate(devices []*Device) {
+	sort.SliceStable(devices, func(i, j int) bool {
+		a, b := devices[i].BuildDate(), devices[j].BuildDate()
+		if a.IsZero() || b.IsZero() {
+			return !a.IsZero() && b.IsZero()
+		}
+		return a.Before(b)
+	})
+}
@@ -0,0 +1,82 @@
+package sadp
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSoftwareVersion(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantVersion string
+		wantBuild   string
+		wantDate    string
+		wantOK      bool
+	}{
+		{in: "V5.5.0 build 191126", wantVersion: "5.5.0", wantBuild: "191126", wantDate: "2019-11-26", wantOK: true},
+		{in: "V5.4.5build 170123", wantVersion: "5.4.5", wantBuild: "170123", wantDate: "2017-01-23", wantOK: true},
+		{in: "v4.1.2 Build 20161209", wantVersion: "4.1.2", wantBuild: "20161209", wantDate: "2016-12-09", wantOK: true},
+		{in: " V5.6.2 build 200602 (IPC) ", wantVersion: "5.6.2", wantBuild: "200602", wantDate: "2020-06-02", wantOK: true},
+		{in: "V5.3.0", wantVersion: "5.3.0", wantOK: true},
+		{in: "5.7", wantVersion: "5.7", wantOK: true},
+		{in: "V5.5.80 build 999", wantVersion: "5.5.80", wantBuild: "999", wantOK: true},
+		{in: "V5.5.0 build 191399", wantVersion: "5.5.0", wantBuild: "191399", wantOK: true},
+		{in: "", wantOK: false},
+		{in: "unknown", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			version, build, date, ok := ParseSoftwareVersion(tt.in)
+			if ok != tt.wantOK || version != tt.wantVersion || build != tt.wantBuild {
+				t.Fatalf("ParseSoftwareVersion(%q) = %q, %q, ok=%v; want %q, %q, ok=%v",
+					tt.in, version, build, ok, tt.wantVersion, tt.wantBuild, tt.wantOK)
+			}
+			gotDate := ""
+			if !date.IsZero() {
+				gotDate = date.Format("2006-01-02")
+			}
+			if gotDate != tt.wantDate {
+				t.Errorf("build date = %q, want %q", gotDate, tt.wantDate)
+			}
+		})
+	}
+}
+
+func TestWithMinFirmware(t *testing.T) {
+	devices := []*Device{
+		{MAC: "old", SoftwareVersion: "V5.4.5 build 170123"},
+		{MAC: "exact", SoftwareVersion: "V5.5.0 build 191126"},
+		{MAC: "newer", SoftwareVersion: "V5.5.80 build 190603"},
+		{MAC: "unknown", SoftwareVersion: ""},
+	}
+
+	got := WithMinFirmware(devices, "5.5.0")
+	if len(got) != 2 || got[0].MAC != "exact" || got[1].MAC != "newer" {
+		var macs []string
+		for _, d := range got {
+			macs = append(macs, d.MAC)
+		}
+		t.Errorf("WithMinFirmware() = %v, want [exact newer]", macs)
+	}
+}
+
+func TestSortByBuildDate(t *testing.T) {
+	devices := []*Device{
+		{MAC: "none", SoftwareVersion: "V5.3.0"},
+		{MAC: "2019", SoftwareVersion: "V5.5.0 build 191126"},
+		{MAC: "2017", SoftwareVersion: "V5.4.5 build 170123"},
+		{MAC: "unparsed", SoftwareVersion: "unknown"},
+	}
+	SortByBuildDate(devices)
+
+	want := []string{"2017", "2019", "none", "unparsed"}
+	for i, w := range want {
+		if devices[i].MAC != w {
+			t.Errorf("devices[%d] = %s, want %s", i, devices[i].MAC, w)
+		}
+	}
+	if d := devices[0].BuildDate(); !d.Equal(time.Date(2017, 1, 23, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("BuildDate() = %v, want 2017-01-23", d)
+	}
+}
@@ -0,0 +1,79 @@
+package sadp
+
+import "strings"
+
+// WarningKind classifies a problem that did not stop discovery
+type WarningKind string
+
+// Warning kinds recorded during discovery
+const (
+	WarningBind      WarningKind = "bind"
+	WarningSend      WarningKind = "send"
+	WarningParse     WarningKind = "parse"
+	WarningTruncated WarningKind = "truncated"
+)
+
+// Warning is a non-fatal problem hit during discovery, such as an interface
+// that could not be bound or a reply that could not be parsed. Interface and
+// IP identify the local adapter; From is the remote address for replies.
+type Warning struct {
+	Kind      WarningKind
+	Interface string
+	IP        string
+	From      string
+	Err       error
+}
+
+// String formats the warning for display
+func (w Warning) String() string {
+	var where []string
+	if w.Interface != "" {
+		where = append(where, w.Interface)
+	}
+	if w.IP != "" {
+		where = append(where, w.IP)
+	}
+	if w.From != "" {
+		where = append(where, "from "+w.From)
+	}
+	msg := string(w.Kind)
+	if len(where) > 0 {
+		msg += " (" + strings.Join(where, ", ") + ")"
+	}
+	if w.Err != nil {
+		msg += ": " + w.Err.Error()
+	}
+	return msg
+}
+
+// recordWarning stores w for Warnings
+func (s *Scanner) recordWarning(w Warning) {
+	s.warningMutex.Lock()
+	defer s.warningMutex.Unlock()
+	s.warnings = append(s.warnings, w)
+}
+
+// resetWarnings clears the warnings of a previous discovery
+func (s *Scanner) resetWarnings() {
+	s.warningMutex.Lock()
+	defer s.warningMutex.Unlock()
+	s.warnings = nil
+}
+
+// Warnings returns the warnings recorded by the most recent Discover or
+// DiscoverOnIP call. Unlike devices they do not accumulate across calls.
+func (s *Scanner) Warnings() []Warning {
+	s.warningMutex.Lock()
+	defer s.warningMutex.Unlock()
+	return append([]Warning(nil), s.warnings...)
+}
+
+// DiscoverWithWarnings performs Discover and also returns the warnings it
+// recorded
+func (s *Scanner) DiscoverWithWarnings() ([]*Device, []Warning, error) {
+	devices, err := s.Discover()
+	if err != nil {
+		return nil, nil, err
+	}
+	return devices, s.Warnings(), nil
+}
@@ -0,0 +1,86 @@
+package sadp
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/cameronnewman/hikvision-tooling/internal/logger"
+)
+
+func TestWarningString(t *testing.T) {
+	tests := []struct {
+		name    string
+		warning Warning
+		want    string
+	}{
+		{
+			name:    "bind failure",
+			warning: Warning{Kind: WarningBind, Interface: "eth0", IP: "192.168.1.10", Err: errors.New("address already in use")},
+			want:    "bind (eth0, 192.168.1.10): address already in use",
+		},
+		{
+			name:    "parse failure with source",
+			warning: Warning{Kind: WarningParse, IP: "192.168.1.10", From: "192.168.1.64:37020", Err: errors.New("bad xml")},
+			want:    "parse (192.168.1.10, from 192.168.1.64:37020): bad xml",
+		},
+		{
+			name:    "kind only",
+			warning: Warning{Kind: WarningSend},
+			want:    "send",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.warning.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScannerWarnings(t *testing.T) {
+	s := NewScanner(time.Second, logger.NewNop())
+	s.recordWarning(Warning{Kind: WarningBind})
+	s.recordWarning(Warning{Kind: WarningParse})
+
+	warnings := s.Warnings()
+	if len(warnings) != 2 {
+		t.Fatalf("got %d warnings, want 2", len(warnings))
+	}
+	warnings[0].Kind = WarningSend
+	if s.Warnings()[0].Kind != WarningBind {
+		t.Errorf("Warnings() returned the scanner's own slice")
+	}
+
+	s.resetWarnings()
+	if n := len(s.Warnings()); n != 0 {
+		t.Errorf("got %d warnings after reset, want 0", n)
+	}
+}
+
+func TestDecodeResponse(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		wantDevice bool
+		wantErr    bool
+	}{
+		{name: "probe match", data: "<ProbeMatch><MAC>aa-bb-cc-dd-ee-ff</MAC></ProbeMatch>", wantDevice: true},
+		{name: "not a probe match", data: "<Probe><Types>inquiry</Types></Probe>"},
+		{name: "malformed probe match", data: "<ProbeMatch><MAC>aa</ProbeMatch>", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			device, err := decodeResponse(tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (device != nil) != tt.wantDevice {
+				t.Errorf("decodeResponse() device = %+v, wantDevice %v", device, tt.wantDevice)
+			}
+		})
+	}
+}